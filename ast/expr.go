@@ -83,6 +83,45 @@ func (e *StringExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt boo
 	}
 }
 
+// TemplateExpr - строка с подстановками "Итого: ${сумма}", части - строки и выражения подстановок по порядку
+type TemplateExpr struct {
+	ExprImpl
+	Parts []Expr
+}
+
+func (x *TemplateExpr) Simplify() Expr {
+	// соседние постоянные части соединяются заранее
+	parts := make([]Expr, 0, len(x.Parts))
+	for _, p := range x.Parts {
+		p = p.Simplify()
+		if v, ok := p.(*NativeExpr); ok && len(parts) > 0 {
+			if prev, ok := parts[len(parts)-1].(*NativeExpr); ok {
+				parts[len(parts)-1] = &NativeExpr{Value: core.Concat(core.VMSlice{prev.Value, v.Value})}
+				continue
+			}
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 1 {
+		if v, ok := parts[0].(*NativeExpr); ok {
+			return &NativeExpr{Value: core.Concat(core.VMSlice{v.Value})}
+		}
+	}
+	x.Parts = parts
+	return x
+}
+
+func (e *TemplateExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt bool, maxreg *int) {
+	// части вычисляются в регистры подряд и соединяются одной инструкцией
+	for i, p := range e.Parts {
+		p.BinTo(bins, reg+i, lid, false, maxreg)
+	}
+	bins.Append(binstmt.NewBinCONCAT(reg, len(e.Parts), e))
+	if r := reg + len(e.Parts) - 1; r > *maxreg {
+		*maxreg = r
+	}
+}
+
 // ArrayExpr provide Array expression.
 type ArrayExpr struct {
 	ExprImpl
@@ -139,6 +178,7 @@ func (e *PairExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt bool,
 type MapExpr struct {
 	ExprImpl
 	MapExpr map[string]Expr
+	Keys    []string // ключи в порядке их записи в исходном коде
 }
 
 func (x *MapExpr) Simplify() Expr {
@@ -427,6 +467,7 @@ type CallExpr struct {
 	SubExprs []Expr
 	VarArg   bool
 	Go       bool
+	Defer    bool // вызов отложен до выхода из функции
 }
 
 func (x *CallExpr) Simplify() Expr {
@@ -459,7 +500,9 @@ func (e *CallExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt bool,
 	}
 
 	// для анонимных (Name==0) - в reg будет функция, иначе первый аргумент (см. выше) или слайс аргументов
-	bins.Append(binstmt.NewBinCALL(e.Name, len(e.SubExprs), reg, reg, e.VarArg, e.Go, e))
+	c := binstmt.NewBinCALL(e.Name, len(e.SubExprs), reg, reg, e.VarArg, e.Go, e)
+	c.Defer = e.Defer
+	bins.Append(c)
 
 	// if reg+regoff+sliceoff > *maxreg {
 	// 	*maxreg = reg + regoff + sliceoff
@@ -476,6 +519,7 @@ type AnonCallExpr struct {
 	SubExprs []Expr
 	VarArg   bool
 	Go       bool
+	Defer    bool // вызов отложен до выхода из функции
 }
 
 func (x *AnonCallExpr) Simplify() Expr {
@@ -495,6 +539,7 @@ func (e *AnonCallExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt b
 		SubExprs: e.SubExprs,
 		VarArg:   e.VarArg,
 		Go:       e.Go,
+		Defer:    e.Defer,
 	}).BinTo(bins, reg, lid, false, maxreg) // передаем именно reg, т.к. он для Name==0 означает функцию, которую надо вызвать в BinCALL
 	if reg > *maxreg {
 		*maxreg = reg
@@ -643,6 +688,7 @@ type FuncExpr struct {
 	Stmts  Stmts
 	Args   []int //string
 	VarArg bool
+	End    pos.Position // позиция КонецФункции
 }
 
 func (x *FuncExpr) Simplify() Expr {
@@ -869,14 +915,18 @@ func (e *TypeCast) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt bool,
 
 type MakeExpr struct {
 	ExprImpl
-	Type     int  //string
-	TypeExpr Expr // должен быть строкой
+	Type     int    //string
+	TypeExpr Expr   // должен быть строкой
+	Args     []Expr // аргументы конструктора типа, объявленного в коде
 }
 
 func (x *MakeExpr) Simplify() Expr {
 	if x.TypeExpr != nil {
 		x.TypeExpr = x.TypeExpr.Simplify()
 	}
+	for i := range x.Args {
+		x.Args[i] = x.Args[i].Simplify()
+	}
 	return x
 }
 
@@ -887,9 +937,13 @@ func (e *MakeExpr) BinTo(bins *binstmt.BinStmts, reg int, lid *int, inStmt bool,
 		e.TypeExpr.BinTo(bins, reg, lid, false, maxreg)
 		bins.Append(binstmt.NewBinSETNAME(reg, e))
 	}
-	bins.Append(binstmt.NewBinMAKE(reg, e))
-	if reg > *maxreg {
-		*maxreg = reg
+	// аргументы идут в регистрах подряд за типом
+	for i, a := range e.Args {
+		a.BinTo(bins, reg+1+i, lid, false, maxreg)
+	}
+	bins.Append(binstmt.NewBinMAKE(reg, len(e.Args), e))
+	if r := reg + len(e.Args); r > *maxreg {
+		*maxreg = r
 	}
 }
 
@@ -98,6 +98,9 @@ type IfStmt struct {
 	Then   Stmts
 	ElseIf Stmts // This is array of IfStmt
 	Else   Stmts
+
+	ElsePos pos.Position // позиция ключевого слова Иначе
+	End     pos.Position // позиция конца блока, нужна для форматирования исходного кода
 }
 
 func (x *IfStmt) Simplify() {
@@ -171,8 +174,13 @@ type TryStmt struct {
 	StmtImpl
 	Try Stmts
 	// Var     string
-	Catch Stmts
-	// Finally Stmts
+	CatchKinds []string
+	Catch      Stmts
+	Finally    Stmts
+
+	CatchPos   pos.Position // позиция ключевого слова Исключение
+	FinallyPos pos.Position // позиция ключевого слова Окончательно
+	End        pos.Position
 }
 
 func (x *TryStmt) Simplify() {
@@ -182,9 +190,46 @@ func (x *TryStmt) Simplify() {
 	for _, st := range x.Catch {
 		st.Simplify()
 	}
+	for _, st := range x.Finally {
+		st.Simplify()
+	}
 }
 
 func (s *TryStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	if s.Finally == nil {
+		s.binTryCatch(bins, reg, lid, maxreg)
+		return
+	}
+
+	*lid++
+	lfin := *lid
+
+	// блок Окончательно исполняется при любом выходе из блоков Попытка и Исключение:
+	// в регистр reg помещается отложенное действие (исключение, возврат, прерывание или продолжение цикла),
+	// которое возобновляется после исполнения блока Окончательно
+	bins.Append(binstmt.NewBinTRYFIN(reg, lfin, s))
+
+	if s.Catch == nil {
+		s.Try.BinTo(bins, reg+1, lid, maxreg)
+	} else {
+		s.binTryCatch(bins, reg+1, lid, maxreg)
+	}
+
+	// нормальный выход из блока Попытка - снимаем обработчик со стека
+	bins.Append(binstmt.NewBinPOPTRY(lfin, s))
+
+	bins.Append(binstmt.NewBinLABEL(lfin, s))
+
+	s.Finally.BinTo(bins, reg+1, lid, maxreg)
+
+	bins.Append(binstmt.NewBinENDFIN(reg, s))
+
+	if reg+1 > *maxreg {
+		*maxreg = reg + 1
+	}
+}
+
+func (s *TryStmt) binTryCatch(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
 	*lid++
 	lend := *lid
 	*lid++
@@ -198,8 +243,9 @@ func (s *TryStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int)
 	// сюда переходим, если в блоке выше возникла ошибка
 	bins.Append(binstmt.NewBinLABEL(li, s))
 
-	// CATCH работает как JFALSE, и определяет функцию ОписаниеОшибки()
-	bins.Append(binstmt.NewBinCATCH(reg, lend, s))
+	// CATCH работает как JFALSE, и определяет функции ОписаниеОшибки() и ИнформацияОбОшибке()
+	// исключение другого вида, чем перечислено в CatchKinds, вызывается повторно
+	bins.Append(binstmt.NewBinCATCH(reg, lend, s.CatchKinds, s))
 
 	// тело обработки ошибки
 	s.Catch.BinTo(bins, reg, lid, maxreg) // регистр с ошибкой больше не нужен, текст определен функцией
@@ -221,19 +267,31 @@ func (s *TryStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int)
 // ForStmt provide "for in" expression statement.
 type ForStmt struct {
 	StmtImpl
-	Var   int //string
-	Value Expr
-	Stmts Stmts
+	Key      int // переменная ключа в цикле Для к, з Из ..., 0 - ключ не нужен
+	Var      int //string
+	Value    Expr
+	Stmts    Stmts
+	Parallel bool // Для каждого ... Параллельно
+	Workers  Expr // число одновременно исполняемых итераций, если nil - по числу процессоров
+	End      pos.Position
 }
 
 func (x *ForStmt) Simplify() {
 	x.Value = x.Value.Simplify()
+	if x.Workers != nil {
+		x.Workers = x.Workers.Simplify()
+	}
 	for _, st := range x.Stmts {
 		st.Simplify()
 	}
 }
 
 func (s *ForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	if s.Parallel {
+		s.binParallelTo(bins, reg, lid, maxreg)
+		return
+	}
+
 	// для каждого
 	s.Value.BinTo(bins, reg, lid, false, maxreg)
 
@@ -245,6 +303,10 @@ func (s *ForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int)
 	regiter := reg + 1
 	regval := reg + 2
 	regsub := reg + 3
+	regkey := regsub
+	if s.Key != 0 {
+		regsub++
+	}
 	// инициализируем итератор, параметры цикла и цикл в стеке циклов
 	bins.Append(binstmt.NewBinFOREACH(reg, regiter, lend, li, s))
 
@@ -252,9 +314,16 @@ func (s *ForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int)
 	// сюда же переходим по Продолжить
 	bins.Append(binstmt.NewBinLABEL(li, s))
 
-	bins.Append(binstmt.NewBinNEXT(reg, regiter, regval, lend, s))
+	next := binstmt.NewBinNEXT(reg, regiter, regval, lend, s)
+	if s.Key != 0 {
+		next.RegKey, next.Pair = regkey, true
+	}
+	bins.Append(next)
 
-	// устанавливаем переменную-итератор
+	// устанавливаем переменные ключа и значения
+	if s.Key != 0 {
+		bins.Append(binstmt.NewBinSET(regkey, s.Key, s))
+	}
 	bins.Append(binstmt.NewBinSET(regval, s.Var, s))
 
 	s.Stmts.BinTo(bins, regsub, lid, maxreg)
@@ -271,23 +340,59 @@ func (s *ForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int)
 	// освобождаем память
 	// bins.Append(binstmt.NewBinFREE(reg+1, s))
 
-	if reg+3 > *maxreg {
-		*maxreg = reg + 3
+	if regsub > *maxreg {
+		*maxreg = regsub
+	}
+}
+
+func (s *ForStmt) binParallelTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	s.Value.BinTo(bins, reg, lid, false, maxreg)
+	if s.Workers != nil {
+		s.Workers.BinTo(bins, reg+1, lid, false, maxreg)
+	} else {
+		bins.Append(binstmt.NewBinLOAD(reg+1, core.VMNil, false, s))
+	}
+
+	*lid++
+	lstart := *lid
+	*lid++
+	lend := *lid
+
+	// тело цикла исполняется отдельно для каждого элемента, подобно телу функции,
+	// в собственном окружении с определенной переменной цикла
+	ii := len(*bins)
+	bins.Append(binstmt.NewBinFOREACHPAR(reg, reg+1, s.Var, lstart, lend, s))
+	bins.Append(binstmt.NewBinLABEL(lstart, s))
+
+	s.Stmts.BinTo(bins, reg+2, lid, maxreg)
+
+	// окончание итерации равнозначно Продолжить
+	bins.Append(binstmt.NewBinCONTINUE(s))
+	bins.Append(binstmt.NewBinLABEL(lend, s))
+
+	if reg+2 > *maxreg {
+		*maxreg = reg + 2
 	}
+	(*bins)[ii].(*binstmt.BinFOREACHPAR).MaxReg = *maxreg
 }
 
-// NumForStmt name = expr1 to expr2
+// NumForStmt name = expr1 to expr2 step expr
 type NumForStmt struct {
 	StmtImpl
 	Name  int //string
 	Expr1 Expr
 	Expr2 Expr
+	Step  Expr // nil - шаг 1 или -1 в зависимости от того, меньше ли конечное значение начального
 	Stmts Stmts
+	End   pos.Position
 }
 
 func (x *NumForStmt) Simplify() {
 	x.Expr1 = x.Expr1.Simplify()
 	x.Expr2 = x.Expr2.Simplify()
+	if x.Step != nil {
+		x.Step = x.Step.Simplify()
+	}
 	for _, st := range x.Stmts {
 		st.Simplify()
 	}
@@ -297,10 +402,17 @@ func (s *NumForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 	// для .. по ..
 	regfrom := reg + 1
 	regto := reg + 2
+	regstep := reg + 3
 	regsub := reg + 3
+	if s.Step != nil {
+		regsub++
+	}
 
 	s.Expr1.BinTo(bins, regfrom, lid, false, maxreg)
 	s.Expr2.BinTo(bins, regto, lid, false, maxreg)
+	if s.Step != nil {
+		s.Step.BinTo(bins, regstep, lid, false, maxreg)
+	}
 
 	*lid++
 	lend := *lid
@@ -308,7 +420,13 @@ func (s *NumForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 	li := *lid
 
 	// инициализируем итератор, параметры цикла и цикл в стеке циклов
-	bins.Append(binstmt.NewBinFORNUM(reg, regfrom, regto, lend, li, s))
+	fornum := binstmt.NewBinFORNUM(reg, regfrom, regto, lend, li, s)
+	nextnum := binstmt.NewBinNEXTNUM(reg, regfrom, regto, lend, s)
+	if s.Step != nil {
+		fornum.RegStep, fornum.HasStep = regstep, true
+		nextnum.RegStep, nextnum.HasStep = regstep, true
+	}
+	bins.Append(fornum)
 
 	// очередная итерация
 	// сюда же переходим по Продолжить
@@ -330,7 +448,7 @@ func (s *NumForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 	// простые присваивания одним и тем же переменным
 	// будут на выходе из всех циклов (воркеров) затерты случайным последним отработавшим воркером
 
-	bins.Append(binstmt.NewBinNEXTNUM(reg, regfrom, regto, lend, s))
+	bins.Append(nextnum)
 
 	// устанавливаем переменную-итератор
 	bins.Append(binstmt.NewBinSET(reg, s.Name, s))
@@ -348,8 +466,8 @@ func (s *NumForStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 	// освобождаем память
 	// bins.Append(binstmt.NewBinFREE(reg+1, s))
 
-	if reg+3 > *maxreg {
-		*maxreg = reg + 3
+	if regsub > *maxreg {
+		*maxreg = regsub
 	}
 
 }
@@ -368,6 +486,7 @@ type LoopStmt struct {
 	StmtImpl
 	Expr  Expr
 	Stmts Stmts
+	End   pos.Position
 }
 
 func (x *LoopStmt) Simplify() {
@@ -498,6 +617,23 @@ func (s *ThrowStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int
 	}
 }
 
+// DeferStmt - оператор Отложить, вызов в Expr исполняется при выходе из функции
+type DeferStmt struct {
+	StmtImpl
+	Expr Expr // CallExpr или AnonCallExpr с признаком Defer
+}
+
+func (x *DeferStmt) Simplify() {
+	x.Expr = x.Expr.Simplify()
+}
+
+func (s *DeferStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	s.Expr.BinTo(bins, reg, lid, true, maxreg)
+	if reg > *maxreg {
+		*maxreg = reg
+	}
+}
+
 // ModuleStmt provide "module" expression statement.
 type ModuleStmt struct {
 	StmtImpl
@@ -538,11 +674,100 @@ func (s *ModuleStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 	}
 }
 
+// TypeStmt - объявление типа объектов Тип ... КонецТипа, его члены - поля FieldStmt и методы MethodStmt
+type TypeStmt struct {
+	StmtImpl
+	Name    int //string
+	Members Stmts
+	End     pos.Position // позиция КонецТипа
+}
+
+// FieldStmt - поля типа объектов, начальное значение может быть только у одного поля
+type FieldStmt struct {
+	StmtImpl
+	Names []int //string
+	Value Expr  // nil, если начального значения нет
+}
+
+// MethodStmt - метод типа объектов, объект доступен в нем как ЭтотОбъект
+type MethodStmt struct {
+	StmtImpl
+	Func *FuncExpr
+}
+
+func (x *TypeStmt) Simplify() {
+	for _, m := range x.Members {
+		m.Simplify()
+	}
+}
+
+func (x *FieldStmt) Simplify() {
+	if x.Value != nil {
+		x.Value = x.Value.Simplify()
+	}
+}
+
+func (x *MethodStmt) Simplify() {
+	x.Func.Simplify()
+}
+
+func (s *FieldStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	// поля компилируются вместе с типом
+}
+
+func (s *MethodStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	// методы компилируются вместе с типом
+}
+
+// ThisObject - имя объекта внутри методов его типа
+var ThisObject = names.UniqueNames.Set("ЭтотОбъект")
+
+// BinTo компилирует методы в функции, которые получают объект первым аргументом,
+// а начальные значения полей - в функцию, которая присваивает их полям объекта.
+// Функции находятся в регистрах подряд, инструкция TYPE создает из них тип.
+func (s *TypeStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *int) {
+	tname := names.UniqueNames.Get(s.Name)
+	var fields, methods []int
+	var inits Stmts
+	r := reg
+	for _, m := range s.Members {
+		switch mm := m.(type) {
+		case *FieldStmt:
+			fields = append(fields, mm.Names...)
+			if mm.Value != nil {
+				this := &IdentExpr{Lit: "ЭтотОбъект", Id: ThisObject}
+				this.SetPosition(mm.Position())
+				field := &MemberExpr{Expr: this, Name: mm.Names[0]}
+				field.SetPosition(mm.Position())
+				inits = append(inits, &LetsStmt{Lhss: []Expr{field}, Operator: "=", Rhss: []Expr{mm.Value}})
+			}
+		case *MethodStmt:
+			methods = append(methods, mm.Func.Name)
+			f := *mm.Func
+			f.Name = names.UniqueNames.Set(tname + "." + names.UniqueNames.Get(mm.Func.Name))
+			f.Args = append([]int{ThisObject}, mm.Func.Args...)
+			f.BinTo(bins, r, lid, false, maxreg)
+			r++
+		}
+	}
+	if len(inits) > 0 {
+		f := &FuncExpr{Name: names.UniqueNames.Set(tname + ".<поля>"), Args: []int{ThisObject}, Stmts: inits, End: s.End}
+		f.SetPosition(s.Position())
+		f.BinTo(bins, r, lid, false, maxreg)
+		r++
+	}
+	bins.Append(binstmt.NewBinTYPE(reg, s.Name, fields, methods, len(inits) > 0, s))
+	if reg > *maxreg {
+		*maxreg = reg
+	}
+}
+
 // SwitchStmt provide switch statement.
 type SwitchStmt struct {
 	StmtImpl
 	Expr  Expr
 	Cases Stmts
+	End   pos.Position
 }
 
 func (x *SwitchStmt) Simplify() {
@@ -588,6 +813,7 @@ func (s *SwitchStmt) BinTo(bins *binstmt.BinStmts, reg int, lid *int, maxreg *in
 type SelectStmt struct {
 	StmtImpl
 	Cases Stmts
+	End   pos.Position
 }
 
 func (x *SelectStmt) Simplify() {
@@ -0,0 +1,45 @@
+package bincode
+
+import (
+	"sync/atomic"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+)
+
+// DebugHook позволяет отладчику управлять исполнением кода, а профилировщику - замерять его, см. пакеты debugger и profiler.
+// Пока они не установлены, виртуальная машина не тратит время на их вызовы.
+type DebugHook interface {
+	// Enter вызывается, когда начинается исполнение кода модуля, функции или итерации параллельного цикла
+	// с инструкции idx в окружении env и регистрах regs. Если этот код не нужно отлаживать, возвращается nil.
+	Enter(code *binstmt.BinCode, idx int, env *core.Env, regs core.VMSlice) DebugFrame
+}
+
+// DebugFrame - кадр стека, исполняемый под отладчиком
+type DebugFrame interface {
+	// Step вызывается перед исполнением инструкции с индексом idx и может приостановить исполнение
+	Step(idx int)
+	// Leave вызывается при выходе из кадра
+	Leave()
+}
+
+// Debugger - установленный отладчик, устанавливается до запуска кода
+var Debugger DebugHook
+
+// profilerHook хранит установленный профилировщик. В отличие от отладчика, профилировщик
+// может включаться и выключаться во время исполнения кода, например в сессиях сервиса.
+var profilerHook atomic.Value
+
+type hookHolder struct{ h DebugHook }
+
+// SetProfiler устанавливает профилировщик, nil выключает его
+func SetProfiler(h DebugHook) {
+	profilerHook.Store(hookHolder{h})
+}
+
+func currentProfiler() DebugHook {
+	if hh, ok := profilerHook.Load().(hookHolder); ok {
+		return hh.h
+	}
+	return nil
+}
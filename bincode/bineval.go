@@ -0,0 +1,122 @@
+package bincode
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/parser"
+)
+
+// файлы исходного кода, которые указываются в ошибках и отладчике для кода Выполнить и Вычислить
+const (
+	executeFile  = "<Выполнить>"
+	evaluateFile = "<Вычислить>"
+)
+
+// maxCompiledSnippets - сколько скомпилированных фрагментов кода хранится одновременно,
+// при переполнении кэш очищается
+const maxCompiledSnippets = 1024
+
+type snippetKey struct {
+	sum  [sha256.Size]byte
+	expr bool
+}
+
+var (
+	snippetsMu sync.Mutex
+	// скомпилированный код Выполнить и Вычислить по хэшу текста, один и тот же фрагмент компилируется один раз
+	compiledSnippets = make(map[snippetKey]binstmt.BinCode)
+)
+
+// compileSnippet компилирует инструкции или выражение, если expr.
+// Ошибка разбора возвращается как исключение ОшибкаСинтаксиса с позицией в тексте фрагмента.
+func compileSnippet(src string, expr bool) (binstmt.BinCode, error) {
+	key := snippetKey{sum: sha256.Sum256([]byte(src)), expr: expr}
+	snippetsMu.Lock()
+	bins, ok := compiledSnippets[key]
+	snippetsMu.Unlock()
+	if ok {
+		return bins, nil
+	}
+
+	file := executeFile
+	var err error
+	if expr {
+		file = evaluateFile
+		_, bins, err = ParseExpr(src)
+	} else {
+		_, bins, err = ParseSrc(src)
+	}
+	if err != nil {
+		if pe, ok := err.(*parser.Error); ok {
+			return bins, &binstmt.Error{Message: pe.Message, Pos: pe.Pos, Kind: binstmt.ErrorKindSyntax, Filename: file}
+		}
+		return bins, err
+	}
+	bins.AttachDebugInfo(file, src)
+
+	snippetsMu.Lock()
+	if len(compiledSnippets) >= maxCompiledSnippets {
+		compiledSnippets = make(map[snippetKey]binstmt.BinCode)
+	}
+	compiledSnippets[key] = bins
+	snippetsMu.Unlock()
+	return bins, nil
+}
+
+// runSnippet исполняет код Выполнить и Вычислить в окружении вызывающего кода, чтобы были доступны его переменные.
+// В песочнице код исполняется в отдельном глобальном контексте, см. core.Env.NewSandbox: переменные
+// вызывающего кода и глобальные ему не видны, а определенные им переменные после исполнения не сохраняются.
+func runSnippet(global *core.Env, args core.VMSlice, rets *core.VMSlice, envout *(*core.Env), expr bool) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("Должны быть текст кода и необязательный признак исполнения в песочнице")
+	}
+	src, ok := args[0].(core.VMString)
+	if !ok {
+		return core.VMErrorNeedString
+	}
+	sandbox := false
+	if len(args) == 2 {
+		b, ok := args[1].(core.VMBool)
+		if !ok {
+			return core.VMErrorNeedBool
+		}
+		sandbox = bool(b)
+	}
+
+	bins, err := compileSnippet(string(src), expr)
+	if err != nil {
+		return err
+	}
+
+	var caller *core.Env
+	if envout != nil {
+		caller = *envout
+	}
+	env := caller
+	if caller == nil || caller.IsGoEnv() {
+		// вызов из встроенной функции или в отдельной горутине, окружения вызывающего кода нет
+		env = global
+	}
+	if sandbox {
+		env = env.NewSandbox()
+	}
+
+	rv, err := RunWorker(&bins, bins.MaxReg+1, env, 0)
+	switch err {
+	case nil, binstmt.ReturnError:
+	case binstmt.BreakError, binstmt.ContinueError:
+		// Прервать и Продолжить вне цикла фрагмента не действуют на циклы вызывающего кода
+		return errors.New(err.Error())
+	default:
+		return err
+	}
+	if rv == nil {
+		rv = core.VMNil
+	}
+	rets.Append(rv)
+	return nil
+}
@@ -0,0 +1,180 @@
+package bincode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+)
+
+// ProjectDir - каталог проекта, в нем в первую очередь ищутся модули, загружаемые через Импорт("путь/модуль").
+// Затем модули ищутся в каталогах из переменной окружения ImportPathEnv.
+var ProjectDir = "."
+
+// ImportPathEnv - переменная окружения со списком каталогов поиска модулей
+const ImportPathEnv = "GONECPATH"
+
+type compiledModule struct {
+	modTime time.Time
+	code    binstmt.BinCode
+}
+
+var (
+	modulesMu sync.Mutex
+	// скомпилированный код модулей по полному пути к файлу, компилируется повторно только после изменения файла
+	compiledModules = make(map[string]*compiledModule)
+)
+
+// loadedModule - модуль, загруженный или загружаемый в глобальном контексте интерпретатора.
+// Хранится в глобальном контексте под полным путем к файлу, поэтому у каждого интерпретатора свои модули.
+type loadedModule struct {
+	core.VMValueStruct
+	done chan struct{} // закрывается по окончании загрузки
+	ns   *core.Env     // пространство имен с экспортируемыми именами
+	err  error         // ошибка загрузки
+}
+
+// importChainKey - ключ контекста исполнения со списком модулей, которые загружаются в цепочке импортов
+type importChainKey struct{}
+
+func init() {
+	core.VMModuleLoader = ImportModule
+}
+
+// ImportSearchPath возвращает каталоги, в которых ищутся модули
+func ImportSearchPath() []string {
+	dirs := []string{ProjectDir}
+	for _, d := range filepath.SplitList(os.Getenv(ImportPathEnv)) {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// FindModule возвращает полный путь к файлу модуля, расширение .gnc или .gnx можно не указывать.
+// Относительный путь ищется в каталогах dirs.
+func FindModule(path string, dirs []string) (string, error) {
+	files := []string{path + ".gnc", path + ".gnx"}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".gnc" || ext == ".gnx" {
+		files = []string{path}
+	}
+	if filepath.IsAbs(path) {
+		dirs = []string{""}
+	}
+	for _, d := range dirs {
+		for _, f := range files {
+			fn := filepath.Join(d, filepath.FromSlash(f))
+			if fi, err := os.Stat(fn); err == nil && !fi.IsDir() {
+				return filepath.Abs(fn)
+			}
+		}
+	}
+	return "", fmt.Errorf("Модуль '%s' не найден в каталогах %s", path, strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// compileModule компилирует файл модуля или загружает его байткод из .gnx
+func compileModule(fn string) (binstmt.BinCode, error) {
+	fi, err := os.Stat(fn)
+	if err != nil {
+		return binstmt.BinCode{}, err
+	}
+
+	modulesMu.Lock()
+	cm, ok := compiledModules[fn]
+	modulesMu.Unlock()
+	if ok && cm.modTime.Equal(fi.ModTime()) {
+		return cm.code, nil
+	}
+
+	body, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return binstmt.BinCode{}, err
+	}
+	var bins binstmt.BinCode
+	if strings.HasSuffix(strings.ToLower(fn), ".gnx") {
+		bins, err = binstmt.ReadBinCode(bytes.NewBuffer(body))
+	} else {
+		_, bins, err = ParseSrc(string(body))
+		if pe, ok := err.(*parser.Error); ok {
+			pe.Filename = fn
+		}
+		if err == nil {
+			bins.AttachDebugInfo(fn, "")
+		}
+	}
+	if err != nil {
+		return bins, err
+	}
+
+	modulesMu.Lock()
+	compiledModules[fn] = &compiledModule{modTime: fi.ModTime(), code: bins}
+	modulesMu.Unlock()
+	return bins, nil
+}
+
+// ImportModule загружает модуль из файла и возвращает его пространство имен с экспортируемыми именами.
+// Код модуля исполняется один раз в собственном окружении и в контексте ctx кода, который его импортирует,
+// повторный импорт возвращает то же пространство имен. Импорт модуля, который в это время загружается
+// в другой горутине, дожидается окончания загрузки, а импорт модуля из его же цепочки импортов - ошибка.
+func ImportModule(ctx context.Context, env *core.Env, path string) (*core.Env, error) {
+	fn, err := FindModule(path, ImportSearchPath())
+	if err != nil {
+		return nil, err
+	}
+	chain, _ := ctx.Value(importChainKey{}).([]string)
+	for _, f := range chain {
+		if f == fn {
+			return nil, fmt.Errorf("Циклический импорт модуля '%s' (%s)", path, fn)
+		}
+	}
+	id := names.UniqueNames.Set(fn)
+
+	modulesMu.Lock()
+	if v, err := env.Get(id); err == nil {
+		if lm, ok := v.(*loadedModule); ok {
+			modulesMu.Unlock()
+			select {
+			case <-lm.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return lm.ns, lm.err
+		}
+	}
+	// модуль регистрируется в глобальном контексте под именем файла
+	lm := &loadedModule{done: make(chan struct{})}
+	env.DefineGlobal(id, lm)
+	modulesMu.Unlock()
+
+	m := env.NewEnv()
+	m.SetName(fn)
+	bins, err := compileModule(fn)
+	if err == nil {
+		_, err = Run(context.WithValue(ctx, importChainKey{}, append(chain[:len(chain):len(chain)], fn)), bins, m)
+	}
+	if err != nil {
+		if e, ok := err.(*binstmt.Error); ok && e.Filename == "" {
+			e.Filename = fn
+		}
+		m.Destroy()
+		lm.err = err
+		modulesMu.Lock()
+		env.DefineGlobal(id, core.VMNil) // при следующем импорте будет новая попытка загрузки
+		modulesMu.Unlock()
+	} else {
+		lm.ns = m.Export()
+	}
+	close(lm.done)
+	return lm.ns, lm.err
+}
@@ -0,0 +1,73 @@
+package bincode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"lib/матем.gnc": `
+		_множитель = 2
+		Функция _Удвоить(х)
+			Возврат х * _множитель
+		КонецФункции
+		Функция Удвоить(х)
+			Возврат _Удвоить(х)
+		КонецФункции
+		Сообщить("загружен")
+		`,
+		"а.gnc":   "б = Импорт(\"б\")",
+		"б.gnc":   "а = Импорт(\"а\")",
+		"мед.gnc": "Пауза(0.05)\nЗнач = 1\nСообщить(\"мед загружен\")\n",
+	}
+	for fn, src := range files {
+		fn = filepath.Join(dir, filepath.FromSlash(fn))
+		os.MkdirAll(filepath.Dir(fn), 0755)
+		if err := ioutil.WriteFile(fn, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(d string) { ProjectDir = d }(ProjectDir)
+	ProjectDir = dir
+
+	out := runScript(t, `
+	м = Импорт("lib/матем")
+	м2 = Импорт("lib/матем.gnc")
+	Сообщить(м.Удвоить(21), м2.Удвоить(1))
+	Попытка
+		м._Удвоить(1)
+	Исключение
+		Сообщить("не экспортировано")
+	КонецПопытки
+	Попытка
+		Импорт("а")
+	Исключение
+		Сообщить(ИнформацияОбОшибке().ИмяФайла = "`+filepath.Join(dir, "б.gnc")+`")
+	КонецПопытки
+	// одновременный импорт из разных горутин загружает модуль один раз и не считается циклическим
+	Функция ИмпортМед(к)
+		Попытка
+			мед = Импорт("мед")
+			к <- мед.Знач
+		Исключение
+			к <- ОписаниеОшибки()
+		КонецПопытки
+	КонецФункции
+	канмед = Новый Канал(2)
+	Старт ИмпортМед(канмед)
+	Старт ИмпортМед(канмед)
+	Сообщить(<-канмед, <-канмед)
+	`)
+	want := "загружен\n42 2\nне экспортировано\ntrue\nмед загружен\n1 1\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
@@ -0,0 +1,627 @@
+package binopt
+
+import (
+	"github.com/covrom/gonec/bincode/binstmt"
+)
+
+// region - код, исполняемый виртуальной машиной с собственным набором регистров:
+// код модуля, тело функции или тело параллельного цикла. Тела вложенных функций
+// располагаются внутри кода объемлющей области, но в нее не входят.
+type region struct {
+	owner  binstmt.BinStmt // FUNC или FOREACHPAR, для кода модуля - nil
+	parent int             // номер объемлющей области, для кода модуля - -1
+	entry  int             // индекс первой исполняемой инструкции
+	idx    []int           // индексы инструкций области по порядку
+	loops  []int           // индексы меток, на которые переходят Прервать и Продолжить
+}
+
+// analysis - разбиение кода на области и граф переходов между инструкциями
+type analysis struct {
+	code    *binstmt.BinCode
+	labels  map[int]int // индекс инструкции LABEL по номеру метки
+	region  []int       // номер области каждой инструкции
+	pos     []int       // позиция инструкции в списке инструкций ее области
+	regions []*region
+	// индексы меток обработчиков исключений и блоков Окончательно, на которые возможен переход из инструкции
+	handlers [][]int
+	succs    [][]int
+	maxReg   int
+}
+
+func analyze(code *binstmt.BinCode) *analysis {
+	n := len(code.Code)
+	a := &analysis{
+		code:     code,
+		labels:   make(map[int]int),
+		region:   make([]int, n),
+		pos:      make([]int, n),
+		handlers: make([][]int, n),
+		succs:    make([][]int, n),
+	}
+	for i, s := range code.Code {
+		if l, ok := s.(*binstmt.BinLABEL); ok {
+			a.labels[l.Label] = i
+		}
+		use, def := regsOf(s)
+		for _, r := range append(use, def...) {
+			if r > a.maxReg {
+				a.maxReg = r
+			}
+		}
+	}
+
+	var tries []int
+	var mark func(r *region, from, to int)
+	mark = func(r *region, from, to int) {
+		rn := len(a.regions)
+		a.regions = append(a.regions, r)
+		for i := from; i < to; i++ {
+			a.region[i] = rn
+			a.pos[i] = len(r.idx)
+			r.idx = append(r.idx, i)
+			var start, end int
+			switch s := code.Code[i].(type) {
+			case *binstmt.BinFUNC:
+				start, end = s.LabelStart, s.LabelEnd
+			case *binstmt.BinFOREACHPAR:
+				start, end = s.LabelStart, s.LabelEnd
+			case *binstmt.BinTRY, *binstmt.BinTRYFIN:
+				tries = append(tries, i)
+				continue
+			case *binstmt.BinFORNUM:
+				r.loops = a.appendLabel(a.appendLabel(r.loops, s.BreakLabel), s.ContinueLabel)
+				continue
+			case *binstmt.BinFOREACH:
+				r.loops = a.appendLabel(a.appendLabel(r.loops, s.BreakLabel), s.ContinueLabel)
+				continue
+			case *binstmt.BinWHILE:
+				r.loops = a.appendLabel(a.appendLabel(r.loops, s.BreakLabel), s.ContinueLabel)
+				continue
+			default:
+				continue
+			}
+			si, ok1 := a.labels[start]
+			ei, ok2 := a.labels[end]
+			if !ok1 || !ok2 || si <= i || ei < si {
+				continue
+			}
+			mark(&region{owner: code.Code[i], parent: rn, entry: si}, si, ei)
+			i = ei - 1
+		}
+	}
+	mark(&region{parent: -1}, 0, n)
+
+	// обработчик установлен от инструкции TRY или TRYFIN до его метки, код компилируется
+	// структурно, поэтому исключение в этих инструкциях переходит на эту метку
+	for _, i := range tries {
+		var l int
+		switch s := code.Code[i].(type) {
+		case *binstmt.BinTRY:
+			l = s.JumpTo
+		case *binstmt.BinTRYFIN:
+			l = s.JumpTo
+		}
+		j, ok := a.labels[l]
+		if !ok || !a.sameRegion(i, j) {
+			continue
+		}
+		r := a.regions[a.region[i]]
+		for p := a.pos[i] + 1; p < len(r.idx) && r.idx[p] < j; p++ {
+			a.handlers[r.idx[p]] = append(a.handlers[r.idx[p]], j)
+		}
+	}
+
+	for i := range code.Code {
+		a.succs[i] = a.successors(i)
+	}
+	return a
+}
+
+func (a *analysis) appendLabel(ls []int, l int) []int {
+	if i, ok := a.labels[l]; ok {
+		return append(ls, i)
+	}
+	return ls
+}
+
+// next возвращает индекс следующей инструкции той же области или -1
+func (a *analysis) next(i int) int {
+	r := a.regions[a.region[i]]
+	if p := a.pos[i] + 1; p < len(r.idx) {
+		return r.idx[p]
+	}
+	return -1
+}
+
+// successors возвращает инструкции, которые могут исполняться после инструкции i.
+// Исключение может возникнуть почти в любой инструкции, поэтому внутри блока Попытка
+// из каждой инструкции возможен переход на обработчик.
+func (a *analysis) successors(i int) []int {
+	r := a.regions[a.region[i]]
+	var res []int
+	fall := true
+	switch s := a.code.Code[i].(type) {
+	case *binstmt.BinJMP:
+		res = a.appendLabel(res, s.JumpTo)
+		fall = false
+	case *binstmt.BinJTRUE:
+		res = a.appendLabel(res, s.JumpTo)
+	case *binstmt.BinJFALSE:
+		res = a.appendLabel(res, s.JumpTo)
+	case *binstmt.BinNEXT:
+		res = a.appendLabel(res, s.JumpTo)
+	case *binstmt.BinNEXTNUM:
+		res = a.appendLabel(res, s.JumpTo)
+	case *binstmt.BinCATCH:
+		res = a.appendLabel(res, s.JumpTo)
+	case *binstmt.BinFUNC:
+		res = a.appendLabel(res, s.LabelEnd)
+		fall = false
+	case *binstmt.BinFOREACHPAR:
+		res = a.appendLabel(res, s.LabelEnd)
+		fall = false
+	case *binstmt.BinRET, *binstmt.BinTHROW, *binstmt.BinERROR:
+		fall = false
+	case *binstmt.BinBREAK, *binstmt.BinCONTINUE:
+		res = append(res, r.loops...)
+		fall = false
+	case *binstmt.BinENDFIN:
+		res = append(res, r.loops...)
+	}
+	if fall {
+		if n := a.next(i); n >= 0 {
+			res = append(res, n)
+		}
+	}
+	return append(res, a.handlers[i]...)
+}
+
+// sameRegion сообщает, что инструкции относятся к одной области
+func (a *analysis) sameRegion(i, j int) bool {
+	return j >= 0 && j < len(a.region) && a.region[i] == a.region[j]
+}
+
+// liveness вычисляет регистры, значения которых еще будут прочитаны перед исполнением
+// каждой инструкции (in) и после нее (out)
+func (a *analysis) liveness() (in, out []regset) {
+	n := len(a.code.Code)
+	w := a.maxReg/64 + 1
+	in = make([]regset, n)
+	out = make([]regset, n)
+	uses := make([][]int, n)
+	defs := make([][]int, n)
+	for i, s := range a.code.Code {
+		in[i] = make(regset, w)
+		out[i] = make(regset, w)
+		uses[i], defs[i] = regsOf(s)
+	}
+	tmp := make(regset, w)
+	for changed := true; changed; {
+		changed = false
+		for i := n - 1; i >= 0; i-- {
+			for _, s := range a.succs[i] {
+				out[i].or(in[s])
+			}
+			copy(tmp, out[i])
+			for _, r := range defs[i] {
+				tmp.del(r)
+			}
+			for _, r := range uses[i] {
+				tmp.add(r)
+			}
+			if in[i].or(tmp) {
+				changed = true
+			}
+		}
+	}
+	return in, out
+}
+
+// regset - множество регистров
+type regset []uint64
+
+func (s regset) has(r int) bool { return s[r/64]&(1<<uint(r%64)) != 0 }
+func (s regset) add(r int)      { s[r/64] |= 1 << uint(r%64) }
+func (s regset) del(r int)      { s[r/64] &^= 1 << uint(r%64) }
+
+// or добавляет к множеству регистры из t и сообщает, изменилось ли оно
+func (s regset) or(t regset) bool {
+	changed := false
+	for i, x := range t {
+		if s[i]|x != s[i] {
+			s[i] |= x
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s regset) each(f func(r int)) {
+	for i, x := range s {
+		for b := uint(0); x != 0; b++ {
+			if x&1 != 0 {
+				f(i*64 + int(b))
+			}
+			x >>= 1
+		}
+	}
+}
+
+// callArgs возвращает регистры, из которых вызов берет функцию и аргументы, они должны идти подряд
+func callArgs(s *binstmt.BinCALL) []int {
+	from, n := s.RegArgs, s.NumArgs
+	if s.Name == 0 {
+		n++
+	}
+	res := make([]int, n)
+	for i := range res {
+		res[i] = from + i
+	}
+	return res
+}
+
+// rangeRegs возвращает регистры, которые инструкция читает подряд начиная с первого:
+// аргументы вызова, части строки с подстановками, аргументы конструктора и методы типа,
+// nil для остальных инструкций
+func rangeRegs(stmt binstmt.BinStmt) []int {
+	n, from := 0, 0
+	switch s := stmt.(type) {
+	case *binstmt.BinCALL:
+		return callArgs(s)
+	case *binstmt.BinCONCAT:
+		n, from = s.Num, s.Reg
+	case *binstmt.BinMAKE:
+		if s.NumArgs == 0 {
+			return nil
+		}
+		n, from = s.NumArgs+1, s.Reg
+	case *binstmt.BinTYPE:
+		n, from = s.NumRegs(), s.Reg
+	}
+	if n == 0 {
+		return nil
+	}
+	res := make([]int, n)
+	for i := range res {
+		res[i] = from + i
+	}
+	return res
+}
+
+// regsOf возвращает регистры, которые инструкция читает (use), и регистры, которые она перезаписывает (def).
+// Регистры, которые перезаписываются не при каждом исполнении, считаются также читаемыми.
+func regsOf(stmt binstmt.BinStmt) (use, def []int) {
+	switch s := stmt.(type) {
+	case *binstmt.BinLOAD:
+		def = []int{s.Reg}
+	case *binstmt.BinMV:
+		use, def = []int{s.RegFrom}, []int{s.RegTo}
+	case *binstmt.BinEQUAL:
+		use, def = []int{s.Reg1, s.Reg2}, []int{s.Reg}
+	case *binstmt.BinMAKESLICE:
+		def = []int{s.Reg}
+	case *binstmt.BinSETIDX:
+		use = []int{s.Reg, s.RegVal}
+	case *binstmt.BinMAKEMAP:
+		def = []int{s.Reg}
+	case *binstmt.BinSETKEY:
+		use = []int{s.Reg, s.RegVal}
+	case *binstmt.BinGET:
+		def = []int{s.Reg}
+	case *binstmt.BinSET:
+		use = []int{s.Reg}
+	case *binstmt.BinGETLOCAL:
+		def = []int{s.Reg}
+	case *binstmt.BinSETLOCAL:
+		use = []int{s.Reg}
+	case *binstmt.BinSETMEMBER:
+		use = []int{s.Reg, s.RegVal}
+	case *binstmt.BinSETITEM:
+		use, def = []int{s.Reg, s.RegIndex, s.RegVal}, []int{s.RegNeedLet}
+	case *binstmt.BinSETSLICE:
+		use, def = []int{s.Reg, s.RegBegin, s.RegEnd, s.RegVal, s.RegNeedLet}, []int{s.RegNeedLet}
+	case *binstmt.BinJTRUE:
+		use = []int{s.Reg}
+	case *binstmt.BinJFALSE:
+		use = []int{s.Reg}
+	case *binstmt.BinOPER:
+		use, def = []int{s.RegL, s.RegR}, []int{s.RegL}
+	case *binstmt.BinCONCAT:
+		use, def = rangeRegs(s), []int{s.Reg}
+	case *binstmt.BinMAKE:
+		if use = rangeRegs(s); use == nil {
+			use = []int{s.Reg}
+		}
+		def = []int{s.Reg}
+	case *binstmt.BinTYPE:
+		use = rangeRegs(s)
+	case *binstmt.BinCALL:
+		use, def = callArgs(s), []int{s.RegRets}
+	case *binstmt.BinGETIDX:
+		use, def = []int{s.Reg, s.RegIndex}, []int{s.Reg}
+	case *binstmt.BinGETSUBSLICE:
+		use, def = []int{s.Reg, s.RegBegin, s.RegEnd}, []int{s.Reg}
+	case *binstmt.BinFUNC:
+		def = []int{s.Reg}
+	case *binstmt.BinCASTTYPE:
+		use, def = []int{s.Reg, s.TypeReg}, []int{s.Reg}
+	case *binstmt.BinMAKEARR:
+		use, def = []int{s.Reg, s.RegCap}, []int{s.Reg}
+	case *binstmt.BinCHANRECV:
+		use, def = []int{s.Reg}, []int{s.RegVal}
+	case *binstmt.BinCHANSEND:
+		use = []int{s.Reg, s.RegVal}
+	case *binstmt.BinISSLICE:
+		use, def = []int{s.Reg}, []int{s.RegBool}
+	case *binstmt.BinTRY:
+		def = []int{s.Reg}
+	case *binstmt.BinTRYFIN:
+		def = []int{s.Reg}
+	case *binstmt.BinFOREACH:
+		use, def = []int{s.Reg}, []int{s.Reg, s.RegIter}
+	case *binstmt.BinFOREACHPAR:
+		use = []int{s.Reg, s.RegWorkers}
+	case *binstmt.BinNEXT:
+		use, def = []int{s.Reg, s.RegIter, s.RegVal}, []int{s.RegIter, s.RegVal}
+		if s.Pair {
+			def = append(def, s.RegKey)
+		}
+	case *binstmt.BinFORNUM:
+		use, def = []int{s.RegFrom, s.RegTo}, []int{s.Reg}
+		if s.HasStep {
+			use = append(use, s.RegStep)
+		}
+	case *binstmt.BinNEXTNUM:
+		use, def = []int{s.Reg, s.RegFrom, s.RegTo}, []int{s.Reg}
+		if s.HasStep {
+			use = append(use, s.RegStep)
+		}
+	case *binstmt.BinRET:
+		use = []int{s.Reg}
+	case *binstmt.BinTHROW:
+		use = []int{s.Reg}
+	case *binstmt.BinTRYRECV:
+		use, def = []int{s.Reg}, []int{s.RegVal, s.RegOk, s.RegClosed}
+	case *binstmt.BinTRYSEND:
+		use, def = []int{s.Reg, s.RegVal}, []int{s.RegOk}
+	case *binstmt.BinCASTNUM, *binstmt.BinSETNAME, *binstmt.BinUNARY, *binstmt.BinGETMEMBER,
+		*binstmt.BinMAKECHAN, *binstmt.BinISKIND, *binstmt.BinCATCH, *binstmt.BinENDFIN,
+		*binstmt.BinINC, *binstmt.BinDEC, *binstmt.BinADDRID, *binstmt.BinADDRMBR,
+		*binstmt.BinUNREFID, *binstmt.BinUNREFMBR:
+		r := *singleReg(s)
+		use, def = []int{r}, []int{r}
+	}
+	return
+}
+
+// singleReg возвращает поле регистра инструкции, которая читает и перезаписывает один регистр
+func singleReg(stmt binstmt.BinStmt) *int {
+	switch s := stmt.(type) {
+	case *binstmt.BinCASTNUM:
+		return &s.Reg
+	case *binstmt.BinSETNAME:
+		return &s.Reg
+	case *binstmt.BinUNARY:
+		return &s.Reg
+	case *binstmt.BinGETMEMBER:
+		return &s.Reg
+	case *binstmt.BinMAKE:
+		return &s.Reg
+	case *binstmt.BinMAKECHAN:
+		return &s.Reg
+	case *binstmt.BinISKIND:
+		return &s.Reg
+	case *binstmt.BinCATCH:
+		return &s.Reg
+	case *binstmt.BinENDFIN:
+		return &s.Reg
+	case *binstmt.BinINC:
+		return &s.Reg
+	case *binstmt.BinDEC:
+		return &s.Reg
+	case *binstmt.BinADDRID:
+		return &s.Reg
+	case *binstmt.BinADDRMBR:
+		return &s.Reg
+	case *binstmt.BinUNREFID:
+		return &s.Reg
+	case *binstmt.BinUNREFMBR:
+		return &s.Reg
+	}
+	return nil
+}
+
+// mapRegs заменяет регистры инструкции. Регистры аргументов вызова должны отображаться с сохранением порядка.
+func mapRegs(stmt binstmt.BinStmt, f func(int) int) {
+	m := func(rs ...*int) {
+		for _, r := range rs {
+			*r = f(*r)
+		}
+	}
+	switch s := stmt.(type) {
+	case *binstmt.BinLOAD:
+		m(&s.Reg)
+	case *binstmt.BinMV:
+		m(&s.RegFrom, &s.RegTo)
+	case *binstmt.BinEQUAL:
+		m(&s.Reg, &s.Reg1, &s.Reg2)
+	case *binstmt.BinMAKESLICE:
+		m(&s.Reg)
+	case *binstmt.BinSETIDX:
+		m(&s.Reg, &s.RegVal)
+	case *binstmt.BinMAKEMAP:
+		m(&s.Reg)
+	case *binstmt.BinSETKEY:
+		m(&s.Reg, &s.RegVal)
+	case *binstmt.BinGET:
+		m(&s.Reg)
+	case *binstmt.BinSET:
+		m(&s.Reg)
+	case *binstmt.BinGETLOCAL:
+		m(&s.Reg)
+	case *binstmt.BinSETLOCAL:
+		m(&s.Reg)
+	case *binstmt.BinSETMEMBER:
+		m(&s.Reg, &s.RegVal)
+	case *binstmt.BinSETITEM:
+		m(&s.Reg, &s.RegIndex, &s.RegVal, &s.RegNeedLet)
+	case *binstmt.BinSETSLICE:
+		m(&s.Reg, &s.RegBegin, &s.RegEnd, &s.RegVal, &s.RegNeedLet)
+	case *binstmt.BinJTRUE:
+		m(&s.Reg)
+	case *binstmt.BinJFALSE:
+		m(&s.Reg)
+	case *binstmt.BinOPER:
+		m(&s.RegL, &s.RegR)
+	case *binstmt.BinCONCAT:
+		m(&s.Reg)
+	case *binstmt.BinTYPE:
+		if s.NumRegs() > 0 {
+			m(&s.Reg)
+		} else {
+			s.Reg = 0
+		}
+	case *binstmt.BinCALL:
+		if len(callArgs(s)) > 0 {
+			m(&s.RegArgs)
+		} else {
+			s.RegArgs = 0
+		}
+		m(&s.RegRets)
+	case *binstmt.BinGETIDX:
+		m(&s.Reg, &s.RegIndex)
+	case *binstmt.BinGETSUBSLICE:
+		m(&s.Reg, &s.RegBegin, &s.RegEnd)
+	case *binstmt.BinFUNC:
+		m(&s.Reg)
+	case *binstmt.BinCASTTYPE:
+		m(&s.Reg, &s.TypeReg)
+	case *binstmt.BinMAKEARR:
+		m(&s.Reg, &s.RegCap)
+	case *binstmt.BinCHANRECV:
+		m(&s.Reg, &s.RegVal)
+	case *binstmt.BinCHANSEND:
+		m(&s.Reg, &s.RegVal)
+	case *binstmt.BinISSLICE:
+		m(&s.Reg, &s.RegBool)
+	case *binstmt.BinTRY:
+		m(&s.Reg)
+	case *binstmt.BinTRYFIN:
+		m(&s.Reg)
+	case *binstmt.BinFOREACH:
+		m(&s.Reg, &s.RegIter)
+	case *binstmt.BinFOREACHPAR:
+		m(&s.Reg, &s.RegWorkers)
+	case *binstmt.BinNEXT:
+		m(&s.Reg, &s.RegVal, &s.RegIter)
+		if s.Pair {
+			m(&s.RegKey)
+		}
+	case *binstmt.BinFORNUM:
+		m(&s.Reg, &s.RegFrom, &s.RegTo)
+		if s.HasStep {
+			m(&s.RegStep)
+		}
+	case *binstmt.BinNEXTNUM:
+		m(&s.Reg, &s.RegFrom, &s.RegTo)
+		if s.HasStep {
+			m(&s.RegStep)
+		}
+	case *binstmt.BinRET:
+		m(&s.Reg)
+	case *binstmt.BinTHROW:
+		m(&s.Reg)
+	case *binstmt.BinTRYRECV:
+		m(&s.Reg, &s.RegVal, &s.RegOk, &s.RegClosed)
+	case *binstmt.BinTRYSEND:
+		m(&s.Reg, &s.RegVal, &s.RegOk)
+	default:
+		if r := singleReg(s); r != nil {
+			m(r)
+		}
+	}
+}
+
+// labelRefs возвращает метки, на которые ссылается инструкция
+func labelRefs(stmt binstmt.BinStmt) []int {
+	switch s := stmt.(type) {
+	case *binstmt.BinJMP:
+		return []int{s.JumpTo}
+	case *binstmt.BinJTRUE:
+		return []int{s.JumpTo}
+	case *binstmt.BinJFALSE:
+		return []int{s.JumpTo}
+	case *binstmt.BinTRY:
+		return []int{s.JumpTo}
+	case *binstmt.BinCATCH:
+		return []int{s.JumpTo}
+	case *binstmt.BinPOPTRY:
+		return []int{s.CatchLabel}
+	case *binstmt.BinTRYFIN:
+		return []int{s.JumpTo}
+	case *binstmt.BinFOREACH:
+		return []int{s.BreakLabel, s.ContinueLabel}
+	case *binstmt.BinFOREACHPAR:
+		return []int{s.LabelStart, s.LabelEnd}
+	case *binstmt.BinNEXT:
+		return []int{s.JumpTo}
+	case *binstmt.BinPOPFOR:
+		return []int{s.ContinueLabel}
+	case *binstmt.BinFORNUM:
+		return []int{s.BreakLabel, s.ContinueLabel}
+	case *binstmt.BinNEXTNUM:
+		return []int{s.JumpTo}
+	case *binstmt.BinWHILE:
+		return []int{s.BreakLabel, s.ContinueLabel}
+	case *binstmt.BinFUNC:
+		return []int{s.LabelStart, s.LabelEnd}
+	}
+	return nil
+}
+
+// jumpTarget возвращает поле метки перехода у инструкций JMP, JTRUE, JFALSE, NEXT, NEXTNUM и CATCH
+func jumpTarget(stmt binstmt.BinStmt) *int {
+	switch s := stmt.(type) {
+	case *binstmt.BinJMP:
+		return &s.JumpTo
+	case *binstmt.BinJTRUE:
+		return &s.JumpTo
+	case *binstmt.BinJFALSE:
+		return &s.JumpTo
+	case *binstmt.BinNEXT:
+		return &s.JumpTo
+	case *binstmt.BinNEXTNUM:
+		return &s.JumpTo
+	case *binstmt.BinCATCH:
+		return &s.JumpTo
+	}
+	return nil
+}
+
+// remove удаляет отмеченные инструкции и пересчитывает индексы меток
+func remove(code *binstmt.BinCode, del []bool) bool {
+	res := code.Code[:0]
+	for i, s := range code.Code {
+		if !del[i] {
+			res = append(res, s)
+		}
+	}
+	changed := len(res) != len(code.Code)
+	code.Code = res
+	relabel(code)
+	return changed
+}
+
+// relabel пересчитывает индексы меток после изменения кода
+func relabel(code *binstmt.BinCode) {
+	last := len(code.Labels) - 1
+	for _, s := range code.Code {
+		if l, ok := s.(*binstmt.BinLABEL); ok && l.Label > last {
+			last = l.Label
+		}
+	}
+	if last < 0 {
+		last = 0
+	}
+	code.MapLabels(last)
+}
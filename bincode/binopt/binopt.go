@@ -0,0 +1,169 @@
+// Package binopt - оптимизация скомпилированного байткода перед исполнением или записью в .gnx.
+//
+// Компилятор ast порождает простой регистровый код: лишние пересылки MV и загрузки LOAD,
+// переходы на переходы, недостижимый код после Возврат и Вызвать исключение, регистры тел функций
+// нумеруются с регистра, в котором объявлена функция, а все переменные ищутся по именам в цепочке окружений.
+// Проходы оптимизации исправляют это, каждый из них можно включать и выключать отдельно.
+package binopt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+)
+
+// Passes - набор проходов оптимизации
+type Passes uint
+
+const (
+	// Peephole удаляет пересылки регистра в себя и неиспользуемые загрузки,
+	// результат инструкции записывается сразу в регистр, в который он затем пересылался
+	Peephole Passes = 1 << iota
+	// JumpThreading заменяет переходы на переходы прямыми переходами и удаляет переходы на следующую инструкцию
+	JumpThreading
+	// DeadCode удаляет недостижимый код, например после Возврат и Вызвать исключение, и неиспользуемые метки
+	DeadCode
+	// RegReuse назначает регистры заново с учетом времени жизни значений, чтобы уменьшить MaxReg
+	RegReuse
+	// HoistBuiltins выносит из циклов получение встроенных функций, которые код не переопределяет
+	HoistBuiltins
+	// Locals определяет номера локальных переменных функций, обращение к ним происходит по номеру, а не по имени
+	Locals
+	// TailCalls отмечает хвостовые вызовы в операторе Возврат, они исполняются без увеличения стека вызовов
+	TailCalls
+
+	// None - без оптимизации
+	None Passes = 0
+	// All - все проходы
+	All = Peephole | JumpThreading | DeadCode | RegReuse | HoistBuiltins | Locals | TailCalls
+)
+
+var passNames = []struct {
+	pass Passes
+	name string
+}{
+	{Peephole, "peephole"},
+	{JumpThreading, "jumps"},
+	{DeadCode, "deadcode"},
+	{RegReuse, "regs"},
+	{HoistBuiltins, "hoist"},
+	{Locals, "locals"},
+	{TailCalls, "tail"},
+}
+
+func (p Passes) String() string {
+	switch p {
+	case None:
+		return "none"
+	case All:
+		return "all"
+	}
+	var ss []string
+	for _, pn := range passNames {
+		if p&pn.pass != 0 {
+			ss = append(ss, pn.name)
+		}
+	}
+	return strings.Join(ss, ",")
+}
+
+// ParsePasses разбирает список проходов через запятую, например "peephole,jumps".
+// Допускаются также all - все проходы и none - без оптимизации.
+func ParsePasses(s string) (Passes, error) {
+	var p Passes
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", "none":
+			continue
+		case "all":
+			p |= All
+			continue
+		}
+		found := false
+		for _, pn := range passNames {
+			if pn.name == name {
+				p |= pn.pass
+				found = true
+			}
+		}
+		if !found {
+			return None, fmt.Errorf("Неизвестный проход оптимизации %q", name)
+		}
+	}
+	return p, nil
+}
+
+// Optimize выполняет проходы оптимизации над кодом и кодом вложенных модулей.
+// Отладочная информация, если она уже добавлена к коду, пересчитывается.
+func Optimize(code *binstmt.BinCode, passes Passes) {
+	var defined map[string]bool
+	if passes&HoistBuiltins != 0 {
+		if loadsCode(code) {
+			// загруженный код может переопределить любое имя, в том числе встроенной функции
+			passes &^= HoistBuiltins
+		} else {
+			defined = definedNames(code)
+		}
+	}
+	optimize(code, passes, defined)
+}
+
+func optimize(code *binstmt.BinCode, passes Passes, defined map[string]bool) {
+	for _, s := range code.Code {
+		if m, ok := s.(*binstmt.BinMODULE); ok {
+			optimize(&m.Code, passes, defined)
+		}
+	}
+	if passes == None {
+		return
+	}
+
+	if passes&Locals != 0 {
+		resolveLocals(code)
+	}
+	if passes&HoistBuiltins != 0 {
+		hoistBuiltins(code, defined)
+	}
+	if passes&JumpThreading != 0 {
+		threadJumps(code)
+	}
+	if passes&DeadCode != 0 {
+		removeDeadCode(code)
+	}
+	if passes&Peephole != 0 {
+		peephole(code)
+	}
+	if passes&RegReuse != 0 {
+		reuseRegs(code)
+	}
+	if passes&TailCalls != 0 {
+		markTailCalls(code)
+	}
+	setMaxRegs(code, passes&RegReuse != 0)
+
+	if code.Debug != nil {
+		code.AttachDebugInfo(code.Debug.File, code.Debug.Source)
+	}
+}
+
+// setMaxRegs устанавливает число регистров кода модуля, функций и тел параллельных циклов по используемым регистрам.
+// Без переназначения регистров число может только увеличиться, например после выноса из цикла.
+func setMaxRegs(code *binstmt.BinCode, exact bool) {
+	a := analyze(code)
+	for _, r := range a.regions {
+		max := 0
+		for _, i := range r.idx {
+			use, def := regsOf(code.Code[i])
+			for _, x := range append(use, def...) {
+				if x > max {
+					max = x
+				}
+			}
+		}
+		if mr := maxRegOf(code, r); exact || max > *mr {
+			*mr = max
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package binopt
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/pos"
+)
+
+func TestParsePasses(t *testing.T) {
+	cases := []struct {
+		s    string
+		want Passes
+		str  string
+	}{
+		{"", None, "none"},
+		{"none", None, "none"},
+		{"all", All, "all"},
+		{"peephole", Peephole, "peephole"},
+		{" Jumps , deadcode", JumpThreading | DeadCode, "jumps,deadcode"},
+		{"tail,regs,hoist,locals", TailCalls | RegReuse | HoistBuiltins | Locals, "regs,hoist,locals,tail"},
+	}
+	for _, c := range cases {
+		p, err := ParsePasses(c.s)
+		if err != nil {
+			t.Errorf("%q: %v", c.s, err)
+			continue
+		}
+		if p != c.want || p.String() != c.str {
+			t.Errorf("%q: получено %v (%s), ожидалось %v (%s)", c.s, uint(p), p, uint(c.want), c.str)
+		}
+		// строковое представление разбирается обратно в те же проходы
+		if back, err := ParsePasses(p.String()); err != nil || back != p {
+			t.Errorf("%q: обратный разбор %q дал %v, %v", c.s, p, back, err)
+		}
+	}
+	if _, err := ParsePasses("peephole,нетакого"); err == nil {
+		t.Error("нет ошибки для неизвестного прохода")
+	}
+}
+
+func TestRegset(t *testing.T) {
+	s := make(regset, 2)
+	for _, r := range []int{0, 5, 63, 64, 100} {
+		s.add(r)
+	}
+	s.del(5)
+	if !s.has(63) || !s.has(64) || s.has(5) || s.has(1) {
+		t.Errorf("неверное множество %v", s)
+	}
+	var got []int
+	s.each(func(r int) { got = append(got, r) })
+	if want := []int{0, 63, 64, 100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %v, ожидалось %v", got, want)
+	}
+
+	t2 := make(regset, 2)
+	t2.add(100)
+	if s.or(t2) {
+		t.Error("объединение с подмножеством изменило множество")
+	}
+	t2.add(7)
+	if !s.or(t2) || !s.has(7) {
+		t.Error("объединение не добавило регистр")
+	}
+}
+
+// testCode возвращает код:
+//
+//	LOAD r0, 1
+//	JMP L1
+//	L1:
+//	JMP L2
+//	LOAD r0, 2
+//	L2:
+//	RETURN r0
+func testCode() *binstmt.BinCode {
+	p := &pos.PosImpl{}
+	code := &binstmt.BinCode{
+		Code: binstmt.BinStmts{
+			binstmt.NewBinLOAD(0, core.VMInt(1), false, p),
+			binstmt.NewBinJMP(1, p),
+			binstmt.NewBinLABEL(1, p),
+			binstmt.NewBinJMP(2, p),
+			binstmt.NewBinLOAD(0, core.VMInt(2), false, p),
+			binstmt.NewBinLABEL(2, p),
+			binstmt.NewBinRET(0, p),
+		},
+	}
+	code.MapLabels(2)
+	return code
+}
+
+// listing возвращает инструкции кода в текстовом виде
+func listing(code *binstmt.BinCode) []string {
+	res := make([]string, len(code.Code))
+	for i, s := range code.Code {
+		res[i] = fmt.Sprint(s)
+	}
+	return res
+}
+
+func TestThreadJumps(t *testing.T) {
+	code := testCode()
+	threadJumps(code)
+	// переход на метку, за которой следует переход, направляется сразу по второму
+	want := []string{"LOAD r0, 1", "JMP L2", "L1:", "JMP L2", "LOAD r0, 2", "L2:", "RETURN r0"}
+	if got := listing(code); !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %q, ожидалось %q", got, want)
+	}
+
+	// переход на следующую инструкцию удаляется
+	code.Code = append(code.Code[:3], code.Code[5:]...)
+	code.MapLabels(2)
+	threadJumps(code)
+	want = []string{"LOAD r0, 1", "L1:", "L2:", "RETURN r0"}
+	if got := listing(code); !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %q, ожидалось %q", got, want)
+	}
+	if code.Labels[2] != 2 {
+		t.Errorf("индексы меток не пересчитаны: %v", code.Labels)
+	}
+}
+
+func TestRemoveDeadCode(t *testing.T) {
+	code := testCode()
+	removeDeadCode(code)
+	want := []string{"LOAD r0, 1", "JMP L1", "L1:", "JMP L2", "L2:", "RETURN r0"}
+	if got := listing(code); !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %q, ожидалось %q", got, want)
+	}
+
+	// после направления переходов метка L1 и переход за ней становятся недостижимыми
+	code = testCode()
+	threadJumps(code)
+	removeDeadCode(code)
+	want = []string{"LOAD r0, 1", "JMP L2", "L2:", "RETURN r0"}
+	if got := listing(code); !reflect.DeepEqual(got, want) {
+		t.Errorf("получено %q, ожидалось %q", got, want)
+	}
+}
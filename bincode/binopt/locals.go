@@ -0,0 +1,111 @@
+package binopt
+
+import (
+	"github.com/covrom/gonec/bincode/binstmt"
+)
+
+// resolveLocals заменяет обращения к локальным переменным функций и тел параллельных циклов
+// инструкциями GETLOCAL и SETLOCAL с номерами переменных в окружении вызова.
+// Локальными считаются параметры функции, переменная параллельного цикла, объявленные в теле функции
+// и все переменные, которым в теле присваивается значение. Присваивание в замыкании изменяет переменную
+// объемлющей функции, если она в ней локальная и ей уже присвоено значение. Код модуля и присваивания
+// в теле параллельного цикла в коде модуля обращаются к переменным по именам.
+func resolveLocals(code *binstmt.BinCode) {
+	a := analyze(code)
+	for _, r := range a.regions {
+		if locals := ownerLocals(r.owner); locals != nil && *locals != nil {
+			// номера уже определены
+			return
+		}
+	}
+
+	slots := make([]map[int]int, len(a.regions))
+	locals := make([][]int, len(a.regions))
+	def := func(rn, id int) {
+		if _, ok := slots[rn][id]; !ok {
+			slots[rn][id] = len(locals[rn])
+			locals[rn] = append(locals[rn], id)
+		}
+	}
+	// find возвращает номер переменной и число окружений вверх до области, в которой она локальная.
+	// Цепочка заканчивается на коде модуля, функции модуля не являются замыканиями.
+	find := func(rn, id int) (slot, depth int, ok bool) {
+		for ; rn >= 0 && a.regions[rn].owner != nil; rn = a.regions[rn].parent {
+			if slot, ok := slots[rn][id]; ok {
+				return slot, depth, true
+			}
+			depth++
+		}
+		return 0, 0, false
+	}
+
+	// inModule сообщает, что область - тело параллельного цикла в коде модуля, возможно вложенное в другие такие циклы
+	inModule := func(rn int) bool {
+		for rn = a.regions[rn].parent; rn >= 0; rn = a.regions[rn].parent {
+			if _, ok := a.regions[rn].owner.(*binstmt.BinFOREACHPAR); !ok {
+				break
+			}
+		}
+		return rn < 0 || a.regions[rn].owner == nil
+	}
+
+	// области перечислены от внешних к вложенным, поэтому переменные объемлющих областей уже известны
+	for rn, r := range a.regions {
+		byName := false
+		switch s := r.owner.(type) {
+		case *binstmt.BinFUNC:
+			slots[rn] = make(map[int]int)
+			for _, arg := range s.Args {
+				def(rn, arg)
+			}
+		case *binstmt.BinFOREACHPAR:
+			slots[rn] = make(map[int]int)
+			def(rn, s.Var)
+			// итерация изменяет переменные модуля, определенные до цикла, а какие из них определены,
+			// известно только при исполнении, поэтому присваивание остается по имени
+			byName = inModule(rn)
+		default:
+			continue
+		}
+		for _, i := range r.idx {
+			switch s := code.Code[i].(type) {
+			case *binstmt.BinSET:
+				if _, _, ok := find(rn, s.Id); !ok && !byName {
+					def(rn, s.Id)
+				}
+			case *binstmt.BinFUNC:
+				// функция всегда определяется в окружении, в котором объявлена
+				def(rn, s.Name)
+			}
+		}
+	}
+
+	for i, s := range code.Code {
+		switch ss := s.(type) {
+		case *binstmt.BinGET:
+			if slot, depth, ok := find(a.region[i], ss.Id); ok {
+				code.Code[i] = binstmt.NewBinGETLOCAL(ss.Reg, ss.Id, slot, depth, ss)
+			}
+		case *binstmt.BinSET:
+			if slot, depth, ok := find(a.region[i], ss.Id); ok {
+				code.Code[i] = binstmt.NewBinSETLOCAL(ss.Reg, ss.Id, slot, depth, ss)
+			}
+		}
+	}
+	for rn, r := range a.regions {
+		if l := ownerLocals(r.owner); l != nil {
+			*l = locals[rn]
+		}
+	}
+}
+
+// ownerLocals возвращает поле локальных переменных функции или параллельного цикла
+func ownerLocals(owner binstmt.BinStmt) *[]int {
+	switch s := owner.(type) {
+	case *binstmt.BinFUNC:
+		return &s.Locals
+	case *binstmt.BinFOREACHPAR:
+		return &s.Locals
+	}
+	return nil
+}
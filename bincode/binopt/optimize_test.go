@@ -0,0 +1,260 @@
+package binopt_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/bincode/binopt"
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+)
+
+// runOptimized компилирует код с заданными проходами оптимизации и исполняет его,
+// возвращая все, что было выведено, включая ошибку исполнения
+func runOptimized(t *testing.T, script string, passes binopt.Passes) (string, binstmt.BinCode) {
+	t.Helper()
+	old := bincode.Optimizations
+	bincode.Optimizations = passes
+	defer func() { bincode.Optimizations = old }()
+	_, bins, err := bincode.ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnv()
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	if _, err = bincode.Run(context.Background(), bins, env); err != nil {
+		fmt.Fprintln(&buf, "ошибка:", err)
+	}
+	return buf.String(), bins
+}
+
+// countStmts возвращает число инструкций кода, для которых f возвращает true
+func countStmts(bins binstmt.BinCode, f func(binstmt.BinStmt) bool) int {
+	n := 0
+	for _, s := range bins.Code {
+		if f(s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestOptimize(t *testing.T) {
+	scripts := []string{`
+	Функция Макс(а, б)
+		Если а > б Тогда
+			Возврат а
+		Иначе
+			Возврат б
+		КонецЕсли
+		Сообщить("не исполняется")
+	КонецФункции
+	Функция Без()
+		х = 5
+	КонецФункции
+	с = 0
+	Для к = 1 По 10 Цикл
+		Если к % 2 = 0 Тогда
+			Продолжить
+		КонецЕсли
+		с = с + Макс(к, 3) + Длина("абв")
+	КонецЦикла
+	н = 0
+	Пока Истина Цикл
+		н = н + 1
+		Если н > 5 Тогда
+			Прервать
+		КонецЕсли
+	КонецЦикла
+	ф = Длина
+	м = [1, 2, 3][1:]
+	а, б = [1, 2]
+	Сообщить(с, н, ф("абвг"), м, а, б, Без())
+	Выбор н:
+	Когда 5:
+		Сообщить("пять")
+	Когда 6:
+		Сообщить("шесть")
+	Другое:
+		Сообщить("другое")
+	КонецВыбора
+	кан = Новый Канал(1)
+	кан <- 7
+	х = <-кан
+	Сообщить(х, ?(х > 5, "больше", "меньше"))
+	Для каждого э Из [3, 1, 2] Цикл
+		Для каждого з Из ["а", "бб"] Цикл
+			Попытка
+				Если э = 1 Тогда
+					ВызватьИсключение "один"
+				КонецЕсли
+				Сообщить(э, Длина(з), Формат(э, ""))
+			Исключение
+				Сообщить(ОписаниеОшибки())
+				Прервать
+			Окончательно
+				Сообщить("ок")
+			КонецПопытки
+		КонецЦикла
+	КонецЦикла
+	р = [0, 0, 0]
+	Для каждого н Из [0, 1, 2] Параллельно Цикл
+		т = 0
+		Для ш = 0 По н Цикл
+			т = т + Длина("ab")
+		КонецЦикла
+		р[н] = т
+	КонецЦикла
+	Сообщить(р)
+	`, `
+	Функция Счетчик()
+		н = 0
+		Возврат Функция()
+			н = н + 1
+			Попытка
+				Если н = 2 Тогда
+					ВызватьИсключение "два"
+				КонецЕсли
+				Возврат н
+			Исключение
+				Возврат -н
+			Окончательно
+				Сообщить("вызов", н)
+			КонецПопытки
+		КонецФункции
+	КонецФункции
+	сч = Счетчик()
+	Сообщить(сч(), сч(), сч())
+	Функция Сумма(м)
+		с = 0
+		Для каждого э Из м Цикл
+			Если э < 0 Тогда
+				Продолжить
+			ИначеЕсли э > 100 Тогда
+				Прервать
+			КонецЕсли
+			с = с + э * Длина(Строка(э))
+		КонецЦикла
+		Возврат с
+	КонецФункции
+	Сообщить(Сумма([1, -2, 30, 400, 5]))
+	ст = {"а": 1, "б": [1, 2, 3]}
+	ст.в = ст.б[1:]
+	ст["а"] = ст["а"] + 10
+	Сообщить(ст.а, ст.в, ст.б[-1])
+	к = 0
+	Пока к < 3 Цикл
+		к = к + 1
+		п = Длина
+		Сообщить(к, п("ёж"), ?(к = 2, "два", "не два"))
+	КонецЦикла
+	`}
+	passes := []binopt.Passes{binopt.Peephole, binopt.JumpThreading, binopt.DeadCode, binopt.RegReuse, binopt.HoistBuiltins, binopt.Locals, binopt.TailCalls, binopt.All}
+	for n, script := range scripts {
+		want, _ := runOptimized(t, script, binopt.None)
+		for _, p := range passes {
+			if got, _ := runOptimized(t, script, p); got != want {
+				t.Errorf("код %d, проходы %s: получено %q, ожидалось %q", n, p, got, want)
+			}
+		}
+	}
+
+	// каждый проход действительно изменяет код
+	script := `
+	Функция Ф(а)
+		Если а Тогда
+			Возврат [1, 2]
+		КонецЕсли
+		Возврат [3]
+		Сообщить("недостижимо")
+	КонецФункции
+	Для н = 1 По 3 Цикл
+		д = Длина
+		с = д(Ф(н > 1))
+		Сообщить(с)
+	КонецЦикла
+	`
+	_, plain := runOptimized(t, script, binopt.None)
+	isGET := func(s binstmt.BinStmt) bool { _, ok := s.(*binstmt.BinGET); return ok }
+	isJMP := func(s binstmt.BinStmt) bool { _, ok := s.(*binstmt.BinJMP); return ok }
+
+	if _, bins := runOptimized(t, script, binopt.Peephole); countStmts(bins, isGET) >= countStmts(plain, isGET) {
+		t.Errorf("peephole: получение присвоенной переменной не заменено\n%s", bins)
+	}
+	if _, bins := runOptimized(t, script, binopt.JumpThreading); countStmts(bins, isJMP) >= countStmts(plain, isJMP) {
+		t.Errorf("jumps: переходы не удалены\n%s", bins)
+	}
+	if _, bins := runOptimized(t, script, binopt.DeadCode); strings.Contains(bins.String(), "недостижимо") {
+		t.Errorf("deadcode: недостижимый код не удален\n%s", bins)
+	}
+	maxRegs := func(bins binstmt.BinCode) (res []int) {
+		for _, s := range bins.Code {
+			if f, ok := s.(*binstmt.BinFUNC); ok {
+				res = append(res, bins.MaxReg, f.MaxReg)
+			}
+		}
+		return
+	}
+	_, bins := runOptimized(t, script, binopt.RegReuse)
+	if got, was := maxRegs(bins), maxRegs(plain); got[0] > was[0] || got[1] >= was[1] {
+		t.Errorf("regs: регистров модуля и функции %v, без оптимизации %v", got, was)
+	}
+	_, bins = runOptimized(t, script, binopt.HoistBuiltins)
+	hoisted := false
+	for _, s := range bins.Code {
+		if g, ok := s.(*binstmt.BinGET); ok && strings.EqualFold(names.UniqueNames.Get(g.Id), "длина") {
+			hoisted = true
+		}
+		if _, ok := s.(*binstmt.BinFORNUM); ok {
+			break
+		}
+	}
+	if !hoisted {
+		t.Errorf("hoist: получение встроенной функции не вынесено из цикла\n%s", bins)
+	}
+}
+
+// TestHoistLoadedCode проверяет, что встроенные функции не выносятся из циклов, если код может быть
+// переопределен загружаемым кодом: результат не должен зависеть от оптимизации
+func TestHoistLoadedCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "длина.gnc")
+	if err := ioutil.WriteFile(fn, []byte("Функция Длина(х)\n\tВозврат 42\nКонецФункции\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scripts := []string{`Для н = 1 По 3 Цикл
+	функцдлины = Длина
+	Сообщить(функцдлины("абв"))
+	Если н = 1 Тогда
+		ЗагрузитьИВыполнить("` + filepath.ToSlash(fn) + `")
+	КонецЕсли
+КонецЦикла
+`, `Для н = 1 По 3 Цикл
+	функцдлины = Длина
+	Сообщить(функцдлины("абв"))
+	Если н = 1 Тогда
+		Выполнить("Функция Длина(х) Возврат 7 КонецФункции")
+	КонецЕсли
+КонецЦикла
+`}
+	for _, script := range scripts {
+		was, _ := runOptimized(t, script, binopt.None)
+		got, _ := runOptimized(t, script, binopt.All)
+		if got != was {
+			t.Errorf("с оптимизацией %q, без оптимизации %q", got, was)
+		}
+	}
+}
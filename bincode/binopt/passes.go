@@ -0,0 +1,503 @@
+package binopt
+
+import (
+	"sort"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/names"
+)
+
+// threadJumps направляет переходы сразу на конечную метку цепочки безусловных переходов,
+// заменяет условный переход через безусловный одним обратным условным переходом
+// и удаляет переходы на следующую инструкцию
+func threadJumps(code *binstmt.BinCode) {
+	a := analyze(code)
+	n := len(code.Code)
+	del := make([]bool, n)
+
+	// skip возвращает индекс первой инструкции, не являющейся меткой, начиная с j в области инструкции i
+	skip := func(i, j int) int {
+		for ; a.sameRegion(i, j); j++ {
+			if _, ok := code.Code[j].(*binstmt.BinLABEL); !ok {
+				return j
+			}
+		}
+		return -1
+	}
+
+	resolve := func(i, l int) int {
+		seen := make(map[int]bool)
+		for !seen[l] {
+			seen[l] = true
+			j, ok := a.labels[l]
+			if !ok {
+				break
+			}
+			j = skip(i, j)
+			if j < 0 {
+				break
+			}
+			jmp, ok := code.Code[j].(*binstmt.BinJMP)
+			if !ok {
+				break
+			}
+			l = jmp.JumpTo
+		}
+		return l
+	}
+
+	for i, s := range code.Code {
+		if t := jumpTarget(s); t != nil {
+			*t = resolve(i, *t)
+		}
+	}
+
+	// jumpsNext сообщает, что переход из i на метку l равнозначен переходу на инструкцию after
+	jumpsNext := func(i, l, after int) bool {
+		j, ok := a.labels[l]
+		k := skip(i, after)
+		return ok && j >= after && k >= 0 && k == skip(i, j)
+	}
+
+	for i, s := range code.Code {
+		if del[i] {
+			continue
+		}
+		switch ss := s.(type) {
+		case *binstmt.BinJMP:
+			if jumpsNext(i, ss.JumpTo, i+1) {
+				del[i] = true
+			}
+		case *binstmt.BinJTRUE, *binstmt.BinJFALSE:
+			if i+1 >= n || !a.sameRegion(i, i+1) {
+				continue
+			}
+			jmp, ok := code.Code[i+1].(*binstmt.BinJMP)
+			if !ok || !jumpsNext(i, *jumpTarget(s), i+2) {
+				continue
+			}
+			if jt, ok := ss.(*binstmt.BinJTRUE); ok {
+				code.Code[i] = binstmt.NewBinJFALSE(jt.Reg, jmp.JumpTo, jt)
+			} else {
+				jf := ss.(*binstmt.BinJFALSE)
+				code.Code[i] = binstmt.NewBinJTRUE(jf.Reg, jmp.JumpTo, jf)
+			}
+			del[i+1] = true
+		}
+	}
+	remove(code, del)
+}
+
+// removeDeadCode удаляет инструкции, которые не могут быть исполнены, и метки, на которые никто не ссылается.
+// Тела функций и параллельных циклов достижимы, только если достижимо их объявление.
+func removeDeadCode(code *binstmt.BinCode) {
+	a := analyze(code)
+	n := len(code.Code)
+	if n == 0 {
+		return
+	}
+	reach := make([]bool, n)
+	stack := []int{0}
+	reach[0] = true
+	push := func(i int) {
+		if !reach[i] {
+			reach[i] = true
+			stack = append(stack, i)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, j := range a.succs[i] {
+			push(j)
+		}
+		var start int
+		switch s := code.Code[i].(type) {
+		case *binstmt.BinFUNC:
+			start = s.LabelStart
+		case *binstmt.BinFOREACHPAR:
+			start = s.LabelStart
+		default:
+			continue
+		}
+		if j, ok := a.labels[start]; ok {
+			push(j)
+		}
+	}
+
+	refs := make(map[int]bool)
+	for i, s := range code.Code {
+		if reach[i] {
+			for _, l := range labelRefs(s) {
+				refs[l] = true
+			}
+		}
+	}
+	del := make([]bool, n)
+	for i, s := range code.Code {
+		if l, ok := s.(*binstmt.BinLABEL); ok {
+			del[i] = !refs[l.Label]
+		} else {
+			del[i] = !reach[i]
+		}
+	}
+	remove(code, del)
+}
+
+// resultReg возвращает поле регистра результата инструкций, которые только записывают его
+func resultReg(stmt binstmt.BinStmt) *int {
+	switch s := stmt.(type) {
+	case *binstmt.BinLOAD:
+		return &s.Reg
+	case *binstmt.BinGET:
+		return &s.Reg
+	case *binstmt.BinGETLOCAL:
+		return &s.Reg
+	case *binstmt.BinCALL:
+		return &s.RegRets
+	}
+	return nil
+}
+
+// peephole удаляет пересылки регистра в себя, повторные встречные пересылки и загрузки значений,
+// которые не будут прочитаны, заменяет получение только что присвоенной переменной пересылкой,
+// а результат загрузки, получения переменной или вызова, сразу пересылаемый в другой регистр, записывает прямо в него
+func peephole(code *binstmt.BinCode) {
+	for changed := true; changed; {
+		changed = false
+		a := analyze(code)
+		_, out := a.liveness()
+		n := len(code.Code)
+		del := make([]bool, n)
+		for i, s := range code.Code {
+			if del[i] {
+				continue
+			}
+			switch ss := s.(type) {
+			case *binstmt.BinMV:
+				if ss.RegFrom == ss.RegTo || !out[i].has(ss.RegTo) {
+					del[i] = true
+					continue
+				}
+				if j := i + 1; j < n && a.sameRegion(i, j) {
+					if mv, ok := code.Code[j].(*binstmt.BinMV); ok && mv.RegFrom == ss.RegTo && mv.RegTo == ss.RegFrom {
+						del[j] = true
+					}
+				}
+				continue
+			case *binstmt.BinLOAD:
+				if !out[i].has(ss.Reg) {
+					del[i] = true
+					continue
+				}
+			case *binstmt.BinSET:
+				// значение только что присвоенной переменной уже есть в регистре
+				if j := i + 1; j < n && a.sameRegion(i, j) {
+					if g, ok := code.Code[j].(*binstmt.BinGET); ok && g.Id == ss.Id {
+						code.Code[j] = binstmt.NewBinMV(ss.Reg, g.Reg, g)
+						changed = true
+					}
+				}
+				continue
+			case *binstmt.BinSETLOCAL:
+				if j := i + 1; j < n && a.sameRegion(i, j) {
+					if g, ok := code.Code[j].(*binstmt.BinGETLOCAL); ok && g.Slot == ss.Slot && g.Depth == ss.Depth {
+						code.Code[j] = binstmt.NewBinMV(ss.Reg, g.Reg, g)
+						changed = true
+					}
+				}
+				continue
+			}
+			r := resultReg(s)
+			j := i + 1
+			if r == nil || j >= n || !a.sameRegion(i, j) {
+				continue
+			}
+			if mv, ok := code.Code[j].(*binstmt.BinMV); ok && mv.RegFrom == *r && mv.RegTo != *r && !out[j].has(*r) {
+				*r = mv.RegTo
+				del[j] = true
+				changed = true
+			}
+		}
+		if remove(code, del) {
+			changed = true
+		}
+	}
+}
+
+// reuseRegs назначает регистры заново в каждой области: регистры, значения которых не нужны одновременно,
+// объединяются, а неиспользуемые номера исключаются. Регистры аргументов вызовов и частей строк с подстановками должны идти подряд,
+// поэтому они только сдвигаются с сохранением порядка, остальные регистры занимают свободные номера.
+func reuseRegs(code *binstmt.BinCode) {
+	a := analyze(code)
+	in, out := a.liveness()
+	for _, r := range a.regions {
+		used := make(map[int]bool)
+		args := make(map[int]bool)
+		adj := make(map[int]map[int]bool)
+		edge := func(x, y int) {
+			if x == y {
+				return
+			}
+			if adj[x] == nil {
+				adj[x] = make(map[int]bool)
+			}
+			if adj[y] == nil {
+				adj[y] = make(map[int]bool)
+			}
+			adj[x][y] = true
+			adj[y][x] = true
+		}
+		for _, i := range r.idx {
+			use, def := regsOf(code.Code[i])
+			for _, x := range use {
+				used[x] = true
+			}
+			for k, d := range def {
+				used[d] = true
+				// значение, записанное инструкцией, не должно затереть значения, которые еще будут прочитаны
+				out[i].each(func(l int) { edge(d, l) })
+				for _, d2 := range def[k+1:] {
+					edge(d, d2)
+				}
+			}
+			for _, x := range rangeRegs(code.Code[i]) {
+				args[x] = true
+			}
+		}
+		// значения, прочитанные до записи, остаются от предыдущего исполнения, их не объединяем
+		var entry []int
+		in[r.entry].each(func(x int) { entry = append(entry, x) })
+		for k, x := range entry {
+			for _, y := range entry[k+1:] {
+				edge(x, y)
+			}
+		}
+
+		var regs, others []int
+		for x := range used {
+			if args[x] {
+				regs = append(regs, x)
+			} else {
+				others = append(others, x)
+			}
+		}
+		sort.Ints(regs)
+		sort.Ints(others)
+		color := make(map[int]int, len(used))
+		for c, x := range regs {
+			color[x] = c
+		}
+		for _, x := range others {
+			taken := make(map[int]bool)
+			for y := range adj[x] {
+				if c, ok := color[y]; ok {
+					taken[c] = true
+				}
+			}
+			c := 0
+			for taken[c] {
+				c++
+			}
+			color[x] = c
+		}
+		for _, i := range r.idx {
+			mapRegs(code.Code[i], func(x int) int { return color[x] })
+		}
+	}
+
+	// после объединения регистров часть пересылок становится пересылками в себя
+	del := make([]bool, len(code.Code))
+	for i, s := range code.Code {
+		if mv, ok := s.(*binstmt.BinMV); ok && mv.RegFrom == mv.RegTo {
+			del[i] = true
+		}
+	}
+	remove(code, del)
+}
+
+// markTailCalls отмечает вызовы в функциях, результат которых сразу возвращается оператором Возврат.
+// Вызов внутри Попытка не хвостовой, после него еще исполняются обработчик исключения или блок Окончательно.
+func markTailCalls(code *binstmt.BinCode) {
+	a := analyze(code)
+	for i, s := range code.Code {
+		c, ok := s.(*binstmt.BinCALL)
+		if !ok || c.Go || c.Defer || len(a.handlers[i]) > 0 {
+			continue
+		}
+		if _, ok := a.regions[a.region[i]].owner.(*binstmt.BinFUNC); !ok {
+			continue
+		}
+		if j := a.next(i); j >= 0 {
+			if r, ok := code.Code[j].(*binstmt.BinRET); ok && r.Reg == c.RegRets {
+				c.Tail = true
+			}
+		}
+	}
+}
+
+// definedNames возвращает имена в нижнем регистре, которые код может определить или изменить
+func definedNames(code *binstmt.BinCode) map[string]bool {
+	defined := make(map[string]bool)
+	def := func(id int) {
+		defined[names.UniqueNames.GetLowerCase(id)] = true
+	}
+	var walk func(code *binstmt.BinCode)
+	walk = func(code *binstmt.BinCode) {
+		for _, s := range code.Code {
+			switch ss := s.(type) {
+			case *binstmt.BinSET:
+				def(ss.Id)
+			case *binstmt.BinSETLOCAL:
+				def(ss.Id)
+			case *binstmt.BinFUNC:
+				def(ss.Name)
+				for _, arg := range ss.Args {
+					def(arg)
+				}
+			case *binstmt.BinFOREACHPAR:
+				def(ss.Var)
+			case *binstmt.BinCATCH:
+				// функции, определяемые в блоке Исключение
+				defined["описаниеошибки"] = true
+				defined["информацияобошибке"] = true
+			case *binstmt.BinMODULE:
+				def(ss.Name)
+				walk(&ss.Code)
+			}
+		}
+	}
+	walk(code)
+	return defined
+}
+
+// loaderNames - встроенные функции, которые исполняют код, не известный при компиляции.
+// Он исполняется в глобальном контексте или в окружении вызывающего кода и может переопределить любое имя.
+var loaderNames = map[string]bool{
+	"загрузитьивыполнить": true,
+	"выполнить":           true,
+	"вычислить":           true,
+	"импорт":              true,
+}
+
+// loadsCode сообщает, что код или вложенные модули вызывают или получают встроенную функцию,
+// исполняющую загружаемый код. Переопределение имени таким кодом действует на всю программу,
+// поэтому проверяется весь код, а не только функция или модуль с циклом.
+func loadsCode(code *binstmt.BinCode) bool {
+	for _, s := range code.Code {
+		var id int
+		switch ss := s.(type) {
+		case *binstmt.BinCALL:
+			id = ss.Name
+		case *binstmt.BinGET:
+			id = ss.Id
+		case *binstmt.BinMODULE:
+			if loadsCode(&ss.Code) {
+				return true
+			}
+			continue
+		default:
+			continue
+		}
+		if id != 0 && loaderNames[names.UniqueNames.GetLowerCase(id)] {
+			return true
+		}
+	}
+	return false
+}
+
+// hoistBuiltins выносит получение встроенных функций из циклов: перед циклом значение получается один раз
+// в свободный регистр, а в теле цикла пересылается из него. Выносятся только имена, которые код не определяет,
+// поэтому их значение не меняется во время исполнения цикла.
+func hoistBuiltins(code *binstmt.BinCode, defined map[string]bool) {
+	if binstmt.BuiltinNames == nil {
+		return
+	}
+	builtins := make(map[string]bool)
+	for _, n := range binstmt.BuiltinNames() {
+		builtins[n] = true
+	}
+
+	a := analyze(code)
+	free := make([]int, len(a.regions))
+	for rn, r := range a.regions {
+		free[rn] = regionMaxReg(code, r) + 1
+	}
+
+	inserts := make(map[int][]binstmt.BinStmt)
+	for h, s := range code.Code {
+		var brk, cont int
+		switch ss := s.(type) {
+		case *binstmt.BinFORNUM:
+			brk, cont = ss.BreakLabel, ss.ContinueLabel
+		case *binstmt.BinFOREACH:
+			brk, cont = ss.BreakLabel, ss.ContinueLabel
+		case *binstmt.BinWHILE:
+			brk, cont = ss.BreakLabel, ss.ContinueLabel
+		default:
+			continue
+		}
+		from, ok1 := a.labels[cont]
+		to, ok2 := a.labels[brk]
+		if !ok1 || !ok2 || from < h || to < from {
+			continue
+		}
+		// циклы обходятся от внешних к внутренним, поэтому значение выносится из самого внешнего цикла
+		regs := make(map[int]int)
+		for i := from + 1; i < to; i++ {
+			g, ok := code.Code[i].(*binstmt.BinGET)
+			if !ok || !a.sameRegion(h, i) {
+				continue
+			}
+			name := names.UniqueNames.GetLowerCase(g.Id)
+			if !builtins[name] || defined[name] {
+				continue
+			}
+			r, ok := regs[g.Id]
+			if !ok {
+				rn := a.region[h]
+				r = free[rn]
+				free[rn]++
+				regs[g.Id] = r
+				inserts[h] = append(inserts[h], binstmt.NewBinGET(r, g.Id, g))
+			}
+			code.Code[i] = binstmt.NewBinMV(r, g.Reg, g)
+		}
+	}
+	if len(inserts) == 0 {
+		return
+	}
+
+	res := make(binstmt.BinStmts, 0, len(code.Code)+len(inserts))
+	for i, s := range code.Code {
+		res = append(res, inserts[i]...)
+		res = append(res, s)
+	}
+	code.Code = res
+	relabel(code)
+}
+
+// regionMaxReg возвращает максимальный регистр области с учетом заявленного числа регистров
+func regionMaxReg(code *binstmt.BinCode, r *region) int {
+	max := *maxRegOf(code, r)
+	for _, i := range r.idx {
+		use, def := regsOf(code.Code[i])
+		for _, x := range append(use, def...) {
+			if x > max {
+				max = x
+			}
+		}
+	}
+	return max
+}
+
+// maxRegOf возвращает поле максимального регистра области
+func maxRegOf(code *binstmt.BinCode, r *region) *int {
+	switch s := r.owner.(type) {
+	case *binstmt.BinFUNC:
+		return &s.MaxReg
+	case *binstmt.BinFOREACHPAR:
+		return &s.MaxReg
+	}
+	return &code.MaxReg
+}
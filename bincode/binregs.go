@@ -1,19 +1,24 @@
 package bincode
 
 import (
+	"github.com/covrom/gonec/bincode/binstmt"
 	"github.com/covrom/gonec/core"
 )
 
 // Регистры виртуальной машины
 
 type VMRegs struct {
-	Env          *core.Env
+	Env *core.Env
 	// Reg          []core.VMValuer // регистры значений
-	Labels       []int           // [label]=index в BinCode
-	TryLabel     []int           // последний элемент - это метка на текущий обработчик CATCH
-	TryRegErr    []int           // последний элемент - это регистр с ошибкой текущего обработчика
-	ForBreaks    []int           // последний элемент - это метка для break
-	ForContinues []int           // последний элемент - это метка для continue
+	Labels       []int          // [label]=index в BinCode
+	TryLabel     []int          // последний элемент - это метка на текущий обработчик CATCH
+	TryRegErr    []int          // последний элемент - это регистр с ошибкой текущего обработчика
+	TryFinally   []bool         // последний элемент - признак того, что текущий обработчик - это блок Окончательно
+	TryLoopDepth []int          // последний элемент - глубина стека циклов при входе в текущий обработчик
+	ForBreaks    []int          // последний элемент - это метка для break
+	ForContinues []int          // последний элемент - это метка для continue
+	Iters        []loopIter     // итераторы циклов Для каждого, которые еще не закрыты
+	Deferred     []deferredCall // вызовы оператора Отложить, исполняются в обратном порядке при выходе из функции
 	// ReturnTo     []int           // стек возвратов по RET
 }
 
@@ -31,8 +36,20 @@ type VMRegs struct {
 // }
 
 func (v *VMRegs) PushTry(reg, label int) {
+	v.pushTry(reg, label, false)
+}
+
+// PushFinally устанавливает обработчик блока Окончательно,
+// в регистр reg при выходе из блока Попытка помещается отложенное действие
+func (v *VMRegs) PushFinally(reg, label int) {
+	v.pushTry(reg, label, true)
+}
+
+func (v *VMRegs) pushTry(reg, label int, finally bool) {
 	v.TryRegErr = append(v.TryRegErr, reg)
 	v.TryLabel = append(v.TryLabel, label)
+	v.TryFinally = append(v.TryFinally, finally)
+	v.TryLoopDepth = append(v.TryLoopDepth, len(v.ForBreaks))
 }
 
 func (v *VMRegs) TopTryLabel() int {
@@ -43,18 +60,33 @@ func (v *VMRegs) TopTryLabel() int {
 	return v.TryLabel[l-1]
 }
 
-func (v *VMRegs) PopTry() (reg int, label int) {
+func (v *VMRegs) PopTry() (reg int, label int, finally bool) {
 	l := len(v.TryLabel)
 	if l == 0 {
-		return -1, -1
+		return -1, -1, false
 	}
 	reg = v.TryRegErr[l-1]
-	v.TryRegErr = v.TryRegErr[0 : l-1]
 	label = v.TryLabel[l-1]
+	finally = v.TryFinally[l-1]
+	v.TryRegErr = v.TryRegErr[0 : l-1]
 	v.TryLabel = v.TryLabel[0 : l-1]
+	v.TryFinally = v.TryFinally[0 : l-1]
+	v.TryLoopDepth = v.TryLoopDepth[0 : l-1]
 	return
 }
 
+// UnwindTry снимает со стека обработчики, установленные внутри циклов с глубиной от depth и больше
+// (depth=0 - все обработчики текущей функции), до первого блока Окончательно.
+// Если такой блок найден, возвращаются его регистр и метка.
+func (v *VMRegs) UnwindTry(depth int) (reg int, label int, ok bool) {
+	for l := len(v.TryLabel); l > 0 && v.TryLoopDepth[l-1] >= depth; l = len(v.TryLabel) {
+		if reg, label, finally := v.PopTry(); finally {
+			return reg, label, true
+		}
+	}
+	return -1, -1, false
+}
+
 func (v *VMRegs) PushBreak(label int) {
 	v.ForBreaks = append(v.ForBreaks, label)
 }
@@ -95,6 +127,87 @@ func (v *VMRegs) PopContinue() (label int) {
 		return -1
 	}
 	label = v.ForContinues[l-1]
-	v.ForBreaks = v.ForContinues[0 : l-1]
+	v.ForContinues = v.ForContinues[0 : l-1]
 	return
 }
+
+// PushIter запоминает итератор цикла, который только что помещен в стек циклов
+func (v *VMRegs) PushIter(it core.VMIterator) {
+	v.Iters = append(v.Iters, loopIter{depth: len(v.ForBreaks), it: it})
+}
+
+// CloseIters закрывает итераторы циклов с глубиной вложенности больше depth
+// (depth=0 - все итераторы функции) и возвращает первую ошибку закрытия
+func (v *VMRegs) CloseIters(depth int) (err error) {
+	for l := len(v.Iters); l > 0 && v.Iters[l-1].depth > depth; l = len(v.Iters) {
+		it := v.Iters[l-1].it
+		v.Iters = v.Iters[:l-1]
+		if e := it.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// виды отложенных действий, выполняемых после блока Окончательно
+const (
+	finallyThrow = iota
+	finallyReturn
+	finallyBreak
+	finallyContinue
+)
+
+// finallyAction помещается в регистр обработчика блока Окончательно,
+// когда выход из блока Попытка происходит по исключению, Возврат, Прервать или Продолжить.
+// После исполнения блока Окончательно действие возобновляется.
+type finallyAction struct {
+	core.VMValueStruct
+
+	kind int
+	err  error
+	val  core.VMValuer
+}
+
+// catchedError помещается в регистр ошибки блока Попытка при возникновении исключения.
+// Инструкция CATCH по нему определяет функции ОписаниеОшибки() и ИнформацияОбОшибке().
+type catchedError struct {
+	core.VMValueStruct
+
+	err error
+}
+
+// loopIter - итератор цикла Для каждого и глубина вложенности этого цикла
+type loopIter struct {
+	depth int
+	it    core.VMIterator
+}
+
+// iterState помещается в регистр итератора цикла Для каждого по коллекции core.VMIterable
+type iterState struct {
+	core.VMValueStruct
+
+	it core.VMIterator
+	n  int // номер очередного значения, начиная с нуля
+}
+
+// deferredCall - вызов оператора Отложить, функция и аргументы вычисляются при исполнении оператора
+type deferredCall struct {
+	fn   core.VMFunc
+	args core.VMSlice
+	stmt *binstmt.BinCALL
+}
+
+// tailCall возвращается из RunWorker вместо ошибки при хвостовом вызове.
+// Функция, тело которой исполнял RunWorker, сама исполняет вызов в цикле,
+// поэтому цепочка хвостовых вызовов не увеличивает стек.
+type tailCall struct {
+	fn   core.VMFunc
+	args core.VMSlice
+	stmt *binstmt.BinCALL
+	file string // файл и имя функции, из которой сделан вызов, для стека вызовов
+	name string
+}
+
+func (tc *tailCall) Error() string {
+	return "Хвостовой вызов вне функции"
+}
@@ -0,0 +1,76 @@
+package bincode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/format"
+	"github.com/covrom/gonec/parser"
+)
+
+// countStmts возвращает число инструкций кода, для которых f возвращает true
+func countStmts(bins binstmt.BinCode, f func(binstmt.BinStmt) bool) int {
+	n := 0
+	for _, s := range bins.Code {
+		if f(s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTemplates(t *testing.T) {
+	script := `имя = "Мир"
+сумма = 12
+а = [1, 2, 3]
+Сообщить("Привет, ${имя}! Итого: ${сумма * 2}, ${а[1]}${Длина(а)} ${"вло${имя}жено"} ${а}")
+Сообщить("экранировано \${имя} $ {имя} $")
+Сообщить(` + "`" + `строка как есть ${имя}
+$${имя} ${` + "`" + `)
+Сообщить("${1 + 2} и ${"константа"}")
+`
+	exp := "Привет, Мир! Итого: 24, 23 влоМиржено [1,2,3]\nэкранировано ${имя} $ {имя} $\nстрока как есть ${имя}\n$${имя} ${\n3 и константа\n"
+	out, bins := runOptimized(t, script, Optimizations)
+	if out != exp {
+		t.Errorf("вывод: %q", out)
+	}
+	// константные подстановки сворачиваются при компиляции, остальные собираются одной инструкцией,
+	// строки в обратных кавычках берутся как есть
+	if n := countStmts(bins, func(s binstmt.BinStmt) bool { _, ok := s.(*binstmt.BinCONCAT); return ok }); n != 2 {
+		t.Errorf("инструкций CONCAT: %d\n%s", n, bins.String())
+	}
+
+	errs := []struct {
+		src, msg  string
+		line, col int
+	}{
+		{"х = 1\nСообщить(\"a ${х +} b\")\n", "syntax error", 2, 18},
+		{"Сообщить(\"a ${} b\")\n", "пустая подстановка ${}", 1, 13},
+		{"Сообщить(\"a ${х b\")\n", "неожиданный EOL", 1, 18},
+		{"Сообщить(\"a ${х + 1", "не закрыта подстановка ${", 1, 13},
+		{"Сообщить(\"a ${Если} b\")\n", "syntax error", 1, 19},
+		{"Сообщить(\"a ${1; 2} b\")\n", "в подстановке ${} ожидается выражение", 1, 13},
+	}
+	for i, c := range errs {
+		_, _, err := ParseSrc(c.src)
+		e, ok := err.(*parser.Error)
+		if !ok {
+			t.Errorf("%d: ожидалась ошибка разбора, получено %v", i, err)
+			continue
+		}
+		if !strings.HasPrefix(e.Message, c.msg) || e.Pos.Line != c.line || e.Pos.Column != c.col {
+			t.Errorf("%d: ошибка %q в %d:%d", i, e.Message, e.Pos.Line, e.Pos.Column)
+		}
+	}
+
+	src := "Сообщить(\"${Длина(\"a\")} \\${x}\", `${x}`)\n"
+	res, err := format.Source(src, format.Options{})
+	if err != nil || res != src {
+		t.Errorf("форматирование: %v %q", err, res)
+	}
+	en, err := format.Translate(src, true)
+	if err != nil || en != "Message(\"${Len(\"a\")} \\${x}\", `${x}`)\n" {
+		t.Errorf("перевод: %v %q", err, en)
+	}
+}
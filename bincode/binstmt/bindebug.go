@@ -0,0 +1,115 @@
+package binstmt
+
+import (
+	"strings"
+
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/pos"
+)
+
+// DebugInfo - отладочная информация, которая может быть сохранена вместе с байткодом в .gnx
+type DebugInfo struct {
+	File      string         // файл исходного кода
+	Source    string         // исходный код, если он встроен при компиляции
+	Positions []pos.Position // позиции в исходном коде для каждой инструкции Code
+	Funcs     map[int]string // имена функций по индексу инструкции FUNC
+}
+
+// AttachDebugInfo добавляет отладочную информацию к коду и к коду вложенных модулей.
+// Исходный код src встраивается, если он не пустой.
+func (v *BinCode) AttachDebugInfo(file, src string) {
+	d := &DebugInfo{
+		File:      file,
+		Source:    src,
+		Positions: make([]pos.Position, len(v.Code)),
+		Funcs:     make(map[int]string),
+	}
+	for i, s := range v.Code {
+		d.Positions[i] = s.Position()
+		switch ss := s.(type) {
+		case *BinFUNC:
+			if ss.Name != 0 {
+				d.Funcs[i] = names.UniqueNames.Get(ss.Name)
+			}
+		case *BinMODULE:
+			// исходный код встраивается только один раз, на верхнем уровне
+			ss.Code.AttachDebugInfo(file, "")
+		}
+	}
+	v.Debug = d
+}
+
+// StripDebugInfo удаляет отладочную информацию из кода и кода вложенных модулей
+func (v *BinCode) StripDebugInfo() {
+	v.Debug = nil
+	for _, s := range v.Code {
+		if ss, ok := s.(*BinMODULE); ok {
+			ss.Code.StripDebugInfo()
+		}
+	}
+}
+
+// SourceFile возвращает имя файла исходного кода, если оно известно
+func (v *BinCode) SourceFile() string {
+	if v.Debug == nil {
+		return ""
+	}
+	return v.Debug.File
+}
+
+// PosAt возвращает позицию инструкции с индексом idx в исходном коде
+func (v *BinCode) PosAt(idx int) pos.Position {
+	if v.Debug != nil && idx >= 0 && idx < len(v.Debug.Positions) {
+		return v.Debug.Positions[idx]
+	}
+	if idx >= 0 && idx < len(v.Code) {
+		return v.Code[idx].Position()
+	}
+	return pos.Position{}
+}
+
+// FuncName возвращает имя функции, объявленной инструкцией FUNC с индексом idx
+func (v *BinCode) FuncName(idx int) string {
+	if v.Debug != nil {
+		if n, ok := v.Debug.Funcs[idx]; ok {
+			return n
+		}
+	}
+	if idx >= 0 && idx < len(v.Code) {
+		if f, ok := v.Code[idx].(*BinFUNC); ok && f.Name != 0 {
+			return names.UniqueNames.Get(f.Name)
+		}
+	}
+	return ""
+}
+
+// Entries возвращает имена функций и параллельных циклов по индексу первой инструкции их кода,
+// с которой виртуальная машина начинает исполнение в отдельном вызове RunWorker
+func (v *BinCode) Entries() map[int]string {
+	res := make(map[int]string)
+	for i, s := range v.Code {
+		switch ss := s.(type) {
+		case *BinFUNC:
+			name := v.FuncName(i)
+			if name == "" {
+				name = "<анонимная функция>"
+			}
+			res[v.Labels[ss.LabelStart]] = name
+		case *BinFOREACHPAR:
+			res[v.Labels[ss.LabelStart]] = "<параллельный цикл>"
+		}
+	}
+	return res
+}
+
+// SourceLine возвращает строку встроенного исходного кода с номером line, начиная с 1
+func (d *DebugInfo) SourceLine(line int) (string, bool) {
+	if d == nil || d.Source == "" || line < 1 {
+		return "", false
+	}
+	lines := strings.Split(d.Source, "\n")
+	if line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
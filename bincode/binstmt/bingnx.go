@@ -0,0 +1,351 @@
+package binstmt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/version"
+)
+
+// Файл .gnx имеет следующую структуру:
+//
+//	GnxMagic            8 байт
+//	GnxFormatVersion    uint16, big endian
+//	длина заголовка     uint32, big endian
+//	GnxHeader           gob
+//	данные              gzip со словарем имен и BinCode в формате gob
+//
+// Файлы, созданные до появления заголовка, начинаются сразу с данных gzip,
+// они считаются файлами формата 0 и версии VM 0.
+
+const (
+	// GnxMagic - сигнатура в начале файла .gnx
+	GnxMagic = "GONECGNX"
+	// GnxFormatVersion - версия формата контейнера, меняется при изменении структуры файла
+	GnxFormatVersion = 1
+	// VMVersion - версия набора инструкций, меняется, только если код прежней версии нельзя исполнить как есть.
+	// Новые инструкции и поля, нулевое значение которых сохраняет прежнее поведение, версию не меняют.
+	// Для старых версий, которые можно привести к текущей, добавляется функция в vmMigrations
+	VMVersion = 1
+	// MinVMVersion - самая старая версия набора инструкций, которую еще можно загрузить
+	MinVMVersion = 0
+	// VMVersionSince - версия интерпретатора, в которой появилась текущая версия набора инструкций
+	VMVersionSince = "3.6"
+)
+
+// GnxHeader - заголовок файла .gnx, читается без распаковки данных
+type GnxHeader struct {
+	FormatVersion  int
+	VMVersion      int
+	Interpreter    string   // версия интерпретатора, которым скомпилирован файл
+	MinInterpreter string   // минимальная версия интерпретатора, способного исполнить файл
+	Builtins       []string // встроенные функции, без которых код не может исполняться
+	Checksum       string   // sha256 данных в hex
+	Size           int64    // размер данных
+}
+
+func (h GnxHeader) String() string {
+	return fmt.Sprintf("формат %d, VM %d, скомпилирован %s, требуется интерпретатор не ниже %s\nвстроенные функции: %s\nsha256: %s, размер данных: %d",
+		h.FormatVersion, h.VMVersion, h.Interpreter, h.MinInterpreter, strings.Join(h.Builtins, ", "), h.Checksum, h.Size)
+}
+
+// BuiltinNames возвращает имена встроенных функций текущего интерпретатора в нижнем регистре.
+// Устанавливается виртуальной машиной, чтобы исключить циклические зависимости пакетов.
+// Если не установлена, список встроенных функций в .gnx не записывается и не проверяется.
+var BuiltinNames func() []string
+
+// vmMigrations приводят код версии VM, равной индексу, к следующей версии
+var vmMigrations = map[int]func(*BinCode){
+	// в версии 0 номера строк были смещены на единицу из-за вставляемого заголовка "Модуль _"
+	0: func(v *BinCode) {
+		v.walk(func(s BinStmt) {
+			p := s.Position()
+			if p.Line > 0 {
+				p.Line--
+				s.SetPosition(p)
+			}
+		})
+	},
+}
+
+// walk обходит инструкции кода и вложенных модулей
+func (v *BinCode) walk(f func(BinStmt)) {
+	for _, s := range v.Code {
+		f(s)
+		if ss, ok := s.(*BinMODULE); ok {
+			ss.Code.walk(f)
+		}
+	}
+}
+
+// requiredBuiltins возвращает встроенные функции, к которым обращается код и которые в нем не переопределены
+func (v *BinCode) requiredBuiltins() []string {
+	if BuiltinNames == nil {
+		return nil
+	}
+	used := make(map[int]bool)
+	defined := make(map[int]bool)
+	v.walk(func(s BinStmt) {
+		switch ss := s.(type) {
+		case *BinGET:
+			used[ss.Id] = true
+		case *BinCALL:
+			if ss.Name != 0 {
+				used[ss.Name] = true
+			}
+		case *BinGETLOCAL:
+			used[ss.Id] = true
+		case *BinSET:
+			defined[ss.Id] = true
+		case *BinSETLOCAL:
+			defined[ss.Id] = true
+		case *BinFUNC:
+			defined[ss.Name] = true
+		}
+	})
+	builtins := make(map[string]bool)
+	for _, n := range BuiltinNames() {
+		builtins[n] = true
+	}
+	var res []string
+	for id := range used {
+		if n := names.UniqueNames.GetLowerCase(id); builtins[n] && !defined[id] {
+			res = append(res, n)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// CompareVersions сравнивает версии вида "3.6.1", возвращает -1, 0 или 1
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func WriteBinCode(w io.Writer, v BinCode) error {
+	var data bytes.Buffer
+
+	zw := gzip.NewWriter(&data)
+	zw.Name = "Gonec binary code"
+	zw.Comment = "Created with https://covrom.github.io/gonec/ by Roman TSovanyan rs@tsov.pro"
+	zw.ModTime = time.Now()
+
+	enc := gob.NewEncoder(zw)
+
+	// так же сохраняем уникальные имена
+	if err := enc.Encode(*names.UniqueNames); err != nil {
+		return err
+	}
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data.Bytes())
+	hdr := GnxHeader{
+		FormatVersion:  GnxFormatVersion,
+		VMVersion:      VMVersion,
+		Interpreter:    version.Version,
+		MinInterpreter: VMVersionSince,
+		Builtins:       v.requiredBuiltins(),
+		Checksum:       hex.EncodeToString(sum[:]),
+		Size:           int64(data.Len()),
+	}
+	var hbuf bytes.Buffer
+	if err := gob.NewEncoder(&hbuf).Encode(hdr); err != nil {
+		return err
+	}
+
+	var pre [len(GnxMagic) + 6]byte
+	copy(pre[:], GnxMagic)
+	binary.BigEndian.PutUint16(pre[len(GnxMagic):], GnxFormatVersion)
+	binary.BigEndian.PutUint32(pre[len(GnxMagic)+2:], uint32(hbuf.Len()))
+
+	for _, b := range [][]byte{pre[:], hbuf.Bytes(), data.Bytes()} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errNotGnx = errors.New("Файл не является скомпилированным кодом на языке Гонец (.gnx)")
+
+// readGnx читает заголовок и данные файла .gnx, для файлов без заголовка возвращается заголовок формата 0
+func readGnx(r io.Reader) (hdr GnxHeader, data []byte, err error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return hdr, nil, err
+	}
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		// сигнатура gzip - файл старого формата без заголовка
+		return GnxHeader{Size: int64(len(body))}, body, nil
+	}
+
+	pl := len(GnxMagic) + 6
+	if len(body) < pl || string(body[:len(GnxMagic)]) != GnxMagic {
+		return hdr, nil, errNotGnx
+	}
+	if fv := int(binary.BigEndian.Uint16(body[len(GnxMagic):])); fv > GnxFormatVersion {
+		return hdr, nil, fmt.Errorf("Формат файла .gnx версии %d не поддерживается интерпретатором %s, требуется обновление интерпретатора", fv, version.Version)
+	}
+	hl := int(binary.BigEndian.Uint32(body[len(GnxMagic)+2:]))
+	if hl > len(body)-pl {
+		return hdr, nil, errors.New("Файл .gnx поврежден: заголовок обрезан")
+	}
+	if err := gob.NewDecoder(bytes.NewReader(body[pl : pl+hl])).Decode(&hdr); err != nil {
+		return hdr, nil, fmt.Errorf("Файл .gnx поврежден: %v", err)
+	}
+	return hdr, body[pl+hl:], nil
+}
+
+// ReadGnxHeader читает только заголовок файла .gnx
+func ReadGnxHeader(r io.Reader) (GnxHeader, error) {
+	hdr, _, err := readGnx(r)
+	return hdr, err
+}
+
+// checkGnx проверяет, что код можно исполнить текущим интерпретатором
+func checkGnx(hdr GnxHeader, data []byte) error {
+	if hdr.FormatVersion > 0 {
+		if int64(len(data)) != hdr.Size {
+			return fmt.Errorf("Файл .gnx поврежден: размер данных %d, ожидался %d", len(data), hdr.Size)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != hdr.Checksum {
+			return errors.New("Файл .gnx поврежден: не совпадает контрольная сумма")
+		}
+	}
+	if hdr.MinInterpreter != "" && CompareVersions(version.Version, hdr.MinInterpreter) < 0 {
+		return fmt.Errorf("Файл .gnx требует интерпретатор версии не ниже %s, текущая версия %s", hdr.MinInterpreter, version.Version)
+	}
+	if hdr.VMVersion > VMVersion {
+		return fmt.Errorf("Файл .gnx скомпилирован для VM версии %d, интерпретатор %s поддерживает версию не выше %d", hdr.VMVersion, version.Version, VMVersion)
+	}
+	if hdr.VMVersion < MinVMVersion {
+		return fmt.Errorf("Файл .gnx скомпилирован для устаревшей VM версии %d, его необходимо перекомпилировать", hdr.VMVersion)
+	}
+	if len(hdr.Builtins) > 0 && BuiltinNames != nil {
+		have := make(map[string]bool)
+		for _, n := range BuiltinNames() {
+			have[n] = true
+		}
+		var miss []string
+		for _, n := range hdr.Builtins {
+			if !have[strings.ToLower(n)] {
+				miss = append(miss, n)
+			}
+		}
+		if len(miss) > 0 {
+			return fmt.Errorf("В интерпретаторе %s нет встроенных функций, необходимых для исполнения файла .gnx: %s", version.Version, strings.Join(miss, ", "))
+		}
+	}
+	return nil
+}
+
+// ReadBinCode загружает код из файла .gnx.
+// Несовместимые файлы отвергаются с ошибкой, код старых совместимых версий VM приводится к текущей.
+func ReadBinCode(r io.Reader) (res BinCode, err error) {
+	hdr, data, err := readGnx(r)
+	if err != nil {
+		return res, err
+	}
+	if err := checkGnx(hdr, data); err != nil {
+		return res, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return res, err
+	}
+
+	dec := gob.NewDecoder(zr)
+
+	var gnxNames = names.NewEnvNames()
+
+	if err := dec.Decode(gnxNames); err != nil {
+		return res, err
+	}
+	if err := dec.Decode(&res); err != nil {
+		return res, fmt.Errorf("Не удалось загрузить код VM версии %d: %v", hdr.VMVersion, err)
+	}
+	if err := zr.Close(); err != nil {
+		return res, err
+	}
+
+	// переносим загруженные имена в текущий контекст
+	// и заменяем идентификаторы в загружаемом коде в случае конфликта
+	swapIdents := make(map[int]int)
+
+	// log.Println(gnxNames)
+
+	for i, v := range gnxNames.Handlow {
+
+		// log.Printf("Проверяем %d, %q", i, v)
+
+		if vv, ok := names.UniqueNames.GetLowerCaseOk(i); ok {
+			// под тем же идентификатором находится другая строка, без учета регистра
+			if v != vv {
+				// новый id
+				ii := names.UniqueNames.Set(gnxNames.Handles[i])
+				swapIdents[i] = ii
+
+				// log.Printf("Заменяем %d на %d для загружаемого %q, уже есть %q\n", i, ii, v, vv)
+
+			}
+		} else {
+			// такого идентификатора еще нет - устанавливаем значение на него
+			// последующие идентификаторы names.UniqueNames будут идти после него
+
+			// log.Printf("Устанавливаем %d для загружаемого %q\n", i, gnxNames.Handles[i])
+
+			names.UniqueNames.SetToId(gnxNames.Handles[i], i)
+		}
+	}
+
+	// заменяем идентификаторы, если при слиянии были конфликты,
+	// BinMODULE сам заменяет идентификаторы во вложенном коде
+	for _, v := range res.Code {
+		v.SwapId(swapIdents)
+	}
+
+	// приводим код старых версий к текущей
+	for ver := hdr.VMVersion; ver < VMVersion; ver++ {
+		vmMigrations[ver](&res)
+	}
+
+	return res, nil
+}
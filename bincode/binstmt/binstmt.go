@@ -1,12 +1,9 @@
 package binstmt
 
 import (
-	"compress/gzip"
 	"encoding/gob"
 	"fmt"
-	"io"
 	"reflect"
-	"time"
 
 	"github.com/covrom/gonec/core"
 	"github.com/covrom/gonec/names"
@@ -36,7 +33,8 @@ func (x *BinStmts) Append(bs BinStmt) {
 type BinCode struct {
 	Code   BinStmts
 	MaxReg int
-	Labels []int //индекс - это номер метки, значение = индекс stmt в Code
+	Labels []int      //индекс - это номер метки, значение = индекс stmt в Code
+	Debug  *DebugInfo // отладочная информация, может отсутствовать
 }
 
 func (v BinCode) String() string {
@@ -57,87 +55,6 @@ func (v *BinCode) MapLabels(lastlabel int) {
 	}
 }
 
-func WriteBinCode(w io.Writer, v BinCode) error {
-	zw := gzip.NewWriter(w)
-	zw.Name = "Gonec binary code"
-	zw.Comment = "Created with https://covrom.github.io/gonec/ by Roman TSovanyan rs@tsov.pro"
-	zw.ModTime = time.Now()
-
-	enc := gob.NewEncoder(zw)
-
-	// так же сохраняем уникальные имена
-	if err := enc.Encode(*names.UniqueNames); err != nil {
-		return err
-	}
-
-	if err := enc.Encode(v); err != nil {
-		return err
-	}
-
-	if err := zw.Close(); err != nil {
-		return err
-	}
-	return nil
-}
-
-func ReadBinCode(r io.Reader) (res BinCode, err error) {
-	zr, err := gzip.NewReader(r)
-	if err != nil {
-		return res, err
-	}
-
-	dec := gob.NewDecoder(zr)
-
-	var gnxNames = names.NewEnvNames()
-
-	if err := dec.Decode(gnxNames); err != nil {
-		return res, err
-	}
-	if err := dec.Decode(&res); err != nil {
-		return res, err
-	}
-	if err := zr.Close(); err != nil {
-		return res, err
-	}
-
-	// переносим загруженные имена в текущий контекст
-	// и заменяем идентификаторы в загружаемом коде в случае конфликта
-	swapIdents := make(map[int]int)
-
-	// log.Println(gnxNames)
-
-	for i, v := range gnxNames.Handlow {
-
-		// log.Printf("Проверяем %d, %q", i, v)
-
-		if vv, ok := names.UniqueNames.GetLowerCaseOk(i); ok {
-			// под тем же идентификатором находится другая строка, без учета регистра
-			if v != vv {
-				// новый id
-				ii := names.UniqueNames.Set(gnxNames.Handles[i])
-				swapIdents[i] = ii
-
-				// log.Printf("Заменяем %d на %d для загружаемого %q, уже есть %q\n", i, ii, v, vv)
-
-			}
-		} else {
-			// такого идентификатора еще нет - устанавливаем значение на него
-			// последующие идентификаторы names.UniqueNames будут идти после него
-
-			// log.Printf("Устанавливаем %d для загружаемого %q\n", i, gnxNames.Handles[i])
-
-			names.UniqueNames.SetToId(gnxNames.Handles[i], i)
-		}
-	}
-
-	// заменяем идентификаторы, если при слиянии были конфликты
-	for _, v := range res.Code {
-		v.SwapId(swapIdents)
-	}
-
-	return res, nil
-}
-
 func init() {
 	gob.Register(BinCode{})
 	gob.Register(&names.EnvNames{})
@@ -165,6 +82,8 @@ func init() {
 	gob.Register(&BinSETKEY{})
 	gob.Register(&BinGET{})
 	gob.Register(&BinSET{})
+	gob.Register(&BinGETLOCAL{})
+	gob.Register(&BinSETLOCAL{})
 	gob.Register(&BinSETMEMBER{})
 	gob.Register(&BinSETNAME{})
 	gob.Register(&BinSETITEM{})
@@ -195,7 +114,10 @@ func init() {
 	gob.Register(&BinTRY{})
 	gob.Register(&BinCATCH{})
 	gob.Register(&BinPOPTRY{})
+	gob.Register(&BinTRYFIN{})
+	gob.Register(&BinENDFIN{})
 	gob.Register(&BinFOREACH{})
+	gob.Register(&BinFOREACHPAR{})
 	gob.Register(&BinNEXT{})
 	gob.Register(&BinPOPFOR{})
 	gob.Register(&BinFORNUM{})
@@ -213,6 +135,8 @@ func init() {
 	gob.Register(&BinINC{})
 	gob.Register(&BinDEC{})
 	gob.Register(&BinFREE{})
+	gob.Register(&BinCONCAT{})
+	gob.Register(&BinTYPE{})
 
 }
 
@@ -453,6 +377,71 @@ func NewBinSET(reg, id int, e pos.Pos) *BinSET {
 	return v
 }
 
+// BinGETLOCAL получает локальную переменную функции по номеру, определенному при компиляции.
+// Переменная находится в окружении, отстоящем от текущего на Depth уровней: 0 - своя переменная,
+// 1 и более - переменная объемлющей функции или тела параллельного цикла, захваченная замыканием.
+// Если переменной еще не присвоено значение, она ищется по имени, как в GET.
+type BinGETLOCAL struct {
+	BinStmtImpl
+
+	Reg   int
+	Id    int // id переменной
+	Slot  int // номер переменной в окружении
+	Depth int // число уровней окружений вверх
+}
+
+func (v *BinGETLOCAL) SwapId(m map[int]int) {
+	if newid, ok := m[v.Id]; ok {
+		v.Id = newid
+	}
+}
+
+func (v BinGETLOCAL) String() string {
+	return fmt.Sprintf("GETLOCAL r%d, %q, SLOT %d, DEPTH %d", v.Reg, names.UniqueNames.Get(v.Id), v.Slot, v.Depth)
+}
+
+func NewBinGETLOCAL(reg, id, slot, depth int, e pos.Pos) *BinGETLOCAL {
+	v := &BinGETLOCAL{
+		Reg:   reg,
+		Id:    id,
+		Slot:  slot,
+		Depth: depth,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
+// BinSETLOCAL присваивает значение локальной переменной функции по номеру, см. BinGETLOCAL
+type BinSETLOCAL struct {
+	BinStmtImpl
+
+	Id    int // id переменной
+	Reg   int // регистр со значением
+	Slot  int // номер переменной в окружении
+	Depth int // число уровней окружений вверх
+}
+
+func (v *BinSETLOCAL) SwapId(m map[int]int) {
+	if newid, ok := m[v.Id]; ok {
+		v.Id = newid
+	}
+}
+
+func (v BinSETLOCAL) String() string {
+	return fmt.Sprintf("SETLOCAL %q, r%d, SLOT %d, DEPTH %d", names.UniqueNames.Get(v.Id), v.Reg, v.Slot, v.Depth)
+}
+
+func NewBinSETLOCAL(reg, id, slot, depth int, e pos.Pos) *BinSETLOCAL {
+	v := &BinSETLOCAL{
+		Reg:   reg,
+		Id:    id,
+		Slot:  slot,
+		Depth: depth,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
 type BinSETMEMBER struct {
 	BinStmtImpl
 
@@ -788,6 +777,13 @@ type BinCALL struct {
 	VarArg bool
 
 	Go bool // признак необходимости запуска в новой горутине
+
+	// Defer - вызов оператора Отложить: функция и аргументы запоминаются и вызываются при выходе из функции
+	Defer bool
+
+	// Tail - хвостовой вызов в операторе Возврат, функция на языке Гонец исполняется вместо текущей,
+	// не увеличивая глубину вызовов
+	Tail bool
 }
 
 func (v *BinCALL) SwapId(m map[int]int) {
@@ -801,10 +797,17 @@ func (v *BinCALL) SwapId(m map[int]int) {
 }
 
 func (v BinCALL) String() string {
+	tail := ""
+	if v.Tail {
+		tail = ", TAIL"
+	}
+	if v.Defer {
+		tail += ", DEFER"
+	}
 	if v.Name == 0 {
-		return fmt.Sprintf("CALL REG r%d, ARGS r%d, ARGS_COUNT %d, VARARG %v, GO %v, RETURN r%d", v.RegArgs, v.RegArgs+1, v.NumArgs, v.VarArg, v.Go, v.RegRets)
+		return fmt.Sprintf("CALL REG r%d, ARGS r%d, ARGS_COUNT %d, VARARG %v, GO %v, RETURN r%d%s", v.RegArgs, v.RegArgs+1, v.NumArgs, v.VarArg, v.Go, v.RegRets, tail)
 	}
-	return fmt.Sprintf("CALL %q, ARGS r%d, ARGS_COUNT %d, VARARG %v, GO %v, RETURN r%d", names.UniqueNames.Get(v.Name), v.RegArgs, v.NumArgs, v.VarArg, v.Go, v.RegRets)
+	return fmt.Sprintf("CALL %q, ARGS r%d, ARGS_COUNT %d, VARARG %v, GO %v, RETURN r%d%s", names.UniqueNames.Get(v.Name), v.RegArgs, v.NumArgs, v.VarArg, v.Go, v.RegRets, tail)
 }
 
 func NewBinCALL(name, numargs, regargs, regrets int, vararg, isgo bool, e pos.Pos) *BinCALL {
@@ -900,7 +903,8 @@ type BinFUNC struct {
 	Args       []int // идентификаторы параметров
 	VarArg     bool
 	// ReturnTo int //метка инструкции возврата из функции
-	MaxReg int // максимальный регистр, достигаемый внутри функции, без учета вызова вложенных функций
+	MaxReg int   // максимальный регистр, достигаемый внутри функции, без учета вызова вложенных функций
+	Locals []int // идентификаторы локальных переменных по номерам GETLOCAL и SETLOCAL, первыми идут параметры
 }
 
 func (v *BinFUNC) SwapId(m map[int]int) {
@@ -914,6 +918,7 @@ func (v *BinFUNC) SwapId(m map[int]int) {
 			// log.Printf("Замена в аргументах %#v %v\n",v, v)
 		}
 	}
+	swapIds(v.Locals, m)
 }
 func (v BinFUNC) String() string {
 	s := ""
@@ -966,21 +971,78 @@ func NewBinCASTTYPE(reg, regtype int, e pos.Pos) *BinCASTTYPE {
 type BinMAKE struct {
 	BinStmtImpl
 
-	Reg int // здесь id типа, и сюда же пишем новое значение
+	Reg     int // здесь id типа, и сюда же пишем новое значение
+	NumArgs int // число аргументов конструктора типа, объявленного в коде, они находятся в регистрах начиная с Reg+1
 }
 
 func (v BinMAKE) String() string {
+	if v.NumArgs > 0 {
+		return fmt.Sprintf("MAKE r%d AS TYPE r%d ARGS r%d..r%d", v.Reg, v.Reg, v.Reg+1, v.Reg+v.NumArgs)
+	}
 	return fmt.Sprintf("MAKE r%d AS TYPE r%d", v.Reg, v.Reg)
 }
 
-func NewBinMAKE(reg int, e pos.Pos) *BinMAKE {
+func NewBinMAKE(reg, numargs int, e pos.Pos) *BinMAKE {
 	v := &BinMAKE{
-		Reg: reg,
+		Reg:     reg,
+		NumArgs: numargs,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
+// BinTYPE объявляет тип объектов с полями и методами, функции методов находятся в регистрах начиная с Reg,
+// за ними - функция заполнения полей начальными значениями, если HasInit
+type BinTYPE struct {
+	BinStmtImpl
+
+	Reg     int
+	Name    int   // имя типа
+	Fields  []int // имена полей
+	Methods []int // имена методов
+	HasInit bool
+}
+
+func (v *BinTYPE) SwapId(m map[int]int) {
+	if newid, ok := m[v.Name]; ok {
+		v.Name = newid
+	}
+	swapIds(v.Fields, m)
+	swapIds(v.Methods, m)
+}
+
+func (v BinTYPE) String() string {
+	fs := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		fs[i] = names.UniqueNames.Get(f)
+	}
+	ms := make([]string, len(v.Methods))
+	for i, f := range v.Methods {
+		ms[i] = names.UniqueNames.Get(f)
+	}
+	return fmt.Sprintf("TYPE %q FIELDS %v METHODS %v FROM r%d", names.UniqueNames.Get(v.Name), fs, ms, v.Reg)
+}
+
+func NewBinTYPE(reg, name int, fields, methods []int, hasInit bool, e pos.Pos) *BinTYPE {
+	v := &BinTYPE{
+		Reg:     reg,
+		Name:    name,
+		Fields:  fields,
+		Methods: methods,
+		HasInit: hasInit,
 	}
 	v.SetPosition(e.Position())
 	return v
 }
 
+// NumRegs возвращает число регистров с функциями методов и заполнения полей
+func (v *BinTYPE) NumRegs() int {
+	if v.HasInit {
+		return len(v.Methods) + 1
+	}
+	return len(v.Methods)
+}
+
 type BinMAKECHAN struct {
 	BinStmtImpl
 
@@ -1124,16 +1186,21 @@ type BinCATCH struct {
 
 	Reg    int
 	JumpTo int
+	Kinds  []string // перехватываемые виды исключений, если пусто - перехватываются все
 }
 
 func (v BinCATCH) String() string {
+	if len(v.Kinds) > 0 {
+		return fmt.Sprintf("CATCH r%d, NOERR L%d, KINDS %q", v.Reg, v.JumpTo, v.Kinds)
+	}
 	return fmt.Sprintf("CATCH r%d, NOERR L%d", v.Reg, v.JumpTo)
 }
 
-func NewBinCATCH(reg, lb int, e pos.Pos) *BinCATCH {
+func NewBinCATCH(reg, lb int, kinds []string, e pos.Pos) *BinCATCH {
 	v := &BinCATCH{
 		Reg:    reg,
 		JumpTo: lb,
+		Kinds:  kinds,
 	}
 	v.SetPosition(e.Position())
 	return v
@@ -1157,6 +1224,44 @@ func NewBinPOPTRY(lb int, e pos.Pos) *BinPOPTRY {
 	return v
 }
 
+type BinTRYFIN struct {
+	BinStmtImpl
+
+	Reg    int // регистр, куда помещается отложенное действие при выходе из блока Попытка
+	JumpTo int // метка блока Окончательно
+}
+
+func (v BinTRYFIN) String() string {
+	return fmt.Sprintf("TRYFIN r%d, FINALLY L%d", v.Reg, v.JumpTo)
+}
+
+func NewBinTRYFIN(reg, lb int, e pos.Pos) *BinTRYFIN {
+	v := &BinTRYFIN{
+		Reg:    reg,
+		JumpTo: lb,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
+type BinENDFIN struct {
+	BinStmtImpl
+
+	Reg int // регистр с отложенным действием: исключение, возврат, прерывание или продолжение цикла
+}
+
+func (v BinENDFIN) String() string {
+	return fmt.Sprintf("ENDFIN r%d", v.Reg)
+}
+
+func NewBinENDFIN(reg int, e pos.Pos) *BinENDFIN {
+	v := &BinENDFIN{
+		Reg: reg,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
 type BinFOREACH struct {
 	BinStmtImpl
 
@@ -1181,6 +1286,50 @@ func NewBinFOREACH(reg, regiter, brl, cnl int, e pos.Pos) *BinFOREACH {
 	return v
 }
 
+type BinFOREACHPAR struct {
+	BinStmtImpl
+
+	Reg        int   // регистр с коллекцией или каналом
+	RegWorkers int   // регистр с числом одновременно исполняемых итераций, nil - по числу процессоров
+	Var        int   // переменная цикла, определяется в окружении итерации
+	LabelStart int   // начало тела цикла, тело завершается инструкцией CONTINUE
+	LabelEnd   int   // продолжение после цикла
+	MaxReg     int   // максимальный регистр, достигаемый в теле цикла
+	Locals     []int // идентификаторы локальных переменных итерации, первой идет переменная цикла
+}
+
+func (v *BinFOREACHPAR) SwapId(m map[int]int) {
+	if newid, ok := m[v.Var]; ok {
+		v.Var = newid
+	}
+	swapIds(v.Locals, m)
+}
+
+// swapIds заменяет идентификаторы в списке
+func swapIds(ids []int, m map[int]int) {
+	for i := range ids {
+		if newid, ok := m[ids[i]]; ok {
+			ids[i] = newid
+		}
+	}
+}
+
+func (v BinFOREACHPAR) String() string {
+	return fmt.Sprintf("FOREACHPAR r%d, WORKERS r%d, VAR %q, BODY L%d, END L%d, MAXREG %d", v.Reg, v.RegWorkers, names.UniqueNames.Get(v.Var), v.LabelStart, v.LabelEnd, v.MaxReg)
+}
+
+func NewBinFOREACHPAR(reg, regworkers, id, lstart, lend int, e pos.Pos) *BinFOREACHPAR {
+	v := &BinFOREACHPAR{
+		Reg:        reg,
+		RegWorkers: regworkers,
+		Var:        id,
+		LabelStart: lstart,
+		LabelEnd:   lend,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
+
 type BinNEXT struct {
 	BinStmtImpl
 
@@ -1190,9 +1339,17 @@ type BinNEXT struct {
 	RegIter int // регистр с итератором, инициализированным FOREACH
 	JumpTo  int // переход в случае, если нет очередного значения (достигнут конец выборки)
 	// туда же переходим по Прервать
+
+	// Pair - цикл Для к, з Из ..., пара КлючИЗначение раскладывается на ключ в RegKey и значение в RegVal,
+	// для остальных значений в RegKey помещается номер значения, начиная с нуля
+	Pair   bool
+	RegKey int
 }
 
 func (v BinNEXT) String() string {
+	if v.Pair {
+		return fmt.Sprintf("NEXT r%d, KEY r%d, FROM r%d, ITER r%d, ENDLOOP L%d", v.RegVal, v.RegKey, v.Reg, v.RegIter, v.JumpTo)
+	}
 	return fmt.Sprintf("NEXT r%d, FROM r%d, ITER r%d, ENDLOOP L%d", v.RegVal, v.Reg, v.RegIter, v.JumpTo)
 }
 
@@ -1233,9 +1390,14 @@ type BinFORNUM struct {
 	RegTo         int // регистр с конечным значением
 	BreakLabel    int
 	ContinueLabel int
+	HasStep       bool // шаг задан в RegStep, иначе он равен 1 или -1
+	RegStep       int
 }
 
 func (v BinFORNUM) String() string {
+	if v.HasStep {
+		return fmt.Sprintf("FORNUM r%d, FROM r%d, TO r%d, STEP r%d, BREAK TO L%d", v.Reg, v.RegFrom, v.RegTo, v.RegStep, v.BreakLabel)
+	}
 	return fmt.Sprintf("FORNUM r%d, FROM r%d, TO r%d, BREAK TO L%d", v.Reg, v.RegFrom, v.RegTo, v.BreakLabel)
 }
 
@@ -1259,6 +1421,8 @@ type BinNEXTNUM struct {
 	RegTo   int // регистр с конечным значением
 	JumpTo  int // переход в случае, если значение после увеличения стало больше, чем ранее определенное в RegTo
 	// туда же переходим по Прервать
+	HasStep bool // шаг задан в RegStep, его знак определяет направление перебора
+	RegStep int
 }
 
 func (v BinNEXTNUM) String() string {
@@ -1372,6 +1536,9 @@ func (v *BinMODULE) SwapId(m map[int]int) {
 		v.Name = newid
 		// log.Printf("Замена в %#v %v\n",v, v)
 	}
+	for _, s := range v.Code.Code {
+		s.SwapId(m)
+	}
 }
 func (v BinMODULE) String() string {
 	return fmt.Sprintf("MODULE %s\n{\n%v}\n", names.UniqueNames.Get(v.Name), v.Code)
@@ -1518,3 +1685,23 @@ func NewBinFREE(reg int, e pos.Pos) *BinFREE {
 	v.SetPosition(e.Position())
 	return v
 }
+
+type BinCONCAT struct {
+	BinStmtImpl
+
+	Reg int // первая часть строки, сюда же помещается результат
+	Num int // число частей в регистрах подряд, начиная с Reg
+}
+
+func (v BinCONCAT) String() string {
+	return fmt.Sprintf("CONCAT r%d, r%d..r%d", v.Reg, v.Reg, v.Reg+v.Num-1)
+}
+
+func NewBinCONCAT(reg, num int, e pos.Pos) *BinCONCAT {
+	v := &BinCONCAT{
+		Reg: reg,
+		Num: num,
+	}
+	v.SetPosition(e.Position())
+	return v
+}
@@ -3,35 +3,56 @@ package binstmt
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/covrom/gonec/core"
 	posit "github.com/covrom/gonec/pos"
 )
 
+// виды исключений, которые формирует сама виртуальная машина
+const (
+	ErrorKindRuntime = "ОшибкаВыполнения" // ошибка исполнения кода или встроенной функции
+	ErrorKindThrow   = "Исключение"       // ВызватьИсключение со строкой или другим значением
+	ErrorKindSyntax  = "ОшибкаСинтаксиса" // ошибка разбора кода, переданного в Выполнить или Вычислить
+)
+
 // Error provides a convenient interface for handling runtime error.
 // It can be Error interface with type cast which can call Pos().
 type Error struct {
-	Message string
-	Pos     posit.Position
+	Message  string
+	Pos      posit.Position
+	Kind     string           // вид исключения, по нему выполняется выборочный перехват
+	GoType   string           // тип исходной ошибки Go, если ошибка возникла во встроенной функции
+	Filename string           // файл с исходным кодом, если он известен
+	Value    core.VMStringMap // поля структуры, переданной в ВызватьИсключение
+	Stack    []StackFrame     // функции, через которые прошло исключение, начиная с места возникновения
+}
+
+// StackFrame - вызов функции на языке Гонец, через который прошло исключение
+type StackFrame struct {
+	Func string // пустое имя у кода модуля
+	File string
+	Pos  posit.Position
 }
 
 var (
 	BreakError     = errors.New("Неверное применение оператора Прервать")
 	ContinueError  = errors.New("Неверное применение оператора Продолжить")
 	ReturnError    = errors.New("Неверное применение оператора Возврат")
-	InterruptError = errors.New("Выполнение прервано")
+	InterruptError = core.VMErrorInterrupted
 )
 
 // NewStringError makes error interface with message.
 func NewStringError(pos posit.Pos, err string) error {
 	if pos == nil {
-		return &Error{Message: err, Pos: posit.Position{1, 1}}
+		return &Error{Message: err, Pos: posit.Position{1, 1}, Kind: ErrorKindRuntime}
 	}
-	return &Error{Message: err, Pos: pos.Position()}
+	return &Error{Message: err, Pos: pos.Position(), Kind: ErrorKindRuntime}
 }
 
 // NewErrorf makes error interface with message.
 func NewErrorf(pos posit.Pos, format string, args ...interface{}) error {
-	return &Error{Message: fmt.Sprintf(format, args...), Pos: pos.Position()}
+	return &Error{Message: fmt.Sprintf(format, args...), Pos: pos.Position(), Kind: ErrorKindRuntime}
 }
 
 // NewError makes error interface with message.
@@ -40,7 +61,7 @@ func NewError(pos posit.Pos, err error) error {
 	if err == nil {
 		return nil
 	}
-	if err == BreakError || err == ContinueError || err == ReturnError {
+	if err == BreakError || err == ContinueError || err == ReturnError || err == InterruptError {
 		return err
 	}
 	// if pe, ok := err.(*parser.Error); ok {
@@ -49,17 +70,96 @@ func NewError(pos posit.Pos, err error) error {
 	if ee, ok := err.(*Error); ok {
 		return ee
 	}
-	return &Error{Message: err.Error(), Pos: pos.Position()}
+	if le, ok := err.(*core.LimitError); ok {
+		// завершение по ограничениям исполнения остается отдельной ошибкой, чтобы его нельзя было перехватить
+		return le
+	}
+	return &Error{Message: err.Error(), Pos: pos.Position(), Kind: ErrorKindRuntime, GoType: fmt.Sprintf("%T", err)}
+}
+
+// NewThrowError создает исключение из значения, переданного в ВызватьИсключение.
+// Из структуры берутся поля Вид и Описание, остальные ее поля доступны при перехвате исключения.
+func NewThrowError(pos posit.Pos, v core.VMValuer) error {
+	e := &Error{Pos: pos.Position(), Kind: ErrorKindThrow}
+	m, ok := v.(core.VMStringMap)
+	if !ok {
+		e.Message = fmt.Sprint(v)
+		return e
+	}
+	e.Value = make(core.VMStringMap, len(m))
+	for k, fv := range m {
+		e.Value[k] = fv
+	}
+	if kind, ok := m["Вид"].(core.VMString); ok && kind != "" {
+		e.Kind = string(kind)
+	}
+	if msg, ok := m["Описание"]; ok {
+		e.Message = fmt.Sprint(msg)
+	} else {
+		e.Message = e.Kind
+	}
+	return e
 }
 
 // Error returns the error message.
 func (e *Error) Error() string {
-	// учитываем вставку модуля _ по умолчанию - вычитаем 1 из номера строки
-	return fmt.Sprintf("[%d:%d] %s", e.Pos.Line-1, e.Pos.Column, e.Message)
+	return fmt.Sprintf("[%d:%d] %s", e.Pos.Line, e.Pos.Column, e.Message)
 }
 
 func (e *Error) String() string {
-	// учитываем вставку модуля _ по умолчанию - вычитаем 1 из номера строки
 	return e.Message
 }
 
+// IsKind проверяет, относится ли исключение к одному из видов (без учета регистра)
+func (e *Error) IsKind(kinds []string) bool {
+	for _, k := range kinds {
+		if strings.EqualFold(k, e.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFrame добавляет в стек вызовов место, через которое исключение покинуло код функции или модуля.
+// Для первого кадра используется позиция возникновения исключения, файл этого кадра становится файлом ошибки.
+func (e *Error) AddFrame(file string, p posit.Position) {
+	if len(e.Stack) == 0 {
+		p = e.Pos
+		if e.Filename == "" {
+			e.Filename = file
+		}
+	}
+	e.Stack = append(e.Stack, StackFrame{File: file, Pos: p})
+}
+
+// SetFrameFunc указывает имя функции для последнего добавленного кадра стека вызовов
+func (e *Error) SetFrameFunc(name string) {
+	if l := len(e.Stack); l > 0 && e.Stack[l-1].Func == "" {
+		e.Stack[l-1].Func = name
+	}
+}
+
+// Info возвращает структуру с информацией об исключении, доступную через ИнформацияОбОшибке()
+func (e *Error) Info() core.VMStringMap {
+	info := make(core.VMStringMap, len(e.Value)+7)
+	for k, v := range e.Value {
+		info[k] = v
+	}
+	info["Описание"] = core.VMString(e.Message)
+	info["Вид"] = core.VMString(e.Kind)
+	info["ИмяФайла"] = core.VMString(e.Filename)
+	info["НомерСтроки"] = core.VMInt(e.Pos.Line)
+	info["НомерКолонки"] = core.VMInt(e.Pos.Column)
+	info["ТипОшибкиGo"] = core.VMString(e.GoType)
+	stack := make(core.VMSlice, len(e.Stack))
+	for i, fr := range e.Stack {
+		stack[i] = core.VMStringMap{
+			"ИмяФункции":   core.VMString(fr.Func),
+			"ИмяФайла":     core.VMString(fr.File),
+			"НомерСтроки":  core.VMInt(fr.Pos.Line),
+			"НомерКолонки": core.VMInt(fr.Pos.Column),
+		}
+	}
+	info["СтекВызовов"] = stack
+	return info
+}
@@ -3,6 +3,7 @@ package bincode
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,18 +13,33 @@ import (
 	"sync"
 
 	"github.com/covrom/gonec/ast"
+	"github.com/covrom/gonec/bincode/binopt"
 	"github.com/covrom/gonec/bincode/binstmt"
 	"github.com/covrom/gonec/core"
 	"github.com/covrom/gonec/names"
 	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
 )
 
 func Interrupt(env *core.Env) {
 	env.Interrupt()
 }
 
+// Optimizations - проходы оптимизации, которые ParseSrc выполняет над скомпилированным кодом
+var Optimizations = binopt.All
+
 // ParseSrc provides way to parse the code from source.
 func ParseSrc(src string) (prs ast.Stmts, bin binstmt.BinCode, err error) {
+	return parseSrc(src, false)
+}
+
+// ParseExpr компилирует единственное выражение, код возвращает его значение оператором Возврат.
+// Позиции в коде и в ошибках разбора отсчитываются от начала выражения.
+func ParseExpr(src string) (prs ast.Stmts, bin binstmt.BinCode, err error) {
+	return parseSrc(src, true)
+}
+
+func parseSrc(src string, expr bool) (prs ast.Stmts, bin binstmt.BinCode, err error) {
 	defer func() {
 		// если это не паника из кода языка
 		// if os.Getenv("GONEC_DEBUG") == "" {
@@ -44,21 +60,55 @@ func ParseSrc(src string) (prs ast.Stmts, bin binstmt.BinCode, err error) {
 
 	scanner := &parser.Scanner{}
 	scanner.Init(src)
+	// вставленная строка не учитывается в номерах строк
+	scanner.SetFirstLine(0)
 
 	prs, err = parser.Parse(scanner)
 	if err != nil {
 		panic(err)
 	}
+	if expr {
+		if err = returnExpr(prs); err != nil {
+			panic(err)
+		}
+	}
 	// оптимизируем дерево AST
 	// свертка констант и нативные значения
 	prs = parser.ConstFolding(prs)
 	// компиляция в бинарный код
 	lid := 0
 	bin = prs.BinaryCode(0, &lid)
+	// оптимизация бинарного кода
+	binopt.Optimize(&bin, Optimizations)
 
 	return prs, bin, err
 }
 
+// returnExpr заменяет единственное выражение модуля оператором Возврат с этим выражением
+func returnExpr(prs ast.Stmts) error {
+	if len(prs) == 1 {
+		if ms, ok := prs[0].(*ast.ModuleStmt); ok && len(ms.Stmts) == 1 {
+			if es, ok := ms.Stmts[0].(*ast.ExprStmt); ok {
+				rs := &ast.ReturnStmt{Exprs: []ast.Expr{es.Expr}}
+				rs.SetPosition(es.Expr.Position())
+				ms.Stmts[0] = rs
+				return nil
+			}
+		}
+	}
+	// указываем на первую инструкцию, которая не является выражением, или на лишнюю после выражения
+	e := &parser.Error{Message: "Ожидается одно выражение", Pos: pos.Position{Line: 1, Column: 1}, Fatal: true}
+	if len(prs) == 1 {
+		if ms, ok := prs[0].(*ast.ModuleStmt); ok && len(ms.Stmts) > 0 {
+			e.Pos = ms.Stmts[0].Position()
+			if _, ok := ms.Stmts[0].(*ast.ExprStmt); ok && len(ms.Stmts) > 1 {
+				e.Pos = ms.Stmts[1].Position()
+			}
+		}
+	}
+	return e
+}
+
 var binRegsPool = sync.Pool{}
 
 func getRegs(ln int) core.VMSlice {
@@ -76,8 +126,96 @@ func putRegs(sl core.VMSlice) {
 	binRegsPool.Put(sl)
 }
 
-// Run запускает код на исполнение, например, после загрузки из файла
-func Run(stmts binstmt.BinCode, env *core.Env) (retval core.VMValuer, reterr error) {
+var (
+	builtinNamesOnce sync.Once
+	builtinNames     []string
+)
+
+func init() {
+	binstmt.BuiltinNames = func() []string {
+		builtinNamesOnce.Do(func() {
+			env := core.NewEnv()
+			loadBuiltins(env)
+			builtinNames = env.Names()
+		})
+		return builtinNames
+	}
+}
+
+// loadBuiltins загружает стандартную библиотеку в окружение
+func loadBuiltins(env *core.Env) {
+	// эту функцию определяем тут, чтобы исключить циклические зависимости пакетов
+	env.DefineS("загрузитьивыполнить", core.VMFunc(func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
+		ctx := core.CallContext(envout)
+		*envout = env
+		if len(args) != 1 {
+			return errors.New("Должен быть один параметр")
+		}
+		if s, ok := args[0].(core.VMString); ok {
+			body, err := ioutil.ReadFile(string(s))
+			if err != nil {
+				panic(err)
+			}
+			isGNX := strings.HasSuffix(strings.ToLower(string(s)), ".gnx")
+			if isGNX {
+				bbuf := bytes.NewBuffer(body)
+				bins, err := binstmt.ReadBinCode(bbuf)
+				if err != nil {
+					panic(err)
+				}
+				// env.Dump()
+				rv, err := Run(ctx, bins, env)
+				// env.Dump()
+				if err != nil {
+					if e, ok := err.(*binstmt.Error); ok && e.Filename == "" {
+						e.Filename = string(s)
+					}
+					panic(err)
+				}
+				rets.Append(rv)
+				return nil
+			} else {
+				_, bins, err := ParseSrc(string(body))
+				if err != nil {
+					if pe, ok := err.(*parser.Error); ok {
+						pe.Filename = string(s)
+						panic(pe)
+					}
+					panic(err)
+				}
+				bins.AttachDebugInfo(string(s), "")
+				// env.Dump()
+				rv, err := Run(ctx, bins, env)
+				// env.Dump()
+				if err != nil {
+					if e, ok := err.(*binstmt.Error); ok && e.Filename == "" {
+						e.Filename = string(s)
+					}
+					panic(err)
+				}
+				rets.Append(rv)
+				return nil
+			}
+			return nil
+		}
+		return errors.New("Должен быть параметр-строка")
+	}))
+
+	// код из строки исполняется в окружении вызывающего кода
+	env.DefineS("выполнить", core.VMFunc(func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
+		return runSnippet(env, args, rets, envout, false)
+	}))
+	env.DefineS("вычислить", core.VMFunc(func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
+		return runSnippet(env, args, rets, envout, true)
+	}))
+
+	core.LoadAllBuiltins(env)
+}
+
+// Run запускает код на исполнение, например, после загрузки из файла.
+// Отмена ctx или вызов Interrupt прерывает исполнение кода и ожидание во встроенных функциях,
+// горутины, запущенные кодом, исполняются в том же контексте.
+func Run(ctx context.Context, stmts binstmt.BinCode, env *core.Env) (retval core.VMValuer, reterr error) {
 	defer func() {
 		// если это не паника из кода языка
 		// if os.Getenv("GONEC_DEBUG") == "" {
@@ -95,65 +233,25 @@ func Run(stmts binstmt.BinCode, env *core.Env) (retval core.VMValuer, reterr err
 	// стандартная библиотека - загружаем, если она еще не была загружена в это или в родительское окружение
 
 	if !env.IsBuiltsLoaded() {
-		// эту функцию определяем тут, чтобы исключить циклические зависимости пакетов
-		env.DefineS("загрузитьивыполнить", core.VMFunc(func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
-			*envout = env
-			if len(args) != 1 {
-				return errors.New("Должен быть один параметр")
-			}
-			if s, ok := args[0].(core.VMString); ok {
-				body, err := ioutil.ReadFile(string(s))
-				if err != nil {
-					panic(err)
-				}
-				isGNX := strings.HasSuffix(strings.ToLower(string(s)), ".gnx")
-				if isGNX {
-					bbuf := bytes.NewBuffer(body)
-					bins, err := binstmt.ReadBinCode(bbuf)
-					if err != nil {
-						panic(err)
-					}
-					// env.Dump()
-					rv, err := Run(bins, env)
-					// env.Dump()
-					if err != nil {
-						panic(err)
-					}
-					rets.Append(rv)
-					return nil
-				} else {
-					_, bins, err := ParseSrc(string(body))
-					if err != nil {
-						if pe, ok := err.(*parser.Error); ok {
-							pe.Filename = string(s)
-							panic(pe)
-						}
-						panic(err)
-					}
-					// env.Dump()
-					rv, err := Run(bins, env)
-					// env.Dump()
-					if err != nil {
-						panic(err)
-					}
-					rets.Append(rv)
-					return nil
-				}
-				return nil
-			}
-			return errors.New("Должен быть параметр-строка")
-		}))
+		loadBuiltins(env)
+	}
 
-		core.LoadAllBuiltins(env)
+	// контекст не отменяется по окончании запуска: запущенные кодом горутины продолжают работать
+	rctx, cancel := context.WithCancel(ctx)
+	defer env.SetContext(rctx, cancel)()
+
+	// ограничения исполнения глобального контекста действуют на весь запуск
+	if b := env.StartBudget(); b != nil {
+		defer env.StopBudget(b)
 	}
 
-	retval, reterr = RunWorker(stmts.Code, stmts.Labels, stmts.MaxReg+1, env, 0)
+	retval, reterr = RunWorker(&stmts, stmts.MaxReg+1, env, 0)
 
 	return
 }
 
 // RunWorker исполняет кусок кода, начиная с инструкции idx
-func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.Env, idx int) (retval core.VMValuer, reterr error) {
+func RunWorker(code *binstmt.BinCode, numofregs int, env *core.Env, idx int) (retval core.VMValuer, reterr error) {
 	defer func() {
 		// если это не паника из кода языка
 		// if os.Getenv("GONEC_DEBUG") == "" {
@@ -175,28 +273,78 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 	regs := &VMRegs{
 		Env: env,
 		// Reg:          registers,
-		Labels:       labels,
+		Labels:       code.Labels,
 		TryLabel:     make([]int, 0, 8),
 		TryRegErr:    make([]int, 0, 8),
 		ForBreaks:    make([]int, 0, 8),
 		ForContinues: make([]int, 0, 8),
 	}
 
+	// при любом выходе из функции, в том числе по исключению, закрываются итераторы незавершенных циклов
+	// и исполняются отложенные вызовы
+	defer func() {
+		if len(regs.Iters) == 0 && len(regs.Deferred) == 0 {
+			return
+		}
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				reterr = e
+			} else {
+				reterr = errors.New(fmt.Sprint(ex))
+			}
+		}
+		if err := regs.CloseIters(0); err != nil && (reterr == nil || reterr == binstmt.ReturnError) {
+			reterr = err
+		}
+		reterr = runDeferred(regs, code, env, reterr)
+	}()
+
 	var (
 		catcherr error
 	)
 
 	cntInterrupt := 0
+	budget := env.Budget()
+	ctx := env.Context()
+	done := ctx.Done()
+
+	stmts := code.Code
+
+	var frame, pframe DebugFrame
+	if Debugger != nil {
+		if frame = Debugger.Enter(code, idx, env, registers[:numofregs]); frame != nil {
+			defer frame.Leave()
+		}
+	}
+	if p := currentProfiler(); p != nil {
+		if pframe = p.Enter(code, idx, env, registers[:numofregs]); pframe != nil {
+			defer pframe.Leave()
+		}
+	}
 
 	for idx < len(stmts) {
 
+		if frame != nil {
+			frame.Step(idx)
+		}
+		if pframe != nil {
+			pframe.Step(idx)
+		}
+
 		// проверка прерывания каждые 10 команд
 		cntInterrupt++
 		if cntInterrupt == 10 {
 			cntInterrupt = 0
-			if regs.Env.CheckInterrupt() {
-				// проверяем, был ли прерван интерпретатор
+			select {
+			case <-done:
+				// исполнение прервано отменой контекста
 				return nil, binstmt.InterruptError
+			default:
+			}
+			if budget != nil {
+				if err := budget.Step(10); err != nil {
+					return nil, err
+				}
 			}
 		}
 
@@ -247,8 +395,31 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			registers[s.Reg] = v
 
 		case *binstmt.BinSET:
-			// всегда сохраняются локальные переменные, глобальные и из внешнего окружения можно только читать
-			env.Define(s.Id, registers[s.Reg])
+			// сохраняются локальные переменные и переменные объемлющих функций, захваченные замыканием,
+			// глобальные и из внешнего окружения можно только читать
+			env.Assign(s.Id, registers[s.Reg])
+
+		case *binstmt.BinGETLOCAL:
+			v := env.Local(s.Depth, s.Slot)
+			if v == nil {
+				// переменной еще не присвоено значение, имя может быть определено в модуле или глобально
+				var err error
+				v, err = env.Get(s.Id)
+				if err != nil {
+					catcherr = binstmt.NewStringError(stmt, "Невозможно получить значение")
+					break
+				}
+			}
+			registers[s.Reg] = v
+
+		case *binstmt.BinSETLOCAL:
+			if s.Depth > 0 && env.Local(s.Depth, s.Slot) == nil {
+				// переменной объемлющей функции еще не присвоено значение, как и при обращении по имени
+				// она определяется в окружении замыкания
+				env.Assign(s.Id, registers[s.Reg])
+				break
+			}
+			env.SetLocal(s.Depth, s.Slot, registers[s.Reg])
 
 		case *binstmt.BinOPER:
 			v1 := registers[s.RegL]
@@ -257,6 +428,12 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				if vv2, ok := v2.(core.VMOperationer); ok {
 					if rv, err := vv1.EvalBinOp(s.Op, vv2); err == nil {
 						registers[s.RegL] = rv
+						if budget != nil {
+							// строки и массивы, полученные сложением, - новые значения
+							if err := budget.Alloc(core.SizeOf(rv)); err != nil {
+								return nil, err
+							}
+						}
 					} else {
 						catcherr = binstmt.NewError(stmt, err)
 						goto catching
@@ -270,6 +447,27 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				goto catching
 			}
 
+		case *binstmt.BinTYPE:
+			methods := make(map[int]core.VMFunc, len(s.Methods))
+			for i, m := range s.Methods {
+				methods[m] = registers[s.Reg+i].(core.VMFunc)
+			}
+			var init core.VMFunc
+			if s.HasInit {
+				init = registers[s.Reg+len(s.Methods)].(core.VMFunc)
+			}
+			env.DefineObjectType(s.Name, core.NewVMObjectType(s.Name, s.Fields, methods, init))
+
+		case *binstmt.BinCONCAT:
+			// строка с подстановками
+			rv := core.Concat(registers[s.Reg : s.Reg+s.Num])
+			registers[s.Reg] = rv
+			if budget != nil {
+				if err := budget.Alloc(core.SizeOf(rv)); err != nil {
+					return nil, err
+				}
+			}
+
 		case *binstmt.BinEQUAL:
 			v1 := registers[s.Reg1]
 			v2 := registers[s.Reg2]
@@ -308,6 +506,11 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			registers[s.Reg] = v
 
 		case *binstmt.BinMAKESLICE:
+			if budget != nil {
+				if err := budget.Alloc(int64(s.Cap) * 16); err != nil {
+					return nil, err
+				}
+			}
 			registers[s.Reg] = make(core.VMSlice, s.Len, s.Cap)
 
 		case *binstmt.BinSETIDX:
@@ -318,6 +521,11 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				break
 			}
 		case *binstmt.BinMAKEMAP:
+			if budget != nil {
+				if err := budget.Alloc(int64(s.Len) * 32); err != nil {
+					return nil, err
+				}
+			}
 			registers[s.Reg] = make(core.VMStringMap, s.Len)
 
 		case *binstmt.BinSETKEY:
@@ -332,6 +540,11 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			m := registers[s.Reg]
 			mv := registers[s.RegVal]
 			switch mm := m.(type) {
+			case *core.VMObject:
+				if err := mm.SetField(s.Id, mv); err != nil {
+					catcherr = binstmt.NewError(stmt, err)
+					goto catching
+				}
 			case core.VMMetaObject:
 				mm.VMSetField(s.Id, mv.(core.VMInterfacer))
 			case core.VMStringMap:
@@ -360,28 +573,40 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				argsl = registers[s.RegArgs : s.RegArgs+s.NumArgs]
 			}
 			if fnc, ok := fgnc.(core.VMFunc); ok {
+				if s.Defer {
+					// аргументы копируются, т.к. регистры будут использованы для других значений
+					regs.Deferred = append(regs.Deferred, deferredCall{fn: fnc, args: append(core.VMSlice(nil), argsl...), stmt: s})
+					registers[s.RegRets] = core.VMNil
+					break
+				}
 				// если ее надо вызвать в горутине - вызываем
 				if s.Go {
 					// env.SetGoRunned(true)
 					rets := core.GetGlobalVMSlice()   // для каждой горутины отдельный массив возвратов, который потом не используется
 					goargs := core.GetGlobalVMSlice() // для горутин аргументы надо скопировать!
 					goargs = append(goargs, argsl...)
-					go func(a, r core.VMSlice) {
-						var e *core.Env
+					go func(a, r core.VMSlice, e *core.Env) {
 						err := fnc(a, &r, &e)
 						core.PutGlobalVMSlice(a) // всегда возвращаем в пул
 						core.PutGlobalVMSlice(r) // всегда возвращаем в пул
 						if err != nil && e.Valid {
 							e.Println(err)
 						}
-					}(goargs, rets)
+					}(goargs, rets, env.GoEnv())
 					registers[s.RegRets] = core.VMSlice{} // для такого вызова - всегда пустой массив возвратов
 					break
 				}
 
+				if s.Tail && regs.TopTryLabel() == -1 && len(regs.Deferred) == 0 {
+					// хвостовой вызов исполнит функция, тело которой сейчас исполняется, аргументы копируются,
+					// т.к. регистры больше не нужны
+					return nil, &tailCall{fn: fnc, args: append(core.VMSlice(nil), argsl...), stmt: s, file: code.SourceFile()}
+				}
+
 				rets := core.GetGlobalVMSlice()
 				// не в горутине
-				var fenv *core.Env
+				// функция получает окружение вызывающего кода, а возвращает свое
+				fenv := env
 				err = fnc(argsl, &rets, &fenv)
 
 				// TODO: проверить, если был передан слайс, и он изменен внутри функции, то что происходит в исходном слайсе?
@@ -412,7 +637,23 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 		case *binstmt.BinFUNC:
 
-			f := func(expr *binstmt.BinFUNC, fstmts binstmt.BinStmts, flabels []int, fenv *core.Env) core.VMFunc {
+			// функция, объявленная внутри другой функции, является замыканием:
+			// окружение объемлющей функции захватывается по ссылке и остается действительным после выхода из нее
+			closure := !env.IsGlobalScope()
+			if closure {
+				env.Capture()
+			}
+
+			// раскладка локальных переменных создается при объявлении функции, а не при каждом вызове,
+			// параметры с разными именами занимают ее первые номера и присваиваются по номерам
+			var frame *core.Frame
+			byslot := false
+			if s.Locals != nil {
+				frame = core.NewFrame(s.Locals)
+				byslot = argsFirst(s.Args, s.Locals)
+			}
+
+			f := func(expr *binstmt.BinFUNC, fcode *binstmt.BinCode, fenv *core.Env, closure bool) core.VMFunc {
 				return func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
 					if !expr.VarArg {
 						if len(args) != len(expr.Args) {
@@ -420,41 +661,101 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 						}
 					}
 					var newenv *core.Env
-					if expr.Name == 0 {
-						// наследуем от окружения текущей функции
-						newenv = fenv.NewSubEnv()
+					if closure {
+						// наследуем от окружения объемлющей функции
+						newenv = fenv.NewClosureEnv()
 					} else {
 						// наследуем от модуля или глобального окружения
-						newenv = fenv.NewEnv()
+						newenv = fenv.NewFuncEnv()
+					}
+					if frame != nil {
+						newenv.SetLocals(frame)
 					}
 
 					// переменное число аргументов передается как один параметр-слайс
-					if expr.VarArg {
+					switch {
+					case byslot && expr.VarArg:
+						newenv.SetLocal(0, 0, args)
+					case byslot:
+						for i := range expr.Args {
+							newenv.SetLocal(0, i, args[i])
+						}
+					case expr.VarArg:
 						newenv.Define(expr.Args[0], args)
-					} else {
+					default:
 						for i, arg := range expr.Args {
 							newenv.Define(arg, args[i])
 						}
 					}
 					// вызов функции возвращает одиночное значение (в т.ч. VMNil) или VMSlice
 
-					rr, err := RunWorker(fstmts, flabels, expr.MaxReg+1, newenv, flabels[expr.LabelStart])
+					// при вызове из кода на языке Гонец в envout передается окружение вызывающего кода,
+					// при хвостовом вызове - окружение функции, место которой занимает эта функция
+					caller := *envout
+					if caller != nil {
+						// функция исполняется в контексте вызывающего кода, в том числе запустившего ее в горутине
+						newenv.InheritContext(caller)
+						if caller.IsGoEnv() {
+							caller = nil
+						}
+					}
+					tail := caller != nil && caller.TailCall()
+					if tail {
+						caller = caller.Caller()
+					}
+					newenv.SetCaller(caller)
+					if err := newenv.CheckCallDepth(); err != nil {
+						newenv.SetCaller(nil)
+						newenv.Destroy()
+						return err
+					}
+					rr, err := RunWorker(fcode, expr.MaxReg+1, newenv, fcode.Labels[expr.LabelStart])
+					if tc, ok := err.(*tailCall); ok {
+						tc.name = names.UniqueNames.Get(expr.Name)
+					}
+
+					// хвостовые вызовы исполняются здесь по очереди, каждая вызванная функция возвращает
+					// свой хвостовой вызов сюда, а не исполняет его сама
+					called := false
+					for tc, ok := err.(*tailCall); ok && !tail; tc, ok = err.(*tailCall) {
+						called = true
+						cenv := newenv
+						newenv.SetTailCall(true)
+						err = tc.fn(tc.args, rets, &cenv)
+						newenv.SetTailCall(false)
+						switch err.(type) {
+						case nil, *tailCall:
+						default:
+							// исключение покидает функцию, место которой занял хвостовой вызов,
+							// в стеке вызовов остается ее кадр с местом вызова
+							err = binstmt.NewError(tc.stmt, err)
+							if e, ok := err.(*binstmt.Error); ok {
+								e.AddFrame(tc.file, tc.stmt.Position())
+								e.SetFrameFunc(tc.name)
+							}
+						}
+					}
+					newenv.SetCaller(nil)
 
 					*envout = newenv // указываем окружение после выполнения
 
 					if err == binstmt.ReturnError {
 						err = nil
+					} else if e, ok := err.(*binstmt.Error); ok {
+						e.SetFrameFunc(names.UniqueNames.Get(expr.Name))
 					}
-					// возврат массива возвращается сразу, иначе добавляется
-					if vsl, ok := rr.(core.VMSlice); ok {
-						*rets = vsl
-					} else {
-						rets.Append(rr)
+					// возврат массива возвращается сразу, иначе добавляется, результат хвостового вызова уже в rets
+					if err == nil && !called {
+						if vsl, ok := rr.(core.VMSlice); ok {
+							*rets = vsl
+						} else {
+							rets.Append(rr)
+						}
 					}
 					newenv.Destroy()
 					return err
 				}
-			}(s, stmts, labels, env)
+			}(s, code, env, closure)
 
 			env.Define(s.Name, f)
 			registers[s.Reg] = f
@@ -462,6 +763,12 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 		case *binstmt.BinRET:
 			retval = registers[s.Reg]
+			// перед выходом из функции исполняются блоки Окончательно
+			if r, lfin, ok := regs.UnwindTry(0); ok {
+				registers[r] = &finallyAction{kind: finallyReturn, val: retval}
+				idx = regs.Labels[lfin]
+				continue
+			}
 			return retval, binstmt.ReturnError
 
 		case *binstmt.BinSETNAME:
@@ -496,6 +803,15 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 						registers[s.Reg] = core.VMNil
 					}
 				}
+			case *core.VMObject:
+				if vv.IsField(s.Name) {
+					registers[s.Reg], _ = vv.Field(s.Name)
+				} else if ff, ok := vv.MethodMember(s.Name); ok {
+					registers[s.Reg] = ff
+				} else {
+					catcherr = binstmt.NewStringError(stmt, "Нет поля или метода с таким именем")
+					goto catching
+				}
 			case core.VMMetaObject:
 				if vv.VMIsField(s.Name) {
 					registers[s.Reg] = vv.VMGetField(s.Name)
@@ -827,6 +1143,16 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				catcherr = binstmt.NewStringError(stmt, "Неизвестный тип")
 				break
 			}
+			if ot, ok := env.ObjectType(int(eType)); ok {
+				// объект типа, объявленного в коде, получается из структуры или ее записи в json
+				v, err := core.ConvertToObject(registers[s.Reg], ot, env)
+				if err != nil {
+					catcherr = binstmt.NewError(stmt, err)
+					break
+				}
+				registers[s.Reg] = v
+				break
+			}
 			nt, err := env.Type(int(eType))
 			if err != nil {
 				catcherr = binstmt.NewError(stmt, err)
@@ -851,6 +1177,19 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				catcherr = binstmt.NewStringError(stmt, "Неизвестный тип")
 				break
 			}
+			if ot, ok := env.ObjectType(int(eType)); ok {
+				v, err := ot.New(registers[s.Reg+1:s.Reg+1+s.NumArgs], env)
+				if err != nil {
+					catcherr = binstmt.NewError(stmt, err)
+					break
+				}
+				registers[s.Reg] = v
+				break
+			}
+			if s.NumArgs > 0 {
+				catcherr = binstmt.NewError(stmt, core.VMErrorNoConstructor)
+				break
+			}
 			rt, err := env.Type(int(eType))
 			if err != nil {
 				catcherr = binstmt.NewError(stmt, err)
@@ -898,6 +1237,12 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				catcherr = binstmt.NewStringError(stmt, "Размер должен быть целым числом")
 				break
 			}
+			if budget != nil {
+				// проверяем до выделения памяти, иначе ее может не хватить всему процессу
+				if err := budget.Alloc(int64(acap) * 16); err != nil {
+					return nil, err
+				}
+			}
 
 			v := make(core.VMSlice, int(alen), int(acap))
 			registers[s.Reg] = v
@@ -908,7 +1253,10 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				catcherr = binstmt.NewStringError(stmt, "Не является каналом")
 				break
 			}
-			v, ok := ch.Recv()
+			v, ok, err := ch.RecvContext(ctx)
+			if err != nil {
+				return nil, err
+			}
 			if !ok {
 				// если закрыт, то пишем nil
 				registers[s.RegVal] = core.VMNil
@@ -922,8 +1270,9 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				catcherr = binstmt.NewStringError(stmt, "Не является каналом")
 				break
 			}
-			v := registers[s.RegVal]
-			ch.Send(v)
+			if err := ch.SendContext(ctx, registers[s.RegVal]); err != nil {
+				return nil, err
+			}
 
 		case *binstmt.BinISKIND:
 			v := reflect.ValueOf(registers).Index(s.Reg).Elem()
@@ -959,11 +1308,39 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 		case *binstmt.BinCATCH:
 			// получаем ошибку, и если ее нет, переходим на метку, иначе, выполняем дальше
-			nerr := registers[s.Reg]
-			if nerr == nil {
+			ce, ok := registers[s.Reg].(*catchedError)
+			if !ok {
 				idx = regs.Labels[s.JumpTo]
 				continue
 			}
+			registers[s.Reg] = nil
+			e, ok := ce.err.(*binstmt.Error)
+			if len(s.Kinds) > 0 && (!ok || !e.IsKind(s.Kinds)) {
+				// исключение другого вида передаем внешнему обработчику
+				catcherr = ce.err
+				goto catching
+			}
+
+			env.DefineS("описаниеошибки", func(s string) core.VMFunc {
+				return func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
+					*envout = env
+					if len(args) != 0 {
+						return errors.New("Данная функция не требует параметров")
+					}
+					rets.Append(core.VMString(s))
+					return nil
+				}
+			}(ce.err.Error()))
+
+			env.DefineS("информацияобошибке", core.VMFuncMustParams(0, func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
+				*envout = env
+				if e, ok := ce.err.(*binstmt.Error); ok {
+					rets.Append(e.Info())
+				} else {
+					rets.Append(core.VMStringMap{"Описание": core.VMString(ce.err.Error())})
+				}
+				return nil
+			}))
 
 		case *binstmt.BinPOPTRY:
 			// если catch блок отработал, то стек уже очищен, иначе снимаем со стека (ошибок не было)
@@ -971,15 +1348,73 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				regs.PopTry()
 			}
 
+		case *binstmt.BinTRYFIN:
+			regs.PushFinally(s.Reg, s.JumpTo)
+			registers[s.Reg] = nil // изначально отложенного действия нет
+
+		case *binstmt.BinENDFIN:
+			// блок Окончательно исполнен, возобновляем прерванное им действие
+			act, ok := registers[s.Reg].(*finallyAction)
+			if !ok {
+				break
+			}
+			registers[s.Reg] = nil
+			switch act.kind {
+			case finallyThrow:
+				catcherr = act.err
+				goto catching
+			case finallyReturn:
+				retval = act.val
+				if r, lfin, ok := regs.UnwindTry(0); ok {
+					registers[r] = act
+					idx = regs.Labels[lfin]
+					continue
+				}
+				return retval, binstmt.ReturnError
+			case finallyBreak:
+				if r, lfin, ok := regs.UnwindTry(len(regs.ForBreaks)); ok {
+					registers[r] = act
+					idx = regs.Labels[lfin]
+					continue
+				}
+				label := regs.PopBreak()
+				if label != -1 {
+					regs.PopContinue()
+					idx = regs.Labels[label]
+					continue
+				}
+				return nil, binstmt.BreakError
+			case finallyContinue:
+				if r, lfin, ok := regs.UnwindTry(len(regs.ForContinues)); ok {
+					registers[r] = act
+					idx = regs.Labels[lfin]
+					continue
+				}
+				label := regs.TopContinue()
+				if label != -1 {
+					idx = regs.Labels[label]
+					continue
+				}
+				return nil, binstmt.ContinueError
+			}
+
 		case *binstmt.BinFOREACH:
 			val := registers[s.Reg]
 
+			var it core.VMIterator
 			switch vv := val.(type) {
+			case core.VMIterable:
+				var err error
+				if it, err = vv.Iterator(env); err != nil {
+					catcherr = binstmt.NewError(stmt, err)
+					goto catching
+				}
+				registers[s.RegIter] = &iterState{it: it}
 			case core.VMSlicer:
 				registers[s.RegIter] = core.VMInt(-1)
 				registers[s.Reg] = vv.Slice()
 			case core.VMChan:
-				registers[s.RegIter] = nil
+				registers[s.RegIter] = core.VMInt(-1)
 			default:
 				catcherr = binstmt.NewStringError(stmt, "Не является коллекцией или каналом")
 				goto catching
@@ -987,8 +1422,38 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 			regs.PushBreak(s.BreakLabel)
 			regs.PushContinue(s.ContinueLabel)
+			if it != nil {
+				regs.PushIter(it)
+			}
+
+		case *binstmt.BinFOREACHPAR:
+			err := runParallel(s, code, registers[s.Reg], registers[s.RegWorkers], env)
+			if err != nil {
+				catcherr = binstmt.NewError(stmt, err)
+				goto catching
+			}
+			idx = regs.Labels[s.LabelEnd]
+			continue
 
 		case *binstmt.BinNEXT:
+			if st, ok := registers[s.RegIter].(*iterState); ok {
+				v, ok, err := st.it.Next()
+				if err != nil {
+					catcherr = binstmt.NewError(stmt, err)
+					break
+				}
+				if !ok {
+					// итератор закроет POPFOR после выхода из цикла
+					idx = regs.Labels[s.JumpTo]
+					continue
+				}
+				registers[s.RegVal] = v
+				if s.Pair {
+					setPair(registers, s, st.n)
+				}
+				st.n++
+				break
+			}
 			val := registers[s.Reg]
 
 			switch vv := val.(type) {
@@ -1009,21 +1474,43 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				} else {
 					registers[s.RegVal] = iv
 				}
+				// в старом коде регистр итератора канала не заполнялся
+				if n, ok := registers[s.RegIter].(core.VMInt); ok {
+					registers[s.RegIter] = n + 1
+				}
 
 			default:
 				catcherr = binstmt.NewStringError(stmt, "Не является коллекцией или каналом")
 				goto catching
 			}
+			if s.Pair {
+				n, _ := registers[s.RegIter].(core.VMInt)
+				setPair(registers, s, int(n))
+			}
 
 		case *binstmt.BinPOPFOR:
 			if regs.TopContinue() == s.ContinueLabel {
 				regs.PopContinue()
 				regs.PopBreak()
 			}
+			// цикл завершен или прерван, его итератор больше не нужен
+			if err := regs.CloseIters(len(regs.ForBreaks)); err != nil {
+				catcherr = binstmt.NewError(stmt, err)
+				break
+			}
 
 		case *binstmt.BinFORNUM:
 			if _, ok := registers[s.RegFrom].(core.VMInt); ok {
 				if _, ok := registers[s.RegTo].(core.VMInt); ok {
+					if s.HasStep {
+						if step, ok := registers[s.RegStep].(core.VMInt); !ok {
+							catcherr = binstmt.NewStringError(stmt, "Шаг должен быть целым числом")
+							break
+						} else if step == 0 {
+							catcherr = binstmt.NewStringError(stmt, "Шаг не может быть равен нулю")
+							break
+						}
+					}
 					registers[s.Reg] = nil
 					regs.PushBreak(s.BreakLabel)
 					regs.PushContinue(s.ContinueLabel)
@@ -1043,6 +1530,10 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			if afrom > ato {
 				fviadd = int64(-1) // если конечное значение меньше первого, идем в обратном порядке
 			}
+			if s.HasStep {
+				// заданный шаг сам определяет направление, при неверном направлении цикл не исполняется ни разу
+				fviadd = int64(registers[s.RegStep].(core.VMInt))
+			}
 			vv := registers[s.Reg]
 			var iter int64
 			if vv == nil {
@@ -1052,7 +1543,7 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 				iter += fviadd
 			}
 			inrange := iter <= ato
-			if afrom > ato {
+			if fviadd < 0 {
 				inrange = iter >= ato
 			}
 			if inrange {
@@ -1067,13 +1558,13 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			regs.PushContinue(s.ContinueLabel)
 
 		case *binstmt.BinTHROW:
-			catcherr = binstmt.NewStringError(stmt, fmt.Sprint(registers[s.Reg]))
+			catcherr = binstmt.NewThrowError(stmt, registers[s.Reg])
 			break
 
 		case *binstmt.BinMODULE:
 			// модуль регистрируется в глобальном контексте
 			newenv := env.NewModule(names.UniqueNames.Get(s.Name))
-			_, err := Run(s.Code, newenv) // инициируем модуль
+			_, err := Run(ctx, s.Code, newenv) // инициируем модуль
 			if err != nil {
 				catcherr = binstmt.NewError(stmt, err)
 				break
@@ -1084,6 +1575,12 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			return retval, binstmt.NewStringError(s, s.Error)
 
 		case *binstmt.BinBREAK:
+			// при выходе из цикла исполняются блоки Окончательно, находящиеся внутри него
+			if r, lfin, ok := regs.UnwindTry(len(regs.ForBreaks)); ok {
+				registers[r] = &finallyAction{kind: finallyBreak}
+				idx = regs.Labels[lfin]
+				continue
+			}
 			label := regs.PopBreak()
 			if label != -1 {
 				regs.PopContinue()
@@ -1093,9 +1590,14 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 			return nil, binstmt.BreakError
 
 		case *binstmt.BinCONTINUE:
-			label := regs.PopContinue()
+			if r, lfin, ok := regs.UnwindTry(len(regs.ForContinues)); ok {
+				registers[r] = &finallyAction{kind: finallyContinue}
+				idx = regs.Labels[lfin]
+				continue
+			}
+			// цикл остается в стеке, переход на очередную итерацию
+			label := regs.TopContinue()
 			if label != -1 {
-				regs.PopBreak()
 				idx = regs.Labels[label]
 				continue
 			}
@@ -1140,26 +1642,32 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 	catching:
 		if catcherr != nil {
+			if catcherr == binstmt.InterruptError || core.IsLimitError(catcherr) {
+				// прерывание и превышение ограничений исполнения не перехватываются
+				return nil, catcherr
+			}
 			nerr := binstmt.NewError(stmt, catcherr)
 			catcherr = nil
 			// учитываем стек обработки ошибок
 			if regs.TopTryLabel() == -1 {
+				// исключение покидает функцию или модуль - запоминаем место в стеке вызовов
+				if e, ok := nerr.(*binstmt.Error); ok {
+					e.AddFrame(code.SourceFile(), stmt.Position())
+				}
 				return nil, nerr
 			} else {
-				env.DefineS("описаниеошибки", func(s string) core.VMFunc {
-					return func(args core.VMSlice, rets *core.VMSlice, envout *(*core.Env)) error {
-						*envout = env
-						if len(args) != 0 {
-							return errors.New("Данная функция не требует параметров")
-						}
-						rets.Append(core.VMString(s))
-						return nil
-					}
-				}(nerr.Error()))
+				// циклы внутри блока Попытка прерваны исключением, их итераторы закрываются
+				regs.CloseIters(regs.TryLoopDepth[len(regs.TryLoopDepth)-1])
+				r, idxl, finally := regs.PopTry()
+				if finally {
+					// исключение будет вызвано повторно после исполнения блока Окончательно
+					registers[r] = &finallyAction{kind: finallyThrow, err: nerr}
+					idx = regs.Labels[idxl]
+					continue
+				}
 
-				r, idxl := regs.PopTry()
-				registers[r] = core.VMString(nerr.Error())
-				idx = regs.Labels[idxl] // переходим в catch блок, функция с описанием ошибки определена
+				registers[r] = &catchedError{err: nerr}
+				idx = regs.Labels[idxl] // переходим в catch блок, функции с описанием ошибки определит CATCH
 				continue
 			}
 		}
@@ -1171,3 +1679,214 @@ func RunWorker(stmts binstmt.BinStmts, labels []int, numofregs int, env *core.En
 
 	return retval, nil
 }
+
+// setPair раскладывает очередное значение цикла Для к, з Из ... на ключ и значение,
+// если это не пара КлючИЗначение, ключом становится номер значения n
+func setPair(registers core.VMSlice, s *binstmt.BinNEXT, n int) {
+	if k, v, ok := core.KeyValueOf(registers[s.RegVal]); ok {
+		registers[s.RegKey], registers[s.RegVal] = k, v
+		return
+	}
+	registers[s.RegKey] = core.VMInt(n)
+}
+
+// runDeferred исполняет отложенные вызовы в обратном порядке, reterr - ошибка, с которой завершился код функции.
+// Исключение в отложенном вызове заменяет эту ошибку, как и паника в отложенной функции Go,
+// но прерывание и превышение ограничений исполнения не заменяются.
+func runDeferred(regs *VMRegs, code *binstmt.BinCode, env *core.Env, reterr error) error {
+	for len(regs.Deferred) > 0 {
+		d := regs.Deferred[len(regs.Deferred)-1]
+		regs.Deferred = regs.Deferred[:len(regs.Deferred)-1]
+		err := callDeferred(d, env)
+		if err == nil || reterr == binstmt.InterruptError || core.IsLimitError(reterr) {
+			continue
+		}
+		if err == binstmt.InterruptError || core.IsLimitError(err) {
+			reterr = err
+			continue
+		}
+		nerr := binstmt.NewError(d.stmt, err)
+		if e, ok := nerr.(*binstmt.Error); ok {
+			e.AddFrame(code.SourceFile(), d.stmt.Position())
+		}
+		reterr = nerr
+	}
+	return reterr
+}
+
+// callDeferred вызывает отложенную функцию, паника встроенной функции становится ошибкой
+func callDeferred(d deferredCall, env *core.Env) (err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				err = e
+			} else {
+				err = errors.New(fmt.Sprint(ex))
+			}
+		}
+	}()
+	var rets core.VMSlice
+	fenv := env
+	return d.fn(d.args, &rets, &fenv)
+}
+
+// argsFirst сообщает, что параметры функции занимают первые номера ее локальных переменных по порядку
+func argsFirst(args, locals []int) bool {
+	if len(args) > len(locals) {
+		return false
+	}
+	for i, id := range args {
+		if locals[i] != id {
+			return false
+		}
+	}
+	return true
+}
+
+// runParallel исполняет тело цикла Для каждого ... Параллельно для каждого элемента коллекции или канала.
+// Одновременно исполняется не более заданного числа итераций, каждая в собственном окружении, см. core.Env.NewParallelEnv:
+// как и в обычном цикле, итерации изменяют переменные, определенные до цикла, в том числе переменные модуля.
+// Одновременные изменения одной переменной разными итерациями не упорядочены.
+// Возврат происходит после завершения всех начатых итераций, первая возникшая ошибка возвращается.
+func runParallel(s *binstmt.BinFOREACHPAR, code *binstmt.BinCode, coll, workers core.VMValuer, env *core.Env) error {
+	nw := runtime.NumCPU()
+	if workers != nil && workers != core.VMNil {
+		w, ok := workers.(core.VMInt)
+		if !ok || w < 1 {
+			return binstmt.NewStringError(s, "Число потоков должно быть целым положительным числом")
+		}
+		nw = int(w)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		stopped  bool
+		firsterr error
+	)
+	// stop закрывается при остановке цикла, чтобы не ждать следующего элемента канала
+	stop := make(chan struct{})
+	halt := func(err error) {
+		mu.Lock()
+		if !stopped {
+			stopped = true
+			close(stop)
+		}
+		if firsterr == nil {
+			firsterr = err
+		}
+		mu.Unlock()
+	}
+	done := env.Context().Done()
+
+	var it core.VMIterator
+	var next func() (core.VMValuer, bool)
+	switch vv := coll.(type) {
+	case core.VMIterable:
+		var err error
+		if it, err = vv.Iterator(env); err != nil {
+			return binstmt.NewError(s, err)
+		}
+		// элементы получаются по одному в этой горутине, ошибка итератора останавливает цикл
+		next = func() (core.VMValuer, bool) {
+			v, ok, err := it.Next()
+			if err != nil {
+				halt(binstmt.NewError(s, err))
+				return nil, false
+			}
+			return v, ok
+		}
+	case core.VMSlicer:
+		sl := vv.Slice()
+		i := 0
+		next = func() (core.VMValuer, bool) {
+			if i >= len(sl) {
+				return nil, false
+			}
+			i++
+			return sl[i-1], true
+		}
+	case core.VMChan:
+		// ожидание канала прекращается при остановке цикла или отмене контекста
+		next = func() (core.VMValuer, bool) {
+			select {
+			case v, ok := <-vv:
+				return v, ok
+			case <-stop:
+			case <-done:
+				halt(binstmt.NewError(s, core.VMErrorInterrupted))
+			}
+			return nil, false
+		}
+	default:
+		return binstmt.NewStringError(s, "Не является коллекцией или каналом")
+	}
+
+	var frame *core.Frame
+	if s.Locals != nil {
+		frame = core.NewFrame(s.Locals)
+	}
+	// итерации обращаются к переменным окружения цикла из разных горутин
+	env.ShareParallel()
+
+	items := make(chan core.VMValuer)
+	for i := 0; i < nw; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range items {
+				ienv := env.NewParallelEnv()
+				if frame != nil {
+					ienv.SetLocals(frame)
+				}
+				ienv.Define(s.Var, v)
+				_, err := RunWorker(code, s.MaxReg+1, ienv, code.Labels[s.LabelStart])
+				ienv.Destroy()
+
+				switch err {
+				case nil, binstmt.ContinueError:
+					continue
+				case binstmt.BreakError:
+					// Прервать останавливает запуск следующих итераций
+					err = nil
+				case binstmt.ReturnError:
+					err = binstmt.NewStringError(s, "Оператор Возврат недопустим в параллельном цикле")
+				}
+				halt(err)
+			}
+		}()
+	}
+
+produce:
+	for {
+		select {
+		case <-stop:
+			break produce
+		case <-done:
+			halt(binstmt.NewError(s, core.VMErrorInterrupted))
+			break produce
+		default:
+		}
+		v, ok := next()
+		if !ok {
+			break
+		}
+		select {
+		case items <- v:
+		case <-stop:
+			break produce
+		case <-done:
+			halt(binstmt.NewError(s, core.VMErrorInterrupted))
+			break produce
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	if it != nil {
+		if err := it.Close(); err != nil && firsterr == nil {
+			firsterr = binstmt.NewError(s, err)
+		}
+	}
+	return firsterr
+}
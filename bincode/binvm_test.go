@@ -0,0 +1,803 @@
+package bincode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/covrom/gonec/bincode/binopt"
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/format"
+	"github.com/covrom/gonec/names"
+)
+
+// runScript компилирует и исполняет код в новом окружении, возвращая все, что было выведено
+func runScript(t *testing.T, script string) string {
+	t.Helper()
+	env := core.NewEnv()
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	_, stmts, err := ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Run(context.Background(), stmts, env); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// runOptimized компилирует код с заданными проходами оптимизации и исполняет его,
+// возвращая все, что было выведено, включая ошибку исполнения
+func runOptimized(t *testing.T, script string, passes binopt.Passes) (string, binstmt.BinCode) {
+	t.Helper()
+	old := Optimizations
+	Optimizations = passes
+	defer func() { Optimizations = old }()
+	_, bins, err := ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnv()
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	if _, err = Run(context.Background(), bins, env); err != nil {
+		fmt.Fprintln(&buf, "ошибка:", err)
+	}
+	return buf.String(), bins
+}
+
+// runTimeout исполняет код как runScript, но не дольше d, зависание считается ошибкой теста
+func runTimeout(t *testing.T, script string, d time.Duration) string {
+	t.Helper()
+	done := make(chan string, 1)
+	go func() {
+		env := core.NewEnv()
+		var buf bytes.Buffer
+		env.SetStdOut(&buf)
+		_, stmts, err := ParseSrc(script)
+		if err == nil {
+			_, err = Run(context.Background(), stmts, env)
+		}
+		if err != nil {
+			fmt.Fprintln(&buf, "ошибка:", err)
+		}
+		done <- buf.String()
+	}()
+	select {
+	case out := <-done:
+		return out
+	case <-time.After(d):
+		t.Fatalf("код не завершился за %v", d)
+	}
+	return ""
+}
+
+func TestParallelForEach(t *testing.T) {
+	out := runScript(t, `
+	р = [0, 0, 0, 0, 0]
+	Для каждого н Из [0, 1, 2, 3, 4] Параллельно 2 Цикл
+		р[н] = н * н
+	КонецЦикла
+	Сообщить(р)
+
+	Попытка
+		Для каждого н Из [1, 2, 3] Параллельно Цикл
+			Если н = 2 Тогда
+				ВызватьИсключение "ошибка итерации"
+			КонецЕсли
+		КонецЦикла
+	Исключение
+		Сообщить(ИнформацияОбОшибке().Описание)
+	КонецПопытки
+	`)
+	want := "[0,1,4,9,16]\nошибка итерации\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
+
+func TestParallelForEachChan(t *testing.T) {
+	// после Прервать или ошибки цикл не ждет следующего значения из канала, в который больше никто не пишет
+	out := runTimeout(t, `
+	Функция Отправить(к)
+		Для н = 1 По 3 Цикл
+			к <- н
+		КонецЦикла
+	КонецФункции
+
+	кпрерв = Новый Канал(0)
+	Старт Отправить(кпрерв)
+	Для каждого х Из кпрерв Параллельно 2 Цикл
+		Если х = 1 Тогда
+			Прервать
+		КонецЕсли
+	КонецЦикла
+	Сообщить("прерван")
+
+	кошиб = Новый Канал(0)
+	Старт Отправить(кошиб)
+	Попытка
+		Для каждого х Из кошиб Параллельно 2 Цикл
+			Если х = 1 Тогда
+				ВызватьИсключение "ошибка итерации"
+			КонецЕсли
+		КонецЦикла
+	Исключение
+		Сообщить(ИнформацияОбОшибке().Описание)
+	КонецПопытки
+	`, 5*time.Second)
+	want := "прерван\nошибка итерации\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
+
+func TestParallelForEachCancel(t *testing.T) {
+	// отмена контекста прекращает ожидание канала
+	_, stmts, err := ParseSrc(`
+	кожид = Новый Канал(0)
+	Для каждого х Из кожид Параллельно 2 Цикл
+	КонецЦикла
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Run(ctx, stmts, core.NewEnv())
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err == nil || !strings.Contains(err.Error(), core.VMErrorInterrupted.Error()) {
+			t.Errorf("получена ошибка %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("цикл не прерван отменой контекста")
+	}
+}
+
+func TestParallelAssign(t *testing.T) {
+	// итерации изменяют переменные, определенные до цикла, и в коде модуля, и в функции,
+	// а переменные, определенные итерацией, после цикла не видны
+	script := `
+	сумпар = 0
+	Для каждого н Из [1, 2] Параллельно 1 Цикл
+		сумпар = сумпар + н
+		итерпар = н
+	КонецЦикла
+	Сообщить(сумпар)
+	Попытка
+		Сообщить(итерпар)
+	Исключение
+		Сообщить("не определена")
+	КонецПопытки
+	Функция СуммаПар(м)
+		сумфпар = 0
+		Для каждого н Из м Параллельно 1 Цикл
+			сумфпар = сумфпар + н
+		КонецЦикла
+		Возврат сумфпар
+	КонецФункции
+	Сообщить(СуммаПар([1, 2, 3]))
+	`
+	want := "3\nне определена\n6\n"
+	for _, p := range []binopt.Passes{binopt.None, binopt.All} {
+		if got, _ := runOptimized(t, script, p); got != want {
+			t.Errorf("проходы %s: получено %q, ожидалось %q", p, got, want)
+		}
+	}
+
+	out := runScript(t, `
+	Попытка
+		Для каждого н Из 5 Параллельно Цикл
+		КонецЦикла
+	Исключение
+		Сообщить(ОписаниеОшибки())
+	КонецПопытки
+	`)
+	if !strings.Contains(out, "Не является коллекцией или каналом") {
+		t.Errorf("получено %q", out)
+	}
+}
+
+func TestLocals(t *testing.T) {
+	script := `
+	х = 10
+	у = 1
+	Функция Внешняя(а)
+		б = а * 2
+		Функция Внутр(п)
+			б = б + п
+			г = п
+			Возврат г
+		КонецФункции
+		Внутр(1)
+		Сообщить(б, х, у)
+		х = 5
+		у = у
+		Сообщить(х, у)
+		Возврат Внутр
+	КонецФункции
+	ф = Внешняя(3)
+	Сообщить(ф(7), х)
+	Функция Фиб(н)
+		Если н < 2 Тогда
+			Возврат н
+		КонецЕсли
+		Возврат Фиб(н - 1) + Фиб(н - 2)
+	КонецФункции
+	Сообщить(Фиб(15))
+	Функция Сумма(м)
+		с = 0
+		Для каждого э Из м Параллельно Цикл
+			т = э * 10
+			с = с + т
+		КонецЦикла
+		Возврат с
+	КонецФункции
+	Сообщить(Сумма([1]))
+	Функция Ошибка()
+		Попытка
+			ВызватьИсключение "сбой"
+		Исключение
+			Возврат ОписаниеОшибки()
+		КонецПопытки
+	КонецФункции
+	Сообщить(Ошибка())
+	Функция Повтор(а, а)
+		Возврат а
+	КонецФункции
+	Сообщить(Повтор(1, 2))
+	`
+	want := "7 10 1\n5 1\n7 10\n610\n10\n[38:4] сбой\n2\n"
+	for _, p := range []binopt.Passes{binopt.None, binopt.Locals, binopt.All} {
+		if got, _ := runOptimized(t, script, p); got != want {
+			t.Errorf("проходы %s: получено %q, ожидалось %q", p, got, want)
+		}
+	}
+
+	// параметры и переменные функций получаются по номерам, переменные модуля - по именам
+	_, bins := runOptimized(t, script, binopt.Locals)
+	byName := make(map[string]bool)
+	for _, s := range bins.Code {
+		switch ss := s.(type) {
+		case *binstmt.BinGET:
+			byName[names.UniqueNames.GetLowerCase(ss.Id)] = true
+		case *binstmt.BinFUNC:
+			if strings.EqualFold(names.UniqueNames.Get(ss.Name), "внутр") && len(ss.Locals) != 2 {
+				t.Errorf("локальные переменные функции Внутр: %v", ss.Locals)
+			}
+		}
+	}
+	for _, n := range []string{"а", "б", "п", "г", "н", "с", "т", "э"} {
+		if byName[n] {
+			t.Errorf("переменная %q получается по имени\n%s", n, bins)
+		}
+	}
+	if !byName["х"] {
+		t.Errorf("переменные модуля не получаются по имени\n%s", bins)
+	}
+}
+
+func TestClosureAssignUndefined(t *testing.T) {
+	// замыкание присваивает переменной объемлющей функции, которой еще не присвоено значение,
+	// поэтому переменная определяется в замыкании, а не в объемлющей функции
+	script := `
+	Функция ВнешняяЗам()
+		Функция ВнутрЗам()
+			знзам = 7
+			Возврат знзам
+		КонецФункции
+		Сообщить(ВнутрЗам())
+		Попытка
+			Сообщить(знзам)
+		Исключение
+			Сообщить("не определена")
+		КонецПопытки
+		знзам = 1
+		ВнутрЗам()
+		Сообщить(знзам)
+	КонецФункции
+	ВнешняяЗам()
+	`
+	want := "7\nне определена\n7\n"
+	for _, p := range []binopt.Passes{binopt.None, binopt.All} {
+		if got, _ := runOptimized(t, script, p); got != want {
+			t.Errorf("проходы %s: получено %q, ожидалось %q", p, got, want)
+		}
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	_, bins, err := ParseSrc(`
+	Функция ФибТест(н)
+		Если н < 2 Тогда
+			Возврат н
+		КонецЕсли
+		Возврат ФибТест(н - 1) + ФибТест(н - 2)
+	КонецФункции
+	ФибТест(20)
+	`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Run(context.Background(), bins, core.NewEnv()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestClosures(t *testing.T) {
+	out := runScript(t, `
+	Функция Счетчик(нач)
+		н = нач
+		возврат Функция()
+			н = н + 1
+			возврат н
+		КонецФункции
+	КонецФункции
+	а = Счетчик(0)
+	б = Счетчик(10)
+	Сообщить(а(), а(), б(), а())
+
+	Функция Внешняя()
+		х = 1
+		изменить = Функция(з)
+			х = з
+		КонецФункции
+		изменить(5)
+		Функция Вложенная()
+			возврат Функция()
+				возврат х * 2
+			КонецФункции
+		КонецФункции
+		возврат Вложенная()
+	КонецФункции
+	Сообщить(Внешняя()())
+
+	г = 100
+	Функция НеИзменяетГлобальную()
+		возврат Функция()
+			г = 1
+			возврат г
+		КонецФункции
+	КонецФункции
+	Сообщить(НеИзменяетГлобальную()(), г)
+	`)
+	want := "1 2 11 3\n10\n1 100\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
+
+func TestFinally(t *testing.T) {
+	out := runScript(t, `
+	Попытка
+		ВызватьИсключение "ош"
+	Исключение
+		Сообщить("исключение")
+	Окончательно
+		Сообщить("окончательно 1")
+	КонецПопытки
+
+	Попытка
+		Попытка
+			ВызватьИсключение "внутр"
+		Окончательно
+			Сообщить("окончательно 2")
+		КонецПопытки
+	Исключение
+		Сообщить("внешнее")
+	КонецПопытки
+
+	Функция Ф()
+		Попытка
+			Возврат 3
+		Окончательно
+			Сообщить("окончательно 3")
+		КонецПопытки
+	КонецФункции
+	Сообщить(Ф())
+
+	Для н = 1 По 3 Цикл
+		Попытка
+			Если н = 1 Тогда
+				Продолжить
+			КонецЕсли
+			Если н = 2 Тогда
+				Прервать
+			КонецЕсли
+		Окончательно
+			Сообщить("цикл", н)
+		КонецПопытки
+	КонецЦикла
+	`)
+	want := "исключение\nокончательно 1\nокончательно 2\nвнешнее\nокончательно 3\n3\nцикл 1\nцикл 2\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
+
+func TestExceptionInfo(t *testing.T) {
+	out := runScript(t, `
+	Функция Элемент(м, н)
+		Возврат м[н]
+	КонецФункции
+	Функция Первый(м)
+		Возврат Элемент(м, 5)
+	КонецФункции
+	Попытка
+		Первый([1])
+	Исключение
+		инфо = ИнформацияОбОшибке()
+		Сообщить(инфо.Вид, инфо.НомерСтроки)
+		Для каждого к Из инфо.СтекВызовов Цикл
+			Сообщить(к.ИмяФункции, к.НомерСтроки)
+		КонецЦикла
+	КонецПопытки
+
+	Попытка
+		ВызватьИсключение {"Вид": "НетДанных", "Описание": "пусто", "Код": 404}
+	Исключение "Другое", "НетДанных"
+		инфо = ИнформацияОбОшибке()
+		Сообщить(инфо.Вид, инфо.Описание, инфо.Код)
+	КонецПопытки
+
+	Попытка
+		Попытка
+			ВызватьИсключение "ош"
+		Исключение "НетДанных"
+			Сообщить("перехвачено")
+		КонецПопытки
+	Исключение
+		Сообщить(ИнформацияОбОшибке().Вид, ИнформацияОбОшибке().Описание)
+	КонецПопытки
+	`)
+	want := "ОшибкаВыполнения 3\nЭлемент 3\nПервый 6\nНетДанных пусто 404\nИсключение ош\n"
+	if out != want {
+		t.Errorf("получено %q, ожидалось %q", out, want)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	script := `
+	Функция Счет(н, акк)
+		Если н = 0 Тогда
+			Возврат акк
+		КонецЕсли
+		Возврат Счет(н - 1, акк + 1)
+	КонецФункции
+	Функция Чет(н)
+		Если н = 0 Тогда
+			Возврат Истина
+		КонецЕсли
+		Возврат Нечет(н - 1)
+	КонецФункции
+	Функция Нечет(н)
+		Если н = 0 Тогда
+			Возврат Ложь
+		КонецЕсли
+		Возврат Чет(н - 1)
+	КонецФункции
+	Функция ВПопытке(н)
+		Попытка
+			Возврат Счет(н, 0)
+		Окончательно
+			Сообщить("окончательно")
+		КонецПопытки
+	КонецФункции
+	Сообщить(Счет(50000, 0), Чет(30001), ВПопытке(3))
+	Функция Глубоко(н)
+		Возврат Глубоко(н + 1) + 1
+	КонецФункции
+	Попытка
+		Глубоко(0)
+	Исключение
+		Сообщить(ОписаниеОшибки())
+	КонецПопытки
+	`
+	_, bins, err := ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tails := 0
+	for _, s := range bins.Code {
+		if c, ok := s.(*binstmt.BinCALL); ok && c.Tail {
+			tails++
+		}
+	}
+	// вызов в блоке Попытка и вызов, результат которого еще складывается, не хвостовые
+	if tails != 3 {
+		t.Errorf("хвостовых вызовов %d, ожидалось 3\n%s", tails, bins)
+	}
+
+	env := core.NewEnv()
+	env.SetMaxCallDepth(1000)
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	if _, err := Run(context.Background(), bins, env); err != nil {
+		t.Fatal(err)
+	}
+	want := "окончательно\n50000 false 3\n[29:11] Превышена максимальная глубина вызовов функций (1000)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("получено %q, ожидалось %q", got, want)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	script := `Функция Ф(н)
+	Отложить Сообщить("первый", н)
+	н = н + 1
+	Отложить Функция(х)
+		Сообщить("второй", х)
+	КонецФункции(н)
+	Если н > 5 Тогда
+		ВызватьИсключение "большое"
+	КонецЕсли
+	Возврат н
+КонецФункции
+
+Функция Хвост(н)
+	Отложить Сообщить("хвост", н)
+	Возврат Ф(н)
+КонецФункции
+
+Функция ОшибкаВОтложенном()
+	Отложить Бросить("отложенное")
+	Возврат 1
+КонецФункции
+
+Функция ВПопытке()
+	Попытка
+		Отложить Сообщить("из попытки")
+		ВызватьИсключение "x"
+	Исключение
+		Сообщить("обработано")
+	КонецПопытки
+	Сообщить("после попытки")
+КонецФункции
+
+Функция Бросить(т)
+	ВызватьИсключение т
+КонецФункции
+
+Сообщить(Ф(1))
+Попытка
+	Ф(10)
+Исключение
+	Сообщить(ОписаниеОшибки())
+КонецПопытки
+Сообщить(Хвост(2))
+Попытка
+	ОшибкаВОтложенном()
+Исключение
+	Сообщить(ОписаниеОшибки())
+КонецПопытки
+ВПопытке()
+Отложить Сообщить("конец")
+Сообщить("модуль")
+`
+	exp := "второй 2\nпервый 1\n2\nвторой 11\nпервый 10\n[8:3] большое\nвторой 3\nпервый 2\nхвост 2\n3\n[34:2] отложенное\nобработано\nпосле попытки\nиз попытки\nмодуль\nконец\n"
+	for _, passes := range []binopt.Passes{binopt.None, Optimizations} {
+		out, bins := runOptimized(t, script, passes)
+		if out != exp {
+			t.Errorf("вывод: %q", out)
+		}
+		// вызов перед Возврат в функции с отложенными вызовами не исполняется как хвостовой
+		if n := countStmts(bins, func(s binstmt.BinStmt) bool { c, ok := s.(*binstmt.BinCALL); return ok && c.Defer && c.Tail }); n != 0 {
+			t.Errorf("отложенных хвостовых вызовов: %d", n)
+		}
+	}
+
+	for _, src := range []string{"Отложить 1 + 2\n", "Отложить Старт Сообщить(1)\n"} {
+		if _, _, err := ParseSrc(src); err == nil || !strings.Contains(err.Error(), "Отложить ожидает вызов функции") {
+			t.Errorf("%q: %v", src, err)
+		}
+	}
+
+	src := "Функция Ф()\n\tОтложить Сообщить(1)\nКонецФункции\n"
+	res, err := format.Source(src, format.Options{})
+	if err != nil || res != src {
+		t.Errorf("форматирование: %v %q", err, res)
+	}
+}
+
+// testRange - коллекция на Го, которая выдает числа от 1 до n по одному и считает закрытые итераторы
+type testRange struct {
+	core.VMValueStruct
+
+	n      int
+	closed int
+}
+
+func (r *testRange) Iterator(env *core.Env) (core.VMIterator, error) {
+	return &testRangeIter{r: r}, nil
+}
+
+type testRangeIter struct {
+	r *testRange
+	i int
+}
+
+func (it *testRangeIter) Next() (core.VMValuer, bool, error) {
+	if it.i >= it.r.n {
+		return core.VMNil, false, nil
+	}
+	it.i++
+	return core.VMInt(it.i), true, nil
+}
+
+func (it *testRangeIter) Close() error {
+	it.r.closed++
+	return nil
+}
+
+func TestIterators(t *testing.T) {
+	script := `с = 0
+Для каждого х Из числа Цикл
+	с = с + х
+КонецЦикла
+Для каждого х Из числа Цикл
+	Если х = 2 Тогда
+		Прервать
+	КонецЕсли
+КонецЦикла
+Функция Ф()
+	Для каждого х Из числа Цикл
+		Возврат х
+	КонецЦикла
+КонецФункции
+Ф()
+Попытка
+	Для каждого х Из числа Цикл
+		ВызватьИсключение "стоп"
+	КонецЦикла
+Исключение
+КонецПопытки
+Для каждого х Из числа Параллельно 2 Цикл
+КонецЦикла
+Сообщить(с)
+
+Тип Счетчик
+	Поле н, до
+
+	Метод Конструктор(до)
+		ЭтотОбъект.н = 0
+		ЭтотОбъект.до = до
+	КонецМетода
+
+	Метод Следующий()
+		Если ЭтотОбъект.н >= ЭтотОбъект.до Тогда
+			Возврат Неопределено, Ложь
+		КонецЕсли
+		ЭтотОбъект.н = ЭтотОбъект.н + 1
+		Возврат ЭтотОбъект.н, Истина
+	КонецМетода
+
+	Метод Закрыть()
+		Сообщить("закрыт", ЭтотОбъект.н)
+	КонецМетода
+КонецТипа
+
+Для каждого х Из Новый Счетчик(2) Цикл
+	Сообщить(х)
+КонецЦикла
+Для каждого х Из Новый Счетчик(5) Цикл
+	Прервать
+КонецЦикла
+`
+	for _, passes := range []binopt.Passes{binopt.None, Optimizations} {
+		old := Optimizations
+		Optimizations = passes
+		_, bins, err := ParseSrc(script)
+		Optimizations = old
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &testRange{n: 4}
+		env := core.NewEnv()
+		env.DefineS("числа", r)
+		var buf bytes.Buffer
+		env.SetStdOut(&buf)
+		if _, err = Run(context.Background(), bins, env); err != nil {
+			t.Fatal(err)
+		}
+		if out := buf.String(); out != "10\n1\n2\nзакрыт 2\nзакрыт 1\n" {
+			t.Errorf("вывод: %q", out)
+		}
+		// итератор закрывается и после последнего элемента, и при выходе из цикла по Прервать, Возврат и исключению
+		if r.closed != 5 {
+			t.Errorf("закрыто итераторов: %d", r.closed)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "gonec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := runScript(t, `б = Новый ФайловаяБазаДанных
+б.Открыть("`+filepath.ToSlash(filepath.Join(dir, "итер.db"))+`")
+т = б.НачатьТранзакцию(Истина)
+тб = т.Таблица("тест")
+тб.Установить("б", "два")
+тб.Установить("а", 1)
+Для каждого кз Из тб Цикл
+	Сообщить(кз.Ключ, кз.Значение, ТипЗнч(кз))
+КонецЦикла
+т.ЗафиксироватьТранзакцию()
+б.Закрыть()
+`)
+	if out != "а 1 КлючИЗначение\nб два КлючИЗначение\n" {
+		t.Errorf("вывод: %q", out)
+	}
+}
+
+func TestForKeyValueAndStep(t *testing.T) {
+	script := `ст = Новый("Структура")
+ст["б"] = 2
+ст["а"] = 1
+ст["в"] = 3
+Для каждого КлючИЗначение Из ст Цикл
+	Сообщить(КлючИЗначение.Ключ, КлючИЗначение.Value)
+КонецЦикла
+Для к, з Из ст Цикл
+	Если к = "б" Тогда
+		Продолжить
+	КонецЕсли
+	Сообщить(к, з)
+КонецЦикла
+Для каждого ном, эл Из ["х", "у", "з"] Цикл
+	Если ном = 2 Тогда
+		Прервать
+	КонецЕсли
+	Сообщить(ном, эл)
+КонецЦикла
+Для н = 10 По 1 Шаг -3 Цикл
+	Сообщить(н)
+КонецЦикла
+Для н = 1 По 10 Шаг 4 Цикл
+	Если н = 5 Тогда
+		Продолжить
+	КонецЕсли
+	Сообщить("шаг", н)
+КонецЦикла
+Для н = 1 По 10 Шаг -1 Цикл
+	Сообщить("не исполняется", н)
+КонецЦикла
+Для н = 2 По 1 Цикл
+	Сообщить("назад", н)
+КонецЦикла
+Попытка
+	Для н = 1 По 3 Шаг 0 Цикл
+	КонецЦикла
+Исключение
+	Сообщить("ноль")
+КонецПопытки
+`
+	want := "а 1\nб 2\nв 3\nа 1\nв 3\n0 х\n1 у\n10\n7\n4\n1\nшаг 1\nшаг 9\nназад 2\nназад 1\nноль\n"
+	for _, passes := range []binopt.Passes{binopt.None, binopt.All} {
+		if out, bins := runOptimized(t, script, passes); out != want {
+			t.Errorf("вывод: %q\n%s", out, bins)
+		}
+	}
+
+	src := "Для Каждого к, з Из ст Цикл\nКонецЦикла\nДля н = 10 По 1 Шаг -2 Цикл\nКонецЦикла\n"
+	if got, err := format.Source(src, format.Options{}); err != nil || got != src {
+		t.Errorf("формат: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,549 @@
+// Package checker выполняет статическую проверку кода на языке Гонец без его исполнения
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/covrom/gonec/ast"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
+)
+
+// Diagnostic - замечание проверки с местом в исходном коде
+type Diagnostic struct {
+	File    string
+	Pos     pos.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Pos.Line, d.Pos.Column, d.Message)
+}
+
+// ExtraNames - имена, которые определяются не стандартной библиотекой, а интерпретатором или виртуальной машиной
+var ExtraNames = []string{
+	"загрузитьивыполнить",
+	"выполнить",
+	"вычислить",
+	"аргументызапуска",
+	"описаниеошибки",
+	"информацияобошибке",
+}
+
+type builtins struct {
+	funcs  map[int]core.VMValuer
+	types  map[int]bool
+	params map[int]int // число параметров функций, созданных через core.VMFuncMustParams
+}
+
+var (
+	stdOnce sync.Once
+	std     builtins
+)
+
+// stdBuiltins возвращает имена стандартной библиотеки, загружаемой в глобальный контекст
+func stdBuiltins() builtins {
+	stdOnce.Do(func() {
+		env := core.NewEnv()
+		core.LoadAllBuiltins(env)
+		std = builtins{
+			funcs:  make(map[int]core.VMValuer),
+			types:  make(map[int]bool),
+			params: make(map[int]int),
+		}
+		for _, n := range env.Names() {
+			id := names.UniqueNames.Set(n)
+			v, _ := env.Get(id)
+			std.funcs[id] = v
+			if f, ok := v.(core.VMFunc); ok {
+				if np, ok := core.FuncParamsCount(f); ok {
+					std.params[id] = np
+				}
+			}
+		}
+		for _, n := range ExtraNames {
+			std.funcs[names.UniqueNames.Set(n)] = nil
+		}
+		for _, n := range env.TypeNames() {
+			std.types[names.UniqueNames.Set(n)] = true
+		}
+		// английские синонимы стандартной библиотеки
+		for _, a := range names.Aliases {
+			ru, en := names.UniqueNames.Set(a.Ru), names.UniqueNames.Set(a.En)
+			if v, ok := std.funcs[ru]; ok {
+				std.funcs[en] = v
+			}
+			if np, ok := std.params[ru]; ok {
+				std.params[en] = np
+			}
+			if std.types[ru] {
+				std.types[en] = true
+			}
+		}
+	})
+	return std
+}
+
+// scope - область видимости имен: модуль или функция
+type scope struct {
+	parent *scope
+	fn     bool                 // область функции, в ней проверяются неиспользуемые переменные
+	defs   map[int]pos.Position // место первого присваивания
+	quiet  map[int]bool         // параметры, переменные циклов и функции не проверяются на использование
+	reads  []read
+	used   map[int]bool
+	kids   []*scope
+}
+
+type read struct {
+	id    int
+	pos   pos.Position
+	nargs int // число аргументов при вызове по имени, -1 если это не вызов
+}
+
+func newScope(parent *scope, fn bool) *scope {
+	s := &scope{
+		parent: parent,
+		fn:     fn,
+		defs:   make(map[int]pos.Position),
+		quiet:  make(map[int]bool),
+		used:   make(map[int]bool),
+	}
+	if parent != nil {
+		parent.kids = append(parent.kids, s)
+	}
+	return s
+}
+
+func (s *scope) define(id int, p pos.Position, quiet bool) {
+	if _, ok := s.defs[id]; !ok {
+		s.defs[id] = p
+	}
+	if quiet {
+		s.quiet[id] = true
+	}
+}
+
+// lookup возвращает область, в которой определено имя
+func (s *scope) lookup(id int) *scope {
+	for ss := s; ss != nil; ss = ss.parent {
+		if _, ok := ss.defs[id]; ok {
+			return ss
+		}
+	}
+	return nil
+}
+
+type checker struct {
+	file  string
+	std   builtins
+	diags []Diagnostic
+	loops int          // глубина вложенности циклов в текущей функции
+	cur   pos.Position // позиция текущей инструкции, если у выражения нет своей позиции
+	// типы, объявленные в коде, и использования типов в конструкции Новый,
+	// которые проверяются после обхода всего кода, так как тип может быть объявлен ниже
+	types map[int]bool
+	makes []read
+}
+
+func (c *checker) report(p pos.Position, format string, args ...interface{}) {
+	if p.Line == 0 {
+		p = c.cur
+	}
+	c.diags = append(c.diags, Diagnostic{File: c.file, Pos: p, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check проверяет исходный код src из файла file и возвращает замечания, отсортированные по месту в коде
+func Check(file, src string) []Diagnostic {
+	c := &checker{file: file, std: stdBuiltins()}
+
+	stmts, err := parse(src)
+	if err != nil {
+		if e, ok := err.(*parser.Error); ok {
+			c.report(e.Pos, "%s", e.Message)
+		} else {
+			c.report(pos.Position{}, "%s", err)
+		}
+		return c.diags
+	}
+
+	root := newScope(nil, false)
+	c.stmts(root, stmts)
+	c.resolve(root)
+	for _, m := range c.makes {
+		if !c.types[m.id] {
+			c.report(m.pos, "Неизвестный тип '%s'", names.UniqueNames.Get(m.id))
+		}
+	}
+
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		a, b := c.diags[i].Pos, c.diags[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return c.diags
+}
+
+// CheckFile проверяет файл с исходным кодом
+func CheckFile(file string) ([]Diagnostic, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Check(file, string(b)), nil
+}
+
+// parse разбирает код так же, как bincode.ParseSrc, но без оптимизации и компиляции
+func parse(src string) (stmts ast.Stmts, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				err = e
+			} else {
+				err = errors.New(fmt.Sprint(ex))
+			}
+		}
+	}()
+	scanner := &parser.Scanner{}
+	scanner.Init("Модуль _\n" + src)
+	scanner.SetFirstLine(0)
+	return parser.Parse(scanner)
+}
+
+// stmtPos возвращает позицию инструкции, у присваивания она берется из левой части
+func stmtPos(st ast.Stmt) pos.Position {
+	if s, ok := st.(*ast.LetsStmt); ok && len(s.Lhss) > 0 {
+		return s.Lhss[0].Position()
+	}
+	return st.Position()
+}
+
+func (c *checker) stmts(sc *scope, stmts ast.Stmts) {
+	for i, st := range stmts {
+		c.stmt(sc, st)
+		switch st.(type) {
+		case *ast.ReturnStmt, *ast.ThrowStmt, *ast.BreakStmt, *ast.ContinueStmt:
+			if i+1 < len(stmts) {
+				c.report(stmtPos(stmts[i+1]), "Недостижимый код")
+				// остальные инструкции блока проверяются, но о недостижимости сообщается один раз
+				for _, rest := range stmts[i+1:] {
+					c.stmt(sc, rest)
+				}
+				return
+			}
+		}
+	}
+}
+
+func (c *checker) loop(sc *scope, stmts ast.Stmts) {
+	c.loops++
+	c.stmts(sc, stmts)
+	c.loops--
+}
+
+func (c *checker) stmt(sc *scope, st ast.Stmt) {
+	if p := stmtPos(st); p.Line > 0 {
+		c.cur = p
+	}
+	switch s := st.(type) {
+	case *ast.ExprStmt:
+		// равенство на уровне инструкции - это присваивание
+		if b, ok := s.Expr.(*ast.BinOpExpr); ok && b.Operator == "==" {
+			c.stmt(sc, &ast.LetsStmt{Lhss: b.Lhss, Operator: "=", Rhss: b.Rhss})
+			return
+		}
+		c.expr(sc, s.Expr)
+	case *ast.IfStmt:
+		c.expr(sc, s.If)
+		c.stmts(sc, s.Then)
+		for _, ei := range s.ElseIf {
+			c.stmt(sc, ei)
+		}
+		c.stmts(sc, s.Else)
+	case *ast.TryStmt:
+		c.stmts(sc, s.Try)
+		c.stmts(sc, s.Catch)
+		c.stmts(sc, s.Finally)
+	case *ast.ForStmt:
+		c.expr(sc, s.Value)
+		c.expr(sc, s.Workers)
+		if s.Key != 0 {
+			sc.define(s.Key, s.Position(), true)
+		}
+		sc.define(s.Var, s.Position(), true)
+		c.loop(sc, s.Stmts)
+	case *ast.NumForStmt:
+		c.expr(sc, s.Expr1)
+		c.expr(sc, s.Expr2)
+		c.expr(sc, s.Step)
+		sc.define(s.Name, s.Position(), true)
+		c.loop(sc, s.Stmts)
+	case *ast.LoopStmt:
+		c.expr(sc, s.Expr)
+		c.loop(sc, s.Stmts)
+	case *ast.BreakStmt:
+		if c.loops == 0 {
+			c.report(s.Position(), "Прервать вне цикла")
+		}
+	case *ast.ContinueStmt:
+		if c.loops == 0 {
+			c.report(s.Position(), "Продолжить вне цикла")
+		}
+	case *ast.ReturnStmt:
+		for _, e := range s.Exprs {
+			c.expr(sc, e)
+		}
+	case *ast.ThrowStmt:
+		c.expr(sc, s.Expr)
+	case *ast.DeferStmt:
+		c.expr(sc, s.Expr)
+	case *ast.ModuleStmt:
+		c.stmts(newScope(sc, false), s.Stmts)
+	case *ast.TypeStmt:
+		if c.types == nil {
+			c.types = make(map[int]bool)
+		}
+		c.types[s.Name] = true
+		// начальные значения полей вычисляются в функции, которая получает объект как ЭтотОбъект
+		fs := newScope(sc, true)
+		fs.define(ast.ThisObject, s.Position(), true)
+		for _, m := range s.Members {
+			switch mm := m.(type) {
+			case *ast.FieldStmt:
+				c.expr(fs, mm.Value)
+			case *ast.MethodStmt:
+				c.method(sc, mm.Func)
+			}
+		}
+	case *ast.SwitchStmt:
+		c.expr(sc, s.Expr)
+		for _, cs := range s.Cases {
+			c.stmt(sc, cs)
+		}
+	case *ast.SelectStmt:
+		for _, cs := range s.Cases {
+			c.stmt(sc, cs)
+		}
+	case *ast.CaseStmt:
+		c.expr(sc, s.Expr)
+		c.stmts(sc, s.Stmts)
+	case *ast.DefaultStmt:
+		c.stmts(sc, s.Stmts)
+	case *ast.LetsStmt:
+		for _, e := range s.Rhss {
+			c.expr(sc, e)
+		}
+		for _, e := range s.Lhss {
+			c.assign(sc, e, stmtPos(s))
+		}
+	case *ast.VarStmt:
+		for _, e := range s.Exprs {
+			c.expr(sc, e)
+		}
+		for _, id := range s.Names {
+			sc.define(id, s.Position(), false)
+		}
+	}
+}
+
+// assign обрабатывает левую часть присваивания
+func (c *checker) assign(sc *scope, e ast.Expr, p pos.Position) {
+	switch ee := e.(type) {
+	case *ast.IdentExpr:
+		sc.define(ee.Id, p, false)
+	case *ast.ParenExpr:
+		c.assign(sc, ee.SubExpr, p)
+	default:
+		// присваивание полю, элементу или по ссылке читает само значение
+		c.expr(sc, e)
+	}
+}
+
+func (c *checker) use(sc *scope, id int, p pos.Position, nargs int) {
+	if p.Line == 0 {
+		p = c.cur
+	}
+	sc.reads = append(sc.reads, read{id: id, pos: p, nargs: nargs})
+}
+
+func (c *checker) exprs(sc *scope, es []ast.Expr) {
+	for _, e := range es {
+		c.expr(sc, e)
+	}
+}
+
+func (c *checker) expr(sc *scope, e ast.Expr) {
+	switch ee := e.(type) {
+	case nil:
+	case *ast.IdentExpr:
+		c.use(sc, ee.Id, ee.Position(), -1)
+	case *ast.ArrayExpr:
+		c.exprs(sc, ee.Exprs)
+	case *ast.TemplateExpr:
+		c.exprs(sc, ee.Parts)
+	case *ast.PairExpr:
+		c.expr(sc, ee.Value)
+	case *ast.MapExpr:
+		for _, v := range ee.MapExpr {
+			c.expr(sc, v)
+		}
+	case *ast.UnaryExpr:
+		c.expr(sc, ee.Expr)
+	case *ast.ParenExpr:
+		c.expr(sc, ee.SubExpr)
+	case *ast.BinOpExpr:
+		c.exprs(sc, ee.Lhss)
+		c.exprs(sc, ee.Rhss)
+	case *ast.TernaryOpExpr:
+		c.expr(sc, ee.Expr)
+		c.expr(sc, ee.Lhs)
+		c.expr(sc, ee.Rhs)
+	case *ast.CallExpr:
+		c.exprs(sc, ee.SubExprs)
+		if ee.Func == nil && ee.Name != 0 {
+			nargs := len(ee.SubExprs)
+			if ee.VarArg {
+				nargs = -1
+			}
+			c.use(sc, ee.Name, ee.Position(), nargs)
+		}
+	case *ast.AnonCallExpr:
+		c.expr(sc, ee.Expr)
+		c.exprs(sc, ee.SubExprs)
+	case *ast.MemberExpr:
+		c.expr(sc, ee.Expr)
+	case *ast.ItemExpr:
+		c.expr(sc, ee.Value)
+		c.expr(sc, ee.Index)
+	case *ast.SliceExpr:
+		c.expr(sc, ee.Value)
+		c.expr(sc, ee.Begin)
+		c.expr(sc, ee.End)
+	case *ast.FuncExpr:
+		if names.UniqueNames.Get(ee.Name) != "<анонимная функция>" {
+			sc.define(ee.Name, ee.Position(), true)
+		}
+		c.function(newScope(sc, true), ee)
+	case *ast.LetExpr:
+		c.expr(sc, ee.Rhs)
+		c.assign(sc, ee.Lhs, ee.Position())
+	case *ast.AssocExpr:
+		c.expr(sc, ee.Lhs)
+		c.expr(sc, ee.Rhs)
+	case *ast.ChanExpr:
+		c.expr(sc, ee.Lhs)
+		c.expr(sc, ee.Rhs)
+	case *ast.TypeCast:
+		c.typ(typeID(ee.Type, ee.TypeExpr), ee.Position())
+		c.expr(sc, ee.TypeExpr)
+		c.expr(sc, ee.CastExpr)
+	case *ast.MakeExpr:
+		c.typ(typeID(ee.Type, ee.TypeExpr), ee.Position())
+		c.expr(sc, ee.TypeExpr)
+		c.exprs(sc, ee.Args)
+	case *ast.MakeChanExpr:
+		c.expr(sc, ee.SizeExpr)
+	case *ast.MakeArrayExpr:
+		c.expr(sc, ee.LenExpr)
+		c.expr(sc, ee.CapExpr)
+	}
+}
+
+// typ проверяет имя типа в конструкции Новый, типы пакетов вида "пакет.Тип" не проверяются
+func (c *checker) typ(id int, p pos.Position) {
+	if id == 0 {
+		return
+	}
+	n := names.UniqueNames.Get(id)
+	if strings.Contains(n, ".") || c.std.types[id] {
+		return
+	}
+	c.makes = append(c.makes, read{id: id, pos: p, nargs: -1})
+}
+
+// typeID возвращает имя типа в конструкции Новый, заданное идентификатором или строковой константой,
+// например Новый("Тип"), для остальных выражений возвращается 0
+func typeID(id int, e ast.Expr) int {
+	if id != 0 {
+		return id
+	}
+	switch ee := e.(type) {
+	case *ast.StringExpr:
+		return names.UniqueNames.Set(ee.Lit)
+	case *ast.NativeExpr:
+		if s, ok := ee.Value.(core.VMString); ok {
+			return names.UniqueNames.Set(string(s))
+		}
+	}
+	return 0
+}
+
+// function проверяет тело функции в ее области видимости fs
+func (c *checker) function(fs *scope, f *ast.FuncExpr) {
+	for _, a := range f.Args {
+		fs.define(a, f.Position(), true)
+	}
+	// циклы объемлющей функции не действуют внутри вложенной
+	loops := c.loops
+	c.loops = 0
+	c.stmts(fs, f.Stmts)
+	c.loops = loops
+}
+
+// method проверяет метод типа, объект доступен в нем как ЭтотОбъект
+func (c *checker) method(sc *scope, f *ast.FuncExpr) {
+	fs := newScope(sc, true)
+	fs.define(ast.ThisObject, f.Position(), true)
+	c.function(fs, f)
+}
+
+// resolve связывает прочитанные имена с областями их определения и проверяет неиспользуемые переменные
+func (c *checker) resolve(sc *scope) {
+	if sc.fn {
+		// присваивание переменной объемлющей функции из замыкания изменяет ее там, где она определена,
+		// глобальные переменные и переменные модуля из функций только читаются
+		for id := range sc.defs {
+			if sc.quiet[id] {
+				continue
+			}
+			for ss := sc.parent; ss != nil && ss.fn; ss = ss.parent {
+				if _, ok := ss.defs[id]; ok {
+					delete(sc.defs, id)
+					break
+				}
+			}
+		}
+	}
+	for _, r := range sc.reads {
+		if def := sc.lookup(r.id); def != nil {
+			def.used[r.id] = true
+			continue
+		}
+		if _, ok := c.std.funcs[r.id]; !ok {
+			c.report(r.pos, "Неизвестное имя '%s'", names.UniqueNames.Get(r.id))
+			continue
+		}
+		if n, ok := c.std.params[r.id]; ok && r.nargs >= 0 && r.nargs != n {
+			c.report(r.pos, "Функция '%s' ожидает параметров: %d, передано: %d", names.UniqueNames.Get(r.id), n, r.nargs)
+		}
+	}
+	for _, k := range sc.kids {
+		c.resolve(k)
+	}
+	if !sc.fn {
+		// переменные модуля могут использоваться в других модулях после импорта
+		return
+	}
+	for id, p := range sc.defs {
+		if sc.used[id] || sc.quiet[id] || strings.HasPrefix(names.UniqueNames.Get(id), "_") {
+			continue
+		}
+		c.report(p, "Переменной '%s' присваивается значение, но оно нигде не используется", names.UniqueNames.Get(id))
+	}
+}
@@ -0,0 +1,98 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+// check возвращает замечания к коду src в виде строк "строка:колонка: сообщение"
+func check(src string) []string {
+	var res []string
+	for _, d := range Check("т.gnc", src) {
+		res = append(res, strings.TrimPrefix(d.String(), "т.gnc:"))
+	}
+	return res
+}
+
+func TestCheck(t *testing.T) {
+	src := `Функция Ф(а)
+	неиспользуемая = 1
+	Возврат а
+	Сообщить("после возврата")
+КонецФункции
+
+Функция Счетчик()
+	н = 0
+	Возврат Функция()
+		н = н + 1
+		Возврат н
+	КонецФункции
+КонецФункции
+
+Для каждого э из [1, 2] Цикл
+	Если э > 1 Тогда
+		Прервать
+	КонецЕсли
+КонецЦикла
+Продолжить
+д = Длина("а", "б")
+г = Новый НесуществующийТип
+Сообщить(неизвестная, Ф(1), Счетчик(), д, г, Новый ГруппаОжидания)
+`
+	exp := []string{
+		"т.gnc:2:2: Переменной 'неиспользуемая' присваивается значение, но оно нигде не используется",
+		"т.gnc:4:2: Недостижимый код",
+		"т.gnc:20:1: Продолжить вне цикла",
+		"т.gnc:21:1: Недостижимый код",
+		"т.gnc:21:5: Функция 'длина' ожидает параметров: 1, передано: 2",
+		"т.gnc:22:5: Неизвестный тип 'НесуществующийТип'",
+		"т.gnc:23:10: Неизвестное имя 'неизвестная'",
+	}
+	var got []string
+	for _, d := range Check("т.gnc", src) {
+		got = append(got, d.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", strings.Join(got, "\n"), strings.Join(exp, "\n"))
+	}
+}
+
+func TestCheckCases(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		exp  []string
+	}{
+		{"без замечаний", "а = 1\nСообщить(а)", nil},
+		{"неиспользуемая переменная", "Функция Ф()\n\tа = 1\nКонецФункции", []string{
+			"2:2: Переменной 'а' присваивается значение, но оно нигде не используется",
+		}},
+		{"параметр не проверяется", "Функция Ф(а)\nКонецФункции", nil},
+		{"недостижимый код", "Функция Ф()\n\tВозврат 1\n\tСообщить(2)\nКонецФункции", []string{
+			"3:2: Недостижимый код",
+		}},
+		{"Прервать вне цикла", "Прервать", []string{"1:1: Прервать вне цикла"}},
+		{"Прервать во вложенной функции", "Пока Истина Цикл\n\tФункция Ф()\n\t\tПрервать\n\tКонецФункции\n\tПрервать\nКонецЦикла", []string{
+			"3:3: Прервать вне цикла",
+		}},
+		{"число параметров", "Сообщить(Длина())", []string{
+			"1:10: Функция 'длина' ожидает параметров: 1, передано: 0",
+		}},
+		{"неизвестное имя", "Сообщить(нетимени)", []string{"1:10: Неизвестное имя 'нетимени'"}},
+		{"имя определено ниже в модуле", "Функция Ф()\n\tВозврат ниже\nКонецФункции\nниже = 1\nСообщить(Ф())", nil},
+		{"неизвестный тип", "а = Новый НетТипаА\nСообщить(а)", []string{"1:5: Неизвестный тип 'НетТипаА'"}},
+		{"неизвестный тип строкой", "б = Новый(\"НетТипаБ\")\nСообщить(б)", []string{"1:5: Неизвестный тип 'НетТипаБ'"}},
+		{"неизвестный тип строкой с параметрами", "б = Новый(\"НетТипаВ\", {})\nСообщить(б)", []string{"1:5: Неизвестный тип 'НетТипаВ'"}},
+		{"тип стандартной библиотеки", "Сообщить(Новый ГруппаОжидания, Новый(\"ГруппаОжидания\"))", nil},
+		{"тип объявлен ниже", "Сообщить(Новый(\"ТипНиже\"))\nТип ТипНиже\n\tПоле А\nКонецТипа", nil},
+		{"тип пакета не проверяется", "Сообщить(Новый(\"пакет.Тип\"))", nil},
+		{"тип вычисляется при исполнении", "имятипа = \"НетТипаГ\"\nСообщить(Новый(имятипа))", nil},
+		{"ошибка синтаксиса", "Если Тогда", []string{"1:11: syntax error"}},
+	}
+	for _, c := range cases {
+		got := check(c.src)
+		if strings.Join(got, "\n") != strings.Join(c.exp, "\n") {
+			t.Errorf("%s: получено %q, ожидалось %q", c.name, got, c.exp)
+		}
+	}
+}
@@ -2,11 +2,13 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/covrom/decnum"
@@ -15,25 +17,52 @@ import (
 	"github.com/satori/go.uuid"
 )
 
-// LoadAllBuiltins is a convenience function that loads all defineSd builtins.
-func LoadAllBuiltins(env *Env) {
-	Import(env)
-
-	pkgs := map[string]func(env *Env) *Env{
+var (
+	pkgsMu sync.RWMutex
+	pkgs   = map[string]func(env *Env) *Env{
 		// "sort":          gonec_sort.Import,
 		// "strings":       gonec_strings.Import,
 	}
+)
+
+// RegisterPackage регистрирует пакет на Go, который загружается в коде через Импорт("имя").
+// Загрузчик возвращает окружение, инициализированное пакетом.
+func RegisterPackage(name string, loader func(env *Env) *Env) {
+	pkgsMu.Lock()
+	pkgs[strings.ToLower(name)] = loader
+	pkgsMu.Unlock()
+}
+
+// VMModuleLoader загружает модуль на языке Гонец по пути импорта, если такого пакета на Go нет.
+// Устанавливается виртуальной машиной, чтобы исключить циклические зависимости пакетов.
+var VMModuleLoader func(ctx context.Context, env *Env, path string) (*Env, error)
+
+// LoadAllBuiltins is a convenience function that loads all defineSd builtins.
+func LoadAllBuiltins(env *Env) {
+	Import(env)
 
 	env.DefineS("импорт", VMFunc(func(args VMSlice, rets *VMSlice, envout *(*Env)) error {
+		ctx := CallContext(envout)
 		*envout = env
 		if len(args) != 1 {
 			return VMErrorNeedSinglePacketName
 		}
 		if s, ok := args[0].(VMString); ok {
-			if loader, ok := pkgs[strings.ToLower(string(s))]; ok {
+			pkgsMu.RLock()
+			loader, ok := pkgs[strings.ToLower(string(s))]
+			pkgsMu.RUnlock()
+			if ok {
 				rets.Append(loader(env)) // возвращает окружение, инициализированное пакетом
 				return nil
 			}
+			if VMModuleLoader != nil {
+				m, err := VMModuleLoader(ctx, env, string(s))
+				if err != nil {
+					return err
+				}
+				rets.Append(m)
+				return nil
+			}
 			return fmt.Errorf("Пакет '%s' не найден", s)
 		} else {
 			return VMErrorNeedString
@@ -113,11 +142,18 @@ func Import(env *Env) *Env {
 	}))
 
 	env.DefineS("пауза", VMFuncMustParams(1, func(args VMSlice, rets *VMSlice, envout *(*Env)) error {
+		ctx := CallContext(envout)
 		*envout = env
 		if v, ok := args[0].(VMNumberer); ok {
 			sec1 := NewVMDecNumFromInt64(int64(VMSecond))
-			time.Sleep(time.Duration(v.DecNum().Mul(sec1).Int()))
-			return nil
+			t := time.NewTimer(time.Duration(v.DecNum().Mul(sec1).Int()))
+			defer t.Stop()
+			select {
+			case <-t.C:
+				return nil
+			case <-ctx.Done():
+				return VMErrorInterrupted
+			}
 		}
 		return VMErrorNeedSeconds
 	}))
@@ -313,6 +349,10 @@ func Import(env *Env) *Env {
 			rets.Append(VMString("Неопределено"))
 			return nil
 		}
+		if obj, ok := args[0].(*VMObject); ok {
+			rets.Append(VMString(names.UniqueNames.Get(obj.Type().Name)))
+			return nil
+		}
 		rets.Append(VMString(names.UniqueNames.Get(env.TypeName(reflect.TypeOf(args[0])))))
 		return nil
 	}))
@@ -393,6 +433,25 @@ func Import(env *Env) *Env {
 	return env
 }
 
+// sandboxNames - встроенные функции стандартной библиотеки, доступные коду в песочнице.
+// Они не обращаются к файлам, сети и переменным процесса и не исполняют другой код.
+var sandboxNames = map[string]bool{
+	"длина": true, "диапазон": true, "врег": true, "нрег": true, "кодсимвола": true, "окр": true,
+	"стрзаменить": true, "стрколичество": true, "стрнайти": true, "стрнайтилюбой": true,
+	"стрнайтипоследний": true, "стрсодержит": true, "стрсодержитлюбой": true,
+	"формат": true, "типзнч": true, "хэш": true, "случайнаястрока": true, "уникальныйидентификатор": true,
+	"сообщить": true, "сообщитьф": true, "пауза": true, "текущаядата": true, "прошловременис": true,
+	"длительностьнаносекунды": true, "длительностьмикросекунды": true, "длительностьмиллисекунды": true,
+	"длительностьсекунды": true, "длительностьминуты": true, "длительностьчаса": true,
+}
+
+// sandboxTypes - типы стандартной библиотеки, доступные коду в песочнице, без файловой базы данных, сервера и клиента
+var sandboxTypes = map[string]bool{
+	"целоечисло": true, "число": true, "булево": true, "строка": true, "массив": true, "структура": true,
+	"дата": true, "длительность": true, "группаожидания": true, "таблицазначений": true,
+	"колонкатаблицызначений": true, "коллекцияколоноктаблицызначений": true, "строкатаблицызначений": true,
+}
+
 /////////////////
 // TttStructTest - тестовая структура для отладки работы с системными функциональными структурами
 type TttStructTest struct {
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"sync"
 	"time"
@@ -49,22 +50,51 @@ func (x *VMBoltDB) Close() {
 	}
 }
 
-func (x *VMBoltDB) Begin(writable bool) (tr *VMBoltTransaction, err error) {
+// Begin начинает транзакцию. Ожидание завершения другой записывающей транзакции прекращается при отмене ctx,
+// а открытая транзакция откатывается, чтобы прерванный код не оставил базу заблокированной.
+func (x *VMBoltDB) Begin(ctx context.Context, writable bool) (tr *VMBoltTransaction, err error) {
 	x.Lock()
 	defer x.Unlock()
 	var tx *bolt.Tx
-	tx, err = x.db.Begin(writable)
+	if ctx.Done() == nil {
+		tx, err = x.db.Begin(writable)
+	} else {
+		type began struct {
+			tx  *bolt.Tx
+			err error
+		}
+		ch := make(chan began, 1)
+		go func(db *bolt.DB) {
+			tx, err := db.Begin(writable)
+			ch <- began{tx, err}
+		}(x.db)
+		select {
+		case b := <-ch:
+			tx, err = b.tx, b.err
+		case <-ctx.Done():
+			// транзакция, которая все же начнется, сразу откатывается
+			go func() {
+				if b := <-ch; b.tx != nil {
+					b.tx.Rollback()
+				}
+			}()
+			return nil, VMErrorInterrupted
+		}
+	}
 	if err != nil {
 		return tr, err
 	}
 	tr = &VMBoltTransaction{tx: tx, writable: writable}
+	tr.stop = afterFunc(ctx, func() {
+		tr.Rollback()
+	})
 	return
 }
 
 func (x *VMBoltDB) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "открыть":
 		return VMFuncMustParams(1, x.Открыть), true
 	case "закрыть":
@@ -88,7 +118,7 @@ func (x *VMBoltDB) НачатьТранзакцию(args VMSlice, rets *VMSlice,
 	if !ok {
 		return VMErrorNeedBool
 	}
-	tr, err := x.Begin(bool(v))
+	tr, err := x.Begin(CallContext(envout), bool(v))
 	if err != nil {
 		return err
 	}
@@ -101,10 +131,14 @@ func (x *VMBoltDB) Закрыть(args VMSlice, rets *VMSlice, envout *(*Env)) e
 	return nil
 }
 
-// VMBoltTransaction реализует функционал Transaction для BoltDB
+// VMBoltTransaction реализует функционал Transaction для BoltDB.
+// Транзакция откатывается при отмене контекста исполнения кода, который ее начал,
+// поэтому обращения к ней и к ее таблицам выполняются под блокировкой.
 type VMBoltTransaction struct {
+	sync.Mutex
 	tx       *bolt.Tx
 	writable bool
+	stop     func() bool
 }
 
 func (x *VMBoltTransaction) vmval() {}
@@ -118,37 +152,46 @@ func (x *VMBoltTransaction) String() string {
 }
 
 func (x *VMBoltTransaction) Commit() error {
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return VMErrorTransactionNotOpened
 	}
+	x.stop()
 	err := x.tx.Commit()
 	x.tx = nil
 	return err
 }
 
 func (x *VMBoltTransaction) Rollback() error {
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return VMErrorTransactionNotOpened
 	}
+	x.stop()
 	x.tx.Rollback()
 	x.tx = nil
 	return nil
 }
 
 func (x *VMBoltTransaction) CreateTableIfNotExists(name string) (*VMBoltTable, error) {
+	if !x.writable {
+		return x.OpenTable(name)
+	}
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return nil, VMErrorTransactionNotOpened
 	}
-	if x.writable {
-		b, err := x.tx.CreateBucketIfNotExists([]byte(name))
-		t := &VMBoltTable{name: name, b: b}
-		return t, err
-	} else {
-		return x.OpenTable(name)
-	}
+	b, err := x.tx.CreateBucketIfNotExists([]byte(name))
+	t := &VMBoltTable{name: name, b: b, tr: x}
+	return t, err
 }
 
 func (x *VMBoltTransaction) OpenTable(name string) (*VMBoltTable, error) {
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return nil, VMErrorTransactionNotOpened
 	}
@@ -156,11 +199,13 @@ func (x *VMBoltTransaction) OpenTable(name string) (*VMBoltTable, error) {
 	if b == nil {
 		return nil, VMErrorTableNotExists
 	}
-	t := &VMBoltTable{name: name, b: b}
+	t := &VMBoltTable{name: name, b: b, tr: x}
 	return t, nil
 }
 
 func (x *VMBoltTransaction) DeleteTable(name string) error {
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return VMErrorTransactionNotOpened
 	}
@@ -168,6 +213,8 @@ func (x *VMBoltTransaction) DeleteTable(name string) error {
 }
 
 func (x *VMBoltTransaction) BackupDBToFile(name string) error {
+	x.Lock()
+	defer x.Unlock()
 	if x.tx == nil {
 		return VMErrorTransactionNotOpened
 	}
@@ -177,7 +224,7 @@ func (x *VMBoltTransaction) BackupDBToFile(name string) error {
 func (x *VMBoltTransaction) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "зафиксироватьтранзакцию":
 		return VMFuncMustParams(0, x.ЗафиксироватьТранзакцию), true
 	case "отменитьтранзакцию":
@@ -233,6 +280,17 @@ func (x *VMBoltTransaction) ПолныйБэкап(args VMSlice, rets *VMSlice,
 type VMBoltTable struct {
 	name string
 	b    *bolt.Bucket
+	tr   *VMBoltTransaction
+}
+
+// lock блокирует транзакцию таблицы, пока с ней работает метод, и проверяет, что она не завершена
+func (x *VMBoltTable) lock() error {
+	x.tr.Lock()
+	if x.tr.tx == nil {
+		x.tr.Unlock()
+		return VMErrorTransactionNotOpened
+	}
+	return nil
 }
 
 func (x *VMBoltTable) vmval() {}
@@ -246,6 +304,10 @@ func (x *VMBoltTable) String() string {
 }
 
 func (x *VMBoltTable) Set(k string, v VMBinaryTyper) error {
+	if err := x.lock(); err != nil {
+		return err
+	}
+	defer x.tr.Unlock()
 	i := []byte{byte(v.BinaryType())}
 	ii, err := v.MarshalBinary()
 	if err != nil {
@@ -254,7 +316,8 @@ func (x *VMBoltTable) Set(k string, v VMBinaryTyper) error {
 	return x.b.Put([]byte(k), append(i, ii...))
 }
 
-func parseBoltValue(sl []byte) (VMValuer, error) {
+// parseBoltValue восстанавливает значение записи, объекты - по типам глобального контекста окружения env
+func parseBoltValue(sl []byte, env *Env) (VMValuer, error) {
 	if len(sl) < 1 {
 		return nil, VMErrorWrongDBValue
 	}
@@ -263,36 +326,52 @@ func parseBoltValue(sl []byte) (VMValuer, error) {
 	if len(sl) > 1 {
 		bb = sl[1:]
 	}
-	vv, err := VMBinaryType(tt).ParseBinary(bb)
+	vv, err := VMBinaryType(tt).ParseBinaryEnv(bb, env)
 	if err != nil {
 		return VMNil, err
 	}
 	return vv, nil
 }
 
-func (x *VMBoltTable) Get(k string) (VMValuer, bool, error) {
+func (x *VMBoltTable) Get(k string, env *Env) (VMValuer, bool, error) {
+	if err := x.lock(); err != nil {
+		return VMNil, false, err
+	}
+	defer x.tr.Unlock()
 	sl := x.b.Get([]byte(k))
 	if sl == nil {
 		return VMNil, false, nil
 	}
-	vv, err := parseBoltValue(sl)
+	vv, err := parseBoltValue(sl, env)
 	return vv, true, err
 }
 
 func (x *VMBoltTable) Delete(k string) error {
+	if err := x.lock(); err != nil {
+		return err
+	}
+	defer x.tr.Unlock()
 	return x.b.Delete([]byte(k))
 }
 
 func (x *VMBoltTable) NextId() (VMInt, error) {
+	if err := x.lock(); err != nil {
+		return 0, err
+	}
+	defer x.tr.Unlock()
 	id, err := x.b.NextSequence()
 	return VMInt(id), err
 }
 
-func (x *VMBoltTable) GetPrefix(pref string) (VMStringMap, error) {
+func (x *VMBoltTable) GetPrefix(pref string, env *Env) (VMStringMap, error) {
+	if err := x.lock(); err != nil {
+		return nil, err
+	}
+	defer x.tr.Unlock()
 	c := x.b.Cursor()
 	vsm := make(VMStringMap)
 	for k, v := c.Seek([]byte(pref)); k != nil && bytes.HasPrefix(k, []byte(pref)); k, v = c.Next() {
-		vx, err := parseBoltValue(v)
+		vx, err := parseBoltValue(v, env)
 		if err != nil {
 			return vsm, err
 		}
@@ -301,11 +380,15 @@ func (x *VMBoltTable) GetPrefix(pref string) (VMStringMap, error) {
 	return vsm, nil
 }
 
-func (x *VMBoltTable) GetRange(kmin, kmax string) (VMStringMap, error) {
+func (x *VMBoltTable) GetRange(kmin, kmax string, env *Env) (VMStringMap, error) {
+	if err := x.lock(); err != nil {
+		return nil, err
+	}
+	defer x.tr.Unlock()
 	c := x.b.Cursor()
 	vsm := make(VMStringMap)
 	for k, v := c.Seek([]byte(kmin)); k != nil && bytes.Compare(k, []byte(kmax)) <= 0; k, v = c.Next() {
-		vx, err := parseBoltValue(v)
+		vx, err := parseBoltValue(v, env)
 		if err != nil {
 			return vsm, err
 		}
@@ -314,11 +397,15 @@ func (x *VMBoltTable) GetRange(kmin, kmax string) (VMStringMap, error) {
 	return vsm, nil
 }
 
-func (x *VMBoltTable) GetAll() (VMStringMap, error) {
+func (x *VMBoltTable) GetAll(env *Env) (VMStringMap, error) {
+	if err := x.lock(); err != nil {
+		return nil, err
+	}
+	defer x.tr.Unlock()
 	c := x.b.Cursor()
 	vsm := make(VMStringMap)
 	for k, v := c.First(); k != nil; k, v = c.Next() {
-		vx, err := parseBoltValue(v)
+		vx, err := parseBoltValue(v, env)
 		if err != nil {
 			return vsm, err
 		}
@@ -338,6 +425,10 @@ func (x *VMBoltTable) SetByMap(m VMStringMap) error {
 		mm[ks] = v
 	}
 
+	if err := x.lock(); err != nil {
+		return err
+	}
+	defer x.tr.Unlock()
 	for ks, vs := range mm {
 
 		i := []byte{byte(vs.BinaryType())}
@@ -357,7 +448,7 @@ func (x *VMBoltTable) SetByMap(m VMStringMap) error {
 func (x *VMBoltTable) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "получить":
 		return VMFuncMustParams(1, x.Получить), true
 	case "установить":
@@ -383,7 +474,7 @@ func (x *VMBoltTable) Получить(args VMSlice, rets *VMSlice, envout *(*En
 	if !ok {
 		return VMErrorNeedString
 	}
-	rv, ok, err := x.Get(string(v))
+	rv, ok, err := x.Get(string(v), CallEnv(envout))
 	if err != nil {
 		return err
 	}
@@ -427,7 +518,7 @@ func (x *VMBoltTable) ПолучитьДиапазон(args VMSlice, rets *VMSli
 	if !ok {
 		return VMErrorNeedString
 	}
-	vsm, err := x.GetRange(string(vmin), string(vmax))
+	vsm, err := x.GetRange(string(vmin), string(vmax), CallEnv(envout))
 	if err != nil {
 		return err
 	}
@@ -440,7 +531,7 @@ func (x *VMBoltTable) ПолучитьПрефикс(args VMSlice, rets *VMSlice
 	if !ok {
 		return VMErrorNeedString
 	}
-	vsm, err := x.GetPrefix(string(pref))
+	vsm, err := x.GetPrefix(string(pref), CallEnv(envout))
 	if err != nil {
 		return err
 	}
@@ -449,7 +540,7 @@ func (x *VMBoltTable) ПолучитьПрефикс(args VMSlice, rets *VMSlice
 }
 
 func (x *VMBoltTable) ПолучитьВсе(args VMSlice, rets *VMSlice, envout *(*Env)) error {
-	vsm, err := x.GetAll()
+	vsm, err := x.GetAll(CallEnv(envout))
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+
 	"github.com/covrom/gonec/names"
 )
 
@@ -22,6 +24,26 @@ func (x VMChan) Recv() (VMValuer, bool) {
 	return rv, ok
 }
 
+// SendContext отправляет значение в канал, ожидание прекращается при отмене контекста
+func (x VMChan) SendContext(ctx context.Context, v VMValuer) error {
+	select {
+	case x <- v:
+		return nil
+	case <-ctx.Done():
+		return VMErrorInterrupted
+	}
+}
+
+// RecvContext получает значение из канала, ожидание прекращается при отмене контекста
+func (x VMChan) RecvContext(ctx context.Context) (VMValuer, bool, error) {
+	select {
+	case rv, ok := <-x:
+		return rv, ok, nil
+	case <-ctx.Done():
+		return nil, false, VMErrorInterrupted
+	}
+}
+
 func (x VMChan) TrySend(v VMValuer) (ok bool) {
 	select {
 	case x <- v:
@@ -50,7 +72,7 @@ func (x VMChan) Size() int { return cap(x) }
 func (x VMChan) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "закрыть":
 		return VMFuncMustParams(0, x.Закрыть), true
 	case "размер":
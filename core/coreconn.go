@@ -112,7 +112,8 @@ func urlValuesFromMap(vals VMStringMap) (url.Values, error) {
 // HttpReq выполняет универсальный (с любыми методами) запрос к серверу и ждет ответа
 // hdrs - заголовки, которые будут помещены в запрос
 // vals - если это GET, то будут помещены в URL, если POST - помещаются в FormValues тела запроса, иначе - игнорируются
-func (x *VMConn) HttpReq(meth, rurl VMString, body []byte, hdrs, vals VMStringMap) (*VMHttpResponse, error) {
+// запрос прерывается при отмене ctx или закрытии соединения
+func (x *VMConn) HttpReq(ctx context.Context, meth, rurl VMString, body []byte, hdrs, vals VMStringMap) (*VMHttpResponse, error) {
 
 	var req *http.Request
 	var err error
@@ -147,7 +148,7 @@ func (x *VMConn) HttpReq(meth, rurl VMString, body []byte, hdrs, vals VMStringMa
 	}
 
 	// заворачиваем в контекст для возможности прерывания
-	x.ctx, x.cancel = context.WithCancel(context.Background())
+	x.ctx, x.cancel = context.WithCancel(ctx)
 	req = req.WithContext(x.ctx)
 
 	for k, v := range hdrs {
@@ -165,6 +166,9 @@ func (x *VMConn) HttpReq(meth, rurl VMString, body []byte, hdrs, vals VMStringMa
 	res := &VMHttpResponse{r: resp, data: x.data}
 	if err != nil {
 		res.Close()
+		if ctx.Err() != nil {
+			return nil, VMErrorInterrupted
+		}
 		return nil, err
 	}
 
@@ -314,14 +318,27 @@ func (x *VMConn) Send(val VMStringMap) error {
 	return nil
 }
 
-func (x *VMConn) Receive() (VMStringMap, error) {
+// Receive получает сообщение, при отмене ctx ожидание прерывается.
+// Объекты в сообщении восстанавливаются по типам глобального контекста окружения env.
+func (x *VMConn) Receive(ctx context.Context, env *Env) (rv VMStringMap, err error) {
+
+	// отмена контекста прерывает чтение из соединения через истекший срок ожидания
+	stop := afterFunc(ctx, func() {
+		x.conn.SetReadDeadline(time.Now())
+	})
+	defer func() {
+		if !stop() && ctx.Err() != nil {
+			x.conn.SetReadDeadline(time.Time{})
+			err = VMErrorInterrupted
+		}
+	}()
 
-	rv := make(VMStringMap)
+	rv = make(VMStringMap)
 	var buf bytes.Buffer
 
 	var head binTCPHead
 
-	err := binary.Read(x.conn, binary.LittleEndian, &head)
+	err = binary.Read(x.conn, binary.LittleEndian, &head)
 	if err != nil {
 		if err == io.EOF {
 			x.Close()
@@ -369,7 +386,7 @@ func (x *VMConn) Receive() (VMStringMap, error) {
 		return rv, err
 	}
 
-	if err := (&rv).UnmarshalBinary(bd); err != nil {
+	if err := (&rv).UnmarshalBinaryEnv(bd, env); err != nil {
 		return rv, err
 	}
 	return rv, nil
@@ -379,7 +396,7 @@ func (c *VMConn) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "получить":
 		return VMFuncMustParams(0, c.Получить), true
 	case "отправить":
@@ -409,7 +426,7 @@ func (x *VMConn) Получить(args VMSlice, rets *VMSlice, envout *(*Env)) e
 		return VMErrorWrongHTTPMethod
 	}
 	// TCP
-	v, err := x.Receive()
+	v, err := x.Receive(CallContext(envout), CallEnv(envout))
 	rets.Append(v)
 	return err // при ошибке вызовет исключение, нужно обрабатывать в попытке
 }
@@ -478,7 +495,7 @@ func (x *VMConn) Запрос(args VMSlice, rets *VMSlice, envout *(*Env)) error
 		}
 	}
 
-	r, err := x.HttpReq(m, p, []byte(b), h, vals)
+	r, err := x.HttpReq(CallContext(envout), m, p, []byte(b), h, vals)
 	if err != nil {
 		return err
 	}
@@ -1,13 +1,16 @@
 package core
 
 import (
+	"context"
 	"encoding/gob"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/covrom/gonec/names"
 )
@@ -40,12 +43,16 @@ type Vals struct {
 	vals VMSlice
 }
 
+// NewVals создает хранилище значений, индекс имен и место для значений отводятся при определении первого имени,
+// окружениям вызовов функций, переменные которых хранятся по номерам, они часто не нужны
 func NewVals() *Vals {
-	v := Vals{
-		idx:  make(map[int]int),
-		vals: getEnvVals(),
-	}
-	return &v
+	return &Vals{}
+}
+
+// Has сообщает, определено ли имя, даже если его значение было удалено
+func (v *Vals) Has(name int) bool {
+	_, ok := v.idx[name]
+	return ok
 }
 
 func (v *Vals) Get(name int) (VMValuer, bool) {
@@ -59,6 +66,10 @@ func (v *Vals) Set(name int, val VMValuer) {
 	if i, ok := v.idx[name]; ok {
 		v.vals[i] = val
 	} else {
+		if v.idx == nil {
+			v.idx = make(map[int]int)
+			v.vals = getEnvVals()
+		}
 		i = len(v.vals)
 		v.idx[name] = i
 		v.vals = append(v.vals, val)
@@ -72,42 +83,130 @@ func (v *Vals) Del(name int) {
 }
 
 func (v *Vals) Destroy() {
-	putEnvVals(v.vals)
+	if v.vals != nil {
+		putEnvVals(v.vals)
+	}
+	v.idx, v.vals = nil, nil
 }
 
 // Env provides interface to run VM. This mean function scope and blocked-scope.
 // If stack goes to blocked-scope, it will make new Env.
+//
+// Модель памяти. Окружения могут одновременно использоваться горутинами, запущенными оператором Старт,
+// и итерациями параллельных циклов. Чтение и запись переменной окружения атомарны: другая горутина видит
+// либо прежнее, либо новое значение, но не его часть. Блокировка берется только в окружениях, которые
+// могут использоваться несколькими горутинами: в глобальном контексте и модулях, в окружениях, захваченных
+// замыканием, и в окружениях, в которых исполняется параллельный цикл, см. locked. Чтение берет только
+// блокировку на чтение, поэтому горутины, читающие одни и те же глобальные переменные, не мешают друг другу.
+// Порядок изменений, сделанных разными горутинами, не определен, если они не упорядочены каналом
+// или ГруппаОжидания - все, что горутина записала до отправки в канал или до ГруппаОжидания.Завершить(),
+// видно горутине после получения из канала или после ГруппаОжидания.Ожидать().
+// Функция не изменяет глобальные переменные и переменные модулей, а определяет свою локальную переменную,
+// поэтому их значения меняет только код модуля. Содержимое массивов и структур не синхронизируется:
+// их одновременное изменение из разных горутин приводит к гонке, такие значения передаются через каналы
+// или изменяются после ожидания горутин.
+// Вывод Сообщить из разных горутин не перемешивается внутри одной строки.
 type Env struct {
 	sync.RWMutex
 	name         string
-	env          *Vals
+	env          Vals
 	typ          map[int]reflect.Type
+	objtyp       map[int]*VMObjectType // типы объектов, объявленные в коде
 	parent       *Env
-	interrupt    *bool
-	stdout       io.Writer
+	stdout       *output
 	sid          string
 	lastid       int
 	lastval      VMValuer
 	builtsLoaded bool
 	Valid        bool
+
+	// closure означает, что окружение создано для вызова замыкания,
+	// и его родитель - это окружение объемлющей функции, переменные которого доступны по ссылке
+	closure bool
+	// parallel означает, что окружение создано для итерации параллельного цикла,
+	// присваивание в нем изменяет и переменные модуля, в коде которого исполняется цикл
+	parallel bool
+	// share - признаки envCaptured и envParallel, они устанавливаются и читаются атомарно,
+	// потому что окружение в этот момент уже может использоваться другими горутинами
+	share int32
+	// caller - окружение кода, вызвавшего функцию, пока она исполняется
+	caller *Env
+	// depth - глубина вызова функции в цепочке вызывающих окружений
+	depth int
+	// tailcall устанавливается, пока функция исполняет хвостовой вызов вместо себя
+	tailcall bool
+	// maxDepth - предел глубины вызовов функций, задается в глобальном контексте, 0 - без ограничения
+	maxDepth int64
+	// policy - ограничения исполнения кода в глобальном контексте, budget - их расход текущим запуском
+	policy ExecPolicy
+	budget atomic.Value
+	// ctx - контекст исполнения: запуска кода для глобального контекста и модулей,
+	// вызывающего кода для окружений вызова функций
+	ctx atomic.Value
+	// goroutine означает, что окружение только передает контекст функции, вызванной в отдельной горутине,
+	// global - глобальный контекст запустившего ее кода, в нем находятся объявленные в коде типы объектов
+	goroutine bool
+	global    *Env
+	// slots - локальные переменные функции или итерации параллельного цикла по номерам,
+	// определенным при компиляции, frame - их раскладка
+	slots VMSlice
+	frame *Frame
+}
+
+// Frame - раскладка локальных переменных функции или тела параллельного цикла по номерам,
+// определенным при компиляции. Создается один раз при объявлении функции и не изменяется,
+// поэтому используется всеми ее вызовами, в том числе одновременными.
+type Frame struct {
+	ids []int
+	idx map[int]int
+}
+
+// Names возвращает идентификаторы локальных переменных по порядку номеров
+func (f *Frame) Names() []int {
+	if f == nil {
+		return nil
+	}
+	return f.ids
+}
+
+// NewFrame создает раскладку локальных переменных с идентификаторами ids по порядку номеров
+func NewFrame(ids []int) *Frame {
+	f := &Frame{
+		ids: ids,
+		idx: make(map[int]int, len(ids)),
+	}
+	for i, id := range ids {
+		f.idx[id] = i
+	}
+	return f
 }
 
 func (e *Env) vmval() {} // нужно для того, чтобы *Env можно было сохранять в переменные VMValuer
 
+const (
+	// envCaptured - окружение захвачено замыканием, такое окружение не освобождается при Destroy,
+	// его освобождает сборщик мусора
+	envCaptured int32 = 1 << iota
+	// envParallel - в окружении исполняется параллельный цикл, его итерации обращаются к переменным окружения
+	envParallel
+)
+
+// DefaultMaxCallDepth - предел глубины вызовов функций в новых глобальных контекстах.
+// Каждый вызов функции на языке Гонец расходует стек Go, и без предела глубокая рекурсия
+// завершает весь процесс интерпретатора вместо исключения в коде.
+var DefaultMaxCallDepth = 10000
+
 // NewEnv creates new global scope.
 // !!!не забывать вызывать core.LoadAllBuiltins(m)!!!
 func NewEnv() *Env {
-	b := false
-
 	m := &Env{
-		env:          NewVals(),
 		typ:          make(map[int]reflect.Type),
 		parent:       nil,
-		interrupt:    &b,
-		stdout:       os.Stdout,
+		stdout:       &output{w: os.Stdout},
 		lastid:       -1,
 		builtsLoaded: false,
 		Valid:        true,
+		maxDepth:     int64(DefaultMaxCallDepth),
 	}
 	return m
 }
@@ -117,13 +216,10 @@ func (e *Env) NewEnv() *Env {
 	for ee := e; ee != nil; ee = ee.parent {
 		if ee.parent == nil {
 			return &Env{
-				env:          NewVals(),
-				typ:          make(map[int]reflect.Type),
 				parent:       ee,
-				interrupt:    e.interrupt,
-				stdout:       e.stdout,
+				stdout:       e.out(),
 				lastid:       -1,
-				builtsLoaded: ee.builtsLoaded,
+				builtsLoaded: ee.IsBuiltsLoaded(),
 				Valid:        true,
 			}
 
@@ -132,20 +228,117 @@ func (e *Env) NewEnv() *Env {
 	panic("Не найден глобальный контекст!")
 }
 
+// NewSandbox создает отдельный глобальный контекст для исполнения кода в песочнице. В нем определены
+// только встроенные функции и типы стандартной библиотеки, которые не обращаются к файлам, сети и процессу
+// и не исполняют другой код. Поток вывода, ограничения исполнения и контекст исполнения берутся из e.
+// Глобальный контекст e и его переменные коду в песочнице не видны и им не изменяются.
+func (e *Env) NewSandbox() *Env {
+	sb := NewEnv()
+	sb.stdout = e.out()
+	Import(sb)
+	for k := range sb.env.idx {
+		if !sandboxNames[names.UniqueNames.GetLowerCase(k)] {
+			sb.env.Del(k)
+		}
+	}
+	for k := range sb.typ {
+		if !sandboxTypes[names.UniqueNames.GetLowerCase(k)] {
+			delete(sb.typ, k)
+		}
+	}
+	sb.builtsLoaded = true
+	if !e.IsGoEnv() {
+		sb.maxDepth = int64(e.MaxCallDepth())
+		sb.policy = e.Policy()
+		if b := e.Budget(); b != nil {
+			sb.budget.Store(b)
+		}
+	}
+	sb.InheritContext(e)
+	return sb
+}
+
 // NewSubEnv создает новое окружение под e, нужно для замыкания в анонимных функциях
 func (e *Env) NewSubEnv() *Env {
 	return &Env{
-		env:          NewVals(),
-		typ:          make(map[int]reflect.Type),
 		parent:       e,
-		interrupt:    e.interrupt,
-		stdout:       e.stdout,
+		stdout:       e.out(),
 		lastid:       -1,
-		builtsLoaded: e.builtsLoaded,
+		builtsLoaded: e.IsBuiltsLoaded(),
 		Valid:        true,
 	}
 }
 
+// NewClosureEnv создает окружение для вызова замыкания под окружением объемлющей функции e.
+// Присваивание в замыкании изменяет переменные объемлющих функций, которым уже присвоено значение.
+func (e *Env) NewClosureEnv() *Env {
+	ne := e.NewSubEnv()
+	ne.closure = true
+	return ne
+}
+
+// NewParallelEnv создает окружение итерации параллельного цикла под e. Как и в обычном цикле, присваивание
+// изменяет переменные, определенные до цикла в e и в объемлющих окружениях, включая переменные модуля,
+// а переменные, которые определяет итерация, после нее не сохраняются.
+func (e *Env) NewParallelEnv() *Env {
+	ne := e.NewSubEnv()
+	ne.parallel = true
+	return ne
+}
+
+// NewFuncEnv создает окружение для вызова функции, объявленной в глобальном контексте или в модуле.
+// Имена модуля доступны в функции, но присваивание не изменяет их, а определяет локальную переменную.
+func (e *Env) NewFuncEnv() *Env {
+	if e.parent == nil {
+		return e.NewEnv()
+	}
+	return e.NewSubEnv()
+}
+
+// nested сообщает, что переменные родительского окружения доступны по ссылке: окружение создано
+// для вызова замыкания или для итерации параллельного цикла
+func (e *Env) nested() bool {
+	return e.closure || e.parallel
+}
+
+// IsGlobalScope возвращает true для глобального контекста и для модулей,
+// функции, объявленные в них, не являются замыканиями
+func (e *Env) IsGlobalScope() bool {
+	return e.parent == nil || e.name != ""
+}
+
+// Capture помечает окружение и все окружения объемлющих функций как захваченные замыканием,
+// после этого они остаются действительными и после выхода из функций, в которых были созданы
+func (e *Env) Capture() {
+	for ee := e; ee != nil && !ee.IsGlobalScope(); ee = ee.parent {
+		ee.setShare(envCaptured)
+		if !ee.nested() {
+			break
+		}
+	}
+}
+
+// ShareParallel помечает окружение и окружения объемлющих функций как используемые итерациями
+// параллельного цикла, после этого обращения к их переменным выполняются с блокировкой
+func (e *Env) ShareParallel() {
+	for ee := e; ee != nil && !ee.IsGlobalScope(); ee = ee.parent {
+		ee.setShare(envParallel)
+		if !ee.nested() {
+			break
+		}
+	}
+}
+
+// setShare устанавливает признак использования окружения несколькими горутинами
+func (e *Env) setShare(flag int32) {
+	for {
+		old := atomic.LoadInt32(&e.share)
+		if old&flag != 0 || atomic.CompareAndSwapInt32(&e.share, old, old|flag) {
+			return
+		}
+	}
+}
+
 // Находим или создаем новый модуль в глобальном скоупе
 func (e *Env) NewModule(n string) *Env {
 	//ni := strings.ToLower(n)
@@ -164,16 +357,28 @@ func (e *Env) NewModule(n string) *Env {
 	return m
 }
 
+// Export создает пространство имен модуля, в которое попадают только экспортируемые имена - не начинающиеся с "_".
+// Значения переменных копируются на момент вызова.
+func (e *Env) Export() *Env {
+	ns := e.NewEnv()
+	ns.name = e.name
+	e.RLock()
+	for k, i := range e.env.idx {
+		if v := e.env.vals[i]; v != nil && !strings.HasPrefix(names.UniqueNames.Get(k), "_") {
+			ns.env.Set(k, v)
+		}
+	}
+	e.RUnlock()
+	return ns
+}
+
 func (e *Env) NewPackage(n string) *Env {
 	return &Env{
-		env:          NewVals(),
-		typ:          make(map[int]reflect.Type),
 		parent:       e,
 		name:         names.FastToLower(n),
-		interrupt:    e.interrupt,
-		stdout:       e.stdout,
+		stdout:       e.out(),
 		lastid:       -1,
-		builtsLoaded: e.builtsLoaded,
+		builtsLoaded: e.IsBuiltsLoaded(),
 		Valid:        true,
 	}
 }
@@ -184,6 +389,11 @@ func (e *Env) Destroy() {
 		return
 	}
 
+	// окружение, захваченное замыканием, продолжает жить вместе с ним
+	if atomic.LoadInt32(&e.share)&envCaptured != 0 {
+		return
+	}
+
 	// if e.goRunned {
 	// 	e.Lock()
 	// 	defer e.Unlock()
@@ -205,16 +415,91 @@ func (e *Env) Destroy() {
 	}
 	e.parent = nil
 	e.env.Destroy()
-	e.env = nil
+	e.slots = nil
+	e.frame = nil
+}
+
+// SetLocals отводит в окружении места для локальных переменных с раскладкой f,
+// к ним обращаются инструкции GETLOCAL и SETLOCAL по номерам, а остальной код - по именам.
+// Переменная, которой еще не присвоено значение, считается неопределенной.
+func (e *Env) SetLocals(f *Frame) {
+	e.slots = make(VMSlice, len(f.ids))
+	e.frame = f
+}
+
+// slot возвращает номер локальной переменной k или -1
+func (e *Env) slot(k int) int {
+	if e.frame != nil {
+		if i, ok := e.frame.idx[k]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// local возвращает окружение, отстоящее от текущего на depth уровней вверх
+func (e *Env) local(depth int) *Env {
+	ee := e
+	for ; depth > 0; depth-- {
+		ee = ee.parent
+	}
+	return ee
+}
+
+// shared сообщает, что окружение может использоваться другими горутинами: оно захвачено замыканием,
+// которое может быть запущено оператором Старт, или в нем исполняется параллельный цикл.
+// Обращения к переменным остальных окружений вызовов функций не требуют блокировки.
+func (e *Env) shared() bool {
+	return atomic.LoadInt32(&e.share) != 0
+}
+
+// locked сообщает, что обращения к именам окружения выполняются с блокировкой: глобальный контекст
+// и модули читаются функциями, запущенными в других горутинах, остальные окружения - если они shared
+func (e *Env) locked() bool {
+	return e.IsGlobalScope() || e.shared()
+}
+
+// Local возвращает значение локальной переменной с номером slot в окружении, отстоящем от текущего на depth уровней вверх
+func (e *Env) Local(depth, slot int) VMValuer {
+	ee := e.local(depth)
+	if !ee.shared() {
+		return ee.slots[slot]
+	}
+	ee.RLock()
+	v := ee.slots[slot]
+	ee.RUnlock()
+	return v
+}
+
+// SetLocal присваивает значение локальной переменной с номером slot в окружении, отстоящем от текущего на depth уровней вверх
+func (e *Env) SetLocal(depth, slot int, v VMValuer) {
+	ee := e.local(depth)
+	if !ee.shared() {
+		ee.slots[slot] = v
+		return
+	}
+	ee.Lock()
+	ee.slots[slot] = v
+	ee.Unlock()
 }
 
 func (e *Env) SetBuiltsIsLoaded() {
+	e.Lock()
 	e.builtsLoaded = true
+	e.Unlock()
 }
 
 func (e *Env) IsBuiltsLoaded() bool {
 	for ee := e; ee != nil; ee = ee.parent {
-		if ee.builtsLoaded {
+		loaded := false
+		if ee.locked() {
+			ee.RLock()
+			loaded = ee.builtsLoaded
+			ee.RUnlock()
+		} else {
+			loaded = ee.builtsLoaded
+		}
+		if loaded {
 			return true
 		}
 	}
@@ -259,6 +544,9 @@ func (e *Env) Type(k int) (reflect.Type, error) {
 		}
 		ee.RUnlock()
 	}
+	if c := names.UniqueNames.Canonical(k); c != k {
+		return e.Type(c)
+	}
 	return nil, fmt.Errorf("Тип неопределен '%s'", names.UniqueNames.Get(k))
 }
 
@@ -267,46 +555,101 @@ func (e *Env) Type(k int) (reflect.Type, error) {
 func (e *Env) Get(k int) (VMValuer, error) {
 
 	for ee := e; ee != nil; ee = ee.parent {
-		ee.RLock()
-		if ee.lastid == k {
-			v := ee.lastval
+		var v VMValuer
+		var ok bool
+		if ee.locked() {
+			ee.RLock()
+			v, ok = ee.lookup(k)
 			ee.RUnlock()
-			return v, nil
+		} else {
+			v, ok = ee.lookup(k)
 		}
-		if v, ok := ee.env.Get(k); ok {
-			li := ee.lastid
-			ee.RUnlock()
-			if k != li {
-				ee.Lock()
-				ee.lastid = k
-				ee.lastval = v
-				ee.Unlock()
-			}
+		if ok {
 			return v, nil
 		}
-		ee.RUnlock()
+	}
+	// английский синоним встроенной функции или значения, если имя не переопределено кодом
+	if c := names.UniqueNames.Canonical(k); c != k {
+		return e.Get(c)
 	}
 	return nil, fmt.Errorf("Имя неопределено '%s'", names.UniqueNames.Get(k))
 }
 
+// lookup возвращает значение имени k, определенного в окружении без родительских
+func (e *Env) lookup(k int) (VMValuer, bool) {
+	if e.lastid == k {
+		return e.lastval, true
+	}
+	if v, ok := e.env.Get(k); ok {
+		return v, true
+	}
+	if i := e.slot(k); i >= 0 && e.slots[i] != nil {
+		return e.slots[i], true
+	}
+	return nil, false
+}
+
+// store изменяет значение имени k, если оно определено в окружении без родительских.
+// При deleted изменяется и имя, значение которого удалено.
+func (e *Env) store(k int, v VMValuer, deleted bool) bool {
+	if _, ok := e.env.Get(k); ok || deleted && e.env.Has(k) {
+		e.env.Set(k, v)
+		e.lastid = k
+		e.lastval = v
+		return true
+	}
+	// пустое место локальной переменной означает, что переменной еще не присвоено значение
+	if i := e.slot(k); i >= 0 && e.slots[i] != nil {
+		e.slots[i] = v
+		return true
+	}
+	return false
+}
+
+// storeLocked вызывает store с блокировкой, если окружение locked
+func (e *Env) storeLocked(k int, v VMValuer, deleted bool) bool {
+	if !e.locked() {
+		return e.store(k, v, deleted)
+	}
+	e.Lock()
+	ok := e.store(k, v, deleted)
+	e.Unlock()
+	return ok
+}
+
 // Set modifies value which specified as symbol. It goes to upper scope until
 // found or returns error.
 func (e *Env) Set(k int, v VMValuer) error {
 
 	for ee := e; ee != nil; ee = ee.parent {
-		ee.Lock()
-		if _, ok := ee.env.Get(k); ok {
-			ee.env.Set(k, v)
-			ee.lastid = k
-			ee.lastval = v
-			ee.Unlock()
+		if ee.storeLocked(k, v, false) {
 			return nil
 		}
-		ee.Unlock()
 	}
 	return fmt.Errorf("Имя неопределено '%s'", names.UniqueNames.Get(k))
 }
 
+// Assign присваивает значение переменной.
+// Если переменная определена в текущем окружении или в окружениях объемлющих функций,
+// захваченных замыканием, то изменяется ее значение там, где она определена.
+// Иначе переменная определяется в текущем окружении.
+// Глобальные переменные и переменные модулей из функций только читаются,
+// а из итераций параллельного цикла в коде модуля изменяются.
+func (e *Env) Assign(k int, v VMValuer) error {
+	parallel := false
+	for ee := e; ee != nil && !(ee != e && ee.IsGlobalScope() && !parallel); ee = ee.parent {
+		parallel = ee.parallel
+		// пустой слот объемлющей функции означает, что переменная там еще не определена, как и в Get и Set
+		if ee.storeLocked(k, v, true) {
+			return nil
+		}
+		if !ee.nested() {
+			break
+		}
+	}
+	return e.Define(k, v)
+}
+
 // DefineGlobal defines symbol in global scope.
 func (e *Env) DefineGlobal(k int, v VMValuer) error {
 	for ee := e; ee != nil; ee = ee.parent {
@@ -334,6 +677,56 @@ func (e *Env) DefineTypeS(k string, t reflect.Type) error {
 	return e.DefineType(names.UniqueNames.Set(k), t)
 }
 
+// DefineObjectType регистрирует тип объектов, объявленный в коде, в глобальном контексте
+func (e *Env) DefineObjectType(k int, t *VMObjectType) error {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			ee.Lock()
+			defer ee.Unlock()
+			if ee.objtyp == nil {
+				ee.objtyp = make(map[int]*VMObjectType)
+			}
+			ee.objtyp[k] = t
+			return nil
+		}
+	}
+	return fmt.Errorf("Отсутствует глобальный контекст!")
+}
+
+// ObjectType возвращает тип объектов, объявленный в коде
+func (e *Env) ObjectType(k int) (*VMObjectType, bool) {
+	for ee := e; ee != nil; ee = ee.parent {
+		ee.RLock()
+		t, ok := ee.objtyp[k]
+		ee.RUnlock()
+		if ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// ObjectTypeByName возвращает тип объектов, объявленный в коде, по имени без учета регистра.
+// Так находятся типы при восстановлении объектов из бинарного формата.
+func (e *Env) ObjectTypeByName(name string) (*VMObjectType, bool) {
+	name = names.FastToLower(name)
+	ee := e
+	if ee.global != nil {
+		ee = ee.global
+	}
+	for ; ee != nil; ee = ee.parent {
+		ee.RLock()
+		for k, t := range ee.objtyp {
+			if names.UniqueNames.GetLowerCase(k) == name {
+				ee.RUnlock()
+				return t, true
+			}
+		}
+		ee.RUnlock()
+	}
+	return nil, false
+}
+
 // DefineTypeStruct регистрирует системную функциональную структуру, переданную в виде указателя!
 func (e *Env) DefineTypeStruct(k string, t interface{}) error {
 	gob.Register(t)
@@ -341,14 +734,20 @@ func (e *Env) DefineTypeStruct(k string, t interface{}) error {
 }
 
 // Define defines symbol in current scope.
+// Локальная переменная с номером, определенным при компиляции, хранится на своем месте.
 func (e *Env) Define(k int, v VMValuer) error {
-	e.Lock()
+	if e.locked() {
+		e.Lock()
+		defer e.Unlock()
+	}
+	if i := e.slot(k); i >= 0 {
+		e.slots[i] = v
+		return nil
+	}
 	e.env.Set(k, v)
 	e.lastid = k
 	e.lastval = v
 
-	e.Unlock()
-
 	return nil
 }
 
@@ -361,6 +760,174 @@ func (e *Env) String() string {
 	return e.name
 }
 
+// Names возвращает имена, определенные в текущем окружении, в нижнем регистре
+func (e *Env) Names() []string {
+	e.RLock()
+	res := make([]string, 0, len(e.env.idx)+len(e.slots))
+	for k := range e.env.idx {
+		res = append(res, names.UniqueNames.GetLowerCase(k))
+	}
+	for _, k := range e.frame.Names() {
+		res = append(res, names.UniqueNames.GetLowerCase(k))
+	}
+	e.RUnlock()
+	sort.Strings(res)
+	return res
+}
+
+// Parent возвращает родительское окружение, у глобального окружения его нет
+func (e *Env) Parent() *Env {
+	return e.parent
+}
+
+// SetCaller запоминает окружение кода, вызвавшего функцию, для которой создано окружение,
+// и глубину вызова. Функция, вызванная без вызывающего окружения, например в отдельной горутине,
+// начинает новую цепочку вызовов.
+func (e *Env) SetCaller(c *Env) {
+	e.caller = c
+	e.depth = 1
+	if c != nil {
+		e.depth = c.depth + 1
+	}
+}
+
+// CheckCallDepth возвращает ошибку, если глубина вызова функции превышает предел глобального контекста
+func (e *Env) CheckCallDepth() error {
+	max := e.MaxCallDepth()
+	if max > 0 && e.depth > max {
+		return fmt.Errorf("Превышена максимальная глубина вызовов функций (%d)", max)
+	}
+	return nil
+}
+
+// MaxCallDepth возвращает предел глубины вызовов функций глобального контекста, 0 - без ограничения
+func (e *Env) MaxCallDepth() int {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			return int(atomic.LoadInt64(&ee.maxDepth))
+		}
+	}
+	return 0
+}
+
+// SetMaxCallDepth устанавливает предел глубины вызовов функций в глобальном контексте, 0 - без ограничения
+func (e *Env) SetMaxCallDepth(n int) {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			atomic.StoreInt64(&ee.maxDepth, int64(n))
+		}
+	}
+}
+
+// SetPolicy устанавливает ограничения исполнения в глобальном контексте, они действуют на каждый следующий запуск кода
+func (e *Env) SetPolicy(p ExecPolicy) {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			ee.Lock()
+			ee.policy = p
+			ee.Unlock()
+		}
+	}
+}
+
+// Policy возвращает ограничения исполнения глобального контекста
+func (e *Env) Policy() ExecPolicy {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			ee.RLock()
+			defer ee.RUnlock()
+			return ee.policy
+		}
+	}
+	return ExecPolicy{}
+}
+
+// StartBudget начинает учет расхода ограничений запуском кода. Возвращает nil, если ограничений нет
+// или учет уже ведется, например при исполнении кода, запущенного из исполняемого кода.
+func (e *Env) StartBudget() *ExecBudget {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			if cur := ee.Budget(); cur != nil && atomic.LoadInt32(&cur.running) != 0 {
+				return nil
+			}
+			p := ee.Policy()
+			if p.IsZero() {
+				ee.budget.Store((*ExecBudget)(nil))
+				return nil
+			}
+			b := NewExecBudget(p)
+			b.running = 1
+			ee.budget.Store(b)
+			return b
+		}
+	}
+	return nil
+}
+
+// StopBudget завершает учет, начатый StartBudget. Расход остается в глобальном контексте до следующего запуска,
+// поэтому горутины, которые продолжают работать после завершения запуска, по-прежнему ограничены.
+func (e *Env) StopBudget(b *ExecBudget) {
+	atomic.StoreInt32(&b.running, 0)
+}
+
+// Budget возвращает расход ограничений текущим запуском кода или nil, если ограничений нет
+func (e *Env) Budget() *ExecBudget {
+	for ee := e; ee != nil; ee = ee.parent {
+		if ee.parent == nil {
+			b, _ := ee.budget.Load().(*ExecBudget)
+			return b
+		}
+	}
+	return nil
+}
+
+// SetTailCall отмечает, что функция, для которой создано окружение, исполняет хвостовой вызов:
+// вызываемая функция занимает ее место в цепочке вызовов
+func (e *Env) SetTailCall(b bool) {
+	e.tailcall = b
+}
+
+// TailCall сообщает, исполняет ли функция хвостовой вызов
+func (e *Env) TailCall() bool {
+	return e.tailcall
+}
+
+// Caller возвращает окружение кода, вызвавшего функцию, или nil, если функция вызвана
+// из встроенной функции или в отдельной горутине
+func (e *Env) Caller() *Env {
+	return e.caller
+}
+
+// Locals возвращает значения, определенные в текущем окружении без родительских
+func (e *Env) Locals() map[string]VMValuer {
+	e.RLock()
+	res := make(map[string]VMValuer, len(e.env.idx)+len(e.slots))
+	for k := range e.env.idx {
+		if v, ok := e.env.Get(k); ok {
+			res[names.UniqueNames.Get(k)] = v
+		}
+	}
+	for i, k := range e.frame.Names() {
+		if v := e.slots[i]; v != nil {
+			res[names.UniqueNames.Get(k)] = v
+		}
+	}
+	e.RUnlock()
+	return res
+}
+
+// TypeNames возвращает имена типов, определенных в текущем окружении, в нижнем регистре
+func (e *Env) TypeNames() []string {
+	e.RLock()
+	res := make([]string, 0, len(e.typ))
+	for k := range e.typ {
+		res = append(res, names.UniqueNames.GetLowerCase(k))
+	}
+	e.RUnlock()
+	sort.Strings(res)
+	return res
+}
+
 // Dump show symbol values in the scope.
 func (e *Env) Dump() {
 	e.RLock()
@@ -371,54 +938,69 @@ func (e *Env) Dump() {
 		i++
 	}
 	sort.Ints(sk)
-	for _, k := range sk {
-		v, _ := e.env.Get(k)
-		e.Printf("%d %s = %#v %T\n", k, names.UniqueNames.Get(k), v, v)
+	vals := make([]VMValuer, len(sk))
+	for i, k := range sk {
+		vals[i], _ = e.env.Get(k)
 	}
 	e.RUnlock()
+	for i, k := range sk {
+		e.Printf("%d %s = %#v %T\n", k, names.UniqueNames.Get(k), vals[i], vals[i])
+	}
+}
+
+// output - поток вывода окружений одного запуска кода, запись в него из разных горутин выполняется по очереди
+type output struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.Lock()
+	defer o.Unlock()
+	return o.w.Write(p)
+}
+
+// out возвращает поток вывода окружения
+func (e *Env) out() *output {
+	e.RLock()
+	defer e.RUnlock()
+	return e.stdout
 }
 
 func (e *Env) Println(a ...interface{}) (n int, err error) {
-	// e.RLock()
-	// defer e.RUnlock()
-	return fmt.Fprintln(e.stdout, a...)
+	return e.out().Write([]byte(fmt.Sprintln(a...)))
 }
 
 func (e *Env) Printf(format string, a ...interface{}) (n int, err error) {
-	// e.RLock()
-	// defer e.RUnlock()
-	return fmt.Fprintf(e.stdout, format, a...)
+	return e.out().Write([]byte(fmt.Sprintf(format, a...)))
 }
 
 func (e *Env) Sprintf(format string, a ...interface{}) string {
-	// e.RLock()
-	// defer e.RUnlock()
 	return fmt.Sprintf(format, a...)
 }
 
 func (e *Env) Print(a ...interface{}) (n int, err error) {
-	// e.RLock()
-	// defer e.RUnlock()
-	return fmt.Fprint(e.stdout, a...)
+	return e.out().Write([]byte(fmt.Sprint(a...)))
 }
 
+// StdOut возвращает поток вывода, запись в который согласована с выводом из других горутин
 func (e *Env) StdOut() reflect.Value {
-	// e.RLock()
-	// defer e.RUnlock()
-	return reflect.ValueOf(e.stdout)
+	return reflect.ValueOf(io.Writer(e.out()))
 }
 
+// SetStdOut устанавливает поток вывода окружения, окружения, созданные после этого, наследуют его
 func (e *Env) SetStdOut(w io.Writer) {
-	// e.Lock()
-	//пренебрегаем возможными коллизиями при установке потока вывода, т.к. это совсем редкая операция
-	e.stdout = w
-	// e.Unlock()
+	e.Lock()
+	e.stdout = &output{w: w}
+	e.Unlock()
 }
 
 func (e *Env) SetSid(s string) error {
 	for ee := e; ee != nil; ee = ee.parent {
 		if ee.parent == nil {
+			ee.Lock()
 			ee.sid = s
+			ee.Unlock()
 			return ee.Define(names.UniqueNames.Set("ГлобальныйИдентификаторСессии"), VMString(s))
 		}
 	}
@@ -428,21 +1010,131 @@ func (e *Env) SetSid(s string) error {
 func (e *Env) GetSid() string {
 	for ee := e; ee != nil; ee = ee.parent {
 		if ee.parent == nil {
-			// пренебрегаем возможными коллизиями, т.к. изменение номера сессии - это совсем редкая операция
+			ee.RLock()
+			defer ee.RUnlock()
 			return ee.sid
 		}
 	}
 	return ""
 }
 
+// runContext - контекст исполнения и функция его отмены, которая прерывает запуск кода
+type runContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// SetContext связывает окружение с контекстом исполнения кода, cancel прерывает исполнение
+// при вызове Interrupt и может быть nil. Возвращает функцию, которая восстанавливает прежний контекст.
+func (e *Env) SetContext(ctx context.Context, cancel context.CancelFunc) (restore func()) {
+	prev, _ := e.ctx.Load().(*runContext)
+	e.ctx.Store(&runContext{ctx: ctx, cancel: cancel})
+	return func() {
+		e.ctx.Store(prev)
+	}
+}
+
+// InheritContext связывает окружение вызова функции с контекстом исполнения вызывающего кода c
+func (e *Env) InheritContext(c *Env) {
+	if rc := c.runContext(); rc != nil {
+		e.ctx.Store(rc)
+	}
+}
+
+func (e *Env) runContext() *runContext {
+	for ee := e; ee != nil; ee = ee.parent {
+		if rc, ok := ee.ctx.Load().(*runContext); ok {
+			return rc
+		}
+	}
+	return nil
+}
+
+// Context возвращает контекст исполнения кода в окружении, при его отмене исполнение прерывается,
+// а встроенные функции прекращают ожидание
+func (e *Env) Context() context.Context {
+	if rc := e.runContext(); rc != nil {
+		return rc.ctx
+	}
+	return context.Background()
+}
+
+// GoEnv возвращает окружение, через которое функции, вызываемой в отдельной горутине, передаются
+// контекст исполнения и поток вывода e. Вызывающим кодом оно не является, вызов начинает новую цепочку вызовов.
+func (e *Env) GoEnv() *Env {
+	ge := &Env{
+		stdout:    e.out(),
+		lastid:    -1,
+		Valid:     true,
+		goroutine: true,
+		global:    e.global,
+	}
+	for ee := e; ee != nil && ge.global == nil; ee = ee.parent {
+		if ee.parent == nil && !ee.goroutine {
+			ge.global = ee
+		}
+	}
+	ge.InheritContext(e)
+	return ge
+}
+
+// IsGoEnv сообщает, что окружение создано GoEnv
+func (e *Env) IsGoEnv() bool {
+	return e.goroutine
+}
+
+// Interrupt прерывает исполнение кода, запущенного в окружении
 func (e *Env) Interrupt() {
-	*(e.interrupt) = true
+	if rc := e.runContext(); rc != nil && rc.cancel != nil {
+		rc.cancel()
+	}
 }
 
+// CheckInterrupt сообщает, что исполнение кода в окружении прервано
 func (e *Env) CheckInterrupt() bool {
-	if *(e.interrupt) {
-		*(e.interrupt) = false
-		return true
+	return e.Context().Err() != nil
+}
+
+// CallEnv возвращает окружение кода, вызвавшего встроенную функцию, по ее параметру envout или nil
+func CallEnv(envout *(*Env)) *Env {
+	if envout != nil {
+		return *envout
+	}
+	return nil
+}
+
+// CallContext возвращает контекст исполнения кода, вызвавшего встроенную функцию, по ее параметру envout
+func CallContext(envout *(*Env)) context.Context {
+	if envout != nil && *envout != nil {
+		return (*envout).Context()
+	}
+	return context.Background()
+}
+
+// afterFunc вызывает f в отдельной горутине после отмены ctx. Вызов stop отменяет ожидание
+// и возвращает false, если f уже вызвана. Так же работает context.AfterFunc из Go 1.21.
+func afterFunc(ctx context.Context, f func()) (stop func() bool) {
+	done := ctx.Done()
+	if done == nil {
+		// контекст не отменяется
+		return func() bool { return true }
+	}
+	var state int32 // 0 - ожидание, 1 - f вызвана, 2 - ожидание отменено
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			if atomic.CompareAndSwapInt32(&state, 0, 1) {
+				f()
+			}
+		case <-stopped:
+		}
+	}()
+	return func() bool {
+		if atomic.CompareAndSwapInt32(&state, 0, 2) {
+			close(stopped)
+			return true
+		}
+		return false
 	}
-	return false
 }
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/covrom/gonec/names"
+)
+
+func TestEnvLocked(t *testing.T) {
+	g := NewEnv()
+	f := g.NewFuncEnv()
+	c := f.NewClosureEnv()
+	if !g.locked() {
+		t.Error("глобальный контекст без блокировки")
+	}
+	if f.locked() || c.locked() {
+		t.Error("окружение вызова функции с блокировкой до захвата замыканием")
+	}
+
+	c.Capture()
+	if !c.locked() || !f.locked() {
+		t.Error("захваченные замыканием окружения без блокировки")
+	}
+
+	p := g.NewFuncEnv()
+	p.ShareParallel()
+	if !p.locked() {
+		t.Error("окружение параллельного цикла без блокировки")
+	}
+	if p.NewParallelEnv().locked() {
+		t.Error("окружение итерации с блокировкой")
+	}
+}
+
+func TestEnvSharedAssign(t *testing.T) {
+	// итерации одновременно изменяют переменную окружения, в котором исполняется параллельный цикл
+	id := names.UniqueNames.Set("тестсчетчик")
+	f := NewEnv().NewFuncEnv()
+	f.Define(id, VMInt(0))
+	f.ShareParallel()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ienv := f.NewParallelEnv()
+			for j := 0; j < 100; j++ {
+				if _, err := ienv.Get(id); err != nil {
+					t.Error(err)
+					return
+				}
+				ienv.Assign(id, VMInt(j))
+			}
+		}()
+	}
+	wg.Wait()
+	if v, err := f.Get(id); err != nil || v != VMInt(99) {
+		t.Errorf("получено %v, %v", v, err)
+	}
+}
+
+func TestEnvClosureAssign(t *testing.T) {
+	// присваивание в замыкании изменяет переменную объемлющей функции,
+	// а в обычном вложенном окружении определяет свою
+	id := names.UniqueNames.Set("тестзамык")
+	f := NewEnv().NewFuncEnv()
+	f.Define(id, VMInt(1))
+
+	f.NewClosureEnv().Assign(id, VMInt(2))
+	if v, _ := f.Get(id); v != VMInt(2) {
+		t.Errorf("замыкание: получено %v", v)
+	}
+
+	sub := f.NewSubEnv()
+	sub.Assign(id, VMInt(3))
+	if v, _ := f.Get(id); v != VMInt(2) {
+		t.Errorf("вложенное окружение: получено %v", v)
+	}
+	if v, _ := sub.Get(id); v != VMInt(3) {
+		t.Errorf("вложенное окружение: получено %v", v)
+	}
+}
@@ -32,6 +32,12 @@ var (
 	VMErrorIncorrectStructType = errors.New("Невозможно использовать данный тип структуры")
 	VMErrorNotDefined          = errors.New("Не определено")
 	VMErrorNotBinaryConverted  = errors.New("Значение не может быть преобразовано в бинарный формат")
+	VMErrorNoField             = errors.New("Нет поля с таким именем")
+	VMErrorNoConstructor       = errors.New("У типа нет конструктора с параметрами")
+	VMErrorNotIterable         = errors.New("Не является коллекцией или каналом")
+	VMErrorIteratorResult      = errors.New("Метод Следующий должен возвращать значение и признак его наличия")
+
+	VMErrorInterrupted = errors.New("Выполнение прервано") // отменен контекст исполнения кода
 
 	VMErrorNoNeedArgs = errors.New("Параметры не требуются")
 	VMErrorNoArgs     = errors.New("Отсутствуют аргументы")
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"reflect"
 )
 
 // VMFunc вызывается как обертка метода объекта метаданных или обертка функции библиотеки
@@ -29,9 +30,16 @@ func (f VMFunc) Func() VMFunc {
 
 type VMMethod = func(VMSlice, *VMSlice, *(*Env)) error
 
+// VMFuncMustParams создает функцию, которая проверяет число переданных параметров.
+// Функция не встраивается, чтобы код обертки был один для всех функций, см. FuncParamsCount
+//
+//go:noinline
 func VMFuncMustParams(n int, f VMMethod) VMFunc {
 	return VMFunc(
 		func(args VMSlice, rets *VMSlice, envout *(*Env)) error {
+			if rets == &paramsProbe {
+				return paramsCount(n)
+			}
 			if len(args) != n {
 				switch n {
 				case 0:
@@ -43,3 +51,26 @@ func VMFuncMustParams(n int, f VMMethod) VMFunc {
 			return f(args, rets, envout)
 		})
 }
+
+// paramsProbe передается в rets функции, созданной VMFuncMustParams, чтобы узнать число ее параметров без исполнения
+var paramsProbe VMSlice
+
+type paramsCount int
+
+func (n paramsCount) Error() string {
+	return fmt.Sprintf("Функция ожидает параметров: %d", int(n))
+}
+
+// все функции, созданные VMFuncMustParams, имеют один и тот же код обертки
+var mustParamsCode = reflect.ValueOf(VMFuncMustParams(0, nil)).Pointer()
+
+// FuncParamsCount возвращает число параметров функции, если оно задано при ее создании через VMFuncMustParams
+func FuncParamsCount(f VMFunc) (int, bool) {
+	if f == nil || reflect.ValueOf(f).Pointer() != mustParamsCode {
+		return 0, false
+	}
+	if n, ok := f(nil, &paramsProbe, nil).(paramsCount); ok {
+		return int(n), true
+	}
+	return 0, false
+}
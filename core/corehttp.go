@@ -139,7 +139,7 @@ func (x *VMHttpRequest) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "метод":
 		return VMFuncMustParams(0, x.Метод), true
 	case "заголовок":
@@ -322,7 +322,7 @@ func (x *VMHttpResponse) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "отправить":
 		return VMFuncMustParams(1, x.Отправить), true
 	case "сообщение":
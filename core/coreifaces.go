@@ -59,6 +59,20 @@ type (
 		IndexVal(VMValuer) VMValuer
 	}
 
+	// VMIterable перебирается циклом Для каждого по одному элементу, без получения всех элементов сразу.
+	// Каждый цикл получает собственный итератор, env - окружение цикла.
+	VMIterable interface {
+		VMValuer
+		Iterator(env *Env) (VMIterator, error)
+	}
+
+	// VMIterator выдает элементы цикла Для каждого: Next возвращает false, когда элементы закончились.
+	// Close освобождает ресурсы итератора и вызывается один раз, в том числе при досрочном выходе из цикла.
+	VMIterator interface {
+		Next() (VMValuer, bool, error)
+		Close() error
+	}
+
 	// VMBinaryTyper может сериализовываться в бинарные данные внутри слайсов и структур
 	VMBinaryTyper interface {
 		VMValuer
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/boltdb/bolt"
+	"github.com/covrom/gonec/names"
+)
+
+// KeyValueType - тип пары ключа и значения, которую выдают итераторы таблиц и структур
+var KeyValueType = NewVMObjectType(names.UniqueNames.Set("КлючИЗначение"),
+	[]int{names.UniqueNames.Set("Ключ"), names.UniqueNames.Set("Значение")}, nil, nil)
+
+// NewVMKeyValue возвращает пару ключа и значения с полями Ключ и Значение
+func NewVMKeyValue(k, v VMValuer) *VMObject {
+	obj := KeyValueType.Zero()
+	obj.fields[0], obj.fields[1] = k, v
+	return obj
+}
+
+// KeyValueOf возвращает ключ и значение, если v - пара КлючИЗначение
+func KeyValueOf(v VMValuer) (k, val VMValuer, ok bool) {
+	obj, ok := v.(*VMObject)
+	if !ok || obj.typ != KeyValueType {
+		return VMNil, VMNil, false
+	}
+	obj.mu.RLock()
+	defer obj.mu.RUnlock()
+	return obj.fields[0], obj.fields[1], true
+}
+
+// Iterator перебирает пары КлючИЗначение структуры в порядке возрастания ключей.
+// Ключи запоминаются в начале перебора, значения берутся из структуры в момент выдачи пары,
+// ключи, удаленные во время перебора, пропускаются.
+func (x VMStringMap) Iterator(env *Env) (VMIterator, error) {
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	i := 0
+	return &funcIterator{next: func() (VMValuer, bool, error) {
+		for i < len(keys) {
+			k := keys[i]
+			i++
+			if v, ok := x[k]; ok {
+				return NewVMKeyValue(VMString(k), v), true, nil
+			}
+		}
+		return VMNil, false, nil
+	}}, nil
+}
+
+// Iterator перебирает строки таблицы по индексу, строки, добавленные во время перебора, тоже перебираются
+func (vt *VMTable) Iterator(env *Env) (VMIterator, error) {
+	i := 0
+	return &funcIterator{next: func() (VMValuer, bool, error) {
+		if i >= len(vt.lines) {
+			return VMNil, false, nil
+		}
+		i++
+		return vt.lines[i-1], true, nil
+	}}, nil
+}
+
+// funcIterator - итератор без ресурсов, которые нужно освобождать
+type funcIterator struct {
+	next func() (VMValuer, bool, error)
+}
+
+func (it *funcIterator) Next() (VMValuer, bool, error) { return it.next() }
+func (it *funcIterator) Close() error                  { return nil }
+
+// Iterator перебирает записи таблицы курсором в порядке ключей, элементы - пары КлючИЗначение.
+// Записи читаются по одной, транзакция должна оставаться открытой до конца перебора,
+// изменять таблицу во время перебора нельзя.
+func (x *VMBoltTable) Iterator(env *Env) (VMIterator, error) {
+	if err := x.lock(); err != nil {
+		return nil, err
+	}
+	defer x.tr.Unlock()
+	return &boltIterator{t: x, c: x.b.Cursor(), env: env}, nil
+}
+
+type boltIterator struct {
+	t       *VMBoltTable
+	c       *bolt.Cursor
+	env     *Env
+	started bool
+}
+
+func (it *boltIterator) Next() (VMValuer, bool, error) {
+	if it.c == nil {
+		return VMNil, false, nil
+	}
+	if err := it.t.lock(); err != nil {
+		return VMNil, false, err
+	}
+	defer it.t.tr.Unlock()
+	var k, v []byte
+	if it.started {
+		k, v = it.c.Next()
+	} else {
+		k, v = it.c.First()
+		it.started = true
+	}
+	if k == nil {
+		return VMNil, false, nil
+	}
+	vx, err := parseBoltValue(v, it.env)
+	if err != nil {
+		return VMNil, false, err
+	}
+	return NewVMKeyValue(VMString(string(k)), vx), true, nil
+}
+
+func (it *boltIterator) Close() error {
+	it.c = nil
+	return nil
+}
+
+// Iterator позволяет перебирать объекты типов, объявленных в коде, у которых есть метод Следующий().
+// Метод возвращает очередное значение и Истина или Неопределено и Ложь, когда значения закончились.
+// Метод Закрыть(), если он есть, вызывается по окончании перебора, в том числе досрочном.
+func (x *VMObject) Iterator(env *Env) (VMIterator, error) {
+	next, ok := x.MethodMember(names.UniqueNames.Set("следующий"))
+	if !ok {
+		return nil, VMErrorNotIterable
+	}
+	it := &objectIterator{next: next, env: env}
+	it.close, _ = x.MethodMember(names.UniqueNames.Set("закрыть"))
+	return it, nil
+}
+
+type objectIterator struct {
+	next, close VMFunc
+	env         *Env
+}
+
+func (it *objectIterator) Next() (VMValuer, bool, error) {
+	var rets VMSlice
+	envout := it.env
+	if err := it.next(VMSlice{}, &rets, &envout); err != nil {
+		return VMNil, false, err
+	}
+	if len(rets) != 2 {
+		return VMNil, false, VMErrorIteratorResult
+	}
+	ok, isbool := rets[1].(VMBool)
+	if !isbool {
+		return VMNil, false, VMErrorIteratorResult
+	}
+	return rets[0], bool(ok), nil
+}
+
+func (it *objectIterator) Close() error {
+	if it.close == nil {
+		return nil
+	}
+	var rets VMSlice
+	envout := it.env
+	return it.close(VMSlice{}, &rets, &envout)
+}
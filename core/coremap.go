@@ -60,7 +60,7 @@ func (x VMStringMap) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "скопировать":
 		return VMFuncMustParams(0, x.Скопировать), true
 	case "ключи":
@@ -356,6 +356,11 @@ func (x VMStringMap) MarshalBinary() ([]byte, error) {
 }
 
 func (x *VMStringMap) UnmarshalBinary(data []byte) error {
+	return x.UnmarshalBinaryEnv(data, nil)
+}
+
+// UnmarshalBinaryEnv восстанавливает структуру, объекты в ней - по типам глобального контекста окружения env
+func (x *VMStringMap) UnmarshalBinaryEnv(data []byte, env *Env) error {
 	buf := bytes.NewBuffer(data)
 	var l, li, lv uint64
 	// количество пар
@@ -383,7 +388,7 @@ func (x *VMStringMap) UnmarshalBinary(data []byte) error {
 			//байты значения
 			bb := buf.Next(int(lv))
 
-			vv, err := VMBinaryType(tt).ParseBinary(bb)
+			vv, err := VMBinaryType(tt).ParseBinaryEnv(bb, env)
 			if err != nil {
 				return err
 			}
@@ -152,6 +152,10 @@ func (v *VMMetaObj) VMGetMethod(name int) (VMFunc, bool) {
 	// fmt.Println(name)
 
 	rv, ok := v.vmMetaCacheM[name]
+	if !ok {
+		// метод вызван по английскому синониму
+		rv, ok = v.vmMetaCacheM[names.UniqueNames.Canonical(name)]
+	}
 	return rv, ok
 }
 
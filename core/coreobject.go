@@ -0,0 +1,306 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/covrom/gonec/names"
+)
+
+// VMObjectType - тип объектов, объявленный в коде на языке Гонец конструкцией Тип ... КонецТипа.
+// Методы получают объект первым аргументом ЭтотОбъект, Init заполняет поля начальными значениями.
+type VMObjectType struct {
+	Name    int   // имя типа
+	Fields  []int // поля в порядке объявления
+	Methods map[int]VMFunc
+	Init    VMFunc // nil, если у полей нет начальных значений
+
+	index map[int]int // номер поля по имени
+}
+
+// NewVMObjectType создает тип объектов, имена методов приводятся к русскому написанию
+func NewVMObjectType(name int, fields []int, methods map[int]VMFunc, init VMFunc) *VMObjectType {
+	t := &VMObjectType{
+		Name:    name,
+		Fields:  fields,
+		Methods: make(map[int]VMFunc, len(methods)),
+		Init:    init,
+		index:   make(map[int]int, len(fields)),
+	}
+	for i, f := range fields {
+		t.index[f] = i
+	}
+	for k, m := range methods {
+		t.Methods[names.UniqueNames.Canonical(k)] = m
+	}
+	return t
+}
+
+func (t *VMObjectType) vmval() {}
+
+func (t *VMObjectType) Interface() interface{} {
+	return t
+}
+
+func (t *VMObjectType) String() string {
+	return names.UniqueNames.Get(t.Name)
+}
+
+// Method возвращает метод типа по имени, в том числе по английскому синониму
+func (t *VMObjectType) Method(name int) (VMFunc, bool) {
+	m, ok := t.Methods[name]
+	if !ok {
+		m, ok = t.Methods[names.UniqueNames.Canonical(name)]
+	}
+	return m, ok
+}
+
+// field возвращает номер поля по имени, в том числе по английскому синониму
+func (t *VMObjectType) field(name int) (int, bool) {
+	i, ok := t.index[name]
+	if !ok {
+		i, ok = t.index[names.UniqueNames.Canonical(name)]
+	}
+	return i, ok
+}
+
+// New создает объект: поля получают начальные значения, затем вызывается метод Конструктор с аргументами args.
+// Без метода Конструктор объект создается только без аргументов.
+func (t *VMObjectType) New(args VMSlice, env *Env) (*VMObject, error) {
+	obj := t.Zero()
+	var rets VMSlice
+	if t.Init != nil {
+		envout := env
+		if err := t.Init(VMSlice{obj}, &rets, &envout); err != nil {
+			return nil, err
+		}
+	}
+	if ctor, ok := t.Method(names.UniqueNames.Set("конструктор")); ok {
+		envout := env
+		rets = rets[:0]
+		if err := ctor(append(VMSlice{obj}, args...), &rets, &envout); err != nil {
+			return nil, err
+		}
+	} else if len(args) > 0 {
+		return nil, VMErrorNoConstructor
+	}
+	return obj, nil
+}
+
+// Zero возвращает объект, все поля которого не определены
+func (t *VMObjectType) Zero() *VMObject {
+	obj := &VMObject{typ: t, fields: make(VMSlice, len(t.Fields))}
+	for i := range obj.fields {
+		obj.fields[i] = VMNil
+	}
+	return obj
+}
+
+// FromStringMap создает объект с начальными значениями полей, затем поля, которые есть в структуре, получают ее значения.
+// Конструктор не вызывается, так объекты восстанавливаются из сохраненных данных.
+func (t *VMObjectType) FromStringMap(m VMStringMap, env *Env) (*VMObject, error) {
+	obj := t.Zero()
+	if t.Init != nil {
+		var rets VMSlice
+		envout := env
+		if err := t.Init(VMSlice{obj}, &rets, &envout); err != nil {
+			return nil, err
+		}
+	}
+	obj.setFields(m)
+	return obj, nil
+}
+
+// VMObject - объект типа, объявленного в коде на языке Гонец.
+// Объект передается по ссылке, поля изменяются на месте.
+type VMObject struct {
+	mu     sync.RWMutex
+	typ    *VMObjectType
+	fields VMSlice
+}
+
+func (x *VMObject) vmval() {}
+
+func (x *VMObject) Interface() interface{} {
+	return x
+}
+
+// Type возвращает тип объекта
+func (x *VMObject) Type() *VMObjectType {
+	return x.typ
+}
+
+// IsField возвращает true, если у объекта есть поле с таким именем
+func (x *VMObject) IsField(name int) bool {
+	_, ok := x.typ.field(name)
+	return ok
+}
+
+func (x *VMObject) Field(name int) (VMValuer, error) {
+	i, ok := x.typ.field(name)
+	if !ok {
+		return VMNil, VMErrorNoField
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.fields[i], nil
+}
+
+func (x *VMObject) SetField(name int, v VMValuer) error {
+	i, ok := x.typ.field(name)
+	if !ok {
+		return VMErrorNoField
+	}
+	x.mu.Lock()
+	x.fields[i] = v
+	x.mu.Unlock()
+	return nil
+}
+
+// MethodMember возвращает метод, вызываемый для этого объекта
+func (x *VMObject) MethodMember(name int) (VMFunc, bool) {
+	m, ok := x.typ.Method(name)
+	if !ok {
+		return nil, false
+	}
+	return func(args VMSlice, rets *VMSlice, envout *(*Env)) error {
+		return m(append(VMSlice{x}, args...), rets, envout)
+	}, true
+}
+
+// StringMap возвращает поля объекта в виде структуры
+func (x *VMObject) StringMap() VMStringMap {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	m := make(VMStringMap, len(x.fields))
+	for i, f := range x.typ.Fields {
+		m[names.UniqueNames.Get(f)] = x.fields[i]
+	}
+	return m
+}
+
+func (x *VMObject) setFields(m VMStringMap) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for k, v := range m {
+		if i, ok := x.typ.index[names.UniqueNames.Set(k)]; ok {
+			x.fields[i] = v
+		}
+	}
+}
+
+func (x *VMObject) String() string {
+	return x.StringMap().String()
+}
+
+func (x *VMObject) Hash() VMString {
+	return x.StringMap().Hash()
+}
+
+func (x *VMObject) EvalBinOp(op VMOperation, y VMOperationer) (VMValuer, error) {
+	// объекты равны, только если это один и тот же объект
+	switch op {
+	case EQL:
+		if yy, ok := y.(*VMObject); ok {
+			return VMBool(x == yy), nil
+		}
+		return VMBool(false), nil
+	case NEQ:
+		if yy, ok := y.(*VMObject); ok {
+			return VMBool(x != yy), nil
+		}
+		return VMBool(true), nil
+	}
+	return VMNil, VMErrorIncorrectOperation
+}
+
+func (x *VMObject) ConvertToType(nt reflect.Type) (VMValuer, error) {
+	switch nt {
+	case ReflectVMString:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return VMNil, err
+		}
+		return VMString(string(b)), nil
+	case ReflectVMStringMap:
+		return x.StringMap(), nil
+	}
+	return VMNil, VMErrorNotConverted
+}
+
+// ConvertToObject создает объект типа t из структуры или строки с ней в формате json, как это делает Новый("Тип", значение)
+func ConvertToObject(v VMValuer, t *VMObjectType, env *Env) (*VMObject, error) {
+	switch vv := v.(type) {
+	case *VMObject:
+		if vv.typ == t {
+			return vv, nil
+		}
+		return t.FromStringMap(vv.StringMap(), env)
+	case VMStringMap:
+		return t.FromStringMap(vv, env)
+	case VMString:
+		m, err := VMStringMapFromJson(string(vv))
+		if err != nil {
+			return nil, err
+		}
+		return t.FromStringMap(m, env)
+	}
+	return nil, VMErrorNotConverted
+}
+
+func (x *VMObject) MarshalJSON() ([]byte, error) {
+	return x.StringMap().MarshalJSON()
+}
+
+func (x *VMObject) BinaryType() VMBinaryType {
+	return VMOBJECT
+}
+
+// MarshalBinary записывает имя типа и поля в бинарном формате структуры
+func (x *VMObject) MarshalBinary() ([]byte, error) {
+	bm, err := x.StringMap().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	bn := []byte(names.UniqueNames.Get(x.typ.Name))
+	binary.Write(&buf, binary.LittleEndian, uint64(len(bn)))
+	buf.Write(bn)
+	buf.Write(bm)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary восстанавливает пару КлючИЗначение, объекты типов, объявленных в коде, восстанавливает UnmarshalBinaryEnv
+func (x *VMObject) UnmarshalBinary(data []byte) error {
+	return x.UnmarshalBinaryEnv(data, nil)
+}
+
+// UnmarshalBinaryEnv восстанавливает объект типа, объявленного в коде, который выполняется в окружении env,
+// методы берутся у этого типа. У каждого интерпретатора свои типы, поэтому без окружения восстанавливается
+// только пара КлючИЗначение.
+func (x *VMObject) UnmarshalBinaryEnv(data []byte, env *Env) error {
+	buf := bytes.NewBuffer(data)
+	var l uint64
+	if err := binary.Read(buf, binary.LittleEndian, &l); err != nil {
+		return err
+	}
+	name := string(buf.Next(int(l)))
+	var m VMStringMap
+	if err := (&m).UnmarshalBinaryEnv(buf.Bytes(), env); err != nil {
+		return err
+	}
+	t, ok := KeyValueType, names.FastToLower(name) == names.UniqueNames.GetLowerCase(KeyValueType.Name)
+	if !ok && env != nil {
+		t, ok = env.ObjectTypeByName(name)
+	}
+	if !ok {
+		return VMErrorUnknownType
+	}
+	obj := t.Zero()
+	obj.setFields(m)
+	x.typ, x.fields = obj.typ, obj.fields
+	return nil
+}
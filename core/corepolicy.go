@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ExecPolicy - ограничения одного запуска кода в глобальном контексте, например кода,
+// присланного в вэб-сервис интерпретатора. Нулевое значение поля означает отсутствие ограничения.
+type ExecPolicy struct {
+	MaxInstructions int64         // число исполненных инструкций байткода
+	Timeout         time.Duration // время исполнения
+	MaxAlloc        int64         // приблизительный объем в байтах строк, массивов и структур, созданных кодом
+}
+
+// IsZero сообщает, что политика ничего не ограничивает
+func (p ExecPolicy) IsZero() bool {
+	return p == ExecPolicy{}
+}
+
+// LimitKind - ограничение политики исполнения, которое было превышено
+type LimitKind int
+
+const (
+	LimitInstructions LimitKind = iota
+	LimitTimeout
+	LimitAlloc
+)
+
+// LimitError завершает исполнение кода при превышении ограничения политики.
+// Ее нельзя перехватить в Попытка, блоки Окончательно тоже не исполняются.
+type LimitError struct {
+	Kind   LimitKind
+	Policy ExecPolicy
+}
+
+func (e *LimitError) Error() string {
+	switch e.Kind {
+	case LimitInstructions:
+		return fmt.Sprintf("Исполнение остановлено: превышено допустимое число инструкций (%d)", e.Policy.MaxInstructions)
+	case LimitTimeout:
+		return fmt.Sprintf("Исполнение остановлено: превышено допустимое время исполнения (%v)", e.Policy.Timeout)
+	case LimitAlloc:
+		return fmt.Sprintf("Исполнение остановлено: превышен допустимый объем памяти (%d байт)", e.Policy.MaxAlloc)
+	}
+	return "Исполнение остановлено"
+}
+
+// IsLimitError сообщает, что ошибка завершает исполнение по политике
+func IsLimitError(err error) bool {
+	_, ok := err.(*LimitError)
+	return ok
+}
+
+// ExecBudget - расход ограничений политики одним запуском кода.
+// Счетчики общие для всех горутин и параллельных циклов запуска.
+type ExecBudget struct {
+	policy  ExecPolicy
+	instrs  int64
+	alloc   int64
+	expired int32
+	running int32 // запуск еще исполняется
+}
+
+// NewExecBudget начинает учет расхода по политике, время исполнения отсчитывается с момента вызова
+func NewExecBudget(p ExecPolicy) *ExecBudget {
+	b := &ExecBudget{policy: p}
+	if p.Timeout > 0 {
+		time.AfterFunc(p.Timeout, func() {
+			atomic.StoreInt32(&b.expired, 1)
+		})
+	}
+	return b
+}
+
+// Policy возвращает политику, по которой ведется учет
+func (b *ExecBudget) Policy() ExecPolicy {
+	return b.policy
+}
+
+// Step учитывает n исполненных инструкций и проверяет время исполнения
+func (b *ExecBudget) Step(n int64) error {
+	if atomic.LoadInt32(&b.expired) != 0 {
+		return &LimitError{Kind: LimitTimeout, Policy: b.policy}
+	}
+	if c := atomic.AddInt64(&b.instrs, n); b.policy.MaxInstructions > 0 && c > b.policy.MaxInstructions {
+		return &LimitError{Kind: LimitInstructions, Policy: b.policy}
+	}
+	return nil
+}
+
+// Alloc учитывает n байт, занятых созданным значением
+func (b *ExecBudget) Alloc(n int64) error {
+	if n == 0 {
+		return nil
+	}
+	if c := atomic.AddInt64(&b.alloc, n); b.policy.MaxAlloc > 0 && c > b.policy.MaxAlloc {
+		return &LimitError{Kind: LimitAlloc, Policy: b.policy}
+	}
+	return nil
+}
+
+// Instructions возвращает число учтенных инструкций
+func (b *ExecBudget) Instructions() int64 {
+	return atomic.LoadInt64(&b.instrs)
+}
+
+// Allocated возвращает учтенный объем созданных значений в байтах
+func (b *ExecBudget) Allocated() int64 {
+	return atomic.LoadInt64(&b.alloc)
+}
+
+// SizeOf возвращает приблизительный объем памяти, занятый значением без учета вложенных в него значений.
+// Элемент массива и значение структуры занимают по 16 байт интерфейса, ключ структуры - еще 16 байт заголовка строки.
+func SizeOf(v VMValuer) int64 {
+	switch vv := v.(type) {
+	case VMString:
+		return int64(len(vv))
+	case VMSlice:
+		return int64(cap(vv)) * 16
+	case VMStringMap:
+		return int64(len(vv)) * 32
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/core"
+)
+
+func TestExecPolicy(t *testing.T) {
+	loop := `
+	Попытка
+		Пока Истина Цикл
+		КонецЦикла
+	Исключение
+		Сообщить("перехвачено")
+	Окончательно
+		Сообщить("окончательно")
+	КонецПопытки
+	`
+	grow := `
+	Функция Удвоить(с)
+		Попытка
+			Возврат с + с
+		Исключение
+			Возврат ""
+		КонецПопытки
+	КонецФункции
+	с = "ab"
+	Для н = 1 по 40 Цикл
+		с = Удвоить(с)
+	КонецЦикла
+	`
+	bigarr := `а = [](0, 1000000000)`
+
+	cases := []struct {
+		src    string
+		policy core.ExecPolicy
+		kind   core.LimitKind
+	}{
+		{loop, core.ExecPolicy{MaxInstructions: 10000}, core.LimitInstructions},
+		{loop, core.ExecPolicy{Timeout: 50 * time.Millisecond}, core.LimitTimeout},
+		{grow, core.ExecPolicy{MaxAlloc: 1 << 20}, core.LimitAlloc},
+		{bigarr, core.ExecPolicy{MaxAlloc: 1 << 20}, core.LimitAlloc},
+	}
+	for i, c := range cases {
+		_, bins, err := bincode.ParseSrc(c.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := core.NewEnv()
+		env.SetPolicy(c.policy)
+		var buf bytes.Buffer
+		env.SetStdOut(&buf)
+		_, err = bincode.Run(context.Background(), bins, env)
+		le, ok := err.(*core.LimitError)
+		if !ok || le.Kind != c.kind {
+			t.Errorf("%d: получена ошибка %v, ожидалось превышение ограничения %d", i, err, c.kind)
+		}
+		// ни Исключение, ни Окончательно не исполняются
+		if buf.Len() != 0 {
+			t.Errorf("%d: получен вывод %q", i, buf.String())
+		}
+	}
+
+	// ограничения действуют на каждый запуск отдельно
+	_, bins, err := bincode.ParseSrc(`
+	Для н = 1 по 100 Цикл
+	КонецЦикла
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnv()
+	env.SetPolicy(core.ExecPolicy{MaxInstructions: 1000})
+	for i := 0; i < 5; i++ {
+		if _, err := bincode.Run(context.Background(), bins, env); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
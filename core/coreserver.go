@@ -145,13 +145,19 @@ func (x *VMServer) Open(proto, addr string, maxconn int, handler VMFunc, data VM
 				})
 			}
 		}
+		// порт открываем сразу, чтобы после возврата из Открыть() сервер уже принимал запросы
+		lnr, err := net.Listen("tcp", addr)
+		if err != nil {
+			x.mux = nil
+			return err
+		}
 		x.srv = &http.Server{
 			Addr:    addr,
 			Handler: x.mux,
 		}
 		go x.healthSender()
 		go func(s *http.Server) {
-			err := s.ListenAndServe()
+			err := s.Serve(lnr)
 			x.done <- err
 		}(x.srv)
 
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestServerOpenListens(t *testing.T) {
+	var x VMServer
+	h := VMFunc(func(args VMSlice, rets *VMSlice, envout *(*Env)) error {
+		return nil
+	})
+	if err := x.Open("http", "127.0.0.1:9992", 10, nil, VMNil, VMStringMap{"/ping": h}); err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	// порт открыт уже при возврате из Open, запрос не ждет запуска сервера
+	resp, err := http.Get("http://127.0.0.1:9992/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("статус ответа %d", resp.StatusCode)
+	}
+}
@@ -95,7 +95,7 @@ func (x VMSlice) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "сортировать":
 		return VMFuncMustParams(0, x.Сортировать), true
 	case "сортироватьубыв":
@@ -486,6 +486,11 @@ func (x VMSlice) MarshalBinary() ([]byte, error) {
 }
 
 func (x *VMSlice) UnmarshalBinary(data []byte) error {
+	return x.UnmarshalBinaryEnv(data, nil)
+}
+
+// UnmarshalBinaryEnv восстанавливает массив, объекты в нем - по типам глобального контекста окружения env
+func (x *VMSlice) UnmarshalBinaryEnv(data []byte, env *Env) error {
 	buf := bytes.NewBuffer(data)
 	var l, lv uint64
 	//количество элементов
@@ -510,7 +515,7 @@ func (x *VMSlice) UnmarshalBinary(data []byte) error {
 			}
 			//байты
 			bb := buf.Next(int(lv))
-			vv, err := VMBinaryType(tt).ParseBinary(bb)
+			vv, err := VMBinaryType(tt).ParseBinaryEnv(bb, env)
 			if err != nil {
 				return err
 			}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -29,6 +30,22 @@ func (x VMString) String() string {
 	return string(x)
 }
 
+// Concat соединяет значения в одну строку, значения, не являющиеся строками, записываются так же, как их выводит Сообщить
+func Concat(vs VMSlice) VMString {
+	var b strings.Builder
+	for _, v := range vs {
+		switch vv := v.(type) {
+		case VMString:
+			b.WriteString(string(vv))
+		case fmt.Stringer:
+			b.WriteString(vv.String())
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	return VMString(b.String())
+}
+
 func (x VMString) Length() VMInt {
 	return VMInt(utf8.RuneCountInString(string(x)))
 }
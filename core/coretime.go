@@ -429,7 +429,7 @@ func (t VMTime) MethodMember(name int) (VMFunc, bool) {
 
 	// только эти методы будут доступны из кода на языке Гонец!
 
-	switch names.UniqueNames.GetLowerCase(name) {
+	switch names.UniqueNames.GetCanonical(name) {
 	case "год":
 		return VMFuncMustParams(0, t.Год), true
 	case "месяц":
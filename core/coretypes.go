@@ -99,9 +99,16 @@ const (
 	VMDURATION
 	VMNIL
 	VMNULL
+	VMOBJECT
 )
 
 func (x VMBinaryType) ParseBinary(data []byte) (VMValuer, error) {
+	return x.ParseBinaryEnv(data, nil)
+}
+
+// ParseBinaryEnv восстанавливает значение из бинарного формата, объекты типов, объявленных в коде,
+// восстанавливаются по типам глобального контекста окружения env
+func (x VMBinaryType) ParseBinaryEnv(data []byte, env *Env) (VMValuer, error) {
 	switch x {
 	case VMBOOL:
 		var v VMBool
@@ -121,11 +128,11 @@ func (x VMBinaryType) ParseBinary(data []byte) (VMValuer, error) {
 		return v, err
 	case VMSLICE:
 		var v VMSlice
-		err := (&v).UnmarshalBinary(data)
+		err := (&v).UnmarshalBinaryEnv(data, env)
 		return v, err
 	case VMSTRINGMAP:
 		var v VMStringMap
-		err := (&v).UnmarshalBinary(data)
+		err := (&v).UnmarshalBinaryEnv(data, env)
 		return v, err
 	case VMTIME:
 		var v VMTime
@@ -139,6 +146,10 @@ func (x VMBinaryType) ParseBinary(data []byte) (VMValuer, error) {
 		return VMNil, nil
 	case VMNULL:
 		return VMNullVar, nil
+	case VMOBJECT:
+		v := &VMObject{}
+		err := v.UnmarshalBinaryEnv(data, env)
+		return v, err
 	}
 	return nil, VMErrorUnknownType
 }
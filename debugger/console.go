@@ -0,0 +1,354 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const consoleHelp = `Команды отладчика:
+  b [файл:]строка [условие]  точка останова, условие - выражение на языке Гонец
+  d номер                    удалить точку останова
+  bl                         список точек останова
+  c                          продолжить
+  n                          следующая строка без захода в функции
+  s                          следующая строка с заходом в функции
+  o                          до выхода из текущей функции
+  pause                      остановить все потоки
+  bt                         стек вызовов текущего потока
+  f номер                    выбрать кадр стека
+  t [номер]                  список потоков или выбор потока
+  l                          исходный код вокруг текущей строки
+  r                          регистры текущего кадра
+  v                          переменные окружений текущего кадра
+  p выражение                вычислить выражение или исполнить код в текущем кадре
+  q                          прервать программу
+  h                          эта справка`
+
+// Console - отладка из командной строки.
+// Как и в других консольных отладчиках, после команд продолжения исполнения
+// следующая команда читается только после остановки или завершения программы.
+type Console struct {
+	d      *Debugger
+	file   string // файл, в котором по умолчанию ставятся точки останова
+	out    io.Writer
+	mu     sync.Mutex
+	tid    int // текущий поток
+	frame  int // номер текущего кадра, 0 - вершина стека
+	src    map[string][]string
+	stops  chan struct{}
+	exited chan struct{}
+}
+
+// NewConsole создает консоль отладчика с выводом в out, она получает события отладчика.
+// Точки останова без указания файла ставятся в файле file.
+func NewConsole(d *Debugger, file string, out io.Writer) *Console {
+	c := &Console{
+		d:      d,
+		file:   file,
+		out:    out,
+		src:    make(map[string][]string),
+		stops:  make(chan struct{}, 1),
+		exited: make(chan struct{}),
+	}
+	d.Notify = c.event
+	c.printf("Отладчик Гонец. Справка по команде h\n")
+	return c
+}
+
+// Run читает и исполняет команды до окончания ввода, команды q или завершения программы.
+// При окончании ввода отладчик отключается, а программа продолжает исполнение.
+func (c *Console) Run(in io.Reader) {
+	if c.d.StopOnEntry {
+		c.wait()
+	}
+	sc := bufio.NewScanner(in)
+	for !c.done() && sc.Scan() {
+		cmd := strings.TrimSpace(sc.Text())
+		if cmd == "" {
+			continue
+		}
+		if cmd == "q" {
+			c.d.Terminate()
+			return
+		}
+		c.mu.Lock()
+		resumed := c.command(cmd)
+		c.mu.Unlock()
+		if resumed {
+			c.wait()
+		}
+	}
+	if !c.done() {
+		c.d.Detach()
+	}
+}
+
+// wait ждет остановки или завершения программы
+func (c *Console) wait() {
+	select {
+	case <-c.stops:
+	case <-c.exited:
+	}
+}
+
+func (c *Console) done() bool {
+	select {
+	case <-c.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// resume сбрасывает сообщение о предыдущей остановке перед продолжением исполнения
+func (c *Console) resume() {
+	select {
+	case <-c.stops:
+	default:
+	}
+}
+
+func (c *Console) printf(format string, args ...interface{}) {
+	fmt.Fprintf(c.out, format, args...)
+}
+
+func (c *Console) event(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch e.Kind {
+	case EventStopped:
+		c.tid = e.Thread.ID
+		c.frame = 0
+		switch e.Reason {
+		case StopBreakpoint:
+			c.printf("Точка останова %d, поток %d\n", e.Breakpoint.ID, e.Thread.ID)
+		case StopPause:
+			c.printf("Остановлено, поток %d\n", e.Thread.ID)
+		}
+		if fs := e.Thread.Frames(); len(fs) > 0 {
+			c.where(fs[0])
+		}
+		select {
+		case c.stops <- struct{}{}:
+		default:
+		}
+	case EventThreadStarted:
+		if e.Thread.ID > 1 {
+			c.printf("Запущен поток %d: %s\n", e.Thread.ID, e.Thread.Name)
+		}
+	case EventThreadExited:
+		if e.Thread.ID > 1 {
+			c.printf("Завершен поток %d: %s\n", e.Thread.ID, e.Thread.Name)
+		}
+	case EventExited:
+		if e.Err != nil {
+			c.printf("Программа завершена с ошибкой: %s\n", e.Err)
+		} else {
+			c.printf("Программа завершена\n")
+		}
+		close(c.exited)
+	}
+}
+
+// where выводит позицию кадра и строку исходного кода
+func (c *Console) where(f *Frame) {
+	p := f.Pos()
+	c.printf("%s:%d в %s\n", filepath.Base(f.File()), p.Line, f.Func)
+	if s, ok := c.line(f.File(), p.Line); ok {
+		c.printf("%5d\t%s\n", p.Line, s)
+	}
+}
+
+// line возвращает строку исходного кода из файла, прочитанного один раз
+func (c *Console) line(file string, n int) (string, bool) {
+	ls, ok := c.src[file]
+	if !ok && file != "" {
+		if b, err := ioutil.ReadFile(file); err == nil {
+			ls = strings.Split(string(b), "\n")
+		}
+		c.src[file] = ls
+	}
+	if n < 1 || n > len(ls) {
+		return "", false
+	}
+	return strings.TrimRight(ls[n-1], "\r"), true
+}
+
+// current возвращает текущий кадр остановленного потока
+func (c *Console) current() (*Frame, bool) {
+	t := c.d.Thread(c.tid)
+	if t == nil || !t.Stopped() {
+		c.printf("Программа не остановлена\n")
+		return nil, false
+	}
+	fs := t.Frames()
+	if c.frame >= len(fs) {
+		c.frame = 0
+	}
+	return fs[c.frame], true
+}
+
+// command исполняет команду и сообщает, продолжено ли исполнение программы
+func (c *Console) command(cmd string) (resumed bool) {
+	name, arg := cmd, ""
+	if i := strings.IndexAny(cmd, " \t"); i > 0 {
+		name, arg = cmd[:i], strings.TrimSpace(cmd[i:])
+	}
+	var err error
+	switch name {
+	case "h":
+		c.printf("%s\n", consoleHelp)
+	case "b":
+		c.breakpoint(arg)
+	case "d":
+		id, _ := strconv.Atoi(arg)
+		if !c.d.ClearBreakpoint(id) {
+			c.printf("Нет точки останова %q\n", arg)
+		}
+	case "bl":
+		for _, bp := range c.d.Breakpoints() {
+			c.printf("%d\t%s:%d", bp.ID, filepath.Base(bp.File), bp.Line)
+			if bp.Cond != "" {
+				c.printf(" если %s", bp.Cond)
+			}
+			if !bp.Verified {
+				c.printf(" (нет кода на строке)")
+			}
+			c.printf(", срабатываний %d\n", bp.Hits)
+		}
+	case "c":
+		c.resume()
+		c.d.Continue()
+		resumed = true
+	case "n", "s", "o":
+		step := c.d.StepOver
+		if name == "s" {
+			step = c.d.StepInto
+		} else if name == "o" {
+			step = c.d.StepOut
+		}
+		c.resume()
+		err = step(c.tid)
+		resumed = err == nil
+	case "pause":
+		c.d.Pause()
+	case "bt":
+		if t := c.d.Thread(c.tid); t != nil {
+			for i, f := range t.Frames() {
+				mark := " "
+				if i == c.frame {
+					mark = "*"
+				}
+				c.printf("%s%d %s %s:%d\n", mark, i, f.Func, filepath.Base(f.File()), f.Pos().Line)
+			}
+		}
+	case "f":
+		n, _ := strconv.Atoi(arg)
+		if t := c.d.Thread(c.tid); t != nil && n >= 0 && n < len(t.Frames()) {
+			c.frame = n
+			c.where(t.Frames()[n])
+		} else {
+			c.printf("Нет кадра %q\n", arg)
+		}
+	case "t":
+		if arg == "" {
+			for _, t := range c.d.Threads() {
+				mark, state := " ", "исполняется"
+				if t.ID == c.tid {
+					mark = "*"
+				}
+				if t.Stopped() {
+					state = "остановлен"
+				}
+				c.printf("%s%d %s (%s)\n", mark, t.ID, t.Name, state)
+			}
+			break
+		}
+		n, _ := strconv.Atoi(arg)
+		if t := c.d.Thread(n); t != nil {
+			c.tid, c.frame = n, 0
+			if fs := t.Frames(); len(fs) > 0 && t.Stopped() {
+				c.where(fs[0])
+			}
+		} else {
+			c.printf("Нет потока %q\n", arg)
+		}
+	case "l":
+		if f, ok := c.current(); ok {
+			cur := f.Pos().Line
+			for n := cur - 5; n <= cur+5; n++ {
+				if s, ok := c.line(f.File(), n); ok {
+					mark := " "
+					if n == cur {
+						mark = ">"
+					}
+					c.printf("%s%4d\t%s\n", mark, n, s)
+				}
+			}
+		}
+	case "r", "v":
+		if f, ok := c.current(); ok {
+			for _, s := range f.Scopes() {
+				if (s.Env == nil) != (name == "r") {
+					continue
+				}
+				if name == "v" {
+					c.printf("%s:\n", s.Name)
+				}
+				for _, v := range s.Variables() {
+					c.printf("  %s = %s\n", v.Name, v.Value)
+				}
+			}
+		}
+	case "p":
+		if f, ok := c.current(); ok {
+			// во время вычисления поток может остановиться на точке останова и сообщить об этом
+			c.mu.Unlock()
+			v, e := c.d.Evaluate(f, arg)
+			c.mu.Lock()
+			if e != nil {
+				err = e
+			} else {
+				c.printf("%s\n", FormatValue(v))
+			}
+		}
+	default:
+		c.printf("Неизвестная команда %q, справка по команде h\n", name)
+	}
+	if err != nil {
+		c.printf("%s\n", err)
+	}
+	return
+}
+
+// breakpoint ставит точку останова по аргументам команды b
+func (c *Console) breakpoint(arg string) {
+	spec, cond := arg, ""
+	if i := strings.IndexAny(arg, " \t"); i > 0 {
+		spec, cond = arg[:i], strings.TrimSpace(arg[i:])
+	}
+	file := c.file
+	if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+		file, spec = spec[:i], spec[i+1:]
+	}
+	line, err := strconv.Atoi(spec)
+	if err != nil || line < 1 {
+		c.printf("Неверный номер строки %q\n", spec)
+		return
+	}
+	bp, err := c.d.SetBreakpoint(file, line, cond)
+	if err != nil {
+		c.printf("%s\n", err)
+		return
+	}
+	c.printf("Точка останова %d: %s:%d\n", bp.ID, filepath.Base(bp.File), bp.Line)
+	if !bp.Verified {
+		c.printf("На строке %d нет исполняемого кода\n", line)
+	}
+}
@@ -0,0 +1,361 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// dapMessage - запрос, ответ или событие протокола Debug Adapter Protocol
+type dapMessage struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command,omitempty"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	RequestSeq int             `json:"request_seq,omitempty"`
+	Success    *bool           `json:"success,omitempty"` // только в ответах
+	Message    string          `json:"message,omitempty"`
+	Event      string          `json:"event,omitempty"`
+	Body       interface{}     `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type dapBreakpoint struct {
+	ID       int    `json:"id,omitempty"`
+	Verified bool   `json:"verified"`
+	Line     int    `json:"line"`
+	Message  string `json:"message,omitempty"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// dapSession - сеанс отладки с редактором
+type dapSession struct {
+	d     *Debugger
+	r     *textproto.Reader
+	w     io.Writer
+	wmu   sync.Mutex
+	seq   int
+	ready chan<- struct{}
+
+	mu     sync.Mutex
+	frames []*Frame      // кадры, переданные редактору, идентификатор - номер + 1
+	refs   []interface{} // области и значения, элементы которых можно раскрыть
+	once   sync.Once
+}
+
+// ServeDAP обслуживает редактор по протоколу Debug Adapter Protocol через rw.
+// Канал ready закрывается, когда редактор передал точки останова и программу можно запускать.
+// Возвращается после запроса disconnect или закрытия соединения.
+func ServeDAP(d *Debugger, rw io.ReadWriter, ready chan<- struct{}) error {
+	s := &dapSession{
+		d:     d,
+		r:     textproto.NewReader(bufio.NewReader(rw)),
+		w:     rw,
+		ready: ready,
+	}
+	d.Notify = s.event
+	for {
+		msg, err := s.read()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			s.start()
+			d.Terminate()
+			return err
+		}
+		if msg.Type != "request" {
+			continue
+		}
+		body, err := s.handle(msg)
+		ok := err == nil
+		resp := &dapMessage{Type: "response", RequestSeq: msg.Seq, Command: msg.Command, Success: &ok, Body: body}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		if err := s.write(resp); err != nil {
+			return err
+		}
+		switch msg.Command {
+		case "initialize":
+			s.send("initialized", nil)
+		case "disconnect", "terminate":
+			s.start()
+			d.Terminate()
+			if msg.Command == "disconnect" {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *dapSession) read() (*dapMessage, error) {
+	hdr, err := s.r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(hdr.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("Неверный заголовок Content-Length: %q", hdr.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.r.R, body); err != nil {
+		return nil, err
+	}
+	msg := &dapMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *dapSession) write(msg *dapMessage) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.seq++
+	msg.Seq = s.seq
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = s.w.Write(body)
+	return err
+}
+
+func (s *dapSession) send(event string, body interface{}) {
+	s.write(&dapMessage{Type: "event", Event: event, Body: body})
+}
+
+// start разрешает запуск программы, если он еще не разрешен
+func (s *dapSession) start() {
+	s.once.Do(func() { close(s.ready) })
+}
+
+// event передает редактору события отладчика
+func (s *dapSession) event(e Event) {
+	switch e.Kind {
+	case EventStopped:
+		body := map[string]interface{}{
+			"reason":            e.Reason,
+			"threadId":          e.Thread.ID,
+			"allThreadsStopped": true,
+		}
+		if e.Breakpoint != nil {
+			body["hitBreakpointIds"] = []int{e.Breakpoint.ID}
+		}
+		s.send("stopped", body)
+	case EventThreadStarted:
+		s.send("thread", map[string]interface{}{"reason": "started", "threadId": e.Thread.ID})
+	case EventThreadExited:
+		s.send("thread", map[string]interface{}{"reason": "exited", "threadId": e.Thread.ID})
+	case EventExited:
+		code := 0
+		if e.Err != nil {
+			code = 1
+			s.send("output", map[string]interface{}{"category": "stderr", "output": e.Err.Error() + "\n"})
+		}
+		s.send("exited", map[string]interface{}{"exitCode": code})
+		s.send("terminated", nil)
+	}
+}
+
+// resumed сбрасывает идентификаторы кадров и значений, они действительны только до продолжения исполнения
+func (s *dapSession) resumed() {
+	s.mu.Lock()
+	s.frames = nil
+	s.refs = nil
+	s.mu.Unlock()
+}
+
+func (s *dapSession) frame(id int) (*Frame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.frames) {
+		return nil, errors.New("Неизвестный кадр стека")
+	}
+	return s.frames[id-1], nil
+}
+
+// ref возвращает идентификатор для раскрытия области или значения
+func (s *dapSession) ref(v interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs = append(s.refs, v)
+	return len(s.refs)
+}
+
+func (s *dapSession) variables(vars []Variable) []dapVariable {
+	res := make([]dapVariable, 0, len(vars))
+	for _, v := range vars {
+		dv := dapVariable{Name: v.Name, Value: v.Value, Type: v.Type}
+		if v.Expandable() {
+			dv.VariablesReference = s.ref(v)
+		}
+		res = append(res, dv)
+	}
+	return res
+}
+
+func (s *dapSession) handle(msg *dapMessage) (interface{}, error) {
+	var args struct {
+		ThreadID           int    `json:"threadId"`
+		FrameID            int    `json:"frameId"`
+		VariablesReference int    `json:"variablesReference"`
+		Expression         string `json:"expression"`
+		StopOnEntry        bool   `json:"stopOnEntry"`
+		Source             struct {
+			Path string `json:"path"`
+		} `json:"source"`
+		Breakpoints []struct {
+			Line      int    `json:"line"`
+			Condition string `json:"condition"`
+		} `json:"breakpoints"`
+	}
+	if len(msg.Arguments) > 0 {
+		if err := json.Unmarshal(msg.Arguments, &args); err != nil {
+			return nil, err
+		}
+	}
+	d := s.d
+	switch msg.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsConditionalBreakpoints":   true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch", "attach":
+		d.StopOnEntry = args.StopOnEntry
+	case "configurationDone":
+		s.start()
+	case "setBreakpoints":
+		lines := make([]int, len(args.Breakpoints))
+		conds := make([]string, len(args.Breakpoints))
+		for i, b := range args.Breakpoints {
+			lines[i], conds[i] = b.Line, b.Condition
+		}
+		res := []dapBreakpoint{}
+		for _, bp := range d.SetBreakpoints(args.Source.Path, lines, conds) {
+			db := dapBreakpoint{ID: bp.ID, Verified: bp.Verified && bp.ID > 0, Line: bp.Line}
+			if bp.ID == 0 {
+				db.Message = "Ошибка в условии"
+			} else if !bp.Verified {
+				db.Message = "На строке нет исполняемого кода"
+			}
+			res = append(res, db)
+		}
+		return map[string]interface{}{"breakpoints": res}, nil
+	case "setExceptionBreakpoints":
+		return map[string]interface{}{"breakpoints": []dapBreakpoint{}}, nil
+	case "threads":
+		ts := []map[string]interface{}{}
+		for _, t := range d.Threads() {
+			ts = append(ts, map[string]interface{}{"id": t.ID, "name": t.Name})
+		}
+		return map[string]interface{}{"threads": ts}, nil
+	case "stackTrace":
+		t := d.Thread(args.ThreadID)
+		if t == nil {
+			return nil, errors.New("Поток завершен")
+		}
+		fs := []map[string]interface{}{}
+		for _, f := range t.Frames() {
+			s.mu.Lock()
+			s.frames = append(s.frames, f)
+			id := len(s.frames)
+			s.mu.Unlock()
+			p := f.Pos()
+			df := map[string]interface{}{"id": id, "name": f.Func, "line": p.Line, "column": p.Column}
+			if file := f.File(); file != "" {
+				file = absFile(file)
+				df["source"] = dapSource{Name: filepath.Base(file), Path: file}
+			}
+			fs = append(fs, df)
+		}
+		return map[string]interface{}{"stackFrames": fs, "totalFrames": len(fs)}, nil
+	case "scopes":
+		f, err := s.frame(args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		ss := []map[string]interface{}{}
+		for _, sc := range f.Scopes() {
+			ss = append(ss, map[string]interface{}{
+				"name":               sc.Name,
+				"variablesReference": s.ref(sc),
+				"expensive":          sc.Env != nil && sc.Env.Parent() == nil,
+			})
+		}
+		return map[string]interface{}{"scopes": ss}, nil
+	case "variables":
+		s.mu.Lock()
+		var v interface{}
+		if args.VariablesReference > 0 && args.VariablesReference <= len(s.refs) {
+			v = s.refs[args.VariablesReference-1]
+		}
+		s.mu.Unlock()
+		var vars []Variable
+		switch vv := v.(type) {
+		case Scope:
+			vars = vv.Variables()
+		case nil:
+			return nil, errors.New("Неизвестная ссылка на значение")
+		default:
+			vars = Children(vv.(Variable).Val)
+		}
+		return map[string]interface{}{"variables": s.variables(vars)}, nil
+	case "continue":
+		s.resumed()
+		d.Continue()
+		return map[string]interface{}{"allThreadsContinued": true}, nil
+	case "next", "stepIn", "stepOut":
+		s.resumed()
+		step := d.StepOver
+		if msg.Command == "stepIn" {
+			step = d.StepInto
+		} else if msg.Command == "stepOut" {
+			step = d.StepOut
+		}
+		return nil, step(args.ThreadID)
+	case "pause":
+		d.Pause()
+	case "evaluate":
+		f, err := s.frame(args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.Evaluate(f, args.Expression)
+		if err != nil {
+			return nil, err
+		}
+		res := map[string]interface{}{"result": FormatValue(v), "type": TypeName(v), "variablesReference": 0}
+		if vv := variable("", v); vv.Expandable() {
+			res["variablesReference"] = s.ref(vv)
+		}
+		return res, nil
+	case "disconnect", "terminate":
+	default:
+		return nil, fmt.Errorf("Команда %s не поддерживается", msg.Command)
+	}
+	return nil, nil
+}
@@ -0,0 +1,681 @@
+// Package debugger - пошаговый отладчик кода на языке Гонец, исполняемого виртуальной машиной bincode.
+//
+// Отладчик устанавливается в bincode.Debugger и получает управление перед каждой инструкцией.
+// Поддерживаются точки останова по строкам исходного кода, в т.ч. с условием,
+// шаги с заходом в функции, без захода и до выхода из функции, просмотр регистров и окружений,
+// вычисление выражений в кадре стека и список потоков, запущенных через Старт.
+// Управлять отладчиком можно из консоли (NewConsole) или из редактора по протоколу Debug Adapter Protocol (ServeDAP).
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/covrom/gonec/ast"
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
+)
+
+// Причины остановки
+const (
+	StopEntry      = "entry"
+	StopBreakpoint = "breakpoint"
+	StopStep       = "step"
+	StopPause      = "pause"
+)
+
+// Виды событий отладчика
+const (
+	EventStopped = iota
+	EventThreadStarted
+	EventThreadExited
+	EventExited
+)
+
+// Event - событие, о котором отладчик сообщает интерфейсу пользователя
+type Event struct {
+	Kind       int
+	Reason     string      // причина остановки
+	Thread     *Thread     // остановленный, запущенный или завершенный поток
+	Breakpoint *Breakpoint // точка останова, на которой остановлен поток
+	Err        error       // ошибка, с которой завершилась программа
+}
+
+// Breakpoint - точка останова на строке исходного кода
+type Breakpoint struct {
+	ID       int
+	File     string // полный путь к файлу
+	Line     int
+	Cond     string // условие на языке Гонец, при пустом условии остановка происходит всегда
+	Verified bool   // на строке есть исполняемый код
+	Hits     int
+}
+
+type stepMode int
+
+const (
+	stepNone stepMode = iota
+	stepInto
+	stepOver
+	stepOut
+)
+
+// Thread - горутина, исполняющая код на языке Гонец: основной поток, Старт функции или итерация параллельного цикла
+type Thread struct {
+	ID   int
+	Name string
+
+	d          *Debugger
+	frames     []*Frame
+	stopped    bool
+	evaluating bool // поток вычисляет выражение, этот код не отлаживается
+	step       stepMode
+	stepDepth  int
+	cmds       chan func() // команды, исполняемые в горутине остановленного потока, nil - продолжить
+}
+
+// Frame - кадр стека потока: исполняемый код модуля, функции или итерации параллельного цикла
+type Frame struct {
+	Func string
+	Code *binstmt.BinCode
+	Env  *core.Env
+	Regs core.VMSlice
+
+	thread *Thread
+	idx    int
+	line   int
+}
+
+// Pos возвращает позицию текущей инструкции кадра в исходном коде
+func (f *Frame) Pos() pos.Position {
+	return f.Code.PosAt(f.idx)
+}
+
+// File возвращает файл исходного кода кадра
+func (f *Frame) File() string {
+	return f.Code.SourceFile()
+}
+
+// codeInfo - сведения о скомпилированном коде, вычисляемые один раз
+type codeInfo struct {
+	file  string         // полный путь к файлу исходного кода
+	funcs map[int]string // имена функций и циклов по индексу первой инструкции
+}
+
+// Debugger управляет исполнением кода и хранит состояние отладки
+type Debugger struct {
+	// Notify получает события отладчика, вызывается из горутин исполняемой программы
+	Notify func(Event)
+	// StopOnEntry останавливает программу перед первой строкой
+	StopOnEntry bool
+
+	mu         sync.Mutex
+	bps        map[string]map[int]*Breakpoint // по файлу и строке
+	lastBP     int
+	lines      map[string]map[int]bool // строки с исполняемым кодом в загруженных файлах
+	threads    map[int64]*Thread       // по идентификатору горутины
+	lastThread int
+	codes      map[*binstmt.BinCode]*codeInfo
+	root       *core.Env
+	halt       bool // все потоки останавливаются перед ближайшей инструкцией
+	reported   bool // об остановке уже сообщено
+	terminated bool
+	entered    bool
+}
+
+// New создает отладчик, его нужно установить в bincode.Debugger до запуска кода
+func New() *Debugger {
+	return &Debugger{
+		bps:     make(map[string]map[int]*Breakpoint),
+		lines:   make(map[string]map[int]bool),
+		threads: make(map[int64]*Thread),
+		codes:   make(map[*binstmt.BinCode]*codeInfo),
+	}
+}
+
+func (d *Debugger) notify(e Event) {
+	if d.Notify != nil {
+		d.Notify(e)
+	}
+}
+
+// absFile возвращает полный путь к файлу, пустое имя остается пустым
+func absFile(file string) string {
+	if file == "" {
+		return ""
+	}
+	if fn, err := filepath.Abs(file); err == nil {
+		return fn
+	}
+	return filepath.Clean(file)
+}
+
+// Load запоминает строки исходного кода, на которых есть инструкции, для проверки точек останова
+func (d *Debugger) Load(code *binstmt.BinCode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.load(code)
+	for _, fbps := range d.bps {
+		for _, bp := range fbps {
+			bp.Verified = d.verified(bp.File, bp.Line)
+		}
+	}
+}
+
+func (d *Debugger) load(code *binstmt.BinCode) {
+	file := absFile(code.SourceFile())
+	ls := d.lines[file]
+	if ls == nil {
+		ls = make(map[int]bool)
+		d.lines[file] = ls
+	}
+	for i, s := range code.Code {
+		switch ss := s.(type) {
+		case *binstmt.BinLABEL:
+			continue
+		case *binstmt.BinMODULE:
+			d.load(&ss.Code)
+		}
+		if l := code.PosAt(i).Line; l > 0 {
+			ls[l] = true
+		}
+	}
+}
+
+// verified сообщает, есть ли код на строке, для незагруженных файлов это неизвестно заранее
+func (d *Debugger) verified(file string, line int) bool {
+	ls, ok := d.lines[file]
+	return !ok || ls[line]
+}
+
+// SetBreakpoint добавляет точку останова с условием cond, которое может быть пустым
+func (d *Debugger) SetBreakpoint(file string, line int, cond string) (*Breakpoint, error) {
+	if cond != "" {
+		if _, err := compile(cond); err != nil {
+			return nil, fmt.Errorf("Ошибка в условии: %s", err)
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	file = absFile(file)
+	fbps := d.bps[file]
+	if fbps == nil {
+		fbps = make(map[int]*Breakpoint)
+		d.bps[file] = fbps
+	}
+	bp, ok := fbps[line]
+	if !ok {
+		d.lastBP++
+		bp = &Breakpoint{ID: d.lastBP, File: file, Line: line}
+		fbps[line] = bp
+	}
+	bp.Cond = cond
+	bp.Verified = d.verified(file, line)
+	return bp, nil
+}
+
+// SetBreakpoints заменяет все точки останова в файле, строки с ошибкой в условии пропускаются
+func (d *Debugger) SetBreakpoints(file string, lines []int, conds []string) []*Breakpoint {
+	d.mu.Lock()
+	delete(d.bps, absFile(file))
+	d.mu.Unlock()
+	var res []*Breakpoint
+	for i, l := range lines {
+		cond := ""
+		if i < len(conds) {
+			cond = conds[i]
+		}
+		bp, err := d.SetBreakpoint(file, l, cond)
+		if err != nil {
+			bp = &Breakpoint{File: absFile(file), Line: l, Cond: cond}
+		}
+		res = append(res, bp)
+	}
+	return res
+}
+
+// ClearBreakpoint удаляет точку останова по номеру
+func (d *Debugger) ClearBreakpoint(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, fbps := range d.bps {
+		for l, bp := range fbps {
+			if bp.ID == id {
+				delete(fbps, l)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Breakpoints возвращает точки останова по порядку номеров
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var res []*Breakpoint
+	for _, fbps := range d.bps {
+		for _, bp := range fbps {
+			res = append(res, bp)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// goid возвращает идентификатор текущей горутины
+func goid() int64 {
+	var buf [64]byte
+	s := string(buf[:runtime.Stack(buf[:], false)])
+	s = strings.TrimPrefix(s, "goroutine ")
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseInt(s, 10, 64)
+	return id
+}
+
+// info возвращает сведения о коде, имена функций определяются по их первой инструкции
+func (d *Debugger) info(code *binstmt.BinCode) *codeInfo {
+	ci, ok := d.codes[code]
+	if ok {
+		return ci
+	}
+	ci = &codeInfo{file: absFile(code.SourceFile()), funcs: code.Entries()}
+	d.codes[code] = ci
+	return ci
+}
+
+// Enter реализует bincode.DebugHook
+func (d *Debugger) Enter(code *binstmt.BinCode, idx int, env *core.Env, regs core.VMSlice) bincode.DebugFrame {
+	id := goid()
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.root == nil {
+		d.root = env
+	}
+	t, ok := d.threads[id]
+	if ok && t.evaluating {
+		d.mu.Unlock()
+		return nil
+	}
+	ci := d.info(code)
+	name, isfunc := ci.funcs[idx]
+	if !isfunc {
+		mod := env.String()
+		if (mod == "" || mod == "_") && ci.file != "" {
+			mod = filepath.Base(ci.file)
+		}
+		name = "<модуль " + mod + ">"
+	}
+	f := &Frame{Func: name, Code: code, Env: env, Regs: regs, idx: idx}
+	if !ok {
+		d.lastThread++
+		t = &Thread{ID: d.lastThread, Name: "Основной", d: d, cmds: make(chan func(), 1)}
+		if d.lastThread > 1 {
+			t.Name = "Старт " + name
+			if name == "<параллельный цикл>" {
+				t.Name = "Параллельно"
+			}
+		}
+		d.threads[id] = t
+	}
+	f.thread = t
+	t.frames = append(t.frames, f)
+	d.mu.Unlock()
+	if !ok {
+		d.notify(Event{Kind: EventThreadStarted, Thread: t})
+	}
+	return f
+}
+
+// Leave реализует bincode.DebugFrame
+func (f *Frame) Leave() {
+	t := f.thread
+	d := t.d
+	d.mu.Lock()
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		if t.frames[i] == f {
+			t.frames = t.frames[:i]
+			break
+		}
+	}
+	exited := len(t.frames) == 0
+	if exited {
+		for id, tt := range d.threads {
+			if tt == t {
+				delete(d.threads, id)
+			}
+		}
+	}
+	d.mu.Unlock()
+	if exited {
+		d.notify(Event{Kind: EventThreadExited, Thread: t})
+	}
+}
+
+// Step реализует bincode.DebugFrame: решает, нужно ли остановиться перед инструкцией
+func (f *Frame) Step(idx int) {
+	f.idx = idx
+	t := f.thread
+	d := t.d
+	line := f.Code.PosAt(idx).Line
+	if _, ok := f.Code.Code[idx].(*binstmt.BinLABEL); ok {
+		line = 0
+	}
+	newLine := line > 0 && line != f.line
+	if line > 0 {
+		f.line = line
+	}
+
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		return
+	}
+	depth := len(t.frames)
+	reason := ""
+	var bp *Breakpoint
+	switch {
+	case d.halt:
+		reason = StopPause
+	case t.step == stepOut && depth < t.stepDepth:
+		reason = StopStep
+	case !newLine:
+	case !d.entered && d.StopOnEntry:
+		reason = StopEntry
+	case t.step == stepInto, t.step == stepOver && depth <= t.stepDepth:
+		reason = StopStep
+	default:
+		bp = d.bps[d.info(f.Code).file][line]
+	}
+	if newLine {
+		d.entered = true
+	}
+	if bp != nil {
+		cond := bp.Cond
+		d.mu.Unlock()
+		// условие вычисляется в горутине потока, пока блокировка отладчика снята
+		if cond != "" && !f.test(cond) {
+			return
+		}
+		d.mu.Lock()
+		switch {
+		case d.terminated:
+			d.mu.Unlock()
+			return
+		case d.halt:
+			// пока вычислялось условие, остановился другой поток
+			bp = nil
+			reason = StopPause
+		default:
+			bp.Hits++
+			reason = StopBreakpoint
+		}
+	}
+	if reason == "" {
+		d.mu.Unlock()
+		return
+	}
+	f.stop(reason, bp)
+}
+
+// stop останавливает поток и ждет команд, вызывается с установленной блокировкой отладчика
+func (f *Frame) stop(reason string, bp *Breakpoint) {
+	t := f.thread
+	d := t.d
+	t.stopped = true
+	t.step = stepNone
+	d.halt = true
+	report := !d.reported
+	d.reported = true
+	d.mu.Unlock()
+	if report {
+		d.notify(Event{Kind: EventStopped, Reason: reason, Thread: t, Breakpoint: bp})
+	}
+	for cmd := range t.cmds {
+		if cmd == nil {
+			return
+		}
+		cmd()
+	}
+}
+
+// test вычисляет условие точки останова, ошибка в условии считается истиной, чтобы ее можно было увидеть
+func (f *Frame) test(cond string) bool {
+	v, err := f.thread.d.eval(f, cond)
+	if err != nil {
+		return true
+	}
+	b, ok := v.(core.VMBool)
+	return !ok || bool(b)
+}
+
+// resume продолжает все остановленные потоки, вызывается с установленной блокировкой отладчика
+func (d *Debugger) resume() {
+	d.halt = false
+	d.reported = false
+	for _, t := range d.threads {
+		if t.stopped {
+			t.stopped = false
+			t.cmds <- nil
+		}
+	}
+}
+
+// Continue продолжает исполнение всех потоков
+func (d *Debugger) Continue() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resume()
+}
+
+func (d *Debugger) step(tid int, mode stepMode) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.thread(tid)
+	if t == nil || !t.stopped {
+		return errors.New("Поток не остановлен")
+	}
+	t.step = mode
+	t.stepDepth = len(t.frames)
+	d.resume()
+	return nil
+}
+
+// StepOver исполняет поток до следующей строки текущей функции, не останавливаясь внутри вызываемых функций
+func (d *Debugger) StepOver(tid int) error {
+	return d.step(tid, stepOver)
+}
+
+// StepInto исполняет поток до следующей строки, в т.ч. внутри вызываемой функции
+func (d *Debugger) StepInto(tid int) error {
+	return d.step(tid, stepInto)
+}
+
+// StepOut исполняет поток до возврата из текущей функции
+func (d *Debugger) StepOut(tid int) error {
+	return d.step(tid, stepOut)
+}
+
+// Pause останавливает все потоки перед ближайшей инструкцией
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	d.halt = true
+	d.mu.Unlock()
+}
+
+// Terminate прерывает исполнение программы, остановки больше не происходят
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminated = true
+	if d.root != nil {
+		d.root.Interrupt()
+	}
+	d.resume()
+}
+
+// Detach отключает отладчик: потоки продолжают исполнение, остановки больше не происходят
+func (d *Debugger) Detach() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminated = true
+	d.resume()
+}
+
+// Exit сообщает об окончании исполнения программы
+func (d *Debugger) Exit(err error) {
+	d.mu.Lock()
+	d.terminated = true
+	d.mu.Unlock()
+	d.notify(Event{Kind: EventExited, Err: err})
+}
+
+func (d *Debugger) thread(tid int) *Thread {
+	for _, t := range d.threads {
+		if t.ID == tid {
+			return t
+		}
+	}
+	return nil
+}
+
+// Threads возвращает потоки по порядку номеров
+func (d *Debugger) Threads() []*Thread {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := make([]*Thread, 0, len(d.threads))
+	for _, t := range d.threads {
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// Thread возвращает поток по номеру или nil, если он уже завершен
+func (d *Debugger) Thread(tid int) *Thread {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.thread(tid)
+}
+
+// Stopped сообщает, остановлен ли поток
+func (t *Thread) Stopped() bool {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	return t.stopped
+}
+
+// Frames возвращает стек вызовов потока, начиная с текущего кадра
+func (t *Thread) Frames() []*Frame {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	res := make([]*Frame, len(t.frames))
+	for i, f := range t.frames {
+		res[len(t.frames)-1-i] = f
+	}
+	return res
+}
+
+// Evaluate вычисляет выражение или исполняет инструкции в окружении кадра остановленного потока.
+// Код исполняется в горутине потока, результат инструкций без Возврат - Неопределено.
+func (d *Debugger) Evaluate(f *Frame, src string) (core.VMValuer, error) {
+	t := f.thread
+	d.mu.Lock()
+	if !t.stopped {
+		d.mu.Unlock()
+		return nil, errors.New("Поток не остановлен")
+	}
+	d.mu.Unlock()
+	type result struct {
+		v   core.VMValuer
+		err error
+	}
+	res := make(chan result)
+	t.cmds <- func() {
+		v, err := d.eval(f, src)
+		res <- result{v, err}
+	}
+	r := <-res
+	return r.v, r.err
+}
+
+// eval исполняет код в горутине потока кадра f
+func (d *Debugger) eval(f *Frame, src string) (v core.VMValuer, err error) {
+	code, err := compile(src)
+	if err != nil {
+		return nil, err
+	}
+	t := f.thread
+	d.mu.Lock()
+	t.evaluating = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		t.evaluating = false
+		d.mu.Unlock()
+	}()
+	v, err = bincode.RunWorker(code, code.MaxReg+1, f.Env, 0)
+	if err == binstmt.ReturnError {
+		err = nil
+	}
+	if err == nil && v == nil {
+		v = core.VMNil
+	}
+	return v, err
+}
+
+// compile компилирует выражение, а если это не выражение - инструкции, без создания модуля
+func compile(src string) (*binstmt.BinCode, error) {
+	stmts, err := parse("Возврат (" + src + ")\n")
+	if err != nil {
+		if stmts, err = parse(src + "\n"); err != nil {
+			return nil, err
+		}
+	}
+	lid := 0
+	code := parser.ConstFolding(stmts).BinaryCode(0, &lid)
+	return &code, nil
+}
+
+func parse(src string) (stmts ast.Stmts, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				err = e
+			} else {
+				err = errors.New(fmt.Sprint(ex))
+			}
+		}
+	}()
+	scanner := &parser.Scanner{}
+	scanner.Init("Модуль _\n" + src)
+	scanner.SetFirstLine(0)
+	stmts, err = parser.Parse(scanner)
+	if err != nil {
+		return nil, err
+	}
+	// код исполняется в окружении кадра, поэтому берутся инструкции модуля без него самого
+	if len(stmts) != 1 {
+		return nil, errors.New("Объявление модуля недопустимо")
+	}
+	m, ok := stmts[0].(*ast.ModuleStmt)
+	if !ok || names.UniqueNames.Get(m.Name) != "_" {
+		return nil, errors.New("Объявление модуля недопустимо")
+	}
+	return m.Stmts, nil
+}
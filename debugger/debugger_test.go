@@ -0,0 +1,166 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/core"
+)
+
+func TestDebugger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "отладка.gnc")
+	src := `Функция Сумма(а, б)
+	в = а + б
+	Возврат в
+КонецФункции
+итог = 0
+Для к = 1 По 5 Цикл
+	итог = Сумма(итог, к)
+КонецЦикла
+Функция Раб(кан)
+	кан <- итог * 2
+КонецФункции
+кан = Новый Канал(0)
+Старт Раб(кан)
+Сообщить(<-кан)
+`
+	if err := ioutil.WriteFile(fn, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, bins, err := bincode.ParseSrc(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bins.AttachDebugInfo(fn, "")
+
+	d := New()
+	d.StopOnEntry = true
+	d.Load(&bins)
+	bincode.Debugger = d
+	defer func() { bincode.Debugger = nil }()
+
+	cmds := "b 2 а > 5\nb 8\nc\np а * 10\nv\no\nn\nn\ns\nbt\nd 1\nb 10\nc\nt\nc\n"
+	var out, prog bytes.Buffer
+	c := NewConsole(d, fn, &out)
+	done := make(chan struct{})
+	go func() {
+		c.Run(strings.NewReader(cmds))
+		close(done)
+	}()
+	env := core.NewEnv()
+	env.SetStdOut(&prog)
+	_, err = bincode.Run(context.Background(), bins, env)
+	d.Exit(err)
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prog.String() != "30\n" {
+		t.Errorf("вывод программы: %q", prog.String())
+	}
+	res := out.String()
+	for _, s := range []string{
+		"отладка.gnc:1 в <модуль отладка.gnc>",
+		"На строке 8 нет исполняемого кода",
+		"Точка останова 1, поток 1\nотладка.gnc:2 в Сумма\n",
+		"\n60\n",
+		"Локальные:\n  а = 6\n  б = 4\n",
+		"отладка.gnc:7 в <модуль отладка.gnc>",
+		"*0 Сумма отладка.gnc:2\n 1 <модуль отладка.gnc> отладка.gnc:7\n",
+		"Запущен поток 2: Старт Раб",
+		"Точка останова 3, поток 2\nотладка.gnc:10 в Раб\n",
+		" 1 Основной (исполняется)\n*2 Старт Раб (остановлен)\n",
+		"Программа завершена\n",
+	} {
+		if !strings.Contains(res, s) {
+			t.Errorf("нет %q в выводе отладчика:\n%s", s, res)
+		}
+	}
+	if bps := d.Breakpoints(); len(bps) != 2 || bps[0].Verified || bps[1].Line != 10 || bps[1].Hits != 1 {
+		t.Errorf("точки останова: %+v", bps)
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	_, bins, err := bincode.ParseSrc("а = 1\n\nСообщить(а)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bins.AttachDebugInfo("точки.gnc", "")
+	d := New()
+	d.Load(&bins)
+
+	if bp, err := d.SetBreakpoint("точки.gnc", 3, ""); err != nil || !bp.Verified || bp.ID != 1 {
+		t.Errorf("точка на строке с кодом: %+v, %v", bp, err)
+	}
+	if bp, err := d.SetBreakpoint("точки.gnc", 2, ""); err != nil || bp.Verified {
+		t.Errorf("точка на пустой строке: %+v, %v", bp, err)
+	}
+	// в незагруженном файле строки с кодом неизвестны
+	if bp, err := d.SetBreakpoint("другой.gnc", 5, ""); err != nil || !bp.Verified {
+		t.Errorf("точка в другом файле: %+v, %v", bp, err)
+	}
+	if _, err := d.SetBreakpoint("точки.gnc", 1, "а >"); err == nil {
+		t.Error("нет ошибки в условии")
+	}
+	// повторная установка на той же строке меняет условие существующей точки
+	if bp, _ := d.SetBreakpoint("точки.gnc", 3, "а = 1"); bp.ID != 1 || bp.Cond != "а = 1" {
+		t.Errorf("повторная точка: %+v", bp)
+	}
+
+	if !d.ClearBreakpoint(3) || d.ClearBreakpoint(3) {
+		t.Error("удаление точки останова")
+	}
+	bps := d.SetBreakpoints("точки.gnc", []int{1, 3}, []string{"", "а >"})
+	if len(bps) != 2 || bps[0].ID == 0 || bps[1].ID != 0 {
+		t.Errorf("замена точек в файле: %+v", bps)
+	}
+	if all := d.Breakpoints(); len(all) != 1 || all[0].Line != 1 {
+		t.Errorf("точки останова: %+v", all)
+	}
+}
+
+func TestValues(t *testing.T) {
+	cases := []struct {
+		v          core.VMValuer
+		value, typ string
+	}{
+		{nil, "<нет значения>", ""},
+		{core.VMInt(5), "5", "ЦелоеЧисло"},
+		{core.VMString("а\"б"), `"а\"б"`, "Строка"},
+		{core.VMBool(true), "true", "Булево"},
+		{core.VMSlice{core.VMInt(1)}, "[1]", "Массив"},
+		{core.VMNil, "Неопределено", "Неопределено"},
+		{core.VMString(strings.Repeat("ю", 300)), `"` + strings.Repeat("ю", 300) + `"`, "Строка"},
+	}
+	for _, c := range cases {
+		if got := FormatValue(c.v); got != c.value {
+			t.Errorf("FormatValue(%#v) = %q, ожидалось %q", c.v, got, c.value)
+		}
+		if got := TypeName(c.v); got != c.typ {
+			t.Errorf("TypeName(%#v) = %q, ожидалось %q", c.v, got, c.typ)
+		}
+	}
+	if s := FormatValue(core.VMSlice{core.VMString(strings.Repeat("ю", 300))}); len([]rune(s)) != 203 {
+		t.Errorf("длинное значение не сокращено: %d символов", len([]rune(s)))
+	}
+
+	kids := Children(core.VMStringMap{"б": core.VMInt(2), "а": core.VMSlice{}})
+	if len(kids) != 2 || kids[0].Name != "а" || kids[0].Expandable() || kids[1].Value != "2" {
+		t.Errorf("элементы структуры: %+v", kids)
+	}
+	if kids := Children(core.VMSlice{core.VMInt(1), core.VMInt(2)}); len(kids) != 2 || kids[1].Name != "[1]" {
+		t.Errorf("элементы массива: %+v", kids)
+	}
+}
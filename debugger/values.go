@@ -0,0 +1,166 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+)
+
+// Variable - значение для просмотра в отладчике
+type Variable struct {
+	Name  string
+	Value string
+	Type  string
+	Val   core.VMValuer
+}
+
+// Expandable сообщает, есть ли у значения элементы, которые можно просмотреть
+func (v Variable) Expandable() bool {
+	switch vv := v.Val.(type) {
+	case core.VMSlice:
+		return len(vv) > 0
+	case core.VMStringMap:
+		return len(vv) > 0
+	case *core.Env:
+		return true
+	}
+	return false
+}
+
+// Scope - группа переменных кадра: регистры виртуальной машины или одно из окружений цепочки
+type Scope struct {
+	Name string
+	Env  *core.Env // nil для регистров
+	Regs core.VMSlice
+}
+
+// Scopes возвращает окружения кадра от локального до глобального и регистры
+func (f *Frame) Scopes() []Scope {
+	var res []Scope
+	for e := f.Env; e != nil; e = e.Parent() {
+		name := "Окружение"
+		switch {
+		case e.Parent() == nil:
+			name = "Глобальные"
+		case e.String() != "":
+			name = "Модуль " + e.String()
+		case e == f.Env:
+			name = "Локальные"
+		}
+		res = append(res, Scope{Name: name, Env: e})
+	}
+	return append(res, Scope{Name: "Регистры", Regs: f.Regs})
+}
+
+// Variables возвращает переменные области, в глобальном окружении встроенные функции не показываются
+func (s Scope) Variables() []Variable {
+	var res []Variable
+	if s.Env == nil {
+		for i, v := range s.Regs {
+			if v != nil {
+				res = append(res, variable("r"+strconv.Itoa(i), v))
+			}
+		}
+		return res
+	}
+	builtins := make(map[string]bool)
+	if s.Env.Parent() == nil && binstmt.BuiltinNames != nil {
+		for _, n := range binstmt.BuiltinNames() {
+			builtins[n] = true
+		}
+	}
+	for n, v := range s.Env.Locals() {
+		if !builtins[names.FastToLower(n)] {
+			res = append(res, variable(n, v))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Children возвращает элементы массива, структуры или переменные модуля
+func Children(v core.VMValuer) []Variable {
+	var res []Variable
+	switch vv := v.(type) {
+	case core.VMSlice:
+		for i, e := range vv {
+			res = append(res, variable("["+strconv.Itoa(i)+"]", e))
+		}
+	case core.VMStringMap:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			res = append(res, variable(k, vv[k]))
+		}
+	case *core.Env:
+		res = Scope{Env: vv}.Variables()
+	}
+	return res
+}
+
+func variable(name string, v core.VMValuer) Variable {
+	return Variable{Name: name, Value: FormatValue(v), Type: TypeName(v), Val: v}
+}
+
+// FormatValue возвращает представление значения, строки выводятся в кавычках
+func FormatValue(v core.VMValuer) string {
+	switch vv := v.(type) {
+	case nil:
+		return "<нет значения>"
+	case core.VMString:
+		return strconv.Quote(string(vv))
+	case core.VMFunc:
+		return "Функция"
+	case *core.Env:
+		return "Модуль " + vv.String()
+	}
+	s := fmt.Sprint(v)
+	if len([]rune(s)) > 200 {
+		s = string([]rune(s)[:200]) + "..."
+	}
+	return s
+}
+
+// TypeName возвращает имя типа значения на языке Гонец
+func TypeName(v core.VMValuer) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case core.VMInt:
+		return "ЦелоеЧисло"
+	case core.VMDecNum:
+		return "Число"
+	case core.VMString:
+		return "Строка"
+	case core.VMBool:
+		return "Булево"
+	case core.VMSlice:
+		return "Массив"
+	case core.VMStringMap:
+		return "Структура"
+	case core.VMTime:
+		return "Дата"
+	case core.VMTimeDuration:
+		return "Длительность"
+	case core.VMFunc:
+		return "Функция"
+	case core.VMChan:
+		return "Канал"
+	case *core.Env:
+		return "Модуль"
+	case core.VMNullType:
+		return "NULL"
+	case core.VMNilType:
+		return "Неопределено"
+	}
+	return strings.TrimPrefix(reflect.TypeOf(v).String(), "*core.")
+}
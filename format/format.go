@@ -0,0 +1,817 @@
+// Package format печатает исходный код на языке Гонец в каноническом виде:
+// с единообразными отступами, написанием ключевых слов, пробелами вокруг операций и строковыми литералами.
+// Комментарии и одиночные пустые строки между инструкциями сохраняются.
+package format
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/covrom/gonec/ast"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
+)
+
+// Options - настройки форматирования
+type Options struct {
+	Lowercase bool   // ключевые слова в нижнем регистре: если … тогда … конецесли
+	English   bool   // ключевые слова на английском: If … Then … EndIf
+	Indent    string // отступ одного уровня вложенности, по умолчанию табуляция
+}
+
+// Source форматирует исходный код, при синтаксической ошибке возвращается *parser.Error
+func Source(src string, opt Options) (string, error) {
+	scanner := &parser.Scanner{KeepLayout: true}
+	stmts, err := parse(scanner, src)
+	if err != nil {
+		return "", err
+	}
+	if opt.Indent == "" {
+		opt.Indent = "\t"
+	}
+	p := &printer{
+		opt:      opt,
+		lits:     scanner.Literals,
+		spell:    spellings(scanner.Literals),
+		comments: scanner.Comments,
+	}
+	for i, st := range stmts {
+		m, ok := st.(*ast.ModuleStmt)
+		if !ok {
+			p.stmt(st)
+			continue
+		}
+		// первый модуль "_" добавляется перед кодом при разборе, его заголовок не печатается
+		if i > 0 || p.name(m.Name) != "_" {
+			p.item(m.Position().Line)
+			p.write(p.kw("Модуль") + " " + p.name(m.Name))
+			p.done(m.Position().Line)
+		}
+		for _, s := range m.Stmts {
+			p.stmt(s)
+		}
+	}
+	p.flush(-1)
+	res := strings.TrimLeft(p.buf.String(), "\n")
+	if res == "" {
+		return "", nil
+	}
+	return res + "\n", nil
+}
+
+func parse(scanner *parser.Scanner, src string) (stmts ast.Stmts, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				err = e
+			} else {
+				err = errors.New(fmt.Sprint(ex))
+			}
+		}
+	}()
+	// так же, как при компиляции, код без заголовка модуля относится к модулю "_"
+	scanner.Init("Модуль _\n" + src)
+	scanner.SetFirstLine(0)
+	return parser.Parse(scanner)
+}
+
+type printer struct {
+	opt      Options
+	lits     map[pos.Position]string
+	spell    map[string]string // первое написание имени в исходном коде по имени в нижнем регистре
+	comments []parser.Comment  // еще не напечатанные комментарии
+
+	buf    strings.Builder
+	indent int
+	nl     int  // число переводов строки, которые нужно вывести перед следующим текстом
+	last   int  // последняя строка исходного кода, уже выведенная при печати
+	opened bool // начат новый блок, пустая строка перед первой инструкцией не нужна
+}
+
+// write выводит текст, предварительно выводя отложенные переводы строки и отступ
+func (p *printer) write(s string) {
+	if p.nl > 0 {
+		p.buf.WriteString(strings.Repeat("\n", p.nl))
+		p.buf.WriteString(strings.Repeat(p.opt.Indent, p.indent))
+		p.nl = 0
+	}
+	p.buf.WriteString(s)
+}
+
+// newline завершает строку, blank добавляет после нее пустую строку
+func (p *printer) newline(blank bool) {
+	if blank {
+		p.nl = 2
+	} else if p.nl == 0 {
+		p.nl = 1
+	}
+}
+
+// item готовит печать элемента, который начинается в строке line исходного кода:
+// выводит предшествующие комментарии и сохраняет пустую строку перед элементом
+func (p *printer) item(line int) {
+	p.flush(line)
+	if !p.opened && p.last > 0 && line > p.last+1 {
+		p.newline(true)
+	}
+	p.opened = false
+}
+
+// done отмечает, что напечатан элемент, заканчивающийся в строке line исходного кода
+func (p *printer) done(line int) {
+	if line > p.last {
+		p.last = line
+	}
+	p.newline(false)
+}
+
+// flush выводит комментарии, расположенные до строки line, при line < 0 - все оставшиеся
+func (p *printer) flush(line int) {
+	for len(p.comments) > 0 && (line < 0 || p.comments[0].Pos.Line < line) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		text := strings.TrimRightFunc(c.Text, unicode.IsSpace)
+		if c.Pos.Line == p.last && p.nl > 0 && p.buf.Len() > 0 {
+			// комментарий в конце строки с кодом
+			p.buf.WriteString(" " + text)
+			continue
+		}
+		if !p.opened && p.last > 0 && c.Pos.Line > p.last+1 {
+			p.newline(true)
+		}
+		p.opened = false
+		p.write(text)
+		p.done(c.Pos.Line)
+	}
+}
+
+// block печатает инструкции блока с отступом, end - строка, с которой начинается следующая часть конструкции
+func (p *printer) block(stmts ast.Stmts, end int) {
+	p.indent++
+	p.opened = true
+	for _, s := range stmts {
+		p.stmt(s)
+	}
+	if end > 0 {
+		p.flush(end)
+	}
+	p.indent--
+	p.opened = false
+}
+
+// closing печатает ключевое слово, завершающее или продолжающее конструкцию в строке line
+func (p *printer) closing(kw string, line int) {
+	p.write(p.kw(kw))
+	p.done(line)
+}
+
+// kw возвращает ключевое слово в выбранном написании
+func (p *printer) kw(s string) string {
+	if p.opt.English {
+		colon := strings.HasSuffix(s, ":")
+		words := strings.Fields(strings.TrimSuffix(s, ":"))
+		for i, w := range words {
+			if t, ok := names.Translate(w, true); ok {
+				words[i] = t
+			}
+		}
+		s = strings.Join(words, " ")
+		if colon {
+			s += ":"
+		}
+	}
+	if p.opt.Lowercase {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// spellings возвращает написание имен, первое по порядку в исходном коде, по имени в нижнем регистре.
+// Общий справочник имен хранит написание, встреченное первым в любом разобранном коде, поэтому
+// имена, позиция которых в дереве разбора не сохраняется, берутся отсюда.
+func spellings(lits map[pos.Position]string) map[string]string {
+	at := make([]pos.Position, 0, len(lits))
+	for ps, lit := range lits {
+		if !strings.HasPrefix(lit, "`") && !strings.HasPrefix(lit, `"`) {
+			at = append(at, ps)
+		}
+	}
+	sort.Slice(at, func(i, j int) bool {
+		if at[i].Line != at[j].Line {
+			return at[i].Line < at[j].Line
+		}
+		return at[i].Column < at[j].Column
+	})
+	res := make(map[string]string)
+	for _, ps := range at {
+		lw := names.FastToLower(lits[ps])
+		if _, ok := res[lw]; !ok {
+			res[lw] = lits[ps]
+		}
+	}
+	return res
+}
+
+// name возвращает имя так, как оно впервые написано в исходном коде
+func (p *printer) name(id int) string {
+	if s, ok := p.spell[names.UniqueNames.GetLowerCase(id)]; ok {
+		return s
+	}
+	return names.UniqueNames.Get(id)
+}
+
+// ident возвращает имя так, как оно написано в исходном коде в позиции at, если оно там есть
+func (p *printer) ident(id int, at pos.Position) string {
+	if lit, ok := p.lits[at]; ok && names.FastToLower(lit) == names.UniqueNames.GetLowerCase(id) {
+		return lit
+	}
+	return p.name(id)
+}
+
+func firstLine(stmts ast.Stmts, next int) int {
+	if len(stmts) > 0 {
+		if l := stmtPos(stmts[0]).Line; l > 0 {
+			return l
+		}
+	}
+	return next
+}
+
+// stmtPos возвращает позицию начала инструкции, у присваивания ее нет, она берется из левой части
+func stmtPos(st ast.Stmt) pos.Position {
+	if s, ok := st.(*ast.LetsStmt); ok && len(s.Lhss) > 0 {
+		return s.Lhss[0].Position()
+	}
+	return st.Position()
+}
+
+func (p *printer) stmt(st ast.Stmt) {
+	start := stmtPos(st).Line
+	p.item(start)
+	switch s := st.(type) {
+	case *ast.ExprStmt:
+		// равенство на уровне инструкции - это присваивание
+		if b, ok := s.Expr.(*ast.BinOpExpr); ok && b.Operator == "==" {
+			p.exprs(b.Lhss)
+			p.write(" = ")
+			p.exprs(b.Rhss)
+		} else {
+			p.expr(s.Expr)
+		}
+		p.done(stmtEnd(st))
+	case *ast.LetsStmt:
+		p.exprs(s.Lhss)
+		p.write(" = ")
+		p.exprs(s.Rhss)
+		p.done(stmtEnd(st))
+	case *ast.VarStmt:
+		for i, id := range s.Names {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(p.name(id))
+		}
+		if len(s.Exprs) > 0 {
+			p.write(" = ")
+			p.exprs(s.Exprs)
+		}
+		p.done(stmtEnd(st))
+	case *ast.BreakStmt:
+		p.closing("Прервать", start)
+	case *ast.ContinueStmt:
+		p.closing("Продолжить", start)
+	case *ast.ReturnStmt:
+		p.write(p.kw("Возврат"))
+		if len(s.Exprs) > 0 {
+			p.write(" ")
+			p.exprs(s.Exprs)
+		}
+		p.done(stmtEnd(st))
+	case *ast.ThrowStmt:
+		p.write(p.kw("ВызватьИсключение") + " ")
+		p.expr(s.Expr)
+		p.done(stmtEnd(st))
+	case *ast.DeferStmt:
+		p.write(p.kw("Отложить") + " ")
+		p.expr(s.Expr)
+		p.done(stmtEnd(st))
+	case *ast.IfStmt:
+		p.ifStmt(s, "Если")
+	case *ast.TryStmt:
+		p.closing("Попытка", start)
+		next := s.End.Line
+		if s.FinallyPos.Line > 0 {
+			next = s.FinallyPos.Line
+		}
+		if s.CatchPos.Line > 0 {
+			p.block(s.Try, s.CatchPos.Line)
+			p.write(p.kw("Исключение"))
+			for i, k := range s.CatchKinds {
+				if i > 0 {
+					p.write(",")
+				}
+				p.write(" " + quote(k))
+			}
+			p.done(s.CatchPos.Line)
+			p.block(s.Catch, next)
+		} else {
+			p.block(s.Try, next)
+		}
+		if s.FinallyPos.Line > 0 {
+			p.closing("Окончательно", s.FinallyPos.Line)
+			p.block(s.Finally, s.End.Line)
+		}
+		p.closing("КонецПопытки", s.End.Line)
+	case *ast.ForStmt:
+		p.write(p.kw("Для Каждого") + " ")
+		if s.Key != 0 {
+			p.write(p.name(s.Key) + ", ")
+		}
+		p.write(p.name(s.Var) + " " + p.kw("Из") + " ")
+		p.expr(s.Value)
+		if s.Parallel {
+			p.write(" " + p.kw("Параллельно"))
+			if s.Workers != nil {
+				p.write(" ")
+				p.expr(s.Workers)
+			}
+		}
+		p.write(" " + p.kw("Цикл"))
+		p.done(start)
+		p.block(s.Stmts, s.End.Line)
+		p.closing("КонецЦикла", s.End.Line)
+	case *ast.NumForStmt:
+		p.write(p.kw("Для") + " " + p.name(s.Name) + " = ")
+		p.expr(s.Expr1)
+		p.write(" " + p.kw("По") + " ")
+		p.expr(s.Expr2)
+		if s.Step != nil {
+			p.write(" " + p.kw("Шаг") + " ")
+			p.expr(s.Step)
+		}
+		p.write(" " + p.kw("Цикл"))
+		p.done(start)
+		p.block(s.Stmts, s.End.Line)
+		p.closing("КонецЦикла", s.End.Line)
+	case *ast.LoopStmt:
+		p.write(p.kw("Пока") + " ")
+		p.expr(s.Expr)
+		p.write(" " + p.kw("Цикл"))
+		p.done(start)
+		p.block(s.Stmts, s.End.Line)
+		p.closing("КонецЦикла", s.End.Line)
+	case *ast.SwitchStmt:
+		p.write(p.kw("Выбор") + " ")
+		p.expr(s.Expr)
+		p.write(":")
+		p.done(start)
+		p.cases(s.Cases, s.End.Line)
+		p.closing("КонецВыбора", s.End.Line)
+	case *ast.SelectStmt:
+		p.write(p.kw("Выбор") + ":")
+		p.done(start)
+		p.cases(s.Cases, s.End.Line)
+		p.closing("КонецВыбора", s.End.Line)
+	case *ast.ModuleStmt:
+		p.write(p.kw("Модуль") + " " + p.name(s.Name))
+		p.done(start)
+		for _, ss := range s.Stmts {
+			p.stmt(ss)
+		}
+	case *ast.TypeStmt:
+		p.write(p.kw("Тип") + " " + p.name(s.Name))
+		p.done(start)
+		p.block(s.Members, s.End.Line)
+		p.closing("КонецТипа", s.End.Line)
+	case *ast.FieldStmt:
+		p.write(p.kw("Поле") + " ")
+		for i, id := range s.Names {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(p.name(id))
+		}
+		if s.Value != nil {
+			p.write(" = ")
+			p.expr(s.Value)
+		}
+		p.done(stmtEnd(st))
+	case *ast.MethodStmt:
+		p.function(s.Func, "Метод", "КонецМетода")
+		p.done(s.Func.End.Line)
+	}
+}
+
+func (p *printer) ifStmt(s *ast.IfStmt, kw string) {
+	p.write(p.kw(kw) + " ")
+	p.expr(s.If)
+	p.write(" " + p.kw("Тогда"))
+	p.done(s.If.Position().Line)
+
+	next := s.End.Line
+	if s.ElsePos.Line > 0 {
+		next = s.ElsePos.Line
+	}
+	for i := len(s.ElseIf) - 1; i >= 0; i-- {
+		s.ElseIf[i].(*ast.IfStmt).End.Line = next
+		next = s.ElseIf[i].Position().Line
+	}
+	p.block(s.Then, next)
+	for _, ei := range s.ElseIf {
+		p.item(ei.Position().Line)
+		e := ei.(*ast.IfStmt)
+		p.write(p.kw("ИначеЕсли") + " ")
+		p.expr(e.If)
+		p.write(" " + p.kw("Тогда"))
+		p.done(e.If.Position().Line)
+		p.block(e.Then, e.End.Line)
+	}
+	if s.ElsePos.Line > 0 {
+		p.closing("Иначе", s.ElsePos.Line)
+		p.block(s.Else, s.End.Line)
+	}
+	p.closing("КонецЕсли", s.End.Line)
+}
+
+func (p *printer) cases(cases ast.Stmts, end int) {
+	p.indent++
+	p.opened = true
+	for i, c := range cases {
+		next := end
+		if i+1 < len(cases) {
+			next = cases[i+1].Position().Line
+		}
+		p.item(c.Position().Line)
+		switch cs := c.(type) {
+		case *ast.CaseStmt:
+			p.write(p.kw("Когда") + " ")
+			p.expr(cs.Expr)
+			p.write(":")
+			p.done(c.Position().Line)
+			p.block(cs.Stmts, next)
+		case *ast.DefaultStmt:
+			p.closing("Другое:", c.Position().Line)
+			p.block(cs.Stmts, next)
+		}
+	}
+	p.flush(end)
+	p.indent--
+	p.opened = false
+}
+
+func (p *printer) exprs(es []ast.Expr) {
+	for i, e := range es {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expr(e)
+	}
+}
+
+// операции, которые печатаются иначе, чем хранятся в дереве
+var opNames = map[string]string{
+	"==": "=",
+	"!=": "<>",
+	"||": "Или",
+	"&&": "И",
+}
+
+var constNames = map[string]string{
+	"истина":       "Истина",
+	"ложь":         "Ложь",
+	"неопределено": "Неопределено",
+	"null":         "NULL",
+}
+
+func (p *printer) call(name string, args []ast.Expr, vararg, goroutine bool) {
+	if goroutine {
+		p.write(p.kw("Старт") + " ")
+	}
+	p.write(name + "(")
+	p.exprs(args)
+	if vararg {
+		p.write("...")
+	}
+	p.write(")")
+}
+
+func (p *printer) expr(e ast.Expr) {
+	switch ee := e.(type) {
+	case nil, *ast.NoneExpr:
+	case *ast.IdentExpr:
+		p.write(ee.Lit)
+	case *ast.NumberExpr:
+		p.write(ee.Lit)
+	case *ast.StringExpr:
+		if lit := p.lits[ee.Position()]; strings.HasPrefix(lit, "`") && !strings.Contains(ee.Lit, "`") {
+			// строки в обратных кавычках остаются как есть, в них удобно писать многострочный текст
+			p.write(lit)
+		} else {
+			p.write(quote(ee.Lit))
+		}
+	case *ast.TemplateExpr:
+		p.write(`"`)
+		for _, pe := range ee.Parts {
+			if se, ok := pe.(*ast.StringExpr); ok {
+				p.write(escape(se.Lit))
+				continue
+			}
+			p.write("${")
+			p.expr(pe)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ast.ConstExpr:
+		p.write(p.kw(constNames[ee.Value]))
+	case *ast.ArrayExpr:
+		p.write("[")
+		p.exprs(ee.Exprs)
+		p.write("]")
+	case *ast.MapExpr:
+		keys := ee.Keys
+		if keys == nil {
+			for k := range ee.MapExpr {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+		}
+		p.write("{")
+		for i, k := range keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(quote(k) + ": ")
+			p.expr(ee.MapExpr[k])
+		}
+		p.write("}")
+	case *ast.UnaryExpr:
+		if ee.Operator == "!" {
+			p.write(p.kw("Не") + " ")
+		} else {
+			p.write(ee.Operator)
+		}
+		p.expr(ee.Expr)
+	case *ast.ParenExpr:
+		p.write("(")
+		p.expr(ee.SubExpr)
+		p.write(")")
+	case *ast.BinOpExpr:
+		op := ee.Operator
+		if o, ok := opNames[op]; ok {
+			op = p.kw(o)
+		}
+		p.exprs(ee.Lhss)
+		p.write(" " + op + " ")
+		p.exprs(ee.Rhss)
+	case *ast.TernaryOpExpr:
+		p.write("?(")
+		p.exprs([]ast.Expr{ee.Expr, ee.Lhs, ee.Rhs})
+		p.write(")")
+	case *ast.CallExpr:
+		p.call(p.ident(ee.Name, ee.Position()), ee.SubExprs, ee.VarArg, ee.Go)
+	case *ast.AnonCallExpr:
+		if ee.Go {
+			p.write(p.kw("Старт") + " ")
+		}
+		p.expr(ee.Expr)
+		p.call("", ee.SubExprs, ee.VarArg, false)
+	case *ast.MemberExpr:
+		p.expr(ee.Expr)
+		p.write("." + p.name(ee.Name))
+	case *ast.ItemExpr:
+		p.expr(ee.Value)
+		p.write("[")
+		p.expr(ee.Index)
+		p.write("]")
+	case *ast.SliceExpr:
+		p.expr(ee.Value)
+		p.write("[")
+		p.expr(ee.Begin)
+		p.write(":")
+		p.expr(ee.End)
+		p.write("]")
+	case *ast.FuncExpr:
+		p.function(ee, "Функция", "КонецФункции")
+	case *ast.LetExpr:
+		p.expr(ee.Lhs)
+		p.write(" = ")
+		p.expr(ee.Rhs)
+	case *ast.AssocExpr:
+		p.expr(ee.Lhs)
+		if ee.Rhs == nil {
+			p.write(ee.Operator)
+		} else {
+			p.write(" " + ee.Operator + " ")
+			p.expr(ee.Rhs)
+		}
+	case *ast.ChanExpr:
+		if ee.Lhs != nil {
+			p.expr(ee.Lhs)
+			p.write(" <- ")
+		} else {
+			p.write("<-")
+		}
+		p.expr(ee.Rhs)
+	case *ast.TypeCast:
+		if ee.TypeExpr != nil {
+			p.write(p.kw("Новый") + "(")
+			p.exprs([]ast.Expr{ee.TypeExpr, ee.CastExpr})
+			p.write(")")
+			break
+		}
+		name, ok := p.lits[ee.Position()]
+		if !ok {
+			name = p.name(ee.Type)
+		}
+		p.call(name, []ast.Expr{ee.CastExpr}, false, false)
+	case *ast.MakeExpr:
+		p.write(p.kw("Новый"))
+		if ee.TypeExpr != nil {
+			p.write("(")
+			p.expr(ee.TypeExpr)
+			p.write(")")
+		} else {
+			p.write(" " + p.name(ee.Type))
+			if len(ee.Args) > 0 {
+				p.call("", ee.Args, false, false)
+			}
+		}
+	case *ast.MakeChanExpr:
+		p.write(p.kw("Новый Канал"))
+		if _, ok := ee.SizeExpr.(*ast.NoneExpr); !ok && ee.SizeExpr != nil {
+			p.write("(")
+			p.expr(ee.SizeExpr)
+			p.write(")")
+		}
+	case *ast.MakeArrayExpr:
+		p.write("[](")
+		p.expr(ee.LenExpr)
+		if ee.CapExpr != nil {
+			p.write(", ")
+			p.expr(ee.CapExpr)
+		}
+		p.write(")")
+	default:
+		panic(fmt.Sprintf("Форматирование выражения %T не поддерживается", e))
+	}
+}
+
+// function печатает функцию или метод типа, начинающиеся ключевым словом kw и заканчивающиеся словом end
+func (p *printer) function(f *ast.FuncExpr, kw, end string) {
+	p.write(p.kw(kw))
+	if n := p.name(f.Name); n != "<анонимная функция>" {
+		p.write(" " + n)
+	}
+	p.write("(")
+	for i, a := range f.Args {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(p.name(a))
+	}
+	if f.VarArg {
+		p.write("...")
+	}
+	p.write(")")
+	p.done(f.Position().Line)
+	p.block(f.Stmts, f.End.Line)
+	p.write(p.kw(end))
+	if f.End.Line > p.last {
+		p.last = f.End.Line
+	}
+}
+
+// quote возвращает строковый литерал в двойных кавычках
+func quote(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+// escape экранирует символы строки для записи в двойных кавычках, в том числе начало подстановки ${
+func escape(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		switch r {
+		case '$':
+			if i+1 < len(rs) && rs[i+1] == '{' {
+				b.WriteString(`\$`)
+			} else {
+				b.WriteRune(r)
+			}
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stmtEnd возвращает последнюю строку исходного кода, которую занимает простая инструкция
+func stmtEnd(st ast.Stmt) int {
+	l := stmtPos(st).Line
+	var es []ast.Expr
+	switch s := st.(type) {
+	case *ast.ExprStmt:
+		es = []ast.Expr{s.Expr}
+	case *ast.LetsStmt:
+		es = append(append(es, s.Lhss...), s.Rhss...)
+	case *ast.VarStmt:
+		es = s.Exprs
+	case *ast.ReturnStmt:
+		es = s.Exprs
+	case *ast.ThrowStmt:
+		es = []ast.Expr{s.Expr}
+	case *ast.DeferStmt:
+		es = []ast.Expr{s.Expr}
+	case *ast.FieldStmt:
+		es = []ast.Expr{s.Value}
+	}
+	for _, e := range es {
+		if el := exprEnd(e); el > l {
+			l = el
+		}
+	}
+	return l
+}
+
+// exprEnd возвращает наибольший известный номер строки внутри выражения
+func exprEnd(e ast.Expr) int {
+	if e == nil {
+		return 0
+	}
+	l := e.Position().Line
+	max := func(es ...ast.Expr) {
+		for _, e := range es {
+			if el := exprEnd(e); el > l {
+				l = el
+			}
+		}
+	}
+	switch ee := e.(type) {
+	case *ast.ArrayExpr:
+		max(ee.Exprs...)
+	case *ast.TemplateExpr:
+		max(ee.Parts...)
+	case *ast.MapExpr:
+		for _, v := range ee.MapExpr {
+			max(v)
+		}
+	case *ast.UnaryExpr:
+		max(ee.Expr)
+	case *ast.ParenExpr:
+		max(ee.SubExpr)
+	case *ast.BinOpExpr:
+		max(ee.Lhss...)
+		max(ee.Rhss...)
+	case *ast.TernaryOpExpr:
+		max(ee.Expr, ee.Lhs, ee.Rhs)
+	case *ast.CallExpr:
+		max(ee.SubExprs...)
+	case *ast.AnonCallExpr:
+		max(ee.Expr)
+		max(ee.SubExprs...)
+	case *ast.MemberExpr:
+		max(ee.Expr)
+	case *ast.ItemExpr:
+		max(ee.Value, ee.Index)
+	case *ast.SliceExpr:
+		max(ee.Value, ee.Begin, ee.End)
+	case *ast.FuncExpr:
+		if ee.End.Line > l {
+			l = ee.End.Line
+		}
+	case *ast.LetExpr:
+		max(ee.Lhs, ee.Rhs)
+	case *ast.AssocExpr:
+		max(ee.Lhs, ee.Rhs)
+	case *ast.ChanExpr:
+		max(ee.Lhs, ee.Rhs)
+	case *ast.TypeCast:
+		max(ee.TypeExpr, ee.CastExpr)
+	case *ast.MakeExpr:
+		max(ee.TypeExpr)
+		max(ee.Args...)
+	case *ast.MakeChanExpr:
+		max(ee.SizeExpr)
+	case *ast.MakeArrayExpr:
+		max(ee.LenExpr, ee.CapExpr)
+	}
+	return l
+}
@@ -0,0 +1,132 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/covrom/gonec/names"
+)
+
+func TestFormat(t *testing.T) {
+	src := `# расчет суммы
+
+
+функция Сумма(м)   // хвост
+  р=0
+  для каждого x из м цикл р+=x конеццикла
+  возврат р
+конецфункции
+к = {"б":1,"а":[1,2,3]}
+если к["б"]!=1 или не истина тогда
+ сообщить('нет')
+иначе
+  // сообщение
+  сообщить("да\t", ?(к["б"]=1, Сумма(к["а"]), 0))
+конецесли
+`
+	exp := `# расчет суммы
+
+Функция Сумма(м) // хвост
+	р = 0
+	Для Каждого x Из м Цикл
+		р += x
+	КонецЦикла
+	Возврат р
+КонецФункции
+к = {"б": 1, "а": [1, 2, 3]}
+Если к["б"] <> 1 Или Не Истина Тогда
+	сообщить("нет")
+Иначе
+	// сообщение
+	сообщить("да\t", ?(к["б"] = 1, Сумма(к["а"]), 0))
+КонецЕсли
+`
+	got, err := Source(src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != exp {
+		t.Fatalf("получено:\n%s\nожидалось:\n%s", got, exp)
+	}
+	// повторное форматирование ничего не меняет
+	if again, err := Source(got, Options{}); err != nil || again != got {
+		t.Fatalf("форматирование не идемпотентно: %v\n%s", err, again)
+	}
+	low, err := Source(src, Options{Lowercase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(low, "\tдля каждого x из м цикл\n") || !strings.Contains(low, "\nконецесли\n") {
+		t.Errorf("ключевые слова не в нижнем регистре:\n%s", low)
+	}
+	if _, err := Source("Если Тогда", Options{}); err == nil {
+		t.Error("нет ошибки синтаксиса")
+	}
+}
+
+func TestSourceCases(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		opt  Options
+		exp  string
+	}{
+		{"операции", "а=1+2*3", Options{}, "а = 1 + 2 * 3\n"},
+		{"унарный минус", "а=-б", Options{}, "а = -б\n"},
+		{"ИначеЕсли", "если а тогда б=1 иначеесли в тогда б=2 иначе б=3 конецесли", Options{},
+			"Если а Тогда\n\tб = 1\nИначеЕсли в Тогда\n\tб = 2\nИначе\n\tб = 3\nКонецЕсли\n"},
+		{"Пока", "пока а<10 цикл а+=1 конеццикла", Options{}, "Пока а < 10 Цикл\n\tа += 1\nКонецЦикла\n"},
+		{"Для с шагом", "для н=1 по 10 шаг 2 цикл прервать конеццикла", Options{},
+			"Для н = 1 По 10 Шаг 2 Цикл\n\tПрервать\nКонецЦикла\n"},
+		{"Попытка", "попытка а=1 исключение сообщить(описаниеошибки()) конецпопытки", Options{},
+			"Попытка\n\tа = 1\nИсключение\n\tсообщить(описаниеошибки())\nКонецПопытки\n"},
+		{"одинарные кавычки", "а = 'строка'", Options{}, "а = \"строка\"\n"},
+		{"обратные кавычки сохраняются", "а = `сырая \\n`", Options{}, "а = `сырая \\n`\n"},
+		{"подстановка", "а = \"${б}\"", Options{}, "а = \"${б}\"\n"},
+		{"пустые строки", "а=1\n\n\n\nб=2", Options{}, "а = 1\n\nб = 2\n"},
+		{"комментарий в конце строки", "а=1 // комментарий", Options{}, "а = 1 // комментарий\n"},
+		{"отступ", "функция ф(а,б) возврат а+б конецфункции", Options{Indent: "  "},
+			"Функция ф(а, б)\n  Возврат а + б\nКонецФункции\n"},
+		{"английские ключевые слова", "если а тогда б=1 конецесли", Options{English: true}, "If а Then\n\tб = 1\nEndIf\n"},
+		{"нижний регистр", "если а тогда б=1 конецесли", Options{Lowercase: true}, "если а тогда\n\tб = 1\nконецесли\n"},
+		{"срез", "а=[1,2][0:1]", Options{}, "а = [1, 2][0:1]\n"},
+		{"Старт", "старт ф(1)", Options{}, "Старт ф(1)\n"},
+		{"пустой код", "", Options{}, ""},
+	}
+	for _, c := range cases {
+		got, err := Source(c.src, c.opt)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.exp {
+			t.Errorf("%s: получено %q, ожидалось %q", c.name, got, c.exp)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"текст":         `"текст"`,
+		"кав\"ычка":     `"кав\"ычка"`,
+		"строка\nтаб\t": `"строка\nтаб\t"`,
+		"${имя}":        `"\${имя}"`,
+		"$ и {":         `"$ и {"`,
+	}
+	for s, exp := range cases {
+		if got := quote(s); got != exp {
+			t.Errorf("quote(%q) = %s, ожидалось %s", s, got, exp)
+		}
+	}
+}
+
+func TestNameSpelling(t *testing.T) {
+	// справочник имен общий для всего разобранного кода, но написание имен берется из форматируемого
+	names.UniqueNames.Set("тестовыйметод")
+	names.UniqueNames.Set("тестовыйтип")
+	src := "Тип ТестовыйТип\n\tМетод ТестовыйМетод(х)\n\t\tВозврат х\n\tКонецМетода\nКонецТипа\nт = Новый ТестовыйТип\nт.тестовыйметод(1)\n"
+	exp := "Тип ТестовыйТип\n\tМетод ТестовыйМетод(х)\n\t\tВозврат х\n\tКонецМетода\nКонецТипа\nт = Новый ТестовыйТип\nт.ТестовыйМетод(1)\n"
+	if got, err := Source(src, Options{}); err != nil || got != exp {
+		t.Errorf("получено %q, %v, ожидалось %q", got, err, exp)
+	}
+}
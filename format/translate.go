@@ -0,0 +1,73 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+)
+
+// Translate переводит ключевые слова, типы, встроенные функции и методы в исходном коде на английский язык, если en,
+// иначе на русский. Остальной текст, включая строки, комментарии и отступы, не изменяется.
+// Слова переводятся независимо от того, что они обозначают, поэтому переменные и ключи структур,
+// названные так же, как слова языка, тоже переводятся.
+func Translate(src string, en bool) (string, error) {
+	text := []rune(src)
+	// смещения начал строк, позиции сканера считаются в символах от начала строки
+	lines := []int{0}
+	for i, r := range text {
+		if r == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	scanner := &parser.Scanner{}
+	scanner.Init(src)
+	var lexemes []parser.Lexeme
+	for {
+		tok, lit, at, err := scanner.Scan()
+		if err != nil {
+			return "", err
+		}
+		if tok == parser.EOF {
+			break
+		}
+		lexemes = appendLexemes(lexemes, parser.Lexeme{Tok: tok, Lit: lit, Pos: at, Parts: scanner.Template()})
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, lx := range lexemes {
+		lit, at := lx.Lit, lx.Pos
+		if at.Line < 1 || at.Line > len(lines) {
+			continue
+		}
+		// слово должно быть в исходном коде на своем месте, так отсекаются строки и имена типов,
+		// которые сканер вставляет после ключевых слов приведения типа
+		off := lines[at.Line-1] + at.Column - 1
+		word := []rune(lit)
+		if len(word) == 0 || off < last || off+len(word) > len(text) || string(text[off:off+len(word)]) != lit {
+			continue
+		}
+		if t, ok := names.Translate(lit, en); ok {
+			b.WriteString(string(text[last:off]))
+			b.WriteString(t)
+			last = off + len(word)
+		}
+	}
+	b.WriteString(string(text[last:]))
+	return b.String(), nil
+}
+
+// appendLexemes добавляет лексему, а для строки с подстановками - лексемы ее подстановок в порядке их следования в коде
+func appendLexemes(lexemes []parser.Lexeme, lx parser.Lexeme) []parser.Lexeme {
+	if lx.Tok != parser.TEMPLATE {
+		return append(lexemes, lx)
+	}
+	for _, p := range lx.Parts {
+		for _, t := range p.Tokens {
+			lexemes = appendLexemes(lexemes, t)
+		}
+	}
+	return lexemes
+}
@@ -0,0 +1,478 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/covrom/gonec/ast"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
+)
+
+// funcDef - функция, объявленная в документе
+type funcDef struct {
+	name  string
+	args  []string
+	start pos.Position // ключевое слово Функция
+	end   pos.Position // КонецФункции
+	kids  []*funcDef
+}
+
+func (f *funcDef) signature() string {
+	return "Функция " + f.name + "(" + strings.Join(f.args, ", ") + ")"
+}
+
+// varDef - переменная модуля, место первого присваивания
+type varDef struct {
+	name string
+	at   pos.Position
+}
+
+// importRef - вызов Импорт("путь") с позицией строки пути
+type importRef struct {
+	path string
+	at   pos.Position
+	vr   string // переменная в нижнем регистре, которой присвоен результат импорта
+}
+
+type moduleDef struct {
+	name  string
+	at    pos.Position
+	funcs []*funcDef
+	vars  []varDef
+}
+
+// document - открытый в редакторе исходный код и результат его последнего успешного разбора
+type document struct {
+	uri     string
+	path    string
+	text    string
+	lines   [][]rune
+	err     error // ошибка разбора текущего текста
+	modules []*moduleDef
+	imports []importRef
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, path: uriToPath(uri)}
+	d.update(text)
+	return d
+}
+
+// update разбирает новый текст, при синтаксической ошибке символы остаются от предыдущего разбора
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = nil
+	for _, l := range strings.Split(text, "\n") {
+		d.lines = append(d.lines, []rune(strings.TrimSuffix(l, "\r")))
+	}
+	stmts, err := parse(text)
+	d.err = err
+	if err != nil {
+		return
+	}
+	d.modules = nil
+	d.imports = nil
+	for _, st := range stmts {
+		if m, ok := st.(*ast.ModuleStmt); ok {
+			md := &moduleDef{name: names.UniqueNames.Get(m.Name), at: m.Position()}
+			d.modules = append(d.modules, md)
+			w := &walker{doc: d, mod: md}
+			w.stmts(m.Stmts)
+		}
+	}
+}
+
+func parse(src string) (stmts ast.Stmts, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			if e, ok := ex.(error); ok {
+				err = e
+			} else {
+				err = errors.New(fmt.Sprint(ex))
+			}
+		}
+	}()
+	scanner := &parser.Scanner{}
+	scanner.Init("Модуль _\n" + src)
+	scanner.SetFirstLine(0)
+	return parser.Parse(scanner)
+}
+
+// allFuncs возвращает все функции документа, включая вложенные
+func (d *document) allFuncs() []*funcDef {
+	var res []*funcDef
+	var add func(fs []*funcDef)
+	add = func(fs []*funcDef) {
+		for _, f := range fs {
+			res = append(res, f)
+			add(f.kids)
+		}
+	}
+	for _, m := range d.modules {
+		add(m.funcs)
+	}
+	return res
+}
+
+// findFunc ищет объявление функции по имени без учета регистра
+func (d *document) findFunc(name string) *funcDef {
+	for _, f := range d.allFuncs() {
+		if strings.EqualFold(f.name, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// importOf возвращает импорт, результат которого присвоен переменной name
+func (d *document) importOf(name string) *importRef {
+	for i := range d.imports {
+		if d.imports[i].vr != "" && strings.EqualFold(d.imports[i].vr, name) {
+			return &d.imports[i]
+		}
+	}
+	return nil
+}
+
+// toLSP переводит позицию парсера (строки и символы с единицы) в позицию протокола
+func (d *document) toLSP(p pos.Position) Position {
+	line, col := p.Line-1, p.Column-1
+	if line < 0 {
+		line = 0
+	}
+	if line >= len(d.lines) {
+		return Position{Line: line, Character: 0}
+	}
+	r := d.lines[line]
+	if col > len(r) {
+		col = len(r)
+	}
+	if col < 0 {
+		col = 0
+	}
+	return Position{Line: line, Character: len(utf16.Encode(r[:col]))}
+}
+
+// rangeOf возвращает диапазон слова длиной n символов, начинающегося в позиции p
+func (d *document) rangeOf(p pos.Position, n int) Range {
+	return Range{Start: d.toLSP(p), End: d.toLSP(pos.Position{Line: p.Line, Column: p.Column + n})}
+}
+
+// wordRange возвращает диапазон слова, которое начинается в позиции p, или одного символа
+func (d *document) wordRange(p pos.Position) Range {
+	n := 0
+	if p.Line >= 1 && p.Line <= len(d.lines) && p.Column >= 1 {
+		line := d.lines[p.Line-1]
+		for i := p.Column - 1; i < len(line) && isWordRune(line[i]); i++ {
+			n++
+		}
+	}
+	if n == 0 {
+		n = 1
+	}
+	return d.rangeOf(p, n)
+}
+
+// nameRange находит имя в строке объявления после позиции p, иначе возвращает диапазон позиции p
+func (d *document) nameRange(p pos.Position, name string) Range {
+	if p.Line >= 1 && p.Line <= len(d.lines) && p.Column >= 1 {
+		line := []rune(strings.ToLower(string(d.lines[p.Line-1])))
+		low := []rune(strings.ToLower(name))
+		for i := p.Column - 1; i+len(low) <= len(line); i++ {
+			if string(line[i:i+len(low)]) == string(low) {
+				return d.rangeOf(pos.Position{Line: p.Line, Column: i + 1}, len(low))
+			}
+		}
+	}
+	return d.rangeOf(p, 0)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// cursor - слово под курсором и то, что записано перед ним
+type cursor struct {
+	word      string
+	start     pos.Position // начало слова
+	qualifier string       // слово перед точкой, если слово - это поле или метод
+	member    bool         // перед словом стоит точка
+	prev      string       // предыдущее слово, если перед словом только пробелы
+	line      []rune
+	col       int // индекс символа курсора в строке
+}
+
+// at возвращает слово под курсором, при prefix - только его часть до курсора, как при автодополнении
+func (d *document) at(p Position, prefix bool) cursor {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return cursor{}
+	}
+	line := d.lines[p.Line]
+	// перевод из единиц UTF-16 в индекс символа
+	col, n := 0, 0
+	for col < len(line) && n < p.Character {
+		n += len(utf16.Encode([]rune{line[col]}))
+		col++
+	}
+	b, e := col, col
+	for b > 0 && isWordRune(line[b-1]) {
+		b--
+	}
+	if !prefix {
+		for e < len(line) && isWordRune(line[e]) {
+			e++
+		}
+	}
+	c := cursor{word: string(line[b:e]), start: pos.Position{Line: p.Line + 1, Column: b + 1}, line: line, col: col}
+	if b > 0 && line[b-1] == '.' {
+		c.member = true
+		q := b - 1
+		for q > 0 && isWordRune(line[q-1]) {
+			q--
+		}
+		c.qualifier = string(line[q : b-1])
+	} else {
+		q := b
+		for q > 0 && unicode.IsSpace(line[q-1]) {
+			q--
+		}
+		pe := q
+		for q > 0 && isWordRune(line[q-1]) {
+			q--
+		}
+		c.prev = string(line[q:pe])
+	}
+	return c
+}
+
+// inString возвращает строку в кавычках, внутри которой стоит курсор
+func (c cursor) inString() (string, bool) {
+	var quote rune
+	start := 0
+	for i := 0; i < c.col && i < len(c.line); i++ {
+		r := c.line[i]
+		switch {
+		case quote != 0 && r == '\\' && quote != '`':
+			i++
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\'' || r == '`'):
+			quote, start = r, i+1
+		case quote == 0 && r == '/' && i+1 < len(c.line) && c.line[i+1] == '/', quote == 0 && r == '#':
+			return "", false
+		}
+	}
+	if quote == 0 {
+		return "", false
+	}
+	end := c.col
+	for end < len(c.line) && c.line[end] != quote {
+		end++
+	}
+	return string(c.line[start:end]), true
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// walker собирает функции, переменные модуля и импорты
+type walker struct {
+	doc   *document
+	mod   *moduleDef
+	fn    *funcDef // текущая функция, nil на уровне модуля
+	known map[string]bool
+}
+
+func (w *walker) stmts(stmts ast.Stmts) {
+	for _, st := range stmts {
+		w.stmt(st)
+	}
+}
+
+// assign обрабатывает присваивание: переменные модуля и результат импорта
+func (w *walker) assign(lhss, rhss []ast.Expr) {
+	for i, l := range lhss {
+		id, ok := l.(*ast.IdentExpr)
+		if !ok {
+			w.expr(l)
+			if i < len(rhss) {
+				w.expr(rhss[i])
+			}
+			continue
+		}
+		low := strings.ToLower(id.Lit)
+		if i < len(rhss) {
+			n := len(w.doc.imports)
+			w.expr(rhss[i])
+			if c, ok := rhss[i].(*ast.CallExpr); ok && names.UniqueNames.GetLowerCase(c.Name) == "импорт" && len(w.doc.imports) > n {
+				w.doc.imports[n].vr = low
+			}
+		}
+		if w.fn == nil && !w.known[low] {
+			if w.known == nil {
+				w.known = make(map[string]bool)
+			}
+			w.known[low] = true
+			w.mod.vars = append(w.mod.vars, varDef{name: id.Lit, at: id.Position()})
+		}
+	}
+	for i := len(lhss); i < len(rhss); i++ {
+		w.expr(rhss[i])
+	}
+}
+
+func (w *walker) stmt(st ast.Stmt) {
+	switch s := st.(type) {
+	case *ast.ExprStmt:
+		if b, ok := s.Expr.(*ast.BinOpExpr); ok && b.Operator == "==" {
+			w.assign(b.Lhss, b.Rhss)
+		} else {
+			w.expr(s.Expr)
+		}
+	case *ast.LetsStmt:
+		w.assign(s.Lhss, s.Rhss)
+	case *ast.VarStmt:
+		w.exprs(s.Exprs)
+	case *ast.ReturnStmt:
+		w.exprs(s.Exprs)
+	case *ast.ThrowStmt:
+		w.expr(s.Expr)
+	case *ast.DeferStmt:
+		w.expr(s.Expr)
+	case *ast.IfStmt:
+		w.expr(s.If)
+		w.stmts(s.Then)
+		w.stmts(s.ElseIf)
+		w.stmts(s.Else)
+	case *ast.TryStmt:
+		w.stmts(s.Try)
+		w.stmts(s.Catch)
+		w.stmts(s.Finally)
+	case *ast.ForStmt:
+		w.expr(s.Value)
+		w.stmts(s.Stmts)
+	case *ast.NumForStmt:
+		w.exprs([]ast.Expr{s.Expr1, s.Expr2, s.Step})
+		w.stmts(s.Stmts)
+	case *ast.LoopStmt:
+		w.expr(s.Expr)
+		w.stmts(s.Stmts)
+	case *ast.SwitchStmt:
+		w.expr(s.Expr)
+		w.stmts(s.Cases)
+	case *ast.SelectStmt:
+		w.stmts(s.Cases)
+	case *ast.CaseStmt:
+		w.expr(s.Expr)
+		w.stmts(s.Stmts)
+	case *ast.DefaultStmt:
+		w.stmts(s.Stmts)
+	case *ast.TypeStmt:
+		tname := names.UniqueNames.Get(s.Name)
+		for _, m := range s.Members {
+			switch mm := m.(type) {
+			case *ast.FieldStmt:
+				w.expr(mm.Value)
+			case *ast.MethodStmt:
+				// метод показывается как функция с именем Тип.Метод
+				w.function(tname+"."+names.UniqueNames.Get(mm.Func.Name), mm.Func)
+			}
+		}
+	}
+}
+
+// function добавляет объявление функции и обходит ее тело
+func (w *walker) function(name string, ee *ast.FuncExpr) {
+	f := &funcDef{name: name, start: ee.Position(), end: ee.End}
+	for _, a := range ee.Args {
+		f.args = append(f.args, names.UniqueNames.Get(a))
+	}
+	if ee.VarArg && len(f.args) > 0 {
+		f.args[len(f.args)-1] += "..."
+	}
+	if w.fn != nil {
+		w.fn.kids = append(w.fn.kids, f)
+	} else {
+		w.mod.funcs = append(w.mod.funcs, f)
+	}
+	outer := w.fn
+	w.fn = f
+	w.stmts(ee.Stmts)
+	w.fn = outer
+}
+
+func (w *walker) exprs(es []ast.Expr) {
+	for _, e := range es {
+		w.expr(e)
+	}
+}
+
+func (w *walker) expr(e ast.Expr) {
+	switch ee := e.(type) {
+	case *ast.FuncExpr:
+		name := names.UniqueNames.Get(ee.Name)
+		if name == "<анонимная функция>" {
+			w.stmts(ee.Stmts)
+			return
+		}
+		w.function(name, ee)
+	case *ast.MakeExpr:
+		w.expr(ee.TypeExpr)
+		w.exprs(ee.Args)
+	case *ast.CallExpr:
+		if names.UniqueNames.GetLowerCase(ee.Name) == "импорт" && len(ee.SubExprs) == 1 {
+			if s, ok := ee.SubExprs[0].(*ast.StringExpr); ok {
+				w.doc.imports = append(w.doc.imports, importRef{path: s.Lit, at: s.Position()})
+			}
+		}
+		w.exprs(ee.SubExprs)
+	case *ast.AnonCallExpr:
+		w.expr(ee.Expr)
+		w.exprs(ee.SubExprs)
+	case *ast.ArrayExpr:
+		w.exprs(ee.Exprs)
+	case *ast.TemplateExpr:
+		w.exprs(ee.Parts)
+	case *ast.MapExpr:
+		for _, v := range ee.MapExpr {
+			w.expr(v)
+		}
+	case *ast.UnaryExpr:
+		w.expr(ee.Expr)
+	case *ast.ParenExpr:
+		w.expr(ee.SubExpr)
+	case *ast.BinOpExpr:
+		w.exprs(ee.Lhss)
+		w.exprs(ee.Rhss)
+	case *ast.TernaryOpExpr:
+		w.exprs([]ast.Expr{ee.Expr, ee.Lhs, ee.Rhs})
+	case *ast.MemberExpr:
+		w.expr(ee.Expr)
+	case *ast.ItemExpr:
+		w.exprs([]ast.Expr{ee.Value, ee.Index})
+	case *ast.SliceExpr:
+		w.exprs([]ast.Expr{ee.Value, ee.Begin, ee.End})
+	case *ast.AssocExpr:
+		w.exprs([]ast.Expr{ee.Lhs, ee.Rhs})
+	case *ast.ChanExpr:
+		w.exprs([]ast.Expr{ee.Lhs, ee.Rhs})
+	case *ast.TypeCast:
+		w.exprs([]ast.Expr{ee.TypeExpr, ee.CastExpr})
+	}
+}
@@ -0,0 +1,76 @@
+package lsp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/covrom/gonec/pos"
+)
+
+func TestDocumentSymbols(t *testing.T) {
+	d := newDocument("file:///тест.gnc", "м = Импорт(\"мат\")\nФункция Внешняя(а, б)\n\tФункция Внутр()\n\tКонецФункции\nКонецФункции\nх = 1\n")
+	if d.err != nil {
+		t.Fatal(d.err)
+	}
+	f := d.findFunc("ВНУТР")
+	if f == nil || f.signature() != "Функция Внутр()" {
+		t.Errorf("вложенная функция: %+v", f)
+	}
+	if f := d.findFunc("внешняя"); f == nil || f.signature() != "Функция Внешняя(а, б)" || len(f.kids) != 1 {
+		t.Errorf("функция: %+v", f)
+	}
+	if imp := d.importOf("М"); imp == nil || imp.path != "мат" {
+		t.Errorf("импорт: %+v", imp)
+	}
+
+	// при синтаксической ошибке символы остаются от предыдущего разбора
+	d.update("Функция Внешняя(\n")
+	if d.err == nil || d.findFunc("внутр") == nil {
+		t.Errorf("ошибка %v, функция %v", d.err, d.findFunc("внутр"))
+	}
+}
+
+func TestDocumentPositions(t *testing.T) {
+	d := newDocument("file:///тест.gnc", "а = \"😀\" + бв\r\nм.Сорт(")
+	// символ вне основной плоскости занимает две единицы UTF-16
+	if p := d.toLSP(pos.Position{Line: 1, Column: 11}); p != (Position{Line: 0, Character: 11}) {
+		t.Errorf("позиция после эмодзи: %+v", p)
+	}
+	if p := d.toLSP(pos.Position{Line: 1, Column: 100}); p != (Position{Line: 0, Character: 13}) {
+		t.Errorf("позиция за концом строки: %+v", p)
+	}
+	if r := d.wordRange(pos.Position{Line: 1, Column: 11}); r.End.Character-r.Start.Character != 2 {
+		t.Errorf("диапазон слова: %+v", r)
+	}
+
+	c := d.at(Position{Line: 0, Character: 12}, false)
+	if c.word != "бв" || c.member || c.prev != "" {
+		t.Errorf("слово под курсором: %+v", c)
+	}
+	if c = d.at(Position{Line: 0, Character: 12}, true); c.word != "б" {
+		t.Errorf("начало слова: %+v", c)
+	}
+	if c = d.at(Position{Line: 1, Character: 4}, false); c.word != "Сорт" || !c.member || c.qualifier != "м" {
+		t.Errorf("метод: %+v", c)
+	}
+	if s, ok := d.at(Position{Line: 0, Character: 6}, false).inString(); !ok || s != "😀" {
+		t.Errorf("строка под курсором: %q, %v", s, ok)
+	}
+	if _, ok := d.at(Position{Line: 0, Character: 12}, false).inString(); ok {
+		t.Error("слово вне строки считается строкой")
+	}
+}
+
+func TestURI(t *testing.T) {
+	path := filepath.Join(string(filepath.Separator)+"каталог", "файл с пробелом.gnc")
+	uri := pathToURI(path)
+	if uri != "file:///%D0%BA%D0%B0%D1%82%D0%B0%D0%BB%D0%BE%D0%B3/%D1%84%D0%B0%D0%B9%D0%BB%20%D1%81%20%D0%BF%D1%80%D0%BE%D0%B1%D0%B5%D0%BB%D0%BE%D0%BC.gnc" {
+		t.Errorf("uri: %s", uri)
+	}
+	if got := uriToPath(uri); got != path {
+		t.Errorf("путь: %s", got)
+	}
+	if got := uriToPath("untitled:Новый"); got != "" {
+		t.Errorf("путь для схемы untitled: %q", got)
+	}
+}
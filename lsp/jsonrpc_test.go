@@ -0,0 +1,57 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConnRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	c := newConn(&buf, &buf)
+	id := json.RawMessage(`7`)
+	if err := c.reply(&id, map[string]int{"а": 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.reply(&id, nil, &rpcError{Code: codeMethodNotFound, Message: "нет метода"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.reply(&id, nil, errors.New("сбой")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.notify("window/logMessage", map[string]string{"message": "текст"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := c.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.JSONRPC != "2.0" || string(*m.ID) != "7" || string(m.Result) != `{"а":1}` || m.Error != nil {
+		t.Errorf("ответ: %+v", m)
+	}
+	if m, err = c.read(); err != nil || m.Error == nil || m.Error.Code != codeMethodNotFound {
+		t.Errorf("ошибка метода: %+v, %v", m, err)
+	}
+	if m, err = c.read(); err != nil || m.Error == nil || m.Error.Code != codeInternalError || m.Error.Message != "сбой" {
+		t.Errorf("внутренняя ошибка: %+v, %v", m, err)
+	}
+	if m, err = c.read(); err != nil || m.ID != nil || m.Method != "window/logMessage" {
+		t.Errorf("уведомление: %+v, %v", m, err)
+	}
+}
+
+func TestConnReadErrors(t *testing.T) {
+	c := newConn(strings.NewReader("Content-Length: х\r\n\r\n{}"), nil)
+	if _, err := c.read(); err == nil || !strings.Contains(err.Error(), "Content-Length") {
+		t.Errorf("неверный заголовок: %v", err)
+	}
+	c = newConn(strings.NewReader("Content-Length: 3\r\n\r\n{x}"), nil)
+	if _, err := c.read(); err == nil {
+		t.Error("нет ошибки разбора")
+	} else if e, ok := err.(*rpcError); !ok || e.Code != codeParseError {
+		t.Errorf("ошибка разбора: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/names"
+	"github.com/covrom/gonec/parser"
+)
+
+// entry - имя стандартной библиотеки: функция, тип или метод типа
+type entry struct {
+	Name   string
+	Params int // -1, если число параметров не проверяется
+	Type   string
+}
+
+// signature возвращает описание функции или метода для подсказки
+func (e entry) signature() string {
+	s := e.Name
+	if e.Type != "" {
+		s = e.Type + "." + e.Name
+	}
+	if e.Params < 0 {
+		return s + "(...)"
+	}
+	args := make([]string, e.Params)
+	for i := range args {
+		args[i] = fmt.Sprintf("п%d", i+1)
+	}
+	return s + "(" + strings.Join(args, ", ") + ")"
+}
+
+type library struct {
+	keywords []string
+	kwset    map[string]bool
+	funcs    map[string]entry   // по имени в нижнем регистре
+	types    map[string]string  // имя в нижнем регистре -> написание в коде
+	methods  map[string][]entry // по имени метода в нижнем регистре, методы с одним именем есть у разных типов
+}
+
+var (
+	libOnce sync.Once
+	lib     *library
+)
+
+// написание составных ключевых слов, остальные пишутся с заглавной буквы
+var keywordSpelling = map[string]string{
+	"вызватьисключение": "ВызватьИсключение",
+	"иначеесли":         "ИначеЕсли",
+	"конеццикла":        "КонецЦикла",
+	"конецесли":         "КонецЕсли",
+	"конецфункции":      "КонецФункции",
+	"конецпопытки":      "КонецПопытки",
+	"конецвыбора":       "КонецВыбора",
+	"null":              "NULL",
+}
+
+// spell возвращает каноническое написание ключевого слова или имени типа
+func spell(s string) string {
+	if sp, ok := keywordSpelling[s]; ok {
+		return sp
+	}
+	if ru, ok := names.Translate(s, false); ok {
+		// английский синоним
+		en, _ := names.Translate(ru, true)
+		return en
+	}
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
+
+// stdLibrary собирает сведения о ключевых словах и стандартной библиотеке глобального контекста
+func stdLibrary() *library {
+	libOnce.Do(func() {
+		env := core.NewEnv()
+		core.LoadAllBuiltins(env)
+		lib = &library{
+			kwset:   make(map[string]bool),
+			funcs:   make(map[string]entry),
+			types:   make(map[string]string),
+			methods: make(map[string][]entry),
+		}
+		for _, kw := range parser.Keywords() {
+			lib.keywords = append(lib.keywords, spell(kw))
+			lib.kwset[kw] = true
+		}
+		for _, n := range env.Names() {
+			v, _ := env.Get(names.UniqueNames.Set(n))
+			if f, ok := v.(core.VMFunc); ok {
+				np, ok := core.FuncParamsCount(f)
+				if !ok {
+					np = -1
+				}
+				lib.funcs[n] = entry{Name: n, Params: np}
+			}
+		}
+		for _, n := range []string{"загрузитьивыполнить", "выполнить", "вычислить", "описаниеошибки", "информацияобошибке"} {
+			lib.funcs[n] = entry{Name: n, Params: -1}
+		}
+		for _, n := range env.TypeNames() {
+			t, err := env.Type(names.UniqueNames.Set(n))
+			if err != nil || strings.HasPrefix(n, "__") {
+				continue
+			}
+			tn := spell(n)
+			lib.types[n] = tn
+			for _, m := range typeMethods(tn, t) {
+				lm := strings.ToLower(m.Name)
+				lib.methods[lm] = append(lib.methods[lm], m)
+			}
+		}
+		// английские синонимы имен стандартной библиотеки
+		for _, a := range names.Aliases {
+			ru, en := strings.ToLower(a.Ru), strings.ToLower(a.En)
+			if f, ok := lib.funcs[ru]; ok {
+				f.Name = en
+				lib.funcs[en] = f
+			}
+			if _, ok := lib.types[ru]; ok {
+				lib.types[en] = a.En
+			}
+			for _, m := range lib.methods[ru] {
+				m.Name = a.En
+				lib.methods[en] = append(lib.methods[en], m)
+			}
+		}
+	})
+	return lib
+}
+
+func (l *library) isKeyword(s string) bool {
+	return l.kwset[s]
+}
+
+// funcList возвращает имена встроенных функций по алфавиту
+func (l *library) funcList() []string {
+	res := make([]string, 0, len(l.funcs))
+	for n := range l.funcs {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// typeList возвращает имена типов по алфавиту
+func (l *library) typeList() []string {
+	res := make([]string, 0, len(l.types))
+	for _, t := range l.types {
+		res = append(res, t)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// typeMethods возвращает методы типа, доступные из кода на языке Гонец.
+// Методы объявлены в Го с именами на кириллице, а доступны через MethodMember или VMGetMethod,
+// поэтому каждый найденный метод проверяется вызовом этих функций у нового значения типа.
+func typeMethods(tn string, t reflect.Type) (res []entry) {
+	defer func() {
+		// тип, который не удается создать без параметров, показывается без методов
+		if recover() != nil {
+			res = nil
+		}
+	}()
+	var v reflect.Value
+	switch t.Kind() {
+	case reflect.Map:
+		v = reflect.MakeMap(t)
+	case reflect.Struct:
+		v = reflect.New(t)
+	default:
+		v = reflect.Zero(t)
+	}
+	var get func(int) (core.VMFunc, bool)
+	switch x := v.Interface().(type) {
+	case core.VMMetaObject:
+		x.VMInit(x)
+		x.VMRegister()
+		get = x.VMGetMethod
+	case core.VMMethodImplementer:
+		get = x.MethodMember
+	default:
+		return nil
+	}
+	vt := v.Type()
+	for i := 0; i < vt.NumMethod(); i++ {
+		name := vt.Method(i).Name
+		if !unicode.Is(unicode.Cyrillic, []rune(name)[0]) {
+			continue
+		}
+		if f, ok := probe(get, names.UniqueNames.Set(strings.ToLower(name))); ok {
+			np, ok := core.FuncParamsCount(f)
+			if !ok {
+				np = -1
+			}
+			res = append(res, entry{Name: name, Params: np, Type: tn})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return
+}
+
+func probe(get func(int) (core.VMFunc, bool), id int) (f core.VMFunc, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return get(id)
+}
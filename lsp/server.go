@@ -0,0 +1,395 @@
+// Package lsp реализует сервер Language Server Protocol для языка Гонец.
+// Сервер работает через stdin/stdout и дает редактору замечания к коду, автодополнение,
+// подсказки со списком параметров встроенных функций, переход к объявлению функции или импортированного модуля
+// и список символов документа.
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/checker"
+	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/pos"
+	"github.com/covrom/gonec/version"
+)
+
+type server struct {
+	conn *conn
+	docs map[string]*document
+}
+
+// Serve обслуживает клиента, читая запросы из r и записывая ответы в w, до уведомления exit или конца ввода
+func Serve(r io.Reader, w io.Writer) error {
+	parser.EnableErrorVerbose()
+	s := &server{conn: newConn(r, w), docs: make(map[string]*document)}
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if e, ok := err.(*rpcError); ok {
+				s.conn.reply(nil, nil, e)
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if msg.Method == "" {
+			continue // ответ клиента на запрос сервера, сервер запросов не отправляет
+		}
+		res, err := s.handle(msg)
+		if msg.ID != nil {
+			if err := s.conn.reply(msg.ID, res, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) handle(msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:       TextDocumentSyncFull,
+				CompletionProvider:     &CompletionOptions{TriggerCharacters: []string{"."}},
+				HoverProvider:          true,
+				DefinitionProvider:     true,
+				DocumentSymbolProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "gonec", Version: version.Version},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		d := newDocument(p.TextDocument.URI, p.TextDocument.Text)
+		s.docs[d.uri] = d
+		return nil, s.publish(d)
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok || len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		d.update(p.ContentChanges[len(p.ContentChanges)-1].Text)
+		return nil, s.publish(d)
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		switch msg.Method {
+		case "textDocument/completion":
+			return s.completion(d, p.Position), nil
+		case "textDocument/hover":
+			return s.hover(d, p.Position), nil
+		default:
+			return s.definition(d, p.Position), nil
+		}
+	case "textDocument/documentSymbol":
+		var p struct {
+			TextDocument TextDocumentIdentifier `json:"textDocument"`
+		}
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return symbols(d), nil
+	}
+	if msg.ID != nil {
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "Метод не поддерживается: " + msg.Method}
+	}
+	return nil, nil // прочие уведомления не обрабатываются
+}
+
+func unmarshal(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// publish отправляет замечания к документу: синтаксическую ошибку или замечания статической проверки
+func (s *server) publish(d *document) error {
+	diags := []Diagnostic{}
+	switch e := d.err.(type) {
+	case nil:
+		for _, c := range checker.Check(d.path, d.text) {
+			diags = append(diags, Diagnostic{
+				Range:    d.wordRange(c.Pos),
+				Severity: SeverityWarning,
+				Source:   "gonec",
+				Message:  c.Message,
+			})
+		}
+	case *parser.Error:
+		diags = append(diags, Diagnostic{Range: d.wordRange(e.Pos), Severity: SeverityError, Source: "gonec", Message: e.Message})
+	default:
+		diags = append(diags, Diagnostic{Severity: SeverityError, Source: "gonec", Message: e.Error()})
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: d.uri, Diagnostics: diags})
+}
+
+// module возвращает разобранный файл модуля, загружаемого из документа d через Импорт(path)
+func (s *server) module(d *document, path string) *document {
+	dirs := append([]string{filepath.Dir(d.path)}, bincode.ImportSearchPath()[1:]...)
+	fn, err := bincode.FindModule(path, dirs)
+	if err != nil {
+		return nil
+	}
+	uri := pathToURI(fn)
+	if md, ok := s.docs[uri]; ok {
+		return md
+	}
+	if strings.ToLower(filepath.Ext(fn)) != ".gnc" {
+		// у скомпилированного модуля есть только файл
+		return &document{uri: uri, path: fn}
+	}
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil
+	}
+	return newDocument(uri, string(b))
+}
+
+func (s *server) completion(d *document, p Position) []CompletionItem {
+	c := d.at(p, true)
+	items := []CompletionItem{}
+	if _, ok := c.inString(); ok {
+		return items
+	}
+	lib := stdLibrary()
+	if c.member {
+		if ref := d.importOf(c.qualifier); ref != nil {
+			if md := s.module(d, ref.path); md != nil {
+				for _, m := range md.modules {
+					for _, f := range m.funcs {
+						items = append(items, CompletionItem{Label: f.name, Kind: CompletionFunction, Detail: f.signature()})
+					}
+				}
+				return items
+			}
+		}
+		ms := make([]string, 0, len(lib.methods))
+		for m := range lib.methods {
+			ms = append(ms, m)
+		}
+		sort.Strings(ms)
+		for _, m := range ms {
+			es := lib.methods[m]
+			var sigs []string
+			for _, e := range es {
+				sigs = append(sigs, e.signature())
+			}
+			items = append(items, CompletionItem{Label: es[0].Name, Kind: CompletionMethod, Detail: strings.Join(sigs, "; ")})
+		}
+		return items
+	}
+	addTypes := func() {
+		for _, t := range lib.typeList() {
+			items = append(items, CompletionItem{Label: t, Kind: CompletionClass, Detail: "Тип"})
+		}
+	}
+	if strings.EqualFold(c.prev, "новый") {
+		addTypes()
+		return items
+	}
+	for _, kw := range lib.keywords {
+		items = append(items, CompletionItem{Label: kw, Kind: CompletionKeyword})
+	}
+	for _, n := range lib.funcList() {
+		items = append(items, CompletionItem{Label: n, Kind: CompletionFunction, Detail: lib.funcs[n].signature()})
+	}
+	addTypes()
+	for _, f := range d.allFuncs() {
+		items = append(items, CompletionItem{Label: f.name, Kind: CompletionFunction, Detail: f.signature()})
+	}
+	for _, m := range d.modules {
+		for _, v := range m.vars {
+			items = append(items, CompletionItem{Label: v.name, Kind: CompletionVariable})
+		}
+	}
+	return items
+}
+
+func (s *server) hover(d *document, p Position) *Hover {
+	c := d.at(p, false)
+	if c.word == "" {
+		return nil
+	}
+	if _, ok := c.inString(); ok {
+		return nil
+	}
+	lib := stdLibrary()
+	low := strings.ToLower(c.word)
+	var code []string
+	desc := ""
+	switch {
+	case c.member:
+		if ref := d.importOf(c.qualifier); ref != nil {
+			if md := s.module(d, ref.path); md != nil {
+				if f := md.findFunc(c.word); f != nil {
+					code = append(code, f.signature())
+					desc = "Модуль " + ref.path
+				}
+			}
+			break
+		}
+		for _, e := range lib.methods[low] {
+			code = append(code, e.signature())
+		}
+		if len(code) > 0 {
+			desc = "Метод"
+		}
+	case d.findFunc(c.word) != nil:
+		code = append(code, d.findFunc(c.word).signature())
+	case lib.funcs[low].Name != "":
+		code = append(code, lib.funcs[low].signature())
+		desc = "Встроенная функция"
+		if lib.funcs[low].Params < 0 {
+			desc += ", число параметров не проверяется"
+		}
+	case lib.types[low] != "":
+		code = append(code, "Новый "+lib.types[low])
+		desc = "Тип"
+		var ms []string
+		for _, es := range lib.methods {
+			for _, e := range es {
+				if e.Type == lib.types[low] {
+					ms = append(ms, e.Name)
+				}
+			}
+		}
+		if len(ms) > 0 {
+			sort.Strings(ms)
+			desc += ", методы: " + strings.Join(ms, ", ")
+		}
+	case lib.isKeyword(low):
+		code = append(code, spell(low))
+		desc = "Ключевое слово"
+	}
+	if len(code) == 0 {
+		return nil
+	}
+	r := d.rangeOf(c.start, len([]rune(c.word)))
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```gonec\n" + strings.Join(code, "\n") + "\n```\n" + desc},
+		Range:    &r,
+	}
+}
+
+func (s *server) definition(d *document, p Position) []Location {
+	c := d.at(p, false)
+	if path, ok := c.inString(); ok {
+		for _, ref := range d.imports {
+			if ref.at.Line == p.Line+1 && ref.path == path {
+				if md := s.module(d, ref.path); md != nil {
+					return []Location{{URI: md.uri}}
+				}
+			}
+		}
+		return nil
+	}
+	if c.word == "" {
+		return nil
+	}
+	if c.member {
+		ref := d.importOf(c.qualifier)
+		if ref == nil {
+			return nil
+		}
+		md := s.module(d, ref.path)
+		if md == nil {
+			return nil
+		}
+		if f := md.findFunc(c.word); f != nil {
+			return []Location{{URI: md.uri, Range: md.nameRange(f.start, f.name)}}
+		}
+		return []Location{{URI: md.uri}}
+	}
+	if f := d.findFunc(c.word); f != nil {
+		return []Location{{URI: d.uri, Range: d.nameRange(f.start, f.name)}}
+	}
+	return nil
+}
+
+// symbols возвращает модули, функции и переменные модулей документа
+func symbols(d *document) []DocumentSymbol {
+	res := []DocumentSymbol{}
+	for i, m := range d.modules {
+		var syms []DocumentSymbol
+		for _, v := range m.vars {
+			r := d.rangeOf(v.at, len([]rune(v.name)))
+			syms = append(syms, DocumentSymbol{Name: v.name, Kind: SymbolVariable, Range: r, SelectionRange: r})
+		}
+		syms = append(syms, funcSymbols(d, m.funcs)...)
+		sort.SliceStable(syms, func(i, j int) bool {
+			a, b := syms[i].Range.Start, syms[j].Range.Start
+			return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+		})
+		// код без заголовка модуля относится к служебному модулю "_", он не показывается
+		if i == 0 && m.name == "_" {
+			res = append(res, syms...)
+			continue
+		}
+		r := d.nameRange(m.at, m.name)
+		end := pos.Position{Line: len(d.lines), Column: len(d.lines[len(d.lines)-1]) + 1}
+		if i+1 < len(d.modules) {
+			end = pos.Position{Line: d.modules[i+1].at.Line - 1, Column: 1 << 20}
+		}
+		res = append(res, DocumentSymbol{
+			Name:           m.name,
+			Kind:           SymbolModule,
+			Range:          Range{Start: d.toLSP(m.at), End: d.toLSP(end)},
+			SelectionRange: r,
+			Children:       syms,
+		})
+	}
+	return res
+}
+
+func funcSymbols(d *document, fs []*funcDef) []DocumentSymbol {
+	var res []DocumentSymbol
+	for _, f := range fs {
+		res = append(res, DocumentSymbol{
+			Name:           f.name,
+			Detail:         "(" + strings.Join(f.args, ", ") + ")",
+			Kind:           SymbolFunction,
+			Range:          Range{Start: d.toLSP(f.start), End: d.wordRange(f.end).End},
+			SelectionRange: d.nameRange(f.start, f.name),
+			Children:       funcSymbols(d, f.kids),
+		})
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLSP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "мат.gnc"), []byte("Функция Квадрат(х)\n\tВозврат х * х\nКонецФункции\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "main.gnc"))
+	src := "м = Импорт(\"мат\")\nФункция Удвоить(а)\n\tВозврат а * 2\nКонецФункции\nн = Удвоить(м.Квадрат(3))\nмас = [3, 1]\nмас.Сорт\nСообщить(Длина(мас), неизвестная)\n"
+	at := func(line, char int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]string{"uri": uri},
+			"position":     map[string]int{"line": line, "character": char},
+		}
+	}
+	reqs := []map[string]interface{}{
+		{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		{"method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "gonec", "version": 1, "text": src}}},
+		{"id": 2, "method": "textDocument/hover", "params": at(7, 11)},
+		{"id": 3, "method": "textDocument/completion", "params": at(6, 8)},
+		{"id": 4, "method": "textDocument/definition", "params": at(4, 6)},
+		{"id": 5, "method": "textDocument/definition", "params": at(4, 16)},
+		{"id": 6, "method": "textDocument/documentSymbol", "params": map[string]interface{}{"textDocument": map[string]string{"uri": uri}}},
+		{"id": 7, "method": "неизвестный/метод"},
+		{"method": "textDocument/didChange", "params": map[string]interface{}{
+			"textDocument":   map[string]string{"uri": uri},
+			"contentChanges": []map[string]string{{"text": "Если а Тогда\n"}}}},
+		{"id": 8, "method": "shutdown"},
+		{"method": "exit"},
+	}
+	var in, out bytes.Buffer
+	for _, r := range reqs {
+		r["jsonrpc"] = "2.0"
+		b, _ := json.Marshal(r)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+	if err := Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type msg struct {
+		ID     int
+		Method string
+		Params json.RawMessage
+		Result json.RawMessage
+		Error  *struct{ Code int }
+	}
+	res := make(map[int]msg)
+	var diags []string
+	for _, part := range strings.Split(out.String(), "Content-Length: ")[1:] {
+		var m msg
+		if err := json.Unmarshal([]byte(part[strings.Index(part, "{"):]), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Method == "textDocument/publishDiagnostics" {
+			diags = append(diags, string(m.Params))
+		} else {
+			res[m.ID] = m
+		}
+	}
+
+	if len(diags) != 2 || !strings.Contains(diags[0], `"severity":2`) || !strings.Contains(diags[0], "неизвестная") ||
+		!strings.Contains(diags[1], `"severity":1`) {
+		t.Errorf("замечания: %v", diags)
+	}
+	if s := string(res[2].Result); !strings.Contains(s, "длина(п1)") {
+		t.Errorf("подсказка: %s", s)
+	}
+	if s := string(res[3].Result); !strings.Contains(s, `"label":"Сортировать"`) || strings.Contains(s, `"label":"Если"`) {
+		t.Errorf("автодополнение метода: %s", s)
+	}
+	if s := string(res[4].Result); !strings.Contains(s, `"start":{"line":1,"character":8}`) {
+		t.Errorf("переход к функции: %s", s)
+	}
+	if s := string(res[5].Result); !strings.Contains(s, "%D0%BC%D0%B0%D1%82.gnc") || !strings.Contains(s, `"start":{"line":0,"character":8}`) {
+		t.Errorf("переход к функции модуля: %s", s)
+	}
+	if s := string(res[6].Result); !strings.Contains(s, `"name":"Удвоить","detail":"(а)","kind":12`) || !strings.Contains(s, `"name":"мас","kind":13`) {
+		t.Errorf("символы: %s", s)
+	}
+	if res[7].Error == nil || res[7].Error.Code != -32601 {
+		t.Errorf("неизвестный метод: %+v", res[7])
+	}
+	if _, ok := res[8]; !ok {
+		t.Error("нет ответа на shutdown")
+	}
+}
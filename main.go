@@ -3,19 +3,27 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/bincode/binopt"
 	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/checker"
 	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/debugger"
+	"github.com/covrom/gonec/format"
+	"github.com/covrom/gonec/lsp"
 	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/profiler"
 	"github.com/covrom/gonec/services/gonecsvc"
 	"github.com/covrom/gonec/version"
 	"github.com/daviddengcn/go-colortext"
@@ -29,6 +37,24 @@ var (
 	fs          = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	line        = fs.String("e", "", "Исполнение одной строчки кода")
 	compile     = fs.Bool("c", false, "Компиляция в файл .gnx")
+	nodebug     = fs.Bool("nodebug", false, "Не сохранять в .gnx отладочную информацию")
+	embedsrc    = fs.Bool("embedsrc", false, "Встроить в отладочную информацию .gnx исходный код")
+	check       = fs.Bool("check", false, "Статическая проверка файлов .gnc без исполнения")
+	fmtsrc      = fs.Bool("fmt", false, "Форматирование файлов .gnc с выводом результата")
+	fmtwrite    = fs.Bool("fmtw", false, "При форматировании и переводе записать результат в исходные файлы")
+	lowercase   = fs.Bool("lowercase", false, "При форматировании писать ключевые слова в нижнем регистре")
+	english     = fs.Bool("english", false, "При форматировании писать ключевые слова на английском")
+	translate   = fs.String("translate", "", "Перевод ключевых слов, типов, встроенных функций и методов в файлах .gnc: en - на английский, ru - на русский")
+	lspmode     = fs.Bool("lsp", false, "Запустить сервер Language Server Protocol на stdin/stdout")
+	debugmode   = fs.Bool("debug", false, "Пошаговая отладка в консоли")
+	dapaddr     = fs.String("dap", "", "Отладка из редактора по протоколу Debug Adapter Protocol, адрес для подключения, например :4711")
+	profile     = fs.String("profile", "", "Профилирование: записать профиль pprof в файл и вывести отчет по функциям и строкам")
+	optpasses   = fs.String("opt", "all", "Проходы оптимизации байткода через запятую: peephole, jumps, deadcode, regs, hoist, locals, tail; all - все, none - без оптимизации")
+	maxdepth    = fs.Int("maxdepth", core.DefaultMaxCallDepth, "Максимальная глубина вызовов функций, при превышении вызывается исключение; 0 - без ограничения")
+	maxinstr    = fs.Int64("maxinstr", 0, "Максимальное число исполняемых инструкций байткода, при превышении исполнение останавливается; 0 - без ограничения")
+	timeout     = fs.Duration("timeout", 0, "Максимальное время исполнения кода, например 10s; 0 - без ограничения")
+	maxalloc    = fs.Int64("maxalloc", 0, "Максимальный объем в байтах строк, массивов и структур, создаваемых кодом; 0 - без ограничения")
+	gnxinfo     = fs.Bool("gnxinfo", false, "Вывести заголовок файла .gnx и проверить его совместимость с интерпретатором")
 	testingMode = fs.Bool("t", false, "Режим вывода отладочной информации")
 	toconsul    = fs.Bool("consul", false, "Зарегистрировать микросервис интерпретатора в Consul")
 	// stackvm     = fs.Bool("stack", false, "Старая стековая виртуальная машина версии 1.8b")
@@ -51,6 +77,127 @@ func colortext(color ct.Color, bright bool, f func()) {
 	}
 }
 
+// checkFiles выводит замечания статической проверки и возвращает код завершения,
+// отличный от нуля, если есть замечания
+func checkFiles(files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Не указаны файлы для проверки")
+		return 2
+	}
+	parser.EnableErrorVerbose()
+	code := 0
+	for _, fn := range files {
+		diags, err := checker.CheckFile(fn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 2
+			continue
+		}
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+		if len(diags) > 0 && code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// formatFiles форматирует или переводит файлы функцией conv и выводит результат или записывает его обратно в файлы
+func formatFiles(files []string, write bool, conv func(string) (string, error)) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Не указаны файлы для форматирования")
+		return 2
+	}
+	parser.EnableErrorVerbose()
+	code := 0
+	for _, fn := range files {
+		b, err := ioutil.ReadFile(fn)
+		if err == nil {
+			var res string
+			res, err = conv(string(b))
+			if pe, ok := err.(*parser.Error); ok {
+				pe.Filename = fn
+			}
+			if err == nil {
+				if !write {
+					fmt.Print(res)
+				} else if res != string(b) {
+					err = ioutil.WriteFile(fn, []byte(res), 0644)
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 2
+		}
+	}
+	return code
+}
+
+// debugRun исполняет код под отладчиком из консоли или из редактора, подключившегося по протоколу DAP
+func debugRun(bins binstmt.BinCode, env *core.Env, source string) error {
+	d := debugger.New()
+	d.Load(&bins)
+	bincode.Debugger = d
+	defer func() { bincode.Debugger = nil }()
+
+	if *dapaddr == "" {
+		d.StopOnEntry = true
+		go debugger.NewConsole(d, source, os.Stdout).Run(os.Stdin)
+		_, err := bincode.Run(context.Background(), bins, env)
+		d.Exit(err)
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *dapaddr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	log.Printf("Ожидание подключения отладчика по адресу %s\n", ln.Addr())
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- debugger.ServeDAP(d, conn, ready)
+	}()
+	// программа запускается после того, как редактор передал точки останова
+	<-ready
+	_, err = bincode.Run(context.Background(), bins, env)
+	d.Exit(err)
+	// редактор завершает сеанс после события terminated
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+	}
+	return err
+}
+
+// profileRun исполняет код под профилировщиком, записывает профиль в файл и выводит отчет
+func profileRun(run func() error, fn string) error {
+	p := profiler.New()
+	p.Start()
+	err := run()
+	p.Stop()
+	fo, ferr := os.Create(fn)
+	if ferr == nil {
+		ferr = p.WriteProfile(fo)
+		if cerr := fo.Close(); ferr == nil {
+			ferr = cerr
+		}
+	}
+	if ferr != nil {
+		log.Println(ferr)
+	}
+	p.WriteReport(os.Stderr)
+	return err
+}
+
 func main() {
 
 	fs.Parse(os.Args[1:])
@@ -58,6 +205,34 @@ func main() {
 		fmt.Println(version.Version)
 		os.Exit(0)
 	}
+	if *check {
+		os.Exit(checkFiles(fs.Args()))
+	}
+	if *lspmode {
+		if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *translate != "" {
+		var en bool
+		switch *translate {
+		case "en":
+			en = true
+		case "ru":
+		default:
+			log.Fatalf("Неизвестный язык перевода %q, допустимы en и ru", *translate)
+		}
+		os.Exit(formatFiles(fs.Args(), *fmtwrite, func(src string) (string, error) {
+			return format.Translate(src, en)
+		}))
+	}
+	if *fmtsrc || *fmtwrite {
+		opt := format.Options{Lowercase: *lowercase, English: *english}
+		os.Exit(formatFiles(fs.Args(), *fmtwrite, func(src string) (string, error) {
+			return format.Source(src, opt)
+		}))
+	}
 
 	var (
 		code      string
@@ -68,6 +243,30 @@ func main() {
 	)
 
 	interactive := fs.NArg() == 0 && *line == "" && !*compile
+	debugging := (*debugmode || *dapaddr != "") && !interactive && !*compile
+	passes, err := binopt.ParsePasses(*optpasses)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if debugging {
+		// при отладке код исполняется в том виде, в котором он написан
+		passes = binopt.None
+	}
+	bincode.Optimizations = passes
+	core.DefaultMaxCallDepth = *maxdepth
+	policy := core.ExecPolicy{MaxInstructions: *maxinstr, Timeout: *timeout, MaxAlloc: *maxalloc}
+	// у вэб-сервиса свои ограничения по умолчанию, ключи запуска их заменяют
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "maxinstr":
+			gonecsvc.DefaultPolicy.MaxInstructions = *maxinstr
+		case "timeout":
+			gonecsvc.DefaultPolicy.Timeout = *timeout
+		case "maxalloc":
+			gonecsvc.DefaultPolicy.MaxAlloc = *maxalloc
+		}
+	})
 	fsArgs = fs.Args()
 
 	ext := ""
@@ -114,12 +313,31 @@ func main() {
 			}
 			fsArgs = fs.Args()[1:]
 			source = filepath.Clean(fs.Arg(0))
+			// модули, загружаемые через Импорт, ищутся рядом с исполняемым файлом
+			bincode.ProjectDir = filepath.Dir(source)
+
+			if *gnxinfo {
+				hdr, err := binstmt.ReadGnxHeader(bytes.NewReader(b))
+				if err == nil {
+					fmt.Println(hdr)
+					_, err = binstmt.ReadBinCode(bytes.NewReader(b))
+				}
+				if err != nil {
+					colortext(ct.Red, false, func() {
+						fmt.Fprintln(os.Stderr, err)
+					})
+					os.Exit(1)
+				}
+				fmt.Println("Файл совместим с интерпретатором", version.Version)
+				return
+			}
 		}
 		os.Args = fs.Args()
 	}
 
 	env := core.NewEnv()
 	env.DefineS("аргументызапуска", core.NewVMSliceFromStrings(fsArgs))
+	env.SetPolicy(policy)
 
 	for {
 		if interactive {
@@ -178,6 +396,14 @@ func main() {
 			//замер производительности
 			_, bins, err = bincode.ParseSrc(code)
 			tsParse = time.Since(tstart)
+			if err == nil {
+				// имя файла и позиции инструкций для сообщений об ошибках, отладчика и профилировщика
+				if *compile && *embedsrc {
+					bins.AttachDebugInfo(source, code)
+				} else {
+					bins.AttachDebugInfo(source, "")
+				}
+			}
 
 			if *testingMode {
 				log.Printf("--Скомпилирован код-- \n%s\n", bins.String())
@@ -222,6 +448,9 @@ func main() {
 						log.Fatal(err)
 					}
 				}()
+				if *nodebug {
+					bins.StripDebugInfo()
+				}
 				if err := binstmt.WriteBinCode(fo, bins); err != nil {
 					log.Fatal(err)
 				}
@@ -247,7 +476,18 @@ func main() {
 			// if *stackvm && stmts != nil {
 			// 	_, err = vm.Run(stmts, env)
 			// } else {
-			_, err = bincode.Run(bins, env)
+			run := func() error {
+				if debugging {
+					return debugRun(bins, env, source)
+				}
+				_, err := bincode.Run(context.Background(), bins, env)
+				return err
+			}
+			if *profile != "" && !interactive {
+				err = profileRun(run, *profile)
+			} else {
+				err = run()
+			}
 			// }
 		}
 
@@ -261,7 +501,10 @@ func main() {
 		if err != nil {
 			colortext(ct.Red, false, func() {
 				if e, ok := err.(*binstmt.Error); ok {
-					fmt.Fprintf(os.Stderr, "%s:%d:%d %s\n", source, e.Pos.Line, e.Pos.Column, err)
+					if e.Filename != "" {
+						source = e.Filename
+					}
+					fmt.Fprintf(os.Stderr, "%s:%d:%d %s\n", source, e.Pos.Line, e.Pos.Column, e.Message)
 				} else if e, ok := err.(*parser.Error); ok {
 					if e.Filename != "" {
 						source = e.Filename
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/bincode/binopt"
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/checker"
 	"github.com/covrom/gonec/core"
+	"github.com/covrom/gonec/format"
+	"github.com/covrom/gonec/names"
 	"github.com/covrom/gonec/parser"
 )
 
@@ -168,8 +180,563 @@ func TestRun(t *testing.T) {
 		log.Fatal(err)
 	}
 	fmt.Println(stmts)
-	_, err = bincode.Run(stmts, env)
+	_, err = bincode.Run(context.Background(), stmts, env)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runScript компилирует и исполняет код в новом окружении, возвращая все, что было выведено
+func runScript(t *testing.T, script string) string {
+	t.Helper()
+	env := core.NewEnv()
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	_, stmts, err := bincode.ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bincode.Run(context.Background(), stmts, env); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDebugInfo(t *testing.T) {
+	src := "а = 1\nФункция Ф(м)\n\tВозврат м[2]\nКонецФункции\nФ([1])\n"
+	_, bins, err := bincode.ParseSrc(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bins.AttachDebugInfo("тест.gnc", src)
+
+	var buf bytes.Buffer
+	if err := binstmt.WriteBinCode(&buf, bins); err != nil {
+		t.Fatal(err)
+	}
+	gnx, err := binstmt.ReadBinCode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gnx.SourceFile() != "тест.gnc" {
+		t.Fatalf("неверный файл в отладочной информации: %q", gnx.SourceFile())
+	}
+	if l, ok := gnx.Debug.SourceLine(3); !ok || l != "\tВозврат м[2]" {
+		t.Fatalf("неверная строка исходного кода: %q", l)
+	}
+
+	env := core.NewEnv()
+	env.SetStdOut(&buf)
+	_, err = bincode.Run(context.Background(), gnx, env)
+	e, ok := err.(*binstmt.Error)
+	if !ok {
+		t.Fatalf("ожидалась ошибка исполнения, получено %v", err)
+	}
+	if e.Filename != "тест.gnc" || e.Pos.Line != 3 || e.Pos.Column != 10 {
+		t.Errorf("неверное место ошибки %s:%d:%d", e.Filename, e.Pos.Line, e.Pos.Column)
+	}
+}
+
+func TestGnxContainer(t *testing.T) {
+	_, bins, err := bincode.ParseSrc("Сообщить(длина(\"абв\"))\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := binstmt.WriteBinCode(&buf, bins); err != nil {
+		t.Fatal(err)
+	}
+	gnx := buf.Bytes()
+
+	hdr, err := binstmt.ReadGnxHeader(bytes.NewReader(gnx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.FormatVersion != binstmt.GnxFormatVersion || hdr.VMVersion != binstmt.VMVersion {
+		t.Errorf("неверные версии в заголовке: %v", hdr)
+	}
+	if strings.Join(hdr.Builtins, ",") != "длина,сообщить" {
+		t.Errorf("неверный список встроенных функций: %v", hdr.Builtins)
+	}
+	if _, err := binstmt.ReadBinCode(bytes.NewReader(gnx)); err != nil {
+		t.Fatal(err)
+	}
+
+	// заголовок с измененными полями и исходными данными
+	pl := len(binstmt.GnxMagic) + 6
+	data := gnx[pl+int(binary.BigEndian.Uint32(gnx[len(binstmt.GnxMagic)+2:])):]
+	withHeader := func(h binstmt.GnxHeader) []byte {
+		var hb bytes.Buffer
+		if err := gob.NewEncoder(&hb).Encode(h); err != nil {
+			t.Fatal(err)
+		}
+		res := append([]byte{}, gnx[:pl]...)
+		binary.BigEndian.PutUint32(res[len(binstmt.GnxMagic)+2:], uint32(hb.Len()))
+		return append(append(res, hb.Bytes()...), data...)
+	}
+
+	// файлы старого формата содержат только данные, номера строк в них смещены на единицу
+	legacy, err := binstmt.ReadBinCode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range legacy.Code {
+		if l := bins.Code[i].Position().Line; l > 0 && s.Position().Line != l-1 {
+			t.Fatalf("номер строки инструкции %v не приведен к текущей версии VM", s)
+		}
+	}
+
+	damaged := append([]byte{}, gnx...)
+	damaged[len(damaged)-10] ^= 0xff
+
+	newer := hdr
+	newer.VMVersion++
+	future := hdr
+	future.MinInterpreter = "1000.0"
+	nobuiltin := hdr
+	nobuiltin.Builtins = append(nobuiltin.Builtins, "несуществующаяфункция")
+
+	cases := []struct {
+		name string
+		body []byte
+		err  string
+	}{
+		{"чужой файл", []byte("Сообщить(1)"), "не является скомпилированным кодом"},
+		{"поврежденный файл", damaged, "контрольная сумма"},
+		{"новая версия VM", withHeader(newer), "скомпилирован для VM версии"},
+		{"новый интерпретатор", withHeader(future), "версии не ниже 1000.0"},
+		{"нет встроенной функции", withHeader(nobuiltin), "несуществующаяфункция"},
+	}
+	for _, c := range cases {
+		_, err := binstmt.ReadBinCode(bytes.NewReader(c.body))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: ожидалась ошибка %q, получено %v", c.name, c.err, err)
+		}
+	}
+}
+
+// runOptimized компилирует код с заданными проходами оптимизации и исполняет его,
+// возвращая все, что было выведено, включая ошибку исполнения
+func runOptimized(t *testing.T, script string, passes binopt.Passes) (string, binstmt.BinCode) {
+	t.Helper()
+	old := bincode.Optimizations
+	bincode.Optimizations = passes
+	defer func() { bincode.Optimizations = old }()
+	_, bins, err := bincode.ParseSrc(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnv()
+	var buf bytes.Buffer
+	env.SetStdOut(&buf)
+	if _, err = bincode.Run(context.Background(), bins, env); err != nil {
+		fmt.Fprintln(&buf, "ошибка:", err)
+	}
+	return buf.String(), bins
+}
+
+// lockedBuffer - буфер вывода, в который пишут горутины кода
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestContext(t *testing.T) {
+	scripts := []string{
+		`
+	Попытка
+		Пока Истина Цикл
+		КонецЦикла
+	Исключение
+		Сообщить("перехвачено")
+	КонецПопытки
+	`,
+		`
+	Попытка
+		Пауза(100)
+	Исключение
+		Сообщить("перехвачено")
+	КонецПопытки
+	`,
+		`
+	Функция Ждать(кан)
+		Возврат <-кан
+	КонецФункции
+	Попытка
+		Ждать(Новый Канал(0))
+	Исключение
+		Сообщить("перехвачено")
+	КонецПопытки
+	`,
+	}
+	for i, src := range scripts {
+		_, bins, err := bincode.ParseSrc(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := core.NewEnv()
+		var buf bytes.Buffer
+		env.SetStdOut(&buf)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		_, err = bincode.Run(ctx, bins, env)
+		cancel()
+		if err != binstmt.InterruptError {
+			t.Errorf("%d: получена ошибка %v", i, err)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Errorf("%d: исполнение прервано через %v", i, d)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%d: прерывание перехвачено: %q", i, buf.String())
+		}
+	}
+
+	// горутина, запущенная кодом, исполняется в контексте запуска
+	_, bins, err := bincode.ParseSrc(`
+	Функция Фон(кан)
+		<-кан
+	КонецФункции
+	Старт Фон(Новый Канал(0))
+	Пауза(100)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnv()
+	buf := &lockedBuffer{}
+	env.SetStdOut(buf)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		env.Interrupt()
+	}()
+	if _, err = bincode.Run(ctx, bins, env); err != binstmt.InterruptError {
+		t.Errorf("получена ошибка %v", err)
+	}
+	cancel()
+	for i := 0; i < 100 && buf.String() == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := buf.String(); !strings.Contains(got, "Выполнение прервано") {
+		t.Errorf("горутина не прервана, вывод %q", got)
+	}
+}
+
+// TestGoroutines проверяет модель памяти окружений, запускать с флагом -race
+func TestGoroutines(t *testing.T) {
+	// горутины читают глобальную переменную, пока код модуля ее изменяет
+	out := runScript(t, `
+	счет = 0
+	гр = Новый ГруппаОжидания
+	Функция Читать(гр)
+		сумма = 0
+		Для н = 1 по 1000 Цикл
+			сумма = сумма + счет
+		КонецЦикла
+		гр.Завершить()
+	КонецФункции
+	Для к = 1 по 4 Цикл
+		гр.Добавить(1)
+		Старт Читать(гр)
+	КонецЦикла
+	Для н = 1 по 1000 Цикл
+		счет = счет + 1
+	КонецЦикла
+	гр.Ожидать()
+	Сообщить(счет)
+	`)
+	if out != "1000\n" {
+		t.Errorf("глобальная переменная: %q", out)
+	}
+
+	// замыкания в горутинах изменяют переменную объемлющей функции,
+	// результат после ожидания виден вызывающему коду
+	out = runScript(t, `
+	Функция Запуск()
+		итог = 0
+		гр = Новый ГруппаОжидания
+		Функция Добавить(к)
+			Для н = 1 по 1000 Цикл
+				итог = к
+			КонецЦикла
+			гр.Завершить()
+		КонецФункции
+		Для к = 1 по 4 Цикл
+			гр.Добавить(1)
+			Старт Добавить(к)
+		КонецЦикла
+		гр.Ожидать()
+		Возврат итог
+	КонецФункции
+	Сообщить(Запуск() > 0)
+	Функция Сумма(м)
+		итог = 0
+		Для Каждого х Из м Параллельно Цикл
+			итог = х
+		КонецЦикла
+		Возврат итог
+	КонецФункции
+	м = []
+	Для н = 1 по 1000 Цикл
+		м = м + [н]
+	КонецЦикла
+	Сообщить(Сумма(м) > 0)
+	`)
+	if out != "true\ntrue\n" {
+		t.Errorf("замыкания: %q", out)
+	}
+
+	// значения передаются между горутинами через канал, вывод строк не перемешивается
+	out = runScript(t, `
+	кан = Новый Канал(0)
+	гр = Новый ГруппаОжидания
+	Функция Раб(гр, кан, к)
+		Для н = 1 по 100 Цикл
+			Сообщить(Формат("строка %v %v", к, н))
+		КонецЦикла
+		кан <- к
+		гр.Завершить()
+	КонецФункции
+	Для к = 1 по 4 Цикл
+		гр.Добавить(1)
+		Старт Раб(гр, кан, к)
+	КонецЦикла
+	сумма = 0
+	Для к = 1 по 4 Цикл
+		сумма = сумма + <-кан
+	КонецЦикла
+	гр.Ожидать()
+	Сообщить(сумма)
+	`)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 401 || lines[400] != "10" {
+		t.Fatalf("каналы: получено %d строк, последняя %q", len(lines), lines[len(lines)-1])
+	}
+	for _, l := range lines[:400] {
+		var k, n int
+		if _, err := fmt.Sscanf(l, "строка %d %d", &k, &n); err != nil || k < 1 || k > 4 || n < 1 || n > 100 {
+			t.Errorf("перемешанный вывод %q", l)
+		}
+	}
+}
+
+func TestEnglish(t *testing.T) {
+	src := `# числа Фибоначчи
+Function Fib(n)
+	If n < 2 Then
+		Return n
+	EndIf
+	Return Fib(n - 1) + Fib(n - 2)
+EndFunction
+arr = [3, 1, 2]
+arr.Sort()
+wg = New WaitGroup
+total = 0
+For Each x In arr Do
+	wg.Add(1)
+	wg.Done()
+	total += x
+EndDo
+wg.Wait()
+Message(Fib(10), StrFind("hello", "l"), Len(arr), String(5) + "!", Integer("7"), CurrentDate().Year() > 2000, arr, total)
+Try
+	Raise "ошибка"
+Except
+	Message(ErrorDescription())
+EndTry
+If Not False And True Then Message("Message") EndIf
+`
+	exp := "55 2 3 5! 7 true [1,2,3] 6\n[20:2] ошибка\nMessage\n"
+	if out := runScript(t, src); out != exp {
+		t.Errorf("английский код: %q", out)
+	}
+
+	ru, err := format.Translate(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"Функция Fib(n)", "КонецЕсли", "arr.Сортировать()", "Новый ГруппаОжидания",
+		"Для Каждого x Из arr Цикл", "СтрНайти(", "Строка(5)", "Сообщить(\"Message\")", "# числа Фибоначчи"} {
+		if !strings.Contains(ru, w) {
+			t.Errorf("в переводе нет %q:\n%s", w, ru)
+		}
+	}
+	if out := runScript(t, ru); out != exp {
+		t.Errorf("переведенный код: %q", out)
+	}
+	if en, err := format.Translate(ru, true); err != nil || en != src {
+		t.Errorf("обратный перевод отличается: %v\n%s", err, en)
+	}
+
+	// перевод однозначен в обе стороны
+	ruWords, enWords := map[string]bool{}, map[string]bool{}
+	for _, a := range names.Aliases {
+		r, e := names.FastToLower(a.Ru), names.FastToLower(a.En)
+		if ruWords[r] || enWords[e] {
+			t.Errorf("повторяется синоним %s - %s", a.Ru, a.En)
+		}
+		ruWords[r], enWords[e] = true, true
+		if ruWords[e] || enWords[r] {
+			t.Errorf("синоним %s - %s совпадает со словом другого языка", a.Ru, a.En)
+		}
+	}
+}
+
+func TestTypes(t *testing.T) {
+	script := `Тип ТочкаПлоскости
+	Поле Абсцисса, Ордината
+	Поле Подпись = "точка"
+
+	Метод Конструктор(x, y)
+		ЭтотОбъект.Абсцисса = x
+		ЭтотОбъект.Ордината = y
+	КонецМетода
+
+	Метод Сдвиг(d)
+		ЭтотОбъект.Абсцисса = ЭтотОбъект.Абсцисса + d
+		Возврат ЭтотОбъект
+	КонецМетода
+КонецТипа
+
+Функция Сдвинуть(п)
+	п.Сдвиг(1)
+КонецФункции
+
+п = Новый ТочкаПлоскости(1, 2)
+Сдвинуть(п)
+Сообщить(п.Абсцисса, п.Ордината, п.Подпись, п.Сдвиг(10).Абсцисса, ТипЗнч(п))
+Сообщить(Строка(п), Структура(п)["Подпись"])
+к = Новый("ТочкаПлоскости", {"Абсцисса": 5})
+Сообщить(к.Абсцисса, к.Ордината, к.Подпись, к = п, п = п)
+к = Новый("ТочкаПлоскости", Строка(п))
+Сообщить(к.Абсцисса)
+Попытка
+	п.Аппликата = 1
+Исключение
+	Сообщить(ОписаниеОшибки())
+КонецПопытки
+Попытка
+	Сообщить(п.Метод)
+Исключение
+	Сообщить(ОписаниеОшибки())
+КонецПопытки
+`
+	exp := "2 2 точка 12 ТочкаПлоскости\n{\"Абсцисса\":12,\"Ордината\":2,\"Подпись\":\"точка\"} точка\n5 Неопределено точка false true\n12\n[29:2] Нет поля с таким именем\n[34:11] Нет поля или метода с таким именем\n"
+	for _, passes := range []binopt.Passes{binopt.None, bincode.Optimizations} {
+		out, _ := runOptimized(t, script, passes)
+		if out != exp {
+			t.Errorf("вывод: %q", out)
+		}
+	}
+
+	// объект без конструктора создается только без аргументов
+	out, _ := runOptimized(t, "Тип Пустой\nПоле а\nКонецТипа\nт = Новый Пустой(1)\n", binopt.None)
+	if !strings.Contains(out, "У типа нет конструктора с параметрами") {
+		t.Errorf("вывод: %q", out)
+	}
+
+	// объект восстанавливается из бинарного формата по имени типа
+	env := core.NewEnv()
+	ft := core.NewVMObjectType(names.UniqueNames.Set("ТестовыйТип"), []int{names.UniqueNames.Set("Поле1")}, nil, nil)
+	env.DefineObjectType(ft.Name, ft)
+	obj, err := ft.New(nil, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.SetField(names.UniqueNames.Set("Поле1"), core.VMString("знач"))
+	b, err := obj.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := obj.BinaryType().ParseBinaryEnv(b, env)
+	if o, ok := v.(*core.VMObject); err != nil || !ok || o.Type() != ft || o.String() != obj.String() {
+		t.Errorf("восстановлено %v: %v", v, err)
+	}
+	// у каждого интерпретатора свои типы, одноименный тип другого глобального контекста не заменяет тип
+	env2 := core.NewEnv()
+	ft2 := core.NewVMObjectType(ft.Name, ft.Fields, nil, nil)
+	env2.DefineObjectType(ft2.Name, ft2)
+	v, err = obj.BinaryType().ParseBinaryEnv(b, env2.NewEnv().GoEnv())
+	if o, ok := v.(*core.VMObject); err != nil || !ok || o.Type() != ft2 {
+		t.Errorf("восстановлено в другом контексте %v: %v", v, err)
+	}
+	if _, err = obj.BinaryType().ParseBinary(b); err != core.VMErrorUnknownType {
+		t.Errorf("восстановлено без окружения: %v", err)
+	}
+
+	src := "Тип Фигура\n\tПоле а, б\n\tПоле в = 1\n\n\tМетод Сумма(х)\n\t\tВозврат ЭтотОбъект.а + х\n\tКонецМетода\nКонецТипа\nт = Новый Фигура\nу = Новый Фигура(1, 2)\n"
+	res, err := format.Source(src, format.Options{})
+	if err != nil || res != src {
+		t.Errorf("форматирование: %v %q", err, res)
+	}
+	if ds := checker.Check("т.gnc", "т = Новый Круг\nСообщить(т)\nТип Круг\nКонецТипа\n"); len(ds) > 0 {
+		t.Errorf("замечания: %v", ds)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	out := runScript(t, `глоб = 100
+Функция Выч(а)
+	б = 2
+	Выполнить("в = а + б; б = 10")
+	Сообщить(б, в, Вычислить("а * б + глоб"))
+	Сообщить(Вычислить("а = 5"), Eval("а <> 4"))
+	Попытка
+		Вычислить("б", Истина)
+	Исключение
+		Сообщить("не видно")
+	КонецПопытки
+КонецФункции
+Выч(5)
+Выполнить("новая = 1; Функция Длина(х) Возврат 42 КонецФункции", Истина)
+Сообщить(глоб, Длина([1, 2]), Вычислить("Длина([1, 2, 3])", Истина))
+Попытка
+	Вычислить("глоб", Истина)
+Исключение
+	Сообщить("глобальные не видны")
+КонецПопытки
+Попытка
+	Выполнить("ЗагрузитьИВыполнить(\"файл.gnc\")", Истина)
+Исключение
+	Сообщить("загрузка недоступна")
+КонецПопытки
+Для н = 1 По 3 Цикл
+	Выполнить("Сообщить(н)")
+КонецЦикла
+Попытка
+	Выполнить("х = 1\nу = (2 +")
+Исключение
+	инф = ИнформацияОбОшибке()
+	Сообщить(инф.Вид, инф.ИмяФайла, инф.НомерСтроки, инф.НомерКолонки)
+КонецПопытки
+Попытка
+	Вычислить("1; 2")
+Исключение
+	Сообщить(ОписаниеОшибки())
+КонецПопытки
+Попытка
+	Выполнить("х = 1\nу = Неизвестная + 1")
+Исключение
+	инф = ИнформацияОбОшибке()
+	Сообщить(инф.Вид, инф.ИмяФайла, инф.НомерСтроки, инф.НомерКолонки)
+КонецПопытки
+`)
+	want := "10 7 150\ntrue true\nне видно\n100 2 3\nглобальные не видны\nзагрузка недоступна\n1\n2\n3\n" +
+		"ОшибкаСинтаксиса <Выполнить> 2 9\n[1:4] Ожидается одно выражение\nОшибкаВыполнения <Выполнить> 2 5\n"
+	if out != want {
+		t.Errorf("вывод: %q", out)
+	}
+}
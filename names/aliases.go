@@ -0,0 +1,253 @@
+package names
+
+// Alias - русское и английское написание ключевого слова, типа, встроенной функции или метода.
+// Английское написание - синоним русского, в коде их можно смешивать.
+type Alias struct {
+	Ru string
+	En string
+}
+
+// Aliases - все английские синонимы языка. Каждому русскому слову соответствует одно английское и наоборот,
+// поэтому код можно механически перевести с одного написания на другое.
+var Aliases = []Alias{
+	// ключевые слова
+	{"Функция", "Function"},
+	{"КонецФункции", "EndFunction"},
+	{"Возврат", "Return"},
+	{"ВызватьИсключение", "Raise"},
+	{"Если", "If"},
+	{"Тогда", "Then"},
+	{"ИначеЕсли", "ElsIf"},
+	{"Иначе", "Else"},
+	{"КонецЕсли", "EndIf"},
+	{"Для", "For"},
+	{"Каждого", "Each"},
+	{"Из", "In"},
+	{"По", "To"},
+	{"Пока", "While"},
+	{"Цикл", "Do"},
+	{"КонецЦикла", "EndDo"},
+	{"Прервать", "Break"},
+	{"Продолжить", "Continue"},
+	{"Истина", "True"},
+	{"Ложь", "False"},
+	{"Неопределено", "Undefined"},
+	{"Модуль", "Module"},
+	{"Попытка", "Try"},
+	{"Исключение", "Except"},
+	{"Окончательно", "Finally"},
+	{"КонецПопытки", "EndTry"},
+	{"Выбор", "Switch"},
+	{"Когда", "Case"},
+	{"Другое", "Default"},
+	{"КонецВыбора", "EndSwitch"},
+	{"Старт", "Go"},
+	{"Параллельно", "Parallel"},
+	{"Канал", "Channel"},
+	{"Новый", "New"},
+	{"Или", "Or"},
+	{"И", "And"},
+	{"Не", "Not"},
+	{"Тип", "Type"},
+	{"Поле", "Field"},
+	{"КонецМетода", "EndMethod"},
+	{"КонецТипа", "EndType"},
+	{"Отложить", "Defer"},
+	{"ЭтотОбъект", "ThisObject"},
+	{"Конструктор", "Constructor"},
+
+	// типы
+	{"Строка", "String"},
+	{"Число", "Number"},
+	{"Булево", "Boolean"},
+	{"ЦелоеЧисло", "Integer"},
+	{"Массив", "Array"},
+	{"Структура", "Structure"},
+	{"Дата", "Date"},
+	{"Длительность", "Duration"},
+	{"ГруппаОжидания", "WaitGroup"},
+	{"ФайловаяБазаДанных", "FileDatabase"},
+	{"Сервер", "Server"},
+	{"Клиент", "Client"},
+	{"ТаблицаЗначений", "ValueTable"},
+	{"КолонкаТаблицыЗначений", "ValueTableColumn"},
+	{"КоллекцияКолонокТаблицыЗначений", "ValueTableColumnCollection"},
+	{"СтрокаТаблицыЗначений", "ValueTableRow"},
+	{"КлючИЗначение", "KeyValue"},
+
+	// встроенные функции и значения
+	{"Импорт", "Import"},
+	{"Длина", "Len"},
+	{"Диапазон", "Range"},
+	{"ТекущаяДата", "CurrentDate"},
+	{"ПрошлоВремениС", "TimeSince"},
+	{"Пауза", "Sleep"},
+	{"ДлительностьНаносекунды", "DurationNanosecond"},
+	{"ДлительностьМикросекунды", "DurationMicrosecond"},
+	{"ДлительностьМиллисекунды", "DurationMillisecond"},
+	{"ДлительностьСекунды", "DurationSecond"},
+	{"ДлительностьМинуты", "DurationMinute"},
+	{"ДлительностьЧаса", "DurationHour"},
+	{"ДлительностьДня", "DurationDay"},
+	{"Хэш", "Hash"},
+	{"УникальныйИдентификатор", "UUID"},
+	{"ПолучитьМассивИзПула", "GetArrayFromPool"},
+	{"ВернутьМассивВПул", "ReturnArrayToPool"},
+	{"СлучайнаяСтрока", "RandomString"},
+	{"НРег", "Lower"},
+	{"ВРег", "Upper"},
+	{"СтрСодержит", "StrContains"},
+	{"СтрСодержитЛюбой", "StrContainsAny"},
+	{"СтрКоличество", "StrCount"},
+	{"СтрНайти", "StrFind"},
+	{"СтрНайтиЛюбой", "StrFindAny"},
+	{"СтрНайтиПоследний", "StrFindLast"},
+	{"СтрЗаменить", "StrReplace"},
+	{"Окр", "Round"},
+	{"Формат", "Format"},
+	{"КодСимвола", "CharCode"},
+	{"ТипЗнч", "TypeOf"},
+	{"Сообщить", "Message"},
+	{"СообщитьФ", "MessageF"},
+	{"ОбработатьГорутины", "Gosched"},
+	{"ПеременнаяОкружения", "EnvironmentVariable"},
+	{"ЗагрузитьИВыполнить", "LoadAndRun"},
+	{"Выполнить", "Execute"},
+	{"Вычислить", "Eval"},
+	{"ОписаниеОшибки", "ErrorDescription"},
+	{"ИнформацияОбОшибке", "ErrorInfo"},
+	{"АргументыЗапуска", "LaunchArguments"},
+	{"ГлобальныйИдентификаторСессии", "GlobalSessionId"},
+
+	// методы даты
+	{"Год", "Year"},
+	{"Месяц", "Month"},
+	{"День", "Day"},
+	{"Неделя", "Week"},
+	{"ДеньНедели", "Weekday"},
+	{"Квартал", "Quarter"},
+	{"ДеньГода", "YearDay"},
+	{"Час", "Hour"},
+	{"Минута", "Minute"},
+	{"Секунда", "Second"},
+	{"Миллисекунда", "Millisecond"},
+	{"Микросекунда", "Microsecond"},
+	{"Наносекунда", "Nanosecond"},
+	{"Вычесть", "Sub"},
+	{"Добавить", "Add"},
+	{"ДобавитьПериод", "AddDate"},
+	{"Раньше", "Before"},
+	{"Позже", "After"},
+	{"Равно", "Equal"},
+	{"Пустая", "IsZero"},
+	{"Местное", "Local"},
+	{"Локация", "Location"},
+	{"ВЛокации", "InLocation"},
+
+	// методы группы ожидания, канала, массива и структуры
+	{"Завершить", "Done"},
+	{"Ожидать", "Wait"},
+	{"Закрыть", "Close"},
+	{"Размер", "Size"},
+	{"Сортировать", "Sort"},
+	{"СортироватьУбыв", "SortDesc"},
+	{"Обратить", "Reverse"},
+	{"Скопировать", "Copy"},
+	{"Найти", "Find"},
+	{"НайтиСорт", "FindSorted"},
+	{"Вставить", "Insert"},
+	{"Удалить", "Delete"},
+	{"СкопироватьУникальные", "CopyUnique"},
+	{"Ключи", "Keys"},
+	{"Значения", "Values"},
+	{"Следующий", "Next"},
+
+	// поля пары ключа и значения
+	{"Ключ", "Key"},
+	{"Значение", "Value"},
+
+	// методы http запроса и ответа, соединения, сервера и клиента
+	{"Метод", "Method"},
+	{"Заголовок", "Header"},
+	{"УстановитьЗаголовок", "SetHeader"},
+	{"Тело", "Body"},
+	{"Путь", "Path"},
+	{"Адрес", "Address"},
+	{"Фрагмент", "Fragment"},
+	{"Параметр", "Param"},
+	{"Данные", "Data"},
+	{"Сообщение", "Contents"},
+	{"Отправить", "Send"},
+	{"Получить", "Get"},
+	{"Закрыто", "Closed"},
+	{"Идентификатор", "Id"},
+	{"Запрос", "Request"},
+	{"Открыть", "Open"},
+	{"Работает", "Running"},
+	{"Соединить", "Connect"},
+	{"ВСтроку", "ToString"},
+
+	// методы файловой базы данных
+	{"НачатьТранзакцию", "BeginTransaction"},
+	{"ЗафиксироватьТранзакцию", "CommitTransaction"},
+	{"ОтменитьТранзакцию", "RollbackTransaction"},
+	{"Таблица", "Table"},
+	{"УдалитьТаблицу", "DeleteTable"},
+	{"ПолныйБэкап", "FullBackup"},
+	{"Установить", "Set"},
+	{"СледующийИдентификатор", "NextId"},
+	{"ПолучитьДиапазон", "GetRange"},
+	{"ПолучитьПрефикс", "GetPrefix"},
+	{"ПолучитьВсе", "GetAll"},
+	{"УстановитьСтруктуру", "SetStructure"},
+}
+
+var (
+	aliasRu = make(map[string]string, len(Aliases)) // английское слово в нижнем регистре -> русское в нижнем регистре
+	spellRu = make(map[string]string, len(Aliases)) // английское слово в нижнем регистре -> русское написание
+	spellEn = make(map[string]string, len(Aliases)) // русское слово в нижнем регистре -> английское написание
+)
+
+func init() {
+	for _, a := range Aliases {
+		ru, en := FastToLower(a.Ru), FastToLower(a.En)
+		aliasRu[en] = ru
+		spellRu[en] = a.Ru
+		spellEn[ru] = a.En
+	}
+}
+
+// Russian возвращает русское слово в нижнем регистре для английского синонима в нижнем регистре,
+// остальные слова возвращаются без изменений
+func Russian(lower string) string {
+	if ru, ok := aliasRu[lower]; ok {
+		return ru
+	}
+	return lower
+}
+
+// Translate возвращает написание слова на другом языке: английское для русского, если en, иначе русское.
+// Регистр слова не учитывается, false - у слова нет синонима.
+func Translate(word string, en bool) (string, bool) {
+	if en {
+		s, ok := spellEn[FastToLower(word)]
+		return s, ok
+	}
+	s, ok := spellRu[FastToLower(word)]
+	return s, ok
+}
+
+// Canonical возвращает идентификатор русского слова, если i - английский синоним, иначе i
+func (en *EnvNames) Canonical(i int) int {
+	ru, ok := spellRu[en.GetLowerCase(i)]
+	if !ok {
+		return i
+	}
+	return en.Set(ru)
+}
+
+// GetCanonical возвращает имя в нижнем регистре, английский синоним заменяется русским словом.
+// Используется для выбора методов по имени.
+func (en *EnvNames) GetCanonical(i int) string {
+	return Russian(en.GetLowerCase(i))
+}
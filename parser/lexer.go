@@ -4,6 +4,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"unicode"
 
 	"github.com/covrom/gonec/ast"
@@ -39,6 +40,37 @@ type Scanner struct {
 	canequal bool
 	typecast bool
 	castType string
+	template []TemplatePart // части последней прочитанной строки с подстановками
+	member   bool           // предыдущая лексема - точка, после нее идет имя поля или метода, а не ключевое слово
+
+	// KeepLayout включает сохранение комментариев и написания ключевых слов и идентификаторов,
+	// это нужно для форматирования исходного кода
+	KeepLayout bool
+	Comments   []Comment
+	Literals   map[posit.Position]string // написание слов и строк в обратных кавычках по их позиции
+}
+
+// Lexeme - лексема, прочитанная сканером
+type Lexeme struct {
+	Tok   int
+	Lit   string
+	Pos   posit.Position
+	Parts []TemplatePart // части строки с подстановками, если Tok - TEMPLATE
+}
+
+// TemplatePart - часть строки с подстановками: текст или лексемы выражения подстановки ${...}
+type TemplatePart struct {
+	Text   string
+	Subst  bool
+	Tokens []Lexeme
+	Pos    posit.Position // начало подстановки
+	End    posit.Position // закрывающая скобка подстановки
+}
+
+// Comment - комментарий в исходном коде, начинается с // или #
+type Comment struct {
+	Pos  posit.Position
+	Text string // текст вместе с начальными символами
 }
 
 // opName is correction of operation names.
@@ -60,14 +92,14 @@ var opName = map[string]int{
 	"модуль":       MODULE,
 	"попытка":      TRY,
 	"исключение":   CATCH,
-	// "окончательно":      FINALLY,
-	"выбор":       SWITCH,
-	"когда":       CASE,
-	"другое":      DEFAULT,
-	"старт":       GO,
-	"параллельно": GO,
-	"канал":       CHAN,
-	"новый":       MAKE,
+	"окончательно": FINALLY,
+	"выбор":        SWITCH,
+	"когда":        CASE,
+	"другое":       DEFAULT,
+	"старт":        GO,
+	"параллельно":  GO,
+	"канал":        CHAN,
+	"новый":        MAKE,
 
 	"или":          OROR,
 	"и":            ANDAND,
@@ -82,8 +114,16 @@ var opName = map[string]int{
 	"null":         NULL,
 	"каждого":      EACH,
 	"по":           TO,
+	"шаг":          STEP,
 	"пока":         WHILE,
 	"иначеесли":    ELSIF,
+	"тип":          TYPE,
+	"поле":         FIELD,
+	"метод":        METHOD,
+	"конецметода":  int('}'),
+	"конецтипа":    int('}'),
+	"этотобъект":   THIS,
+	"отложить":     DEFER,
 
 	"строка":       TYPECAST,
 	"число":        TYPECAST,
@@ -95,6 +135,21 @@ var opName = map[string]int{
 	"длительность": TYPECAST,
 }
 
+// Keywords возвращает ключевые слова языка вместе с английскими синонимами в нижнем регистре, отсортированные по алфавиту
+func Keywords() []string {
+	kws := make([]string, 0, 2*len(opName))
+	for k, tok := range opName {
+		if tok != TYPECAST {
+			kws = append(kws, k)
+			if en, ok := names.Translate(k, true); ok {
+				kws = append(kws, names.FastToLower(en))
+			}
+		}
+	}
+	sort.Strings(kws)
+	return kws
+}
+
 var opCanEqual = map[int]bool{
 	RETURN: true,
 	THROW:  true,
@@ -123,6 +178,12 @@ func (s *Scanner) Init(src string) {
 	s.src = []rune(src)
 }
 
+// SetFirstLine задает номер первой строки исходного кода,
+// например, 0 - если перед кодом вставлена служебная строка
+func (s *Scanner) SetFirstLine(n int) {
+	s.line = n - 1
+}
+
 // Scan analyses token, and decide identify or literals.
 func (s *Scanner) Scan() (tok int, lit string, pos posit.Position, err error) {
 	if s.typecast {
@@ -137,14 +198,18 @@ func (s *Scanner) Scan() (tok int, lit string, pos posit.Position, err error) {
 retry:
 	s.skipBlank()
 	pos = s.pos()
+	member := s.member
+	s.member = false
 	switch ch := s.peek(); {
 	case isLetter(ch):
 		lit, err = s.scanIdentifier()
 		if err != nil {
 			return
 		}
-		lowlit := names.FastToLower(lit)
-		if name, ok := opName[lowlit]; ok {
+		s.keep(pos, lit)
+		// английские ключевые слова - синонимы русских
+		lowlit := names.Russian(names.FastToLower(lit))
+		if name, ok := opName[lowlit]; ok && !member {
 			tok = name
 			_, s.canequal = opCanEqual[tok]
 			if tok == TYPECAST {
@@ -160,32 +225,35 @@ retry:
 		if err != nil {
 			return
 		}
-	case ch == '"':
+	case ch == '"', ch == '\'':
+		start := s.offset
 		tok = STRING
-		lit, err = s.scanString('"')
+		lit, err = s.scanString(ch)
 		if err != nil {
 			return
 		}
-	case ch == '\'':
-		tok = STRING
-		lit, err = s.scanString('\'')
-		if err != nil {
-			return
+		if s.template != nil {
+			tok = TEMPLATE
+			s.keep(pos, string(s.src[start:s.offset]))
 		}
 	case ch == '`':
+		start := s.offset
 		tok = STRING
 		lit, err = s.scanRawString()
 		if err != nil {
 			return
 		}
+		s.keep(pos, string(s.src[start:s.offset]))
 	default:
 		switch ch {
 		case EOF:
 			tok = EOF
 		case '#':
+			start := s.offset
 			for !isEOL(s.peek()) {
 				s.next()
 			}
+			s.comment(pos, start)
 			goto retry
 		case '!':
 			s.next()
@@ -261,9 +329,11 @@ retry:
 			s.next()
 			switch s.peek() {
 			case '/':
+				start := s.offset - 1
 				for !isEOL(s.peek()) {
 					s.next()
 				}
+				s.comment(pos, start)
 				goto retry
 			case '=':
 				tok = DIVEQ
@@ -349,6 +419,7 @@ retry:
 				s.back()
 				tok = int(ch)
 				lit = string(ch)
+				s.member = true
 			}
 		case '\n':
 			tok = int(ch)
@@ -417,6 +488,23 @@ retry:
 	return
 }
 
+// keep сохраняет написание слова или строки в обратных кавычках
+func (s *Scanner) keep(pos posit.Position, lit string) {
+	if s.KeepLayout {
+		if s.Literals == nil {
+			s.Literals = make(map[posit.Position]string)
+		}
+		s.Literals[pos] = lit
+	}
+}
+
+// comment сохраняет комментарий, который начинается со смещения start и заканчивается в текущей позиции
+func (s *Scanner) comment(pos posit.Position, start int) {
+	if s.KeepLayout {
+		s.Comments = append(s.Comments, Comment{Pos: pos, Text: string(s.src[start:s.offset])})
+	}
+}
+
 // isLetter returns true if the rune is a letter for identity.
 func isLetter(ch rune) bool {
 	return unicode.IsLetter(ch) || ch == '_'
@@ -548,13 +636,13 @@ func (s *Scanner) scanNumber() (string, error) {
 }
 
 // scanRawString returns raw-string starting at current position.
+// Строка в обратных кавычках берется как есть, подстановки ${...} в ней не выполняются.
 func (s *Scanner) scanRawString() (string, error) {
 	var ret []rune
 	for {
 		s.next()
 		if s.peek() == EOF {
 			return "", errors.New("неожиданный EOF")
-			break
 		}
 		if s.peek() == '`' {
 			s.next()
@@ -565,10 +653,67 @@ func (s *Scanner) scanRawString() (string, error) {
 	return string(ret), nil
 }
 
+// ahead возвращает символ, отстоящий от текущего на n позиций вперед
+func (s *Scanner) ahead(n int) rune {
+	if s.offset+n >= len(s.src) {
+		return EOF
+	}
+	return s.src[s.offset+n]
+}
+
+func appendText(parts []TemplatePart, text []rune) []TemplatePart {
+	if len(text) > 0 {
+		parts = append(parts, TemplatePart{Text: string(text)})
+	}
+	return parts
+}
+
+// scanSubst читает лексемы подстановки ${...} до парной закрывающей скобки, текущая позиция - на символе $.
+// После чтения текущей становится закрывающая скобка.
+func (s *Scanner) scanSubst() (TemplatePart, error) {
+	at := s.pos()
+	part := TemplatePart{Subst: true, Pos: at}
+	s.next()
+	s.next()
+	depth := 0
+	for {
+		tok, lit, pos, err := s.Scan()
+		if err != nil {
+			if e, ok := err.(*Error); ok {
+				return part, e
+			}
+			return part, &Error{Message: err.Error(), Pos: pos, Fatal: true}
+		}
+		switch {
+		case tok == EOF:
+			return part, &Error{Message: "не закрыта подстановка ${", Pos: at, Fatal: true}
+		case tok == '\n':
+			return part, &Error{Message: "неожиданный EOL", Pos: pos, Fatal: true}
+		case tok == '{' && lit == "{":
+			depth++
+		case tok == '}' && lit == "}":
+			if depth == 0 {
+				part.End = pos
+				s.back()
+				return part, nil
+			}
+			depth--
+		}
+		lx := Lexeme{Tok: tok, Lit: lit, Pos: pos}
+		if tok == TEMPLATE {
+			lx.Parts = s.template
+		}
+		part.Tokens = append(part.Tokens, lx)
+	}
+}
+
 // scanString returns string starting at current position.
 // This handles backslash escaping.
+// Строка может содержать подстановки ${...}, \${ записывается в строку как ${.
 func (s *Scanner) scanString(l rune) (string, error) {
 	var ret []rune
+	var parts []TemplatePart
+	s.template = nil
 eos:
 	for {
 		s.next()
@@ -601,13 +746,34 @@ eos:
 			}
 			ret = append(ret, s.peek())
 			continue
+		case '$':
+			if s.ahead(1) == '{' {
+				p, err := s.scanSubst()
+				if err != nil {
+					return "", err
+				}
+				parts = appendText(parts, ret)
+				parts = append(parts, p)
+				ret = nil
+				continue
+			}
+			ret = append(ret, s.peek())
 		default:
 			ret = append(ret, s.peek())
 		}
 	}
+	if parts != nil {
+		s.template = appendText(parts, ret)
+		return "", nil
+	}
 	return string(ret), nil
 }
 
+// Template возвращает части строки с подстановками, прочитанной последней как лексема TEMPLATE
+func (s *Scanner) Template() []TemplatePart {
+	return s.template
+}
+
 // Lexer provides inteface to parse codes.
 type Lexer struct {
 	s     *Scanner
@@ -615,23 +781,98 @@ type Lexer struct {
 	pos   posit.Position
 	e     error
 	stmts ast.Stmts
+	toks  []Lexeme // лексемы подстановки, если лексер разбирает выражение подстановки, а не исходный код
 }
 
 // Lex scans the token and literals.
 func (l *Lexer) Lex(lval *yySymType) int {
-	tok, lit, pos, err := l.s.Scan()
-	if err != nil {
-		l.e = &Error{Message: fmt.Sprintf("%s", err.Error()), Pos: pos, Fatal: true}
+	var lx Lexeme
+	if l.s != nil {
+		tok, lit, pos, err := l.s.Scan()
+		if err != nil {
+			if e, ok := err.(*Error); ok {
+				l.e = e
+			} else {
+				l.e = &Error{Message: fmt.Sprintf("%s", err.Error()), Pos: pos, Fatal: true}
+			}
+		}
+		lx = Lexeme{Tok: tok, Lit: lit, Pos: pos}
+		if tok == TEMPLATE {
+			lx.Parts = l.s.Template()
+		}
+	} else if len(l.toks) > 0 {
+		lx = l.toks[0]
+		l.toks = l.toks[1:]
+	} else {
+		lx = Lexeme{Tok: EOF, Pos: l.pos}
+	}
+	if lx.Tok == TEMPLATE {
+		lval.expr = l.template(lx)
 	}
-	lval.tok = ast.Token{Tok: tok, Lit: lit}
-	lval.tok.SetPosition(pos)
-	l.lit = lit
-	l.pos = pos
-	return tok
+	lval.tok = ast.Token{Tok: lx.Tok, Lit: lx.Lit}
+	lval.tok.SetPosition(lx.Pos)
+	l.lit = lx.Lit
+	l.pos = lx.Pos
+	return lx.Tok
+}
+
+// template разбирает выражения подстановок строки в выражение конкатенации частей строки
+func (l *Lexer) template(lx Lexeme) ast.Expr {
+	e := &ast.TemplateExpr{}
+	e.SetPosition(lx.Pos)
+	for _, p := range lx.Parts {
+		if !p.Subst {
+			se := &ast.StringExpr{Lit: p.Text}
+			se.SetPosition(lx.Pos)
+			e.Parts = append(e.Parts, se)
+			continue
+		}
+		pe, err := parseSubst(p)
+		if err != nil {
+			if l.e == nil {
+				l.e = err
+			}
+			continue
+		}
+		e.Parts = append(e.Parts, pe)
+	}
+	return e
+}
+
+// parseSubst разбирает выражение подстановки ${...} как единственное выражение модуля
+func parseSubst(p TemplatePart) (ast.Expr, *Error) {
+	if len(p.Tokens) == 0 {
+		return nil, &Error{Message: "пустая подстановка ${}", Pos: p.Pos, Fatal: true}
+	}
+	sub := &Lexer{}
+	sub.toks = append(sub.toks,
+		Lexeme{Tok: MODULE, Lit: "Модуль", Pos: p.Pos},
+		Lexeme{Tok: IDENT, Lit: "_", Pos: p.Pos},
+		Lexeme{Tok: '\n', Lit: "\n", Pos: p.Pos})
+	sub.toks = append(sub.toks, p.Tokens...)
+	sub.toks = append(sub.toks, Lexeme{Tok: EOF, Pos: p.End})
+	if yyParse(sub) != 0 || sub.e != nil {
+		if e, ok := sub.e.(*Error); ok {
+			return nil, e
+		}
+		return nil, &Error{Message: "в подстановке ${} ожидается выражение", Pos: p.Pos, Fatal: true}
+	}
+	if len(sub.stmts) == 1 {
+		if ms, ok := sub.stmts[0].(*ast.ModuleStmt); ok && len(ms.Stmts) == 1 {
+			if es, ok := ms.Stmts[0].(*ast.ExprStmt); ok {
+				return es.Expr, nil
+			}
+		}
+	}
+	return nil, &Error{Message: "в подстановке ${} ожидается выражение", Pos: p.Pos, Fatal: true}
 }
 
 // Error sets parse error.
 func (l *Lexer) Error(msg string) {
+	if e, ok := l.e.(*Error); ok && e.Fatal {
+		// ошибка сканера или подстановки точнее ошибки разбора, вызванной ею
+		return
+	}
 	l.e = &Error{Message: msg, Pos: l.pos, Fatal: false}
 }
 
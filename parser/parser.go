@@ -12,7 +12,7 @@ import (
 	"github.com/covrom/gonec/names"
 )
 
-//line parser.y:30
+//line parser.y:34
 type yySymType struct {
 	yys          int
 	compstmt     ast.Stmts
@@ -33,6 +33,7 @@ type yySymType struct {
 	expr_pair    ast.Expr
 	expr_pairs   []ast.Expr
 	expr_idents  []int
+	catch_kinds  []string
 	tok          ast.Token
 	term         ast.Token
 	terms        ast.Token
@@ -92,7 +93,14 @@ const ELSIF = 57395
 const WHILE = 57396
 const TERNARY = 57397
 const TYPECAST = 57398
-const UNARY = 57399
+const TYPE = 57399
+const FIELD = 57400
+const METHOD = 57401
+const THIS = 57402
+const DEFER = 57403
+const STEP = 57404
+const TEMPLATE = 57405
+const UNARY = 57406
 
 var yyToknames = [...]string{
 	"$end",
@@ -151,6 +159,13 @@ var yyToknames = [...]string{
 	"WHILE",
 	"TERNARY",
 	"TYPECAST",
+	"TYPE",
+	"FIELD",
+	"METHOD",
+	"THIS",
+	"DEFER",
+	"STEP",
+	"TEMPLATE",
 	"'='",
 	"'?'",
 	"':'",
@@ -165,11 +180,11 @@ var yyToknames = [...]string{
 	"UNARY",
 	"'{'",
 	"'}'",
+	"'('",
+	"')'",
 	"'.'",
 	"'!'",
 	"'^'",
-	"')'",
-	"'('",
 	"'['",
 	"']'",
 	"'|'",
@@ -177,697 +192,882 @@ var yyToknames = [...]string{
 	"';'",
 	"'\\n'",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line parser.y:738
+//line parser.y:899
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
 	-2, 0,
 	-1, 6,
 	1, 7,
 	25, 7,
-	-2, 127,
+	-2, 152,
 	-1, 12,
-	60, 50,
+	67, 72,
 	-2, 5,
 	-1, 16,
-	60, 51,
-	-2, 25,
+	67, 73,
+	-2, 37,
 	-1, 25,
 	27, 7,
-	-2, 127,
-	-1, 50,
-	60, 50,
-	-2, 128,
-	-1, 127,
+	28, 7,
+	-2, 152,
+	-1, 54,
+	67, 72,
+	-2, 153,
+	-1, 133,
 	16, 0,
 	17, 0,
-	-2, 83,
-	-1, 128,
+	-2, 107,
+	-1, 134,
 	16, 0,
 	17, 0,
-	-2, 84,
-	-1, 148,
-	60, 51,
-	-2, 45,
+	-2, 108,
 	-1, 154,
-	70, 7,
-	-2, 127,
-	-1, 155,
-	70, 7,
-	-2, 127,
-	-1, 179,
+	67, 73,
+	-2, 67,
+	-1, 161,
+	77, 7,
+	-2, 152,
+	-1, 162,
+	28, 7,
+	77, 7,
+	-2, 152,
+	-1, 163,
+	77, 7,
+	-2, 152,
+	-1, 189,
 	13, 7,
 	53, 7,
-	70, 7,
-	-2, 127,
-	-1, 224,
+	77, 7,
+	-2, 152,
+	-1, 203,
+	28, 7,
+	77, 7,
+	-2, 152,
+	-1, 241,
 	16, 0,
-	60, 52,
-	-2, 46,
-	-1, 225,
-	1, 47,
-	13, 47,
-	16, 47,
-	25, 47,
-	27, 47,
-	43, 47,
-	44, 47,
-	53, 47,
-	57, 47,
-	60, 53,
-	70, 47,
-	80, 47,
-	81, 47,
-	-2, 54,
-	-1, 232,
-	1, 53,
-	8, 53,
-	13, 53,
-	25, 53,
-	27, 53,
-	43, 53,
-	44, 53,
-	53, 53,
-	60, 53,
-	70, 53,
-	74, 53,
-	77, 53,
-	80, 53,
-	81, 53,
-	-2, 54,
-	-1, 247,
-	70, 7,
-	-2, 127,
+	67, 74,
+	-2, 68,
+	-1, 242,
+	1, 69,
+	13, 69,
+	16, 69,
+	25, 69,
+	27, 69,
+	28, 69,
+	43, 69,
+	44, 69,
+	53, 69,
+	64, 69,
+	67, 75,
+	77, 69,
+	87, 69,
+	88, 69,
+	-2, 76,
+	-1, 249,
+	1, 75,
+	8, 75,
+	13, 75,
+	25, 75,
+	27, 75,
+	28, 75,
+	43, 75,
+	44, 75,
+	53, 75,
+	67, 75,
+	77, 75,
+	79, 75,
+	84, 75,
+	87, 75,
+	88, 75,
+	-2, 76,
 	-1, 257,
-	1, 104,
-	8, 104,
-	13, 104,
-	25, 104,
-	27, 104,
-	43, 104,
-	44, 104,
-	52, 104,
-	53, 104,
-	57, 104,
-	59, 104,
-	60, 104,
-	69, 104,
-	70, 104,
-	74, 104,
-	77, 104,
-	80, 104,
-	81, 104,
-	-2, 102,
-	-1, 259,
-	1, 108,
-	8, 108,
-	13, 108,
-	25, 108,
-	27, 108,
-	43, 108,
-	44, 108,
-	52, 108,
-	53, 108,
-	57, 108,
-	59, 108,
-	60, 108,
-	69, 108,
-	70, 108,
-	74, 108,
-	77, 108,
-	80, 108,
-	81, 108,
-	-2, 106,
-	-1, 266,
-	70, 7,
-	-2, 127,
-	-1, 270,
+	77, 7,
+	-2, 152,
+	-1, 274,
+	77, 7,
+	-2, 152,
+	-1, 284,
+	1, 128,
+	8, 128,
+	13, 128,
+	25, 128,
+	27, 128,
+	28, 128,
+	43, 128,
+	44, 128,
+	45, 128,
+	52, 128,
+	53, 128,
+	62, 128,
+	64, 128,
+	66, 128,
+	67, 128,
+	76, 128,
+	77, 128,
+	79, 128,
+	84, 128,
+	87, 128,
+	88, 128,
+	-2, 126,
+	-1, 286,
+	1, 132,
+	8, 132,
+	13, 132,
+	25, 132,
+	27, 132,
+	28, 132,
+	43, 132,
+	44, 132,
+	45, 132,
+	52, 132,
+	53, 132,
+	62, 132,
+	64, 132,
+	66, 132,
+	67, 132,
+	76, 132,
+	77, 132,
+	79, 132,
+	84, 132,
+	87, 132,
+	88, 132,
+	-2, 130,
+	-1, 294,
+	77, 7,
+	-2, 152,
+	-1, 302,
+	77, 7,
+	-2, 152,
+	-1, 305,
 	43, 7,
 	44, 7,
-	70, 7,
-	-2, 127,
-	-1, 275,
-	70, 7,
-	-2, 127,
-	-1, 276,
-	70, 7,
-	-2, 127,
-	-1, 281,
-	1, 103,
-	8, 103,
-	13, 103,
-	25, 103,
-	27, 103,
-	43, 103,
-	44, 103,
-	52, 103,
-	53, 103,
-	57, 103,
-	59, 103,
-	60, 103,
-	69, 103,
-	70, 103,
-	74, 103,
-	77, 103,
-	80, 103,
-	81, 103,
-	-2, 101,
-	-1, 282,
-	1, 107,
-	8, 107,
-	13, 107,
-	25, 107,
-	27, 107,
-	43, 107,
-	44, 107,
-	52, 107,
-	53, 107,
-	57, 107,
-	59, 107,
-	60, 107,
-	69, 107,
-	70, 107,
-	74, 107,
-	77, 107,
-	80, 107,
-	81, 107,
-	-2, 105,
-	-1, 286,
-	70, 7,
-	-2, 127,
-	-1, 290,
-	70, 7,
-	-2, 127,
-	-1, 291,
-	70, 7,
-	-2, 127,
-	-1, 292,
+	77, 7,
+	-2, 152,
+	-1, 314,
+	77, 7,
+	-2, 152,
+	-1, 315,
+	77, 7,
+	-2, 152,
+	-1, 320,
+	1, 127,
+	8, 127,
+	13, 127,
+	25, 127,
+	27, 127,
+	28, 127,
+	43, 127,
+	44, 127,
+	45, 127,
+	52, 127,
+	53, 127,
+	62, 127,
+	64, 127,
+	66, 127,
+	67, 127,
+	76, 127,
+	77, 127,
+	79, 127,
+	84, 127,
+	87, 127,
+	88, 127,
+	-2, 125,
+	-1, 321,
+	1, 131,
+	8, 131,
+	13, 131,
+	25, 131,
+	27, 131,
+	28, 131,
+	43, 131,
+	44, 131,
+	45, 131,
+	52, 131,
+	53, 131,
+	62, 131,
+	64, 131,
+	66, 131,
+	67, 131,
+	76, 131,
+	77, 131,
+	79, 131,
+	84, 131,
+	87, 131,
+	88, 131,
+	-2, 129,
+	-1, 325,
+	77, 7,
+	-2, 152,
+	-1, 329,
+	77, 7,
+	-2, 152,
+	-1, 332,
+	77, 7,
+	-2, 152,
+	-1, 333,
+	77, 7,
+	-2, 152,
+	-1, 335,
+	77, 7,
+	-2, 152,
+	-1, 339,
 	43, 7,
 	44, 7,
-	70, 7,
-	-2, 127,
-	-1, 298,
-	70, 7,
-	-2, 127,
-	-1, 309,
+	77, 7,
+	-2, 152,
+	-1, 349,
+	77, 7,
+	-2, 152,
+	-1, 354,
+	77, 5,
+	-2, 61,
+	-1, 355,
+	77, 7,
+	-2, 152,
+	-1, 356,
+	77, 7,
+	-2, 152,
+	-1, 373,
 	13, 7,
 	53, 7,
-	70, 7,
-	-2, 127,
+	77, 7,
+	-2, 152,
+	-1, 380,
+	77, 7,
+	-2, 152,
+	-1, 382,
+	77, 7,
+	-2, 152,
+	-1, 390,
+	77, 7,
+	-2, 152,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 3055
-
-var yyAct = [...]int{
-
-	86, 168, 163, 10, 157, 193, 194, 8, 9, 94,
-	95, 173, 252, 16, 212, 177, 47, 17, 174, 210,
-	171, 95, 87, 165, 111, 90, 282, 92, 173, 91,
-	96, 97, 98, 312, 8, 9, 101, 85, 99, 8,
-	9, 281, 104, 106, 277, 248, 110, 113, 241, 115,
-	227, 16, 258, 117, 256, 119, 120, 121, 122, 123,
-	124, 125, 126, 127, 128, 129, 130, 131, 132, 133,
-	134, 135, 136, 137, 138, 12, 311, 139, 140, 141,
-	142, 199, 144, 146, 148, 148, 180, 169, 108, 49,
-	68, 69, 70, 71, 72, 73, 160, 143, 74, 75,
-	59, 147, 149, 310, 150, 308, 150, 100, 205, 82,
-	175, 159, 176, 306, 150, 102, 103, 109, 259, 166,
-	257, 305, 249, 301, 54, 55, 56, 57, 58, 205,
-	195, 196, 53, 150, 286, 295, 80, 81, 150, 76,
-	78, 254, 237, 206, 195, 196, 184, 200, 114, 236,
-	240, 280, 181, 187, 188, 214, 153, 238, 189, 190,
-	84, 191, 203, 204, 197, 198, 7, 89, 208, 158,
-	107, 192, 155, 11, 288, 218, 15, 3, 223, 224,
-	186, 51, 250, 226, 228, 207, 231, 233, 215, 216,
-	178, 287, 195, 196, 14, 169, 239, 152, 273, 217,
-	6, 83, 209, 242, 164, 151, 118, 110, 50, 5,
-	2, 167, 4, 264, 88, 255, 112, 51, 285, 22,
-	13, 261, 1, 262, 0, 0, 185, 116, 0, 0,
-	0, 0, 158, 0, 0, 267, 268, 0, 0, 0,
-	0, 0, 211, 213, 0, 0, 272, 0, 0, 0,
-	0, 274, 231, 0, 0, 279, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 0, 0, 59, 0, 0,
-	289, 0, 0, 0, 293, 0, 82, 0, 0, 296,
-	297, 246, 247, 0, 0, 0, 251, 0, 253, 300,
-	299, 0, 0, 0, 302, 303, 304, 0, 0, 53,
-	0, 0, 307, 80, 81, 0, 76, 78, 0, 0,
-	0, 0, 0, 313, 0, 0, 270, 0, 0, 0,
-	0, 0, 0, 0, 275, 276, 0, 0, 27, 28,
-	32, 0, 0, 38, 20, 21, 48, 0, 23, 0,
-	0, 0, 0, 0, 0, 292, 33, 34, 35, 0,
-	25, 0, 0, 298, 0, 0, 0, 0, 0, 18,
-	19, 0, 0, 0, 0, 0, 26, 0, 0, 42,
-	0, 43, 46, 44, 36, 0, 0, 0, 24, 37,
-	45, 0, 0, 0, 0, 0, 0, 0, 29, 0,
-	0, 0, 0, 40, 0, 0, 30, 31, 0, 41,
-	39, 0, 0, 0, 8, 9, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+const yyLast = 4072
+
+var yyAct = [...]int16{
+	90, 172, 177, 14, 165, 279, 208, 209, 321, 6,
+	259, 204, 17, 16, 187, 228, 182, 54, 226, 51,
+	8, 9, 91, 320, 100, 94, 285, 96, 98, 101,
+	8, 9, 102, 103, 104, 8, 9, 316, 8, 9,
+	89, 7, 105, 183, 180, 182, 110, 112, 11, 101,
+	325, 119, 275, 121, 10, 16, 55, 123, 283, 125,
+	126, 127, 128, 129, 130, 131, 132, 133, 134, 135,
+	136, 137, 138, 139, 140, 141, 142, 143, 144, 215,
+	95, 145, 146, 147, 148, 156, 150, 152, 154, 154,
+	327, 268, 8, 9, 221, 156, 55, 286, 221, 190,
+	153, 155, 169, 167, 149, 244, 383, 287, 107, 12,
+	276, 344, 221, 302, 326, 257, 185, 156, 186, 178,
+	168, 116, 184, 53, 222, 266, 267, 174, 175, 284,
+	72, 73, 74, 75, 76, 77, 117, 394, 156, 392,
+	63, 210, 211, 391, 264, 387, 210, 211, 341, 86,
+	216, 386, 194, 108, 109, 384, 381, 379, 156, 378,
+	199, 200, 301, 114, 256, 374, 372, 370, 196, 206,
+	191, 219, 220, 212, 213, 261, 224, 369, 160, 84,
+	207, 57, 106, 362, 85, 235, 80, 82, 240, 241,
+	319, 352, 346, 337, 245, 115, 248, 250, 281, 260,
+	231, 232, 255, 234, 156, 120, 230, 166, 263, 55,
+	15, 262, 343, 88, 113, 93, 201, 202, 205, 269,
+	197, 210, 211, 3, 296, 252, 159, 162, 163, 158,
+	188, 282, 277, 223, 303, 178, 368, 364, 289, 312,
+	290, 309, 308, 251, 243, 233, 225, 198, 173, 157,
+	124, 116, 99, 297, 298, 299, 5, 2, 258, 4,
+	342, 87, 92, 307, 118, 122, 195, 265, 214, 306,
+	203, 176, 292, 311, 166, 324, 22, 13, 1, 248,
+	0, 0, 0, 318, 0, 227, 229, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 330, 331, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 300, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 351, 313,
+	0, 273, 274, 0, 0, 359, 278, 361, 280, 0,
+	0, 0, 0, 365, 366, 0, 367, 0, 55, 328,
+	0, 0, 0, 0, 0, 16, 0, 338, 0, 0,
+	340, 0, 0, 0, 0, 0, 0, 0, 0, 347,
+	348, 0, 0, 305, 0, 0, 0, 0, 0, 0,
+	350, 0, 0, 0, 353, 314, 315, 357, 358, 0,
+	360, 0, 0, 0, 363, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 371, 0, 0, 0, 0, 0,
+	376, 377, 0, 0, 339, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 349, 0, 385, 0,
+	0, 0, 0, 0, 0, 388, 0, 389, 0, 354,
+	0, 0, 0, 0, 0, 393, 29, 30, 34, 0,
+	0, 42, 20, 21, 52, 0, 23, 0, 0, 0,
+	0, 0, 0, 0, 36, 37, 38, 0, 25, 0,
+	0, 0, 0, 0, 0, 0, 0, 18, 19, 0,
+	0, 0, 0, 0, 26, 0, 0, 46, 0, 47,
+	50, 48, 39, 390, 0, 0, 24, 41, 49, 28,
+	0, 0, 40, 27, 0, 35, 0, 0, 0, 0,
+	0, 0, 0, 31, 0, 0, 0, 0, 44, 0,
+	45, 0, 0, 32, 33, 43, 0, 0, 0, 8,
+	9, 66, 67, 69, 71, 81, 83, 0, 0, 0,
+	0, 0, 0, 0, 72, 73, 74, 75, 76, 77,
+	0, 0, 78, 79, 63, 64, 65, 0, 0, 0,
+	0, 0, 0, 86, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 336, 0, 0,
+	0, 0, 0, 68, 70, 58, 59, 60, 61, 62,
+	0, 335, 0, 84, 0, 57, 0, 0, 85, 0,
+	80, 82, 66, 67, 69, 71, 81, 83, 0, 0,
+	0, 0, 0, 0, 0, 72, 73, 74, 75, 76,
+	77, 0, 0, 78, 79, 63, 64, 65, 0, 0,
+	0, 0, 0, 0, 86, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 334, 0,
+	0, 0, 0, 0, 68, 70, 58, 59, 60, 61,
+	62, 0, 333, 0, 84, 0, 57, 0, 0, 85,
+	0, 80, 82, 66, 67, 69, 71, 81, 83, 0,
+	0, 0, 0, 0, 0, 0, 72, 73, 74, 75,
+	76, 77, 0, 0, 78, 79, 63, 64, 65, 0,
+	0, 0, 295, 0, 0, 86, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 68, 70, 58, 59, 60,
+	61, 62, 0, 294, 0, 84, 0, 57, 0, 0,
+	85, 0, 80, 82, 66, 67, 69, 71, 81, 83,
+	0, 0, 0, 0, 0, 0, 0, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 239, 68, 70, 58, 59,
+	60, 61, 62, 0, 0, 0, 84, 238, 57, 0,
+	0, 85, 0, 80, 82, 66, 67, 69, 71, 81,
+	83, 0, 0, 0, 0, 0, 0, 0, 72, 73,
+	74, 75, 76, 77, 0, 0, 78, 79, 63, 64,
+	65, 0, 0, 0, 0, 0, 0, 86, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 237, 68, 70, 58,
+	59, 60, 61, 62, 0, 0, 0, 84, 236, 57,
+	0, 0, 85, 0, 80, 82, 66, 67, 69, 71,
+	81, 83, 0, 0, 0, 0, 0, 0, 0, 72,
+	73, 74, 75, 76, 77, 0, 0, 78, 79, 63,
+	64, 65, 0, 0, 0, 0, 0, 0, 86, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 218, 0, 68, 70,
+	58, 59, 60, 61, 62, 0, 0, 0, 84, 0,
+	57, 0, 0, 85, 217, 80, 82, 66, 67, 69,
+	71, 81, 83, 0, 0, 0, 0, 0, 0, 0,
+	72, 73, 74, 75, 76, 77, 0, 0, 78, 79,
+	63, 64, 65, 0, 0, 0, 0, 0, 0, 86,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 193, 0, 68,
+	70, 58, 59, 60, 61, 62, 0, 0, 0, 84,
+	0, 57, 0, 0, 85, 192, 80, 82, 66, 67,
+	69, 71, 81, 83, 0, 0, 0, 0, 0, 0,
+	0, 72, 73, 74, 75, 76, 77, 0, 0, 78,
+	79, 63, 64, 65, 0, 0, 0, 0, 0, 0,
+	86, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	68, 70, 58, 59, 60, 61, 62, 0, 382, 0,
+	84, 0, 57, 0, 0, 85, 0, 80, 82, 66,
+	67, 69, 71, 81, 83, 0, 0, 0, 0, 0,
+	0, 0, 72, 73, 74, 75, 76, 77, 0, 0,
+	78, 79, 63, 64, 65, 0, 0, 0, 0, 0,
+	0, 86, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 68, 70, 58, 59, 60, 61, 62, 0, 380,
+	0, 84, 0, 57, 0, 0, 85, 0, 80, 82,
+	66, 67, 69, 71, 81, 83, 0, 0, 0, 0,
+	0, 0, 0, 72, 73, 74, 75, 76, 77, 0,
+	0, 78, 79, 63, 64, 65, 0, 0, 0, 0,
+	0, 0, 86, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 68, 70, 58, 59, 60, 61, 62, 0,
+	373, 0, 84, 0, 57, 0, 0, 85, 0, 80,
+	82, 66, 67, 69, 71, 81, 83, 0, 0, 0,
+	0, 0, 0, 0, 72, 73, 74, 75, 76, 77,
+	0, 0, 78, 79, 63, 64, 65, 0, 0, 0,
+	0, 0, 0, 86, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 68, 70, 58, 59, 60, 61, 62,
+	0, 356, 0, 84, 0, 57, 0, 0, 85, 0,
+	80, 82, 66, 67, 69, 71, 81, 83, 0, 0,
+	0, 0, 0, 0, 0, 72, 73, 74, 75, 76,
+	77, 0, 0, 78, 79, 63, 64, 65, 0, 0,
+	0, 0, 0, 0, 86, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 68, 70, 58, 59, 60, 61,
+	62, 0, 355, 0, 84, 0, 57, 0, 0, 85,
+	0, 80, 82, 66, 67, 69, 71, 81, 83, 0,
+	0, 0, 0, 0, 0, 0, 72, 73, 74, 75,
+	76, 77, 0, 0, 78, 79, 63, 64, 65, 0,
+	0, 0, 0, 0, 0, 86, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 68, 70, 58, 59, 60,
+	61, 62, 0, 0, 0, 84, 345, 57, 0, 0,
+	85, 0, 80, 82, 66, 67, 69, 71, 81, 83,
+	0, 0, 0, 0, 0, 0, 0, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 68, 70, 58, 59,
+	60, 61, 62, 0, 332, 0, 84, 0, 57, 0,
+	0, 85, 0, 80, 82, 66, 67, 69, 71, 81,
+	83, 0, 0, 0, 0, 0, 0, 0, 72, 73,
+	74, 75, 76, 77, 0, 0, 78, 79, 63, 64,
+	65, 0, 0, 0, 0, 0, 0, 86, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 68, 70, 58,
+	59, 60, 61, 62, 0, 0, 0, 84, 323, 57,
+	0, 0, 85, 0, 80, 82, 66, 67, 69, 71,
+	81, 83, 0, 0, 0, 0, 0, 0, 0, 72,
+	73, 74, 75, 76, 77, 0, 0, 78, 79, 63,
+	64, 65, 0, 0, 0, 0, 0, 0, 86, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 68, 70,
+	58, 59, 60, 61, 62, 0, 0, 0, 84, 322,
+	57, 0, 0, 85, 0, 80, 82, 66, 67, 69,
+	71, 81, 83, 0, 0, 0, 0, 0, 0, 0,
+	72, 73, 74, 75, 76, 77, 0, 0, 78, 79,
+	63, 64, 65, 0, 0, 0, 0, 0, 0, 86,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 68,
+	70, 58, 59, 60, 61, 62, 0, 0, 0, 84,
+	0, 57, 0, 0, 85, 310, 80, 82, 66, 67,
+	69, 71, 81, 83, 0, 0, 0, 0, 0, 0,
+	0, 72, 73, 74, 75, 76, 77, 0, 0, 78,
+	79, 63, 64, 65, 0, 0, 0, 0, 0, 0,
+	86, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 304, 0,
+	68, 70, 58, 59, 60, 61, 62, 0, 0, 0,
+	84, 0, 57, 0, 0, 85, 0, 80, 82, 66,
+	67, 69, 71, 81, 83, 0, 0, 0, 0, 0,
+	0, 0, 72, 73, 74, 75, 76, 77, 0, 0,
+	78, 79, 63, 64, 65, 0, 0, 0, 0, 0,
+	0, 86, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 68, 70, 58, 59, 60, 61, 62, 0, 0,
+	0, 84, 0, 57, 0, 0, 85, 293, 80, 82,
+	66, 67, 69, 71, 81, 83, 0, 0, 0, 0,
+	0, 0, 0, 72, 73, 74, 75, 76, 77, 0,
+	0, 78, 79, 63, 64, 65, 0, 0, 0, 0,
+	0, 0, 86, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 68, 70, 58, 59, 60, 61, 62, 0,
+	0, 0, 84, 291, 57, 0, 0, 85, 0, 80,
+	82, 66, 67, 69, 71, 81, 83, 0, 0, 0,
+	0, 0, 0, 0, 72, 73, 74, 75, 76, 77,
+	0, 0, 78, 79, 63, 64, 65, 0, 0, 0,
+	0, 0, 0, 86, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	222, 64, 66, 54, 55, 56, 57, 58, 0, 0,
-	0, 53, 0, 0, 221, 80, 81, 0, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 220, 64, 66, 54, 55, 56,
-	57, 58, 0, 0, 0, 53, 0, 0, 219, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 202, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 0, 0, 53,
-	0, 0, 0, 80, 81, 201, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 183, 0, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 0, 80, 81, 182,
-	76, 78, 62, 63, 65, 67, 77, 79, 0, 0,
-	0, 0, 0, 0, 0, 68, 69, 70, 71, 72,
-	73, 0, 0, 74, 75, 59, 60, 61, 0, 0,
-	0, 0, 0, 0, 82, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 64, 66, 54,
-	55, 56, 57, 58, 0, 309, 0, 53, 0, 0,
-	0, 80, 81, 0, 76, 78, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+	0, 0, 0, 68, 70, 58, 59, 60, 61, 62,
+	0, 0, 0, 84, 288, 57, 0, 0, 85, 0,
+	80, 82, 66, 67, 69, 71, 81, 83, 0, 0,
+	0, 0, 0, 0, 0, 72, 73, 74, 75, 76,
+	77, 0, 0, 78, 79, 63, 64, 65, 0, 0,
+	0, 0, 0, 0, 86, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 64, 66, 54, 55, 56, 57, 58, 0, 0,
-	0, 53, 0, 0, 294, 80, 81, 0, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 64, 66, 54, 55, 56,
-	57, 58, 0, 291, 0, 53, 0, 0, 0, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 290, 0, 53,
-	0, 0, 0, 80, 81, 0, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 284, 80, 81, 0,
-	76, 78, 62, 63, 65, 67, 77, 79, 0, 0,
-	0, 0, 0, 0, 0, 68, 69, 70, 71, 72,
-	73, 0, 0, 74, 75, 59, 60, 61, 0, 0,
-	0, 0, 0, 0, 82, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 64, 66, 54,
-	55, 56, 57, 58, 0, 0, 0, 53, 0, 0,
-	283, 80, 81, 0, 76, 78, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+	0, 0, 0, 272, 68, 70, 58, 59, 60, 61,
+	62, 0, 0, 0, 84, 0, 57, 0, 0, 85,
+	0, 80, 82, 66, 67, 69, 71, 81, 83, 0,
+	0, 0, 0, 0, 0, 0, 72, 73, 74, 75,
+	76, 77, 0, 0, 78, 79, 63, 64, 65, 0,
+	0, 0, 0, 0, 0, 86, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 64, 66, 54, 55, 56, 57, 58, 0, 0,
-	0, 53, 0, 0, 0, 80, 81, 271, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 269, 0, 64, 66, 54, 55, 56,
-	57, 58, 0, 0, 0, 53, 0, 0, 0, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 266, 0, 53,
-	0, 0, 0, 80, 81, 0, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 0, 80, 81, 265,
-	76, 78, 62, 63, 65, 67, 77, 79, 0, 0,
-	0, 0, 0, 0, 0, 68, 69, 70, 71, 72,
-	73, 0, 0, 74, 75, 59, 60, 61, 0, 0,
-	0, 0, 0, 0, 82, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 64, 66, 54,
-	55, 56, 57, 58, 0, 0, 0, 53, 0, 0,
-	263, 80, 81, 0, 76, 78, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+	0, 0, 0, 0, 0, 68, 70, 58, 59, 60,
+	61, 62, 0, 0, 0, 84, 0, 57, 0, 0,
+	85, 271, 80, 82, 66, 67, 69, 71, 81, 83,
+	0, 0, 0, 0, 0, 0, 0, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
+	254, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 68, 70, 58, 59,
+	60, 61, 62, 0, 0, 0, 84, 0, 57, 0,
+	0, 85, 0, 80, 82, 66, 67, 69, 71, 81,
+	83, 0, 0, 0, 0, 0, 0, 0, 72, 73,
+	74, 75, 76, 77, 0, 0, 78, 79, 63, 64,
+	65, 0, 0, 0, 0, 0, 0, 86, 0, 0,
+	0, 253, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 68, 70, 58,
+	59, 60, 61, 62, 0, 0, 0, 84, 0, 57,
+	0, 0, 85, 0, 80, 82, 66, 67, 69, 71,
+	81, 83, 0, 0, 0, 0, 0, 0, 0, 72,
+	73, 74, 75, 76, 77, 0, 0, 78, 79, 63,
+	64, 65, 0, 0, 0, 0, 0, 0, 86, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 64, 66, 54, 55, 56, 57, 58, 0, 0,
-	0, 53, 0, 0, 260, 80, 81, 0, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 245, 64, 66, 54, 55, 56,
-	57, 58, 0, 0, 0, 53, 0, 0, 0, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 0, 0, 53,
-	0, 0, 0, 80, 81, 244, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 235, 0, 0, 0, 0, 0,
-	0, 0, 0, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 0, 80, 81, 0,
-	76, 78, 62, 63, 65, 67, 77, 79, 0, 0,
-	0, 0, 0, 0, 0, 68, 69, 70, 71, 72,
-	73, 0, 0, 74, 75, 59, 60, 61, 0, 0,
-	0, 0, 0, 0, 82, 0, 0, 0, 234, 0,
-	0, 0, 0, 0, 0, 0, 0, 64, 66, 54,
-	55, 56, 57, 58, 0, 0, 0, 53, 0, 0,
-	0, 80, 81, 0, 76, 78, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 68, 70,
+	58, 59, 60, 61, 62, 0, 0, 0, 84, 0,
+	57, 0, 0, 85, 247, 80, 82, 66, 67, 69,
+	71, 81, 83, 0, 0, 0, 0, 0, 0, 0,
+	72, 73, 74, 75, 76, 77, 0, 0, 78, 79,
+	63, 64, 65, 0, 0, 0, 0, 0, 0, 86,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 64, 66, 54, 55, 56, 57, 58, 0, 0,
-	0, 53, 0, 0, 0, 80, 81, 230, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 64, 66, 54, 55, 56,
-	57, 58, 0, 179, 0, 53, 0, 0, 0, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 0, 0, 53,
-	0, 0, 170, 80, 81, 0, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 162, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 0, 80, 81, 0,
-	76, 78, 62, 63, 65, 67, 77, 79, 0, 0,
-	0, 0, 0, 0, 0, 68, 69, 70, 71, 72,
-	73, 0, 0, 74, 75, 59, 60, 61, 0, 0,
-	0, 0, 0, 0, 82, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 156, 0, 64, 66, 54,
-	55, 56, 57, 58, 0, 0, 0, 53, 0, 0,
-	0, 80, 81, 0, 76, 78, 62, 63, 65, 67,
-	77, 79, 0, 0, 0, 0, 0, 0, 0, 68,
-	69, 70, 71, 72, 73, 0, 0, 74, 75, 59,
-	60, 61, 0, 0, 0, 0, 0, 0, 82, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 68,
+	70, 58, 59, 60, 61, 62, 0, 189, 0, 84,
+	0, 57, 0, 0, 85, 0, 80, 82, 66, 67,
+	69, 71, 81, 83, 0, 0, 0, 0, 0, 0,
+	0, 72, 73, 74, 75, 76, 77, 0, 0, 78,
+	79, 63, 64, 65, 0, 0, 0, 0, 0, 0,
+	86, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 64, 66, 54, 55, 56, 57, 58, 0, 154,
-	0, 53, 0, 0, 0, 80, 81, 0, 76, 78,
-	62, 63, 65, 67, 77, 79, 0, 0, 0, 0,
-	0, 0, 0, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 0, 0, 0, 0, 0, 0,
-	0, 52, 0, 0, 0, 64, 66, 54, 55, 56,
-	57, 58, 0, 0, 0, 53, 0, 0, 0, 80,
-	81, 0, 76, 78, 62, 63, 65, 67, 77, 79,
-	0, 0, 0, 0, 0, 0, 0, 68, 69, 70,
-	71, 72, 73, 0, 0, 74, 75, 59, 60, 61,
-	0, 0, 0, 0, 0, 0, 82, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 64,
-	66, 54, 55, 56, 57, 58, 0, 0, 0, 53,
-	0, 0, 0, 80, 81, 0, 76, 78, 62, 63,
-	65, 67, 77, 79, 0, 0, 0, 0, 0, 0,
-	0, 68, 69, 70, 71, 72, 73, 0, 0, 74,
-	75, 59, 60, 61, 0, 0, 0, 0, 0, 0,
-	82, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 64, 66, 54, 55, 56, 57, 58,
-	0, 0, 0, 53, 0, 0, 0, 172, 81, 0,
-	76, 78, 63, 65, 67, 77, 79, 0, 0, 0,
-	0, 0, 0, 0, 68, 69, 70, 71, 72, 73,
-	0, 0, 74, 75, 59, 60, 61, 0, 0, 0,
-	0, 0, 0, 82, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 64, 66, 54, 55,
-	56, 57, 58, 0, 0, 0, 53, 0, 0, 0,
-	80, 81, 0, 76, 78, 62, 63, 65, 67, 0,
-	79, 0, 0, 0, 0, 0, 0, 0, 68, 69,
-	70, 71, 72, 73, 0, 0, 74, 75, 59, 60,
-	61, 0, 0, 0, 0, 0, 0, 82, 0, 0,
+	68, 70, 58, 59, 60, 61, 62, 0, 0, 0,
+	84, 179, 57, 0, 0, 85, 0, 80, 82, 66,
+	67, 69, 71, 81, 83, 0, 0, 0, 0, 0,
+	0, 0, 72, 73, 74, 75, 76, 77, 0, 0,
+	78, 79, 63, 64, 65, 0, 0, 0, 0, 0,
+	0, 86, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	64, 66, 54, 55, 56, 57, 58, 0, 0, 0,
-	53, 0, 0, 0, 80, 81, 0, 76, 78, 62,
-	63, 65, 67, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 68, 69, 70, 71, 72, 73, 0, 0,
-	74, 75, 59, 60, 61, 0, 0, 0, 0, 0,
-	0, 82, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 64, 66, 54, 55, 56, 57,
-	58, 0, 65, 67, 53, 0, 0, 0, 80, 81,
-	0, 76, 78, 68, 69, 70, 71, 72, 73, 0,
-	0, 74, 75, 59, 60, 61, 0, 0, 0, 0,
-	0, 0, 82, 0, 27, 28, 32, 0, 0, 38,
-	20, 21, 48, 0, 23, 64, 66, 54, 55, 56,
-	57, 58, 33, 34, 35, 53, 25, 0, 0, 80,
-	81, 0, 76, 78, 0, 18, 19, 0, 0, 232,
-	28, 32, 26, 0, 38, 42, 0, 43, 46, 44,
-	36, 0, 0, 0, 24, 37, 45, 33, 34, 35,
-	0, 0, 0, 0, 29, 0, 0, 0, 0, 40,
-	0, 0, 30, 31, 0, 41, 39, 0, 0, 0,
-	42, 0, 43, 46, 44, 36, 0, 0, 0, 0,
-	37, 45, 0, 0, 0, 27, 28, 32, 0, 29,
-	38, 0, 0, 0, 40, 0, 0, 30, 31, 0,
-	41, 39, 278, 33, 34, 35, 0, 0, 0, 0,
+	171, 68, 70, 58, 59, 60, 61, 62, 0, 0,
+	0, 84, 0, 57, 0, 0, 85, 0, 80, 82,
+	66, 67, 69, 71, 81, 83, 0, 0, 0, 0,
+	0, 0, 0, 72, 73, 74, 75, 76, 77, 0,
+	0, 78, 79, 63, 64, 65, 0, 0, 0, 0,
+	0, 0, 86, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	27, 28, 32, 0, 0, 38, 42, 0, 43, 46,
-	44, 36, 0, 0, 0, 0, 37, 45, 33, 34,
-	35, 0, 0, 0, 0, 29, 0, 0, 0, 0,
-	40, 0, 0, 30, 31, 0, 41, 39, 243, 0,
-	0, 42, 0, 43, 46, 44, 36, 0, 0, 0,
-	0, 37, 45, 0, 0, 0, 27, 28, 32, 0,
-	29, 38, 0, 0, 0, 40, 0, 0, 30, 31,
-	0, 41, 39, 229, 33, 34, 35, 0, 0, 0,
+	164, 0, 68, 70, 58, 59, 60, 61, 62, 0,
+	0, 0, 84, 0, 57, 0, 0, 85, 0, 80,
+	82, 66, 67, 69, 71, 81, 83, 0, 0, 0,
+	0, 0, 0, 0, 72, 73, 74, 75, 76, 77,
+	0, 0, 78, 79, 63, 64, 65, 0, 0, 0,
+	0, 0, 0, 86, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 42, 0, 43,
-	46, 44, 36, 0, 0, 0, 0, 37, 45, 0,
-	0, 161, 27, 28, 32, 0, 29, 38, 0, 0,
-	0, 40, 0, 0, 30, 31, 0, 41, 39, 0,
-	33, 34, 35, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 68, 70, 58, 59, 60, 61, 62,
+	0, 161, 0, 84, 0, 57, 0, 0, 85, 0,
+	80, 82, 66, 67, 69, 71, 81, 83, 0, 0,
+	0, 0, 0, 0, 0, 72, 73, 74, 75, 76,
+	77, 0, 0, 78, 79, 63, 64, 65, 0, 0,
+	0, 0, 0, 0, 86, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 42, 0, 43, 46, 44, 36, 0,
-	0, 0, 0, 37, 45, 0, 0, 145, 27, 28,
-	32, 0, 29, 38, 0, 0, 0, 40, 0, 0,
-	30, 31, 0, 41, 39, 0, 33, 34, 35, 0,
+	56, 0, 0, 0, 68, 70, 58, 59, 60, 61,
+	62, 0, 0, 0, 84, 0, 57, 0, 0, 85,
+	0, 80, 82, 66, 67, 69, 71, 81, 83, 0,
+	0, 0, 0, 0, 0, 0, 72, 73, 74, 75,
+	76, 77, 0, 0, 78, 79, 63, 64, 65, 0,
+	0, 0, 0, 0, 0, 86, 0, 0, 0, 0,
+	0, 0, 0, 0, 29, 30, 375, 0, 0, 42,
+	20, 21, 52, 0, 23, 68, 70, 58, 59, 60,
+	61, 62, 36, 37, 38, 84, 25, 57, 0, 0,
+	85, 0, 80, 82, 0, 18, 19, 0, 0, 0,
+	0, 0, 26, 0, 0, 46, 0, 47, 50, 48,
+	39, 0, 0, 0, 24, 41, 49, 28, 0, 0,
+	40, 27, 0, 35, 0, 0, 0, 0, 0, 0,
+	0, 31, 0, 0, 0, 0, 44, 0, 45, 0,
+	0, 32, 33, 43, 66, 67, 69, 71, 81, 83,
+	0, 0, 0, 0, 0, 0, 0, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
+	0, 0, 0, 0, 0, 29, 30, 34, 0, 0,
+	42, 20, 21, 52, 0, 23, 68, 70, 58, 59,
+	60, 61, 62, 36, 37, 38, 181, 25, 57, 0,
+	0, 85, 0, 80, 82, 0, 18, 19, 0, 0,
+	0, 0, 0, 26, 0, 0, 46, 0, 47, 50,
+	48, 39, 0, 0, 0, 24, 41, 49, 28, 0,
+	0, 40, 27, 0, 35, 0, 0, 0, 0, 0,
+	0, 0, 31, 0, 0, 0, 0, 44, 0, 45,
+	0, 0, 32, 33, 43, 67, 69, 71, 81, 83,
+	0, 0, 0, 0, 0, 0, 0, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 42,
-	0, 43, 46, 44, 36, 0, 0, 0, 0, 37,
-	45, 0, 0, 93, 27, 28, 32, 0, 29, 38,
-	0, 0, 0, 40, 0, 0, 30, 31, 0, 41,
-	39, 0, 33, 34, 35, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 232,
-	28, 32, 0, 0, 38, 42, 0, 43, 46, 44,
-	36, 0, 0, 0, 0, 37, 45, 33, 34, 35,
-	0, 0, 0, 0, 29, 0, 0, 0, 0, 40,
-	0, 0, 30, 31, 0, 41, 39, 0, 0, 0,
-	42, 0, 43, 46, 44, 36, 0, 0, 0, 0,
-	37, 45, 0, 0, 0, 225, 28, 32, 0, 29,
-	38, 0, 0, 0, 40, 0, 0, 30, 31, 0,
-	41, 39, 0, 33, 34, 35, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 68, 70, 58, 59,
+	60, 61, 62, 0, 0, 0, 84, 0, 57, 0,
+	0, 85, 0, 80, 82, 66, 67, 69, 71, 0,
+	83, 0, 0, 0, 0, 0, 0, 0, 72, 73,
+	74, 75, 76, 77, 0, 0, 78, 79, 63, 64,
+	65, 0, 0, 0, 0, 0, 0, 86, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	105, 28, 32, 0, 0, 38, 42, 0, 43, 46,
-	44, 36, 0, 0, 0, 0, 37, 45, 33, 34,
-	35, 0, 0, 0, 0, 29, 0, 0, 0, 0,
-	40, 0, 0, 30, 31, 0, 41, 39, 0, 0,
-	0, 42, 0, 43, 46, 44, 36, 0, 0, 0,
-	0, 37, 45, 0, 68, 69, 70, 71, 72, 73,
-	29, 0, 0, 0, 59, 40, 0, 0, 30, 31,
-	0, 41, 39, 82, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 68, 70, 58,
+	59, 60, 61, 62, 0, 0, 0, 84, 0, 57,
+	0, 0, 85, 0, 80, 82, 66, 67, 69, 71,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 72,
+	73, 74, 75, 76, 77, 0, 0, 78, 79, 63,
+	64, 65, 0, 0, 0, 0, 0, 0, 86, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	56, 57, 58, 0, 0, 0, 53, 0, 0, 0,
-	80, 81, 0, 76, 78,
+	0, 0, 0, 0, 0, 0, 0, 0, 68, 70,
+	58, 59, 60, 61, 62, 0, 69, 71, 84, 0,
+	57, 0, 0, 85, 0, 80, 82, 72, 73, 74,
+	75, 76, 77, 0, 0, 78, 79, 63, 64, 65,
+	0, 0, 0, 0, 0, 0, 86, 0, 0, 0,
+	0, 0, 0, 0, 0, 249, 30, 34, 0, 0,
+	42, 0, 0, 0, 0, 0, 68, 70, 58, 59,
+	60, 61, 62, 36, 37, 38, 84, 0, 57, 0,
+	0, 85, 0, 80, 82, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 46, 0, 47, 50,
+	48, 39, 0, 0, 0, 0, 41, 49, 0, 0,
+	0, 40, 0, 0, 35, 0, 0, 0, 29, 30,
+	34, 0, 31, 42, 0, 0, 0, 44, 0, 45,
+	0, 0, 32, 33, 43, 317, 36, 37, 38, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 46,
+	0, 47, 50, 48, 39, 0, 0, 0, 0, 41,
+	49, 0, 0, 0, 40, 0, 0, 35, 0, 0,
+	0, 29, 30, 34, 0, 31, 42, 0, 0, 0,
+	44, 0, 45, 0, 0, 32, 33, 43, 270, 36,
+	37, 38, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 46, 0, 47, 50, 48, 39, 0, 0,
+	0, 0, 41, 49, 0, 0, 0, 40, 0, 0,
+	35, 0, 0, 0, 0, 0, 0, 0, 31, 0,
+	0, 0, 0, 44, 0, 45, 0, 0, 32, 33,
+	43, 246, 72, 73, 74, 75, 76, 77, 0, 0,
+	78, 79, 63, 0, 0, 0, 0, 0, 0, 0,
+	0, 86, 29, 30, 34, 0, 0, 42, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	36, 37, 38, 58, 59, 60, 61, 62, 0, 0,
+	0, 84, 0, 57, 0, 0, 85, 0, 80, 82,
+	0, 0, 0, 46, 0, 47, 50, 48, 39, 0,
+	0, 0, 0, 41, 49, 0, 0, 0, 40, 0,
+	0, 35, 0, 0, 170, 29, 30, 34, 0, 31,
+	42, 0, 0, 0, 44, 0, 45, 0, 0, 32,
+	33, 43, 0, 36, 37, 38, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 46, 0, 47, 50,
+	48, 39, 0, 0, 0, 0, 41, 49, 0, 0,
+	0, 40, 0, 0, 35, 0, 0, 151, 29, 30,
+	34, 0, 31, 42, 0, 0, 0, 44, 0, 45,
+	0, 0, 32, 33, 43, 0, 36, 37, 38, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 46,
+	0, 47, 50, 48, 39, 0, 0, 0, 0, 41,
+	49, 0, 0, 0, 40, 0, 0, 35, 0, 0,
+	97, 29, 30, 34, 0, 31, 42, 0, 0, 0,
+	44, 0, 45, 0, 0, 32, 33, 43, 0, 36,
+	37, 38, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 46, 0, 47, 50, 48, 39, 0, 0,
+	0, 0, 41, 49, 0, 0, 0, 40, 0, 0,
+	35, 0, 0, 0, 29, 30, 34, 0, 31, 42,
+	0, 0, 0, 44, 0, 45, 0, 0, 32, 33,
+	43, 0, 36, 37, 38, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 46, 0, 47, 50, 48,
+	39, 0, 0, 0, 0, 41, 49, 0, 0, 0,
+	40, 0, 0, 35, 0, 0, 0, 249, 30, 34,
+	0, 31, 42, 0, 0, 0, 329, 0, 45, 0,
+	0, 32, 33, 43, 0, 36, 37, 38, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 46, 0,
+	47, 50, 48, 39, 0, 0, 0, 0, 41, 49,
+	0, 0, 0, 40, 0, 0, 35, 0, 0, 0,
+	242, 30, 34, 0, 31, 42, 0, 0, 0, 44,
+	0, 45, 0, 0, 32, 33, 43, 0, 36, 37,
+	38, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 46, 0, 47, 50, 48, 39, 0, 0, 0,
+	0, 41, 49, 0, 0, 0, 40, 0, 0, 35,
+	0, 0, 0, 111, 30, 34, 0, 31, 42, 0,
+	0, 0, 44, 0, 45, 0, 0, 32, 33, 43,
+	0, 36, 37, 38, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 46, 0, 47, 50, 48, 39,
+	0, 0, 0, 0, 41, 49, 0, 0, 0, 40,
+	0, 0, 35, 0, 72, 73, 74, 75, 76, 77,
+	31, 0, 0, 0, 63, 44, 0, 45, 0, 0,
+	32, 33, 43, 86, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 60, 61, 62,
+	0, 0, 0, 84, 0, 57, 0, 0, 85, 0,
+	80, 82,
 }
-var yyPact = [...]int{
-
-	152, 152, -1000, 205, -1000, -73, -73, -1000, -1000, -1000,
-	-1000, -1000, 2470, -73, -73, -1000, 2054, 144, -1000, -1000,
-	2820, 2820, -1000, 163, 2820, -73, 2764, -66, -1000, 2820,
-	2820, 2820, -1000, -1000, -1000, -1000, -1000, 2820, 32, -73,
-	-73, 2820, 2946, 42, -51, 203, 2820, 88, 2820, -1000,
-	324, -1000, 2820, 202, 2820, 2820, 2820, 2820, 2820, 2820,
-	2820, 2820, 2820, 2820, 2820, 2820, 2820, 2820, 2820, 2820,
-	2820, 2820, 2820, 2820, -1000, -1000, 2820, 2820, 2820, 2820,
-	2820, 2708, 2820, 2820, 2820, 54, 2118, 2118, 201, 140,
-	1990, 145, 1926, -73, 2820, 2652, 228, 228, 228, 1862,
-	200, -52, 2820, 189, 1798, -55, 2182, -43, -57, 2820,
-	-1000, 2820, -60, 2118, -73, 1734, -1000, 2118, -1000, 2975,
-	2975, 228, 228, 228, 2118, 61, 61, 2424, 2424, 61,
-	61, 61, 61, 2118, 2118, 2118, 2118, 2118, 2118, 2118,
-	2309, 2118, 2373, 78, 582, 2820, 2118, -1000, 2118, -1000,
-	-73, 165, 2820, 2820, -73, -73, -73, 101, 149, 73,
-	518, 2820, 2820, 69, 177, 198, -41, -46, -1000, 96,
-	-1000, 2820, 2820, 195, 2820, 454, 390, 2820, 2911, -73,
-	-24, -1000, -1000, 2596, 1670, 2855, 2820, 1606, 1542, 79,
-	72, 87, -1000, -1000, -1000, 2820, 91, -1000, -1000, -26,
-	-1000, -1000, 2561, 1478, 1414, -73, -73, -29, 48, 174,
-	-73, -65, -73, 71, 2820, 46, 44, -1000, 1350, -1000,
-	2820, -1000, 2820, 1286, 2245, -66, -1000, -1000, 1222, -1000,
-	-1000, 2118, -66, 1158, 2820, 2820, -1000, -1000, -1000, 1094,
-	-73, -1000, 1030, -1000, -1000, 2820, 194, -73, -73, -73,
-	-30, 2505, -1000, 81, -1000, 2118, -33, -1000, -48, -1000,
-	-1000, 966, 902, -1000, 121, -1000, -73, 838, 774, -73,
-	-73, -1000, 710, -1000, 65, -73, -73, -73, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -73, -1000, 2820, 53,
-	-73, -73, -73, -1000, -1000, -1000, 51, 43, -73, 35,
-	646, -1000, 33, 6, -1000, -1000, -1000, -37, -1000, -73,
-	-1000, -1000, -1000, -1000,
+
+var yyPact = [...]int16{
+	198, 198, -1000, 252, -1000, -67, -67, -1000, -1000, -1000,
+	-1000, -1000, 2961, -67, -67, -1000, 2716, 197, -1000, -1000,
+	3697, 3697, -1000, 211, 3697, -67, 3634, 3697, 248, -54,
+	-1000, 3697, 3697, 3697, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 3697, 104, -67, -67, 3697, 3949, 117, 58, 247,
+	3697, 138, 3697, -1000, 442, -1000, 3697, 246, 3697, 3697,
+	3697, 3697, 3697, 3697, 3697, 3697, 3697, 3697, 3697, 3697,
+	3697, 3697, 3697, 3697, 3697, 3697, 3697, 3697, -1000, -1000,
+	3697, 3697, 3697, 3697, 3697, 3571, 3697, 3697, 3697, 137,
+	2787, 2787, 245, 162, 2645, 200, 2574, -67, 2787, -67,
+	3697, 3508, 101, 101, 101, 2503, 244, 49, 3697, 229,
+	2432, -34, 2908, -35, 44, 3697, -1000, 3697, -64, 2787,
+	-67, 2361, -1000, 2787, -1000, 3985, 3985, 101, 101, 101,
+	2787, 3463, 3463, 3228, 3228, 3463, 3463, 3463, 3463, 2787,
+	2787, 2787, 2787, 2787, 2787, 2787, 3099, 2787, 3170, 91,
+	941, 3697, 2787, -1000, 2787, -1000, -67, 153, 243, 3697,
+	3697, -67, 5, -67, -67, 103, 178, -67, 71, 870,
+	3697, 3697, 45, 225, 242, -49, -52, -1000, 140, -1000,
+	3697, 3697, 241, 3697, 3697, 799, 728, 3697, 3886, -67,
+	26, -1000, -1000, 3407, 2290, 3823, 3697, 239, 210, 2219,
+	2148, 125, 87, -57, -1000, 122, 98, -1000, -1000, -1000,
+	3697, 142, -1000, -1000, 67, 12, -1000, -1000, 3344, 2077,
+	2006, -67, -67, -27, 31, 224, -67, -79, -67, 121,
+	3697, 50, 18, -1000, 28, 1935, -1000, 3697, -1000, 3697,
+	1864, 3028, -54, -1000, -1000, 1793, -1000, -1000, 2787, -54,
+	657, 209, 3697, 3697, 3697, -1000, -1000, -67, 85, 228,
+	-1000, -1000, 1722, -67, -1000, -67, 238, 237, -1000, 1651,
+	-1000, -1000, 3697, 235, -67, -67, -67, -42, 3281, -1000,
+	113, -1000, 2787, -56, -1000, -71, -1000, -1000, -1000, 1580,
+	1509, -1000, 37, -1000, -67, 3760, 3697, 1438, 586, 515,
+	116, -1000, -67, -1000, -67, -67, -67, 81, 196, 33,
+	-1000, 1367, -1000, 115, -67, -67, -67, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -67, -1000, 3697, 114, -67,
+	1296, 1225, -67, -67, 3697, -67, 3697, -1000, 106, -67,
+	-1000, 233, 3697, 3697, 232, -1000, -1000, 100, 90, -67,
+	89, 1154, -1000, 88, 2840, -67, -67, 82, 80, 1083,
+	79, 1012, -1000, -1000, -1000, 2787, 2787, 27, -1000, -1000,
+	-1000, 78, -1000, -67, -1000, 140, 74, 68, -1000, -1000,
+	-67, -1000, -67, -67, -1000, -1000, -1000, -1000, 66, 62,
+	-67, -1000, -1000, 60, -1000,
 }
-var yyPgo = [...]int{
 
-	0, 3, 222, 210, 220, 176, 219, 6, 5, 4,
-	218, 213, 170, 0, 16, 17, 1, 211, 2, 194,
-	75, 166,
+var yyPgo = [...]int16{
+	0, 54, 278, 257, 277, 210, 276, 7, 6, 4,
+	275, 272, 214, 0, 19, 12, 2, 271, 1, 270,
+	268, 267, 263, 3, 109, 41,
 }
-var yyR1 = [...]int{
 
+var yyR1 = [...]int8{
 	0, 2, 2, 2, 3, 1, 1, 4, 4, 4,
 	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 11, 11, 10, 6,
-	6, 9, 9, 9, 9, 9, 8, 7, 16, 17,
-	17, 17, 18, 18, 18, 15, 15, 15, 12, 12,
-	14, 14, 14, 14, 13, 13, 13, 13, 13, 13,
+	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
+	5, 5, 5, 5, 5, 5, 5, 5, 20, 20,
+	21, 21, 21, 21, 22, 22, 11, 11, 10, 6,
+	6, 9, 9, 9, 9, 9, 8, 7, 19, 19,
+	16, 17, 17, 17, 18, 18, 18, 15, 15, 15,
+	12, 12, 14, 14, 14, 14, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
 	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
-	13, 13, 13, 13, 13, 13, 13, 20, 20, 19,
-	19, 21, 21,
+	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
+	13, 13, 24, 24, 23, 23, 25, 25,
 }
-var yyR2 = [...]int{
 
+var yyR2 = [...]int8{
 	0, 0, 1, 2, 4, 1, 2, 0, 2, 3,
 	3, 3, 3, 1, 1, 2, 2, 1, 8, 9,
-	9, 5, 5, 5, 4, 1, 0, 2, 4, 8,
-	6, 0, 2, 2, 2, 2, 5, 4, 3, 0,
-	1, 4, 0, 1, 4, 1, 4, 4, 1, 3,
-	0, 1, 4, 4, 1, 1, 2, 2, 2, 1,
-	1, 1, 1, 1, 7, 3, 7, 8, 8, 9,
-	5, 6, 5, 6, 3, 3, 3, 3, 3, 3,
+	10, 10, 9, 9, 9, 11, 11, 5, 5, 7,
+	6, 8, 5, 5, 4, 2, 5, 1, 0, 3,
+	2, 4, 4, 8, 1, 3, 0, 2, 4, 8,
+	6, 0, 2, 2, 2, 2, 5, 4, 1, 3,
+	3, 0, 1, 4, 0, 1, 4, 1, 4, 4,
+	1, 3, 0, 1, 4, 4, 1, 1, 2, 2,
+	2, 1, 1, 1, 1, 1, 1, 1, 7, 3,
+	7, 8, 8, 9, 5, 6, 5, 6, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 2, 2, 3, 3, 3,
-	3, 5, 4, 6, 5, 5, 4, 6, 5, 4,
-	4, 6, 5, 5, 6, 5, 5, 2, 2, 5,
-	4, 6, 5, 4, 6, 3, 2, 0, 1, 1,
-	2, 1, 1,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 2,
+	2, 3, 3, 3, 3, 5, 4, 6, 5, 5,
+	4, 6, 5, 4, 4, 6, 5, 5, 6, 5,
+	5, 2, 5, 2, 5, 4, 6, 5, 4, 6,
+	3, 2, 0, 1, 1, 2, 1, 1,
 }
-var yyChk = [...]int{
-
-	-1000, -2, -3, 25, -3, 4, -19, -21, 80, 81,
-	-1, -21, -20, -4, -19, -5, -13, -15, 35, 36,
-	10, 11, -6, 14, 54, 26, 42, 4, 5, 64,
-	72, 73, 6, 22, 23, 24, 50, 55, 9, 76,
-	69, 75, 45, 47, 49, 56, 48, -14, 12, -20,
-	-19, -21, 57, 71, 63, 64, 65, 66, 67, 39,
-	40, 41, 16, 17, 61, 18, 62, 19, 29, 30,
-	31, 32, 33, 34, 37, 38, 78, 20, 79, 21,
-	75, 76, 48, 57, 16, -14, -13, -13, 51, 4,
-	-13, -1, -13, 59, 75, 76, -13, -13, -13, -13,
-	75, 4, -20, -20, -13, 4, -13, -12, 46, 75,
-	4, 75, -12, -13, 60, -13, -5, -13, 4, -13,
-	-13, -13, -13, -13, -13, -13, -13, -13, -13, -13,
+
+var yyChk = [...]int16{
+	-1000, -2, -3, 25, -3, 4, -23, -25, 87, 88,
+	-1, -25, -24, -4, -23, -5, -13, -15, 35, 36,
+	10, 11, -6, 14, 54, 26, 42, 61, 57, 4,
+	5, 71, 81, 82, 6, 63, 22, 23, 24, 50,
+	60, 55, 9, 83, 76, 78, 45, 47, 49, 56,
+	48, -14, 12, -24, -23, -25, 64, 80, 70, 71,
+	72, 73, 74, 39, 40, 41, 16, 17, 68, 18,
+	69, 19, 29, 30, 31, 32, 33, 34, 37, 38,
+	85, 20, 86, 21, 78, 83, 48, 64, 16, -14,
+	-13, -13, 51, 4, -13, -1, -13, 66, -13, 4,
+	78, 83, -13, -13, -13, -13, 78, 4, -24, -24,
+	-13, 4, -13, -12, 46, 78, 4, 78, -12, -13,
+	67, -13, -5, -13, 4, -13, -13, -13, -13, -13,
 	-13, -13, -13, -13, -13, -13, -13, -13, -13, -13,
-	-13, -13, -13, -14, -13, 59, -13, -15, -13, -15,
-	60, 4, 57, 16, 69, 27, 59, -9, -20, -14,
-	-13, 59, 60, -18, 4, 75, -14, -17, -16, 6,
-	74, 75, 75, 71, 75, -13, -13, 75, -20, 69,
-	8, 74, 77, 59, -13, -20, 15, -13, -13, -1,
-	-1, -9, 70, -8, -7, 43, 44, -8, -7, 8,
-	74, 77, 59, -13, -13, 60, 74, 8, -18, 4,
-	60, -20, 60, -20, 59, -14, -14, 4, -13, 74,
-	60, 74, 60, -13, -13, 4, -1, 74, -13, 77,
-	77, -13, 4, -13, 52, 52, 70, 70, 70, -13,
-	59, 74, -13, 77, 77, 60, -20, -20, 74, 74,
-	8, -20, 77, -20, 70, -13, 8, 74, 8, 74,
-	74, -13, -13, 74, -11, 77, 69, -13, -13, 59,
-	-20, 77, -13, 4, -1, -20, -20, 74, 77, -16,
-	70, 74, 74, 74, 74, -10, 13, 70, 53, -1,
-	69, 69, -20, -1, 74, 70, -1, -1, -20, -1,
-	-13, 70, -1, -1, -1, 70, 70, -1, 70, 69,
-	70, 70, 70, -1,
+	-13, -13, -13, -13, -13, -13, -13, -13, -13, -14,
+	-13, 66, -13, -15, -13, -15, 67, 4, 67, 64,
+	16, 76, 27, 28, 66, -9, -24, -23, -14, -13,
+	66, 67, -18, 4, 78, -14, -17, -16, 6, 79,
+	78, 78, 80, 78, 78, -13, -13, 78, -24, 76,
+	8, 79, 84, 66, -13, -24, 15, 67, 4, -13,
+	-13, -1, -1, -19, 6, -1, -9, 77, -8, -7,
+	43, 44, -8, -7, -20, 8, 79, 84, 66, -13,
+	-13, 67, 79, 8, -18, 4, 67, -24, 67, -24,
+	66, -14, -14, 4, -14, -13, 79, 67, 79, 67,
+	-13, -13, 4, -1, 79, -13, 84, 84, -13, 4,
+	-13, 4, 15, 52, 52, 77, 77, 28, -1, 67,
+	77, 77, -13, 66, 77, -21, 58, 59, 79, -13,
+	84, 84, 67, -24, -24, 79, 79, 8, -24, 84,
+	-24, 77, -13, 8, 79, 8, 79, 79, 79, -13,
+	-13, 79, -11, 84, 76, 45, 15, -13, -13, -13,
+	-1, 77, 28, 6, 66, -24, -23, -22, 4, 4,
+	84, -13, 4, -1, -24, -24, 79, 84, -16, 77,
+	79, 79, 79, 79, -10, 13, 77, 53, -1, 76,
+	-13, -13, 76, 76, 62, 76, 62, 77, -1, -24,
+	-1, 67, 64, 16, 78, 79, 77, -1, -1, -24,
+	-1, -13, 77, -1, -24, 76, 76, -1, -1, -13,
+	-1, -13, 77, -1, 4, -13, -13, -18, 4, 77,
+	77, -1, 77, 76, 77, 6, -1, -1, 77, 77,
+	76, 77, 76, 79, 77, -1, 77, 77, -1, -1,
+	-24, 77, 77, -1, 77,
 }
-var yyDef = [...]int{
-
-	1, -2, 2, 0, 3, 0, -2, 129, 131, 132,
-	4, 129, -2, 127, 128, 8, -2, 0, 13, 14,
-	50, 0, 17, 0, 0, -2, 0, 54, 55, 0,
-	0, 0, 59, 60, 61, 62, 63, 0, 0, 127,
-	127, 0, 0, 0, 0, 0, 0, 0, 0, 6,
-	-2, 130, 0, 0, 0, 0, 0, 0, 0, 0,
+
+var yyDef = [...]int16{
+	1, -2, 2, 0, 3, 0, -2, 154, 156, 157,
+	4, 154, -2, 152, 153, 8, -2, 0, 13, 14,
+	72, 0, 17, 0, 0, -2, 0, 0, 0, 76,
+	77, 0, 0, 0, 81, 82, 83, 84, 85, 86,
+	87, 0, 0, 152, 152, 0, 0, 0, 0, 0,
+	0, 0, 0, 6, -2, 155, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 95, 96, 0, 0, 0, 0,
-	50, 0, 0, 50, 50, 15, 51, 16, 0, 0,
-	0, 0, 0, 31, 50, 0, 56, 57, 58, 0,
-	42, 0, 50, 39, 0, 54, 0, 117, 118, 0,
-	48, 0, 0, 126, 127, 0, 9, 10, 65, 75,
-	76, 77, 78, 79, 80, 81, 82, -2, -2, 85,
-	86, 87, 88, 89, 90, 91, 92, 93, 94, 97,
-	98, 99, 100, 0, 0, 0, 125, 11, -2, 12,
-	127, 0, 0, 0, -2, -2, 31, 0, 0, 0,
-	0, 0, 0, 0, 43, 42, 127, 127, 40, 0,
-	74, 50, 50, 0, 0, 0, 0, 0, 0, -2,
-	0, 106, 110, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 24, 34, 35, 0, 0, 32, 33, 0,
-	102, 109, 0, 0, 0, 127, 127, 0, 0, 43,
-	127, 0, 127, 0, 0, 0, 0, 49, 0, 123,
-	0, 120, 0, 0, -2, -2, 26, 105, 0, 115,
-	116, 52, -2, 0, 0, 0, 21, 22, 23, 0,
-	127, 101, 0, 112, 113, 0, 0, -2, 127, 127,
-	0, 0, 70, 0, 72, 38, 0, -2, 0, -2,
-	119, 0, 0, 122, 0, 114, -2, 0, 0, 127,
-	-2, 111, 0, 44, 0, -2, -2, 127, 71, 41,
-	73, -2, -2, 124, 121, 27, -2, 30, 0, 0,
-	-2, -2, -2, 37, 64, 66, 0, 0, -2, 0,
-	0, 18, 0, 0, 36, 67, 68, 0, 29, -2,
-	19, 20, 69, 28,
+	0, 0, 0, 0, 0, 0, 0, 0, 119, 120,
+	0, 0, 0, 0, 72, 0, 0, 72, 72, 15,
+	73, 16, 0, 0, 0, 0, 0, 51, 35, 0,
+	72, 0, 78, 79, 80, 0, 64, 0, 72, 61,
+	0, 76, 0, 141, 143, 0, 70, 0, 0, 151,
+	152, 0, 9, 10, 89, 99, 100, 101, 102, 103,
+	104, 105, 106, -2, -2, 109, 110, 111, 112, 113,
+	114, 115, 116, 117, 118, 121, 122, 123, 124, 0,
+	0, 0, 150, 11, -2, 12, 152, 0, 0, 0,
+	0, -2, -2, -2, 51, 0, 0, 38, 0, 0,
+	0, 0, 0, 65, 64, 152, 152, 62, 0, 98,
+	72, 72, 0, 72, 0, 0, 0, 0, 0, -2,
+	0, 130, 134, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, -2, 58, 0, 0, 34, 54, 55,
+	0, 0, 52, 53, 0, 0, 126, 133, 0, 0,
+	0, 152, 152, 0, 0, 65, 152, 0, 152, 0,
+	0, 0, 0, 71, 0, 0, 148, 0, 145, 0,
+	0, -2, -2, 46, 129, 0, 139, 140, 74, -2,
+	0, 0, 0, 0, 0, 27, 28, -2, 0, 0,
+	32, 33, 0, 152, 36, 0, 0, 0, 125, 0,
+	136, 137, 0, 0, -2, 152, 152, 0, 0, 94,
+	0, 96, 60, 0, -2, 0, -2, 142, 144, 0,
+	0, 147, 0, 138, -2, 0, 0, 0, 0, 0,
+	0, 30, -2, 59, 152, -2, 39, 40, 44, 0,
+	135, 0, 66, 0, -2, -2, 152, 95, 63, 97,
+	-2, -2, 149, 146, 47, -2, 50, 0, 0, -2,
+	0, 0, -2, -2, 0, -2, 0, 29, 0, -2,
+	57, 0, 0, 0, 64, 88, 90, 0, 0, -2,
+	0, 0, 18, 0, -2, -2, -2, 0, 0, 0,
+	0, 0, 31, 56, 45, 41, 42, 0, 65, 91,
+	92, 0, 49, -2, 19, 81, 0, 0, 22, 23,
+	-2, 24, -2, 152, 93, 48, 20, 21, 0, 0,
+	-2, 25, 26, 0, 43,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	81, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	88, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 72, 3, 3, 3, 67, 79, 3,
-	75, 74, 65, 63, 60, 64, 71, 66, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 59, 80,
-	62, 57, 61, 58, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 81, 3, 3, 3, 74, 86, 3,
+	78, 79, 72, 70, 67, 71, 80, 73, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 66, 87,
+	69, 64, 68, 65, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 76, 3, 77, 73, 3, 3, 3, 3, 3,
+	3, 83, 3, 84, 82, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 69, 78, 70,
+	3, 3, 3, 76, 85, 77,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
-	52, 53, 54, 55, 56, 68,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 75,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -949,9 +1149,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -961,13 +1161,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -998,30 +1198,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -1076,7 +1276,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -1087,8 +1287,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -1101,7 +1301,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -1110,18 +1310,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -1143,10 +1343,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -1182,7 +1382,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -1193,16 +1393,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -1210,7 +1410,7 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:72
+//line parser.y:78
 		{
 			yyVAL.modules = nil
 			if l, ok := yylex.(*Lexer); ok {
@@ -1219,7 +1419,7 @@ yydefault:
 		}
 	case 2:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:79
+//line parser.y:85
 		{
 			yyVAL.modules = ast.Stmts{yyDollar[1].module}
 			if l, ok := yylex.(*Lexer); ok {
@@ -1228,7 +1428,7 @@ yydefault:
 		}
 	case 3:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:86
+//line parser.y:92
 		{
 			if yyDollar[2].module != nil {
 				yyVAL.modules = append(yyDollar[1].modules, yyDollar[2].module)
@@ -1239,38 +1439,38 @@ yydefault:
 		}
 	case 4:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:97
+//line parser.y:103
 		{
 			yyVAL.module = &ast.ModuleStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Stmts: yyDollar[4].compstmt}
 			yyVAL.module.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 5:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:103
+//line parser.y:109
 		{
 			yyVAL.compstmt = nil
 		}
 	case 6:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:107
+//line parser.y:113
 		{
 			yyVAL.compstmt = yyDollar[1].stmts
 		}
 	case 7:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:112
+//line parser.y:118
 		{
 			yyVAL.stmts = nil
 		}
 	case 8:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:116
+//line parser.y:122
 		{
 			yyVAL.stmts = ast.Stmts{yyDollar[2].stmt}
 		}
 	case 9:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:120
+//line parser.y:126
 		{
 			if yyDollar[3].stmt != nil {
 				yyVAL.stmts = append(yyDollar[1].stmts, yyDollar[3].stmt)
@@ -1278,172 +1478,328 @@ yydefault:
 		}
 	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:128
+//line parser.y:134
 		{
 			yyVAL.stmt = &ast.LetsStmt{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "=", Rhss: []ast.Expr{yyDollar[3].expr}}
 		}
 	case 11:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:132
+//line parser.y:138
 		{
 			yyVAL.stmt = &ast.LetsStmt{Lhss: yyDollar[1].expr_many, Operator: "=", Rhss: yyDollar[3].expr_many}
 		}
 	case 12:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:136
+//line parser.y:142
 		{
 			yyVAL.stmt = &ast.ExprStmt{Expr: &ast.BinOpExpr{Lhss: yyDollar[1].expr_many, Operator: "==", Rhss: yyDollar[3].expr_many}}
 		}
 	case 13:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:140
+//line parser.y:146
 		{
 			yyVAL.stmt = &ast.BreakStmt{}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 14:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:145
+//line parser.y:151
 		{
 			yyVAL.stmt = &ast.ContinueStmt{}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 15:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:150
+//line parser.y:156
 		{
 			yyVAL.stmt = &ast.ReturnStmt{Exprs: yyDollar[2].exprs}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 16:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:155
+//line parser.y:161
 		{
 			yyVAL.stmt = &ast.ThrowStmt{Expr: yyDollar[2].expr}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 17:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:160
+//line parser.y:166
 		{
 			yyVAL.stmt = yyDollar[1].stmt_if
 			yyVAL.stmt.SetPosition(yyDollar[1].stmt_if.Position())
 		}
 	case 18:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parser.y:165
+//line parser.y:171
 		{
-			yyVAL.stmt = &ast.ForStmt{Var: names.UniqueNames.Set(yyDollar[3].tok.Lit), Value: yyDollar[5].expr, Stmts: yyDollar[7].compstmt}
+			yyVAL.stmt = &ast.ForStmt{Var: names.UniqueNames.Set(yyDollar[3].tok.Lit), Value: yyDollar[5].expr, Stmts: yyDollar[7].compstmt, End: yyDollar[8].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 19:
 		yyDollar = yyS[yypt-9 : yypt+1]
-//line parser.y:170
+//line parser.y:176
 		{
-			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Stmts: yyDollar[8].compstmt}
+			yyVAL.stmt = &ast.ForStmt{Var: names.UniqueNames.Set(yyDollar[3].tok.Lit), Value: yyDollar[5].expr, Stmts: yyDollar[8].compstmt, Parallel: true, End: yyDollar[9].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 20:
-		yyDollar = yyS[yypt-9 : yypt+1]
-//line parser.y:175
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line parser.y:181
 		{
-			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Stmts: yyDollar[8].compstmt}
+			yyVAL.stmt = &ast.ForStmt{Var: names.UniqueNames.Set(yyDollar[3].tok.Lit), Value: yyDollar[5].expr, Stmts: yyDollar[9].compstmt, Parallel: true, Workers: yyDollar[7].expr, End: yyDollar[10].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 21:
-		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:180
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line parser.y:186
 		{
-			yyVAL.stmt = &ast.LoopStmt{Expr: yyDollar[2].expr, Stmts: yyDollar[4].compstmt}
+			yyVAL.stmt = &ast.ForStmt{Key: names.UniqueNames.Set(yyDollar[3].tok.Lit), Var: names.UniqueNames.Set(yyDollar[5].tok.Lit), Value: yyDollar[7].expr, Stmts: yyDollar[9].compstmt, End: yyDollar[10].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 22:
-		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:185
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line parser.y:191
 		{
-			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, Catch: yyDollar[4].compstmt}
+			yyVAL.stmt = &ast.ForStmt{Key: names.UniqueNames.Set(yyDollar[2].tok.Lit), Var: names.UniqueNames.Set(yyDollar[4].tok.Lit), Value: yyDollar[6].expr, Stmts: yyDollar[8].compstmt, End: yyDollar[9].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 23:
-		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:190
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line parser.y:196
 		{
-			yyVAL.stmt = &ast.SwitchStmt{Expr: yyDollar[2].expr, Cases: yyDollar[4].stmt_cases}
+			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Stmts: yyDollar[8].compstmt, End: yyDollar[9].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 24:
-		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:195
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line parser.y:201
 		{
-			yyVAL.stmt = &ast.SelectStmt{Cases: yyDollar[3].stmt_cases}
+			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Stmts: yyDollar[8].compstmt, End: yyDollar[9].tok.Position()}
 			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
 		}
 	case 25:
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line parser.y:206
+		{
+			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Step: yyDollar[8].expr, Stmts: yyDollar[10].compstmt, End: yyDollar[11].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 26:
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line parser.y:211
+		{
+			yyVAL.stmt = &ast.NumForStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Expr1: yyDollar[4].expr, Expr2: yyDollar[6].expr, Step: yyDollar[8].expr, Stmts: yyDollar[10].compstmt, End: yyDollar[11].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 27:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:216
+		{
+			yyVAL.stmt = &ast.LoopStmt{Expr: yyDollar[2].expr, Stmts: yyDollar[4].compstmt, End: yyDollar[5].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 28:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:221
+		{
+			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, Catch: yyDollar[4].compstmt, CatchPos: yyDollar[3].tok.Position(), End: yyDollar[5].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 29:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.y:226
+		{
+			// пустые блоки Исключение и Окончательно не должны теряться
+			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, Catch: append(ast.Stmts{}, yyDollar[4].compstmt...), Finally: append(ast.Stmts{}, yyDollar[6].compstmt...), CatchPos: yyDollar[3].tok.Position(), FinallyPos: yyDollar[5].tok.Position(), End: yyDollar[7].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 30:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.y:232
+		{
+			// исключение перехватывается, только если его вид есть в списке
+			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, CatchKinds: yyDollar[4].catch_kinds, Catch: append(ast.Stmts{}, yyDollar[5].compstmt...), CatchPos: yyDollar[3].tok.Position(), End: yyDollar[6].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 31:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.y:238
+		{
+			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, CatchKinds: yyDollar[4].catch_kinds, Catch: append(ast.Stmts{}, yyDollar[5].compstmt...), Finally: append(ast.Stmts{}, yyDollar[7].compstmt...), CatchPos: yyDollar[3].tok.Position(), FinallyPos: yyDollar[6].tok.Position(), End: yyDollar[8].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 32:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:243
+		{
+			yyVAL.stmt = &ast.TryStmt{Try: yyDollar[2].compstmt, Finally: append(ast.Stmts{}, yyDollar[4].compstmt...), FinallyPos: yyDollar[3].tok.Position(), End: yyDollar[5].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 33:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:248
+		{
+			yyVAL.stmt = &ast.SwitchStmt{Expr: yyDollar[2].expr, Cases: yyDollar[4].stmt_cases, End: yyDollar[5].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 34:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:253
+		{
+			yyVAL.stmt = &ast.SelectStmt{Cases: yyDollar[3].stmt_cases, End: yyDollar[4].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 35:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:258
+		{
+			// откладывается только вызов функции, ее аргументы вычисляются сразу
+			switch c := yyDollar[2].expr.(type) {
+			case *ast.CallExpr:
+				if c.Go {
+					yylex.Error("Отложить ожидает вызов функции без Старт")
+				}
+				c.Defer = true
+			case *ast.AnonCallExpr:
+				if c.Go {
+					yylex.Error("Отложить ожидает вызов функции без Старт")
+				}
+				c.Defer = true
+			default:
+				yylex.Error("Отложить ожидает вызов функции")
+			}
+			yyVAL.stmt = &ast.DeferStmt{Expr: yyDollar[2].expr}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 36:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:278
+		{
+			yyVAL.stmt = &ast.TypeStmt{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Members: yyDollar[4].stmts, End: yyDollar[5].tok.Position()}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 37:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:200
+//line parser.y:283
 		{
 			yyVAL.stmt = &ast.ExprStmt{Expr: yyDollar[1].expr}
 			yyVAL.stmt.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 26:
+	case 38:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:206
+//line parser.y:289
+		{
+			yyVAL.stmts = nil
+		}
+	case 39:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:293
+		{
+			yyVAL.stmts = append(yyDollar[1].stmts, yyDollar[2].stmt)
+		}
+	case 40:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:299
+		{
+			yyVAL.stmt = &ast.FieldStmt{Names: yyDollar[2].expr_idents}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 41:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:304
+		{
+			yyVAL.stmt = &ast.FieldStmt{Names: []int{names.UniqueNames.Set(yyDollar[2].tok.Lit)}, Value: yyDollar[4].expr}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 42:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:309
+		{
+			yyVAL.stmt = &ast.FieldStmt{Names: []int{names.UniqueNames.Set(yyDollar[2].tok.Lit)}, Value: yyDollar[4].expr}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 43:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.y:314
+		{
+			f := &ast.FuncExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Args: yyDollar[4].expr_idents, Stmts: yyDollar[7].compstmt, End: yyDollar[8].tok.Position()}
+			f.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.stmt = &ast.MethodStmt{Func: f}
+			yyVAL.stmt.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 44:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:323
+		{
+			yyVAL.expr_idents = []int{names.UniqueNames.Set(yyDollar[1].tok.Lit)}
+		}
+	case 45:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:327
+		{
+			yyVAL.expr_idents = append(yyDollar[1].expr_idents, names.UniqueNames.Set(yyDollar[3].tok.Lit))
+		}
+	case 46:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:332
 		{
 			yyVAL.stmt_elsifs = ast.Stmts{}
 		}
-	case 27:
+	case 47:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:210
+//line parser.y:336
 		{
 			yyVAL.stmt_elsifs = append(yyDollar[1].stmt_elsifs, yyDollar[2].stmt_elsif)
 		}
-	case 28:
+	case 48:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:216
+//line parser.y:342
 		{
 			yyVAL.stmt_elsif = &ast.IfStmt{If: yyDollar[2].expr, Then: yyDollar[4].compstmt}
+			yyVAL.stmt_elsif.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 29:
+	case 49:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parser.y:222
+//line parser.y:349
 		{
-			yyVAL.stmt_if = &ast.IfStmt{If: yyDollar[2].expr, Then: yyDollar[4].compstmt, ElseIf: yyDollar[5].stmt_elsifs, Else: yyDollar[7].compstmt}
+			yyVAL.stmt_if = &ast.IfStmt{If: yyDollar[2].expr, Then: yyDollar[4].compstmt, ElseIf: yyDollar[5].stmt_elsifs, Else: yyDollar[7].compstmt, ElsePos: yyDollar[6].tok.Position(), End: yyDollar[8].tok.Position()}
 			yyVAL.stmt_if.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 30:
+	case 50:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:227
+//line parser.y:354
 		{
-			yyVAL.stmt_if = &ast.IfStmt{If: yyDollar[2].expr, Then: yyDollar[4].compstmt, ElseIf: yyDollar[5].stmt_elsifs, Else: nil}
+			yyVAL.stmt_if = &ast.IfStmt{If: yyDollar[2].expr, Then: yyDollar[4].compstmt, ElseIf: yyDollar[5].stmt_elsifs, Else: nil, End: yyDollar[6].tok.Position()}
 			yyVAL.stmt_if.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 31:
+	case 51:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:233
+//line parser.y:360
 		{
 			yyVAL.stmt_cases = ast.Stmts{}
 		}
-	case 32:
+	case 52:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:237
+//line parser.y:364
 		{
 			yyVAL.stmt_cases = ast.Stmts{yyDollar[2].stmt_case}
 		}
-	case 33:
+	case 53:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:241
+//line parser.y:368
 		{
 			yyVAL.stmt_cases = ast.Stmts{yyDollar[2].stmt_default}
 		}
-	case 34:
+	case 54:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:245
+//line parser.y:372
 		{
 			yyVAL.stmt_cases = append(yyDollar[1].stmt_cases, yyDollar[2].stmt_case)
 		}
-	case 35:
+	case 55:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:249
+//line parser.y:376
 		{
 			for _, stmt := range yyDollar[1].stmt_cases {
 				if _, ok := stmt.(*ast.DefaultStmt); ok {
@@ -1452,661 +1808,703 @@ yydefault:
 			}
 			yyVAL.stmt_cases = append(yyDollar[1].stmt_cases, yyDollar[2].stmt_default)
 		}
-	case 36:
+	case 56:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:260
+//line parser.y:387
 		{
 			yyVAL.stmt_case = &ast.CaseStmt{Expr: yyDollar[2].expr, Stmts: yyDollar[5].compstmt}
+			yyVAL.stmt_case.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 37:
+	case 57:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:266
+//line parser.y:394
 		{
 			yyVAL.stmt_default = &ast.DefaultStmt{Stmts: yyDollar[4].compstmt}
+			yyVAL.stmt_default.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 38:
+	case 58:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:401
+		{
+			yyVAL.catch_kinds = []string{yyDollar[1].tok.Lit}
+		}
+	case 59:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:405
+		{
+			yyVAL.catch_kinds = append(yyDollar[1].catch_kinds, yyDollar[3].tok.Lit)
+		}
+	case 60:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:272
+//line parser.y:411
 		{
 			yyVAL.expr_pair = &ast.PairExpr{Key: yyDollar[1].tok.Lit, Value: yyDollar[3].expr}
 		}
-	case 39:
+	case 61:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:277
+//line parser.y:416
 		{
 			yyVAL.expr_pairs = []ast.Expr{}
 		}
-	case 40:
+	case 62:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:281
+//line parser.y:420
 		{
 			yyVAL.expr_pairs = []ast.Expr{yyDollar[1].expr_pair}
 		}
-	case 41:
+	case 63:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:285
+//line parser.y:424
 		{
 			yyVAL.expr_pairs = append(yyDollar[1].expr_pairs, yyDollar[4].expr_pair)
 		}
-	case 42:
+	case 64:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:290
+//line parser.y:429
 		{
 			yyVAL.expr_idents = []int{}
 		}
-	case 43:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:294
+//line parser.y:433
 		{
 			yyVAL.expr_idents = []int{names.UniqueNames.Set(yyDollar[1].tok.Lit)}
 		}
-	case 44:
+	case 66:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:298
+//line parser.y:437
 		{
 			yyVAL.expr_idents = append(yyDollar[1].expr_idents, names.UniqueNames.Set(yyDollar[4].tok.Lit))
 		}
-	case 45:
+	case 67:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:304
+//line parser.y:443
 		{
 			yyVAL.expr_many = []ast.Expr{yyDollar[1].expr}
 		}
-	case 46:
+	case 68:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:308
+//line parser.y:447
 		{
 			yyVAL.expr_many = append(yyDollar[1].exprs, yyDollar[4].expr)
 		}
-	case 47:
+	case 69:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:312
+//line parser.y:451
 		{
 			yyVAL.expr_many = append(yyDollar[1].exprs, &ast.IdentExpr{Lit: yyDollar[4].tok.Lit, Id: names.UniqueNames.Set(yyDollar[4].tok.Lit)})
 		}
-	case 48:
+	case 70:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:317
+//line parser.y:456
 		{
 			yyVAL.typ = ast.Type{Name: names.UniqueNames.Set(yyDollar[1].tok.Lit)}
 		}
-	case 49:
+	case 71:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:321
+//line parser.y:460
 		{
 			yyVAL.typ = ast.Type{Name: names.UniqueNames.Set(names.UniqueNames.Get(yyDollar[1].typ.Name) + "." + yyDollar[3].tok.Lit)}
 		}
-	case 50:
+	case 72:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:326
+//line parser.y:465
 		{
 			yyVAL.exprs = nil
 		}
-	case 51:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:330
+//line parser.y:469
 		{
 			yyVAL.exprs = []ast.Expr{yyDollar[1].expr}
 		}
-	case 52:
+	case 74:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:334
+//line parser.y:473
 		{
 			yyVAL.exprs = append(yyDollar[1].exprs, yyDollar[4].expr)
 		}
-	case 53:
+	case 75:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:338
+//line parser.y:477
 		{
 			yyVAL.exprs = append(yyDollar[1].exprs, &ast.IdentExpr{Lit: yyDollar[4].tok.Lit, Id: names.UniqueNames.Set(yyDollar[4].tok.Lit)})
 		}
-	case 54:
+	case 76:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:344
+//line parser.y:483
 		{
 			yyVAL.expr = &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: names.UniqueNames.Set(yyDollar[1].tok.Lit)}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 55:
+	case 77:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:349
+//line parser.y:488
 		{
 			yyVAL.expr = &ast.NumberExpr{Lit: yyDollar[1].tok.Lit}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 56:
+	case 78:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:354
+//line parser.y:493
 		{
 			yyVAL.expr = &ast.UnaryExpr{Operator: "-", Expr: yyDollar[2].expr}
 			yyVAL.expr.SetPosition(yyDollar[2].expr.Position())
 		}
-	case 57:
+	case 79:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:359
+//line parser.y:498
 		{
 			yyVAL.expr = &ast.UnaryExpr{Operator: "!", Expr: yyDollar[2].expr}
 			yyVAL.expr.SetPosition(yyDollar[2].expr.Position())
 		}
-	case 58:
+	case 80:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:364
+//line parser.y:503
 		{
 			yyVAL.expr = &ast.UnaryExpr{Operator: "^", Expr: yyDollar[2].expr}
 			yyVAL.expr.SetPosition(yyDollar[2].expr.Position())
 		}
-	case 59:
+	case 81:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:369
+//line parser.y:508
 		{
 			yyVAL.expr = &ast.StringExpr{Lit: yyDollar[1].tok.Lit}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 60:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:374
+//line parser.y:513
+		{
+			yyVAL.expr = yyDollar[1].expr
+		}
+	case 83:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:517
 		{
 			yyVAL.expr = &ast.ConstExpr{Value: "истина"}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 61:
+	case 84:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:379
+//line parser.y:522
 		{
 			yyVAL.expr = &ast.ConstExpr{Value: "ложь"}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 62:
+	case 85:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:384
+//line parser.y:527
 		{
 			yyVAL.expr = &ast.ConstExpr{Value: "неопределено"}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 63:
+	case 86:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:389
+//line parser.y:532
 		{
 			yyVAL.expr = &ast.ConstExpr{Value: "null"}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 64:
+	case 87:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:537
+		{
+			yyVAL.expr = &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: ast.ThisObject}
+			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 88:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line parser.y:394
+//line parser.y:542
 		{
 			yyVAL.expr = &ast.TernaryOpExpr{Expr: yyDollar[2].expr, Lhs: yyDollar[4].expr, Rhs: yyDollar[6].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 65:
+	case 89:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:399
+//line parser.y:547
 		{
 			yyVAL.expr = &ast.MemberExpr{Expr: yyDollar[1].expr, Name: names.UniqueNames.Set(yyDollar[3].tok.Lit)}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 66:
+	case 90:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line parser.y:404
+//line parser.y:552
 		{
-			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set("<анонимная функция>"), Args: yyDollar[3].expr_idents, Stmts: yyDollar[6].compstmt}
+			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set("<анонимная функция>"), Args: yyDollar[3].expr_idents, Stmts: yyDollar[6].compstmt, End: yyDollar[7].tok.Position()}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 67:
+	case 91:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parser.y:409
+//line parser.y:557
 		{
-			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set("<анонимная функция>"), Args: []int{names.UniqueNames.Set(yyDollar[3].tok.Lit)}, Stmts: yyDollar[7].compstmt, VarArg: true}
+			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set("<анонимная функция>"), Args: []int{names.UniqueNames.Set(yyDollar[3].tok.Lit)}, Stmts: yyDollar[7].compstmt, VarArg: true, End: yyDollar[8].tok.Position()}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 68:
+	case 92:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parser.y:414
+//line parser.y:562
 		{
-			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Args: yyDollar[4].expr_idents, Stmts: yyDollar[7].compstmt}
+			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Args: yyDollar[4].expr_idents, Stmts: yyDollar[7].compstmt, End: yyDollar[8].tok.Position()}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 69:
+	case 93:
 		yyDollar = yyS[yypt-9 : yypt+1]
-//line parser.y:419
+//line parser.y:567
 		{
-			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Args: []int{names.UniqueNames.Set(yyDollar[4].tok.Lit)}, Stmts: yyDollar[8].compstmt, VarArg: true}
+			yyVAL.expr = &ast.FuncExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), Args: []int{names.UniqueNames.Set(yyDollar[4].tok.Lit)}, Stmts: yyDollar[8].compstmt, VarArg: true, End: yyDollar[9].tok.Position()}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 70:
+	case 94:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:424
+//line parser.y:572
 		{
 			yyVAL.expr = &ast.ArrayExpr{Exprs: yyDollar[3].exprs}
 			if l, ok := yylex.(*Lexer); ok {
 				yyVAL.expr.SetPosition(l.pos)
 			}
 		}
-	case 71:
+	case 95:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:429
+//line parser.y:577
 		{
 			yyVAL.expr = &ast.ArrayExpr{Exprs: yyDollar[3].exprs}
 			if l, ok := yylex.(*Lexer); ok {
 				yyVAL.expr.SetPosition(l.pos)
 			}
 		}
-	case 72:
+	case 96:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:434
+//line parser.y:582
 		{
 			mapExpr := make(map[string]ast.Expr)
+			var keys []string
 			for _, v := range yyDollar[3].expr_pairs {
+				if _, ok := mapExpr[v.(*ast.PairExpr).Key]; !ok {
+					keys = append(keys, v.(*ast.PairExpr).Key)
+				}
 				mapExpr[v.(*ast.PairExpr).Key] = v.(*ast.PairExpr).Value
 			}
-			yyVAL.expr = &ast.MapExpr{MapExpr: mapExpr}
+			yyVAL.expr = &ast.MapExpr{MapExpr: mapExpr, Keys: keys}
 			if l, ok := yylex.(*Lexer); ok {
 				yyVAL.expr.SetPosition(l.pos)
 			}
 		}
-	case 73:
+	case 97:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:443
+//line parser.y:595
 		{
 			mapExpr := make(map[string]ast.Expr)
+			var keys []string
 			for _, v := range yyDollar[3].expr_pairs {
+				if _, ok := mapExpr[v.(*ast.PairExpr).Key]; !ok {
+					keys = append(keys, v.(*ast.PairExpr).Key)
+				}
 				mapExpr[v.(*ast.PairExpr).Key] = v.(*ast.PairExpr).Value
 			}
-			yyVAL.expr = &ast.MapExpr{MapExpr: mapExpr}
+			yyVAL.expr = &ast.MapExpr{MapExpr: mapExpr, Keys: keys}
 			if l, ok := yylex.(*Lexer); ok {
 				yyVAL.expr.SetPosition(l.pos)
 			}
 		}
-	case 74:
+	case 98:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:452
+//line parser.y:608
 		{
 			yyVAL.expr = &ast.ParenExpr{SubExpr: yyDollar[2].expr}
 			if l, ok := yylex.(*Lexer); ok {
 				yyVAL.expr.SetPosition(l.pos)
 			}
 		}
-	case 75:
+	case 99:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:457
+//line parser.y:613
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "+", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 76:
+	case 100:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:462
+//line parser.y:618
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "-", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 77:
+	case 101:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:467
+//line parser.y:623
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "*", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 78:
+	case 102:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:472
+//line parser.y:628
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "/", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 79:
+	case 103:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:477
+//line parser.y:633
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "%", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 80:
+	case 104:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:482
+//line parser.y:638
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "**", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 81:
+	case 105:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:487
+//line parser.y:643
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "<<", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 82:
+	case 106:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:492
+//line parser.y:648
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: ">>", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 83:
+	case 107:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:497
+//line parser.y:653
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "==", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 84:
+	case 108:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:502
+//line parser.y:658
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "!=", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 85:
+	case 109:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:507
+//line parser.y:663
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: ">", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 86:
+	case 110:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:512
+//line parser.y:668
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: ">=", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 87:
+	case 111:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:517
+//line parser.y:673
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "<", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 88:
+	case 112:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:522
+//line parser.y:678
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "<=", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 89:
+	case 113:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:527
+//line parser.y:683
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "+=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 90:
+	case 114:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:532
+//line parser.y:688
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "-=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 91:
+	case 115:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:537
+//line parser.y:693
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "*=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 92:
+	case 116:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:542
+//line parser.y:698
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "/=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 93:
+	case 117:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:547
+//line parser.y:703
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "&=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 94:
+	case 118:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:552
+//line parser.y:708
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "|=", Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 95:
+	case 119:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:557
+//line parser.y:713
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "++"}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 96:
+	case 120:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:562
+//line parser.y:718
 		{
 			yyVAL.expr = &ast.AssocExpr{Lhs: yyDollar[1].expr, Operator: "--"}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 97:
+	case 121:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:567
+//line parser.y:723
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "|", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 98:
+	case 122:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:572
+//line parser.y:728
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "||", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 99:
+	case 123:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:577
+//line parser.y:733
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "&", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 100:
+	case 124:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:582
+//line parser.y:738
 		{
 			yyVAL.expr = &ast.BinOpExpr{Lhss: []ast.Expr{yyDollar[1].expr}, Operator: "&&", Rhss: []ast.Expr{yyDollar[3].expr}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 101:
+	case 125:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:587
+//line parser.y:743
 		{
 			yyVAL.expr = &ast.CallExpr{Name: names.UniqueNames.Set(yyDollar[1].tok.Lit), SubExprs: yyDollar[3].exprs, VarArg: true}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 102:
+	case 126:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:592
+//line parser.y:748
 		{
 			yyVAL.expr = &ast.CallExpr{Name: names.UniqueNames.Set(yyDollar[1].tok.Lit), SubExprs: yyDollar[3].exprs}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 103:
+	case 127:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:597
+//line parser.y:753
 		{
 			yyVAL.expr = &ast.CallExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), SubExprs: yyDollar[4].exprs, VarArg: true, Go: true}
 			yyVAL.expr.SetPosition(yyDollar[2].tok.Position())
 		}
-	case 104:
+	case 128:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:602
+//line parser.y:758
 		{
 			yyVAL.expr = &ast.CallExpr{Name: names.UniqueNames.Set(yyDollar[2].tok.Lit), SubExprs: yyDollar[4].exprs, Go: true}
 			yyVAL.expr.SetPosition(yyDollar[2].tok.Position())
 		}
-	case 105:
+	case 129:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:607
+//line parser.y:763
 		{
 			yyVAL.expr = &ast.AnonCallExpr{Expr: yyDollar[1].expr, SubExprs: yyDollar[3].exprs, VarArg: true}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 106:
+	case 130:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:612
+//line parser.y:768
 		{
 			yyVAL.expr = &ast.AnonCallExpr{Expr: yyDollar[1].expr, SubExprs: yyDollar[3].exprs}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 107:
+	case 131:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:617
+//line parser.y:773
 		{
 			yyVAL.expr = &ast.AnonCallExpr{Expr: yyDollar[2].expr, SubExprs: yyDollar[4].exprs, VarArg: true, Go: true}
 			yyVAL.expr.SetPosition(yyDollar[2].expr.Position())
 		}
-	case 108:
+	case 132:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:622
+//line parser.y:778
 		{
 			yyVAL.expr = &ast.AnonCallExpr{Expr: yyDollar[2].expr, SubExprs: yyDollar[4].exprs, Go: true}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 109:
+	case 133:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:627
+//line parser.y:783
 		{
 			yyVAL.expr = &ast.ItemExpr{Value: &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: names.UniqueNames.Set(yyDollar[1].tok.Lit)}, Index: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 110:
+	case 134:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:632
+//line parser.y:788
 		{
 			yyVAL.expr = &ast.ItemExpr{Value: yyDollar[1].expr, Index: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 111:
+	case 135:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:637
+//line parser.y:793
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: names.UniqueNames.Set(yyDollar[1].tok.Lit)}, Begin: yyDollar[3].expr, End: yyDollar[5].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 112:
+	case 136:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:642
+//line parser.y:798
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: names.UniqueNames.Set(yyDollar[1].tok.Lit)}, Begin: yyDollar[3].expr, End: &ast.NoneExpr{}}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 113:
+	case 137:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:647
+//line parser.y:803
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: &ast.IdentExpr{Lit: yyDollar[1].tok.Lit, Id: names.UniqueNames.Set(yyDollar[1].tok.Lit)}, Begin: &ast.NoneExpr{}, End: yyDollar[4].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 114:
+	case 138:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:652
+//line parser.y:808
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: yyDollar[1].expr, Begin: yyDollar[3].expr, End: yyDollar[5].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 115:
+	case 139:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:657
+//line parser.y:813
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: yyDollar[1].expr, Begin: yyDollar[3].expr, End: &ast.NoneExpr{}}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 116:
+	case 140:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:662
+//line parser.y:818
 		{
 			yyVAL.expr = &ast.SliceExpr{Value: yyDollar[1].expr, Begin: &ast.NoneExpr{}, End: yyDollar[4].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 117:
+	case 141:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:667
+//line parser.y:823
 		{
 			yyVAL.expr = &ast.MakeExpr{Type: yyDollar[2].typ.Name}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 118:
+	case 142:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:828
+		{
+			yyVAL.expr = &ast.MakeExpr{Type: yyDollar[2].typ.Name, Args: yyDollar[4].exprs}
+			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 143:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:672
+//line parser.y:833
 		{
 			yyVAL.expr = &ast.MakeChanExpr{SizeExpr: &ast.NoneExpr{}}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 119:
+	case 144:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:677
+//line parser.y:838
 		{
 			yyVAL.expr = &ast.MakeChanExpr{SizeExpr: yyDollar[4].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 120:
+	case 145:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:682
+//line parser.y:843
 		{
 			yyVAL.expr = &ast.MakeArrayExpr{LenExpr: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 121:
+	case 146:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:687
+//line parser.y:848
 		{
 			yyVAL.expr = &ast.MakeArrayExpr{LenExpr: yyDollar[3].expr, CapExpr: yyDollar[5].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 122:
+	case 147:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:692
+//line parser.y:853
 		{
 			yyVAL.expr = &ast.TypeCast{Type: yyDollar[2].typ.Name, CastExpr: yyDollar[4].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 123:
+	case 148:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:697
+//line parser.y:858
 		{
 			yyVAL.expr = &ast.MakeExpr{TypeExpr: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 124:
+	case 149:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:702
+//line parser.y:863
 		{
 			yyVAL.expr = &ast.TypeCast{TypeExpr: yyDollar[3].expr, CastExpr: yyDollar[5].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 125:
+	case 150:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:707
+//line parser.y:868
 		{
 			yyVAL.expr = &ast.ChanExpr{Lhs: yyDollar[1].expr, Rhs: yyDollar[3].expr}
 			yyVAL.expr.SetPosition(yyDollar[1].expr.Position())
 		}
-	case 126:
+	case 151:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:712
+//line parser.y:873
 		{
 			yyVAL.expr = &ast.ChanExpr{Rhs: yyDollar[2].expr}
 			yyVAL.expr.SetPosition(yyDollar[2].expr.Position())
 		}
-	case 129:
+	case 154:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:723
+//line parser.y:884
 		{
 		}
-	case 130:
+	case 155:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:726
+//line parser.y:887
 		{
 		}
-	case 131:
+	case 156:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:731
+//line parser.y:892
 		{
 		}
-	case 132:
+	case 157:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:734
+//line parser.y:895
 		{
 		}
 	}
@@ -26,6 +26,10 @@ import (
 %type<expr_pair> expr_pair
 %type<expr_pairs> expr_pairs
 %type<expr_idents> expr_idents
+%type<catch_kinds> catch_kinds
+%type<stmts> type_members
+%type<stmt> type_member
+%type<expr_idents> field_idents
 
 %union{
 	compstmt               ast.Stmts
@@ -46,13 +50,15 @@ import (
 	expr_pair              ast.Expr
 	expr_pairs             []ast.Expr
 	expr_idents            []int
+	catch_kinds            []string
 	tok                    ast.Token
 	term                   ast.Token
 	terms                  ast.Token
 	opt_terms              ast.Token
 }
 
-%token<tok> IDENT NUMBER STRING ARRAY VARARG FUNC RETURN THROW IF ELSE FOR IN EQEQ NEQ GE LE OROR ANDAND TRUE FALSE NIL MODULE TRY CATCH FINALLY PLUSEQ MINUSEQ MULEQ DIVEQ ANDEQ OREQ BREAK CONTINUE PLUSPLUS MINUSMINUS POW SHIFTLEFT SHIFTRIGHT SWITCH CASE DEFAULT GO CHAN MAKE OPCHAN ARRAYLIT NULL EACH TO ELSIF WHILE TERNARY TYPECAST
+%token<tok> IDENT NUMBER STRING ARRAY VARARG FUNC RETURN THROW IF ELSE FOR IN EQEQ NEQ GE LE OROR ANDAND TRUE FALSE NIL MODULE TRY CATCH FINALLY PLUSEQ MINUSEQ MULEQ DIVEQ ANDEQ OREQ BREAK CONTINUE PLUSPLUS MINUSMINUS POW SHIFTLEFT SHIFTRIGHT SWITCH CASE DEFAULT GO CHAN MAKE OPCHAN ARRAYLIT NULL EACH TO ELSIF WHILE TERNARY TYPECAST TYPE FIELD METHOD THIS DEFER STEP
+%token<expr> TEMPLATE
 
 %right '='
 %right '?' ':'
@@ -163,37 +169,114 @@ stmt :
 	}
 	| FOR EACH IDENT IN expr '{' compstmt '}'
 	{
-		$$ = &ast.ForStmt{Var: names.UniqueNames.Set($3.Lit), Value: $5, Stmts: $7}
+		$$ = &ast.ForStmt{Var: names.UniqueNames.Set($3.Lit), Value: $5, Stmts: $7, End: $<tok>8.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR EACH IDENT IN expr GO '{' compstmt '}'
+	{
+		$$ = &ast.ForStmt{Var: names.UniqueNames.Set($3.Lit), Value: $5, Stmts: $8, Parallel: true, End: $<tok>9.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR EACH IDENT IN expr GO expr '{' compstmt '}'
+	{
+		$$ = &ast.ForStmt{Var: names.UniqueNames.Set($3.Lit), Value: $5, Stmts: $9, Parallel: true, Workers: $7, End: $<tok>10.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR EACH IDENT ',' IDENT IN expr '{' compstmt '}'
+	{
+		$$ = &ast.ForStmt{Key: names.UniqueNames.Set($3.Lit), Var: names.UniqueNames.Set($5.Lit), Value: $7, Stmts: $9, End: $<tok>10.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR IDENT ',' IDENT IN expr '{' compstmt '}'
+	{
+		$$ = &ast.ForStmt{Key: names.UniqueNames.Set($2.Lit), Var: names.UniqueNames.Set($4.Lit), Value: $6, Stmts: $8, End: $<tok>9.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| FOR IDENT '=' expr TO expr '{' compstmt '}'
 	{
-		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Stmts: $8}
+		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Stmts: $8, End: $<tok>9.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| FOR IDENT EQEQ expr TO expr '{' compstmt '}'
 	{
-		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Stmts: $8}
+		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Stmts: $8, End: $<tok>9.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR IDENT '=' expr TO expr STEP expr '{' compstmt '}'
+	{
+		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Step: $8, Stmts: $10, End: $<tok>11.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| FOR IDENT EQEQ expr TO expr STEP expr '{' compstmt '}'
+	{
+		$$ = &ast.NumForStmt{Name: names.UniqueNames.Set($2.Lit), Expr1: $4, Expr2: $6, Step: $8, Stmts: $10, End: $<tok>11.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| WHILE expr '{' compstmt '}'
 	{
-		$$ = &ast.LoopStmt{Expr: $2, Stmts: $4}
+		$$ = &ast.LoopStmt{Expr: $2, Stmts: $4, End: $<tok>5.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| TRY compstmt CATCH compstmt '}'
 	{
-		$$ = &ast.TryStmt{Try: $2, Catch: $4}
+		$$ = &ast.TryStmt{Try: $2, Catch: $4, CatchPos: $3.Position(), End: $<tok>5.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| TRY compstmt CATCH compstmt FINALLY compstmt '}'
+	{
+		// пустые блоки Исключение и Окончательно не должны теряться
+		$$ = &ast.TryStmt{Try: $2, Catch: append(ast.Stmts{}, $4...), Finally: append(ast.Stmts{}, $6...), CatchPos: $3.Position(), FinallyPos: $5.Position(), End: $<tok>7.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| TRY compstmt CATCH catch_kinds compstmt '}'
+	{
+		// исключение перехватывается, только если его вид есть в списке
+		$$ = &ast.TryStmt{Try: $2, CatchKinds: $4, Catch: append(ast.Stmts{}, $5...), CatchPos: $3.Position(), End: $<tok>6.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| TRY compstmt CATCH catch_kinds compstmt FINALLY compstmt '}'
+	{
+		$$ = &ast.TryStmt{Try: $2, CatchKinds: $4, Catch: append(ast.Stmts{}, $5...), Finally: append(ast.Stmts{}, $7...), CatchPos: $3.Position(), FinallyPos: $6.Position(), End: $<tok>8.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| TRY compstmt FINALLY compstmt '}'
+	{
+		$$ = &ast.TryStmt{Try: $2, Finally: append(ast.Stmts{}, $4...), FinallyPos: $3.Position(), End: $<tok>5.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| SWITCH expr ':' stmt_cases '}'
 	{
-		$$ = &ast.SwitchStmt{Expr: $2, Cases: $4}
+		$$ = &ast.SwitchStmt{Expr: $2, Cases: $4, End: $<tok>5.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| SWITCH ':' stmt_cases '}'
 	{
-		$$ = &ast.SelectStmt{Cases: $3}
+		$$ = &ast.SelectStmt{Cases: $3, End: $<tok>4.Position()}
+		$$.SetPosition($1.Position())
+	}
+	| DEFER expr
+	{
+		// откладывается только вызов функции, ее аргументы вычисляются сразу
+		switch c := $2.(type) {
+		case *ast.CallExpr:
+			if c.Go {
+				yylex.Error("Отложить ожидает вызов функции без Старт")
+			}
+			c.Defer = true
+		case *ast.AnonCallExpr:
+			if c.Go {
+				yylex.Error("Отложить ожидает вызов функции без Старт")
+			}
+			c.Defer = true
+		default:
+			yylex.Error("Отложить ожидает вызов функции")
+		}
+		$$ = &ast.DeferStmt{Expr: $2}
+		$$.SetPosition($1.Position())
+	}
+	| TYPE IDENT terms type_members '}'
+	{
+		$$ = &ast.TypeStmt{Name: names.UniqueNames.Set($2.Lit), Members: $4, End: $<tok>5.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| expr
@@ -202,6 +285,49 @@ stmt :
 		$$.SetPosition($1.Position())
 	}
 
+type_members :
+	{
+		$$ = nil
+	}
+	| type_members type_member terms
+	{
+		$$ = append($1, $2)
+	}
+
+type_member :
+	FIELD field_idents
+	{
+		$$ = &ast.FieldStmt{Names: $2}
+		$$.SetPosition($1.Position())
+	}
+	| FIELD IDENT '=' expr
+	{
+		$$ = &ast.FieldStmt{Names: []int{names.UniqueNames.Set($2.Lit)}, Value: $4}
+		$$.SetPosition($1.Position())
+	}
+	| FIELD IDENT EQEQ expr
+	{
+		$$ = &ast.FieldStmt{Names: []int{names.UniqueNames.Set($2.Lit)}, Value: $4}
+		$$.SetPosition($1.Position())
+	}
+	| METHOD IDENT '(' expr_idents ')' opt_terms compstmt '}'
+	{
+		f := &ast.FuncExpr{Name: names.UniqueNames.Set($2.Lit), Args: $4, Stmts: $7, End: $<tok>8.Position()}
+		f.SetPosition($1.Position())
+		$$ = &ast.MethodStmt{Func: f}
+		$$.SetPosition($1.Position())
+	}
+
+field_idents :
+	IDENT
+	{
+		$$ = []int{names.UniqueNames.Set($1.Lit)}
+	}
+	| field_idents ',' IDENT
+	{
+		$$ = append($1, names.UniqueNames.Set($3.Lit))
+	}
+
 stmt_elsifs:
 	{
 		$$ = ast.Stmts{}
@@ -215,17 +341,18 @@ stmt_elsif :
 	ELSIF expr '{' compstmt
 	{
 		$$ = &ast.IfStmt{If: $2, Then: $4}
+		$$.SetPosition($1.Position())
 	}
 
 stmt_if :
 	IF expr '{' compstmt stmt_elsifs ELSE compstmt '}'
 	{
-		$$ = &ast.IfStmt{If: $2, Then: $4, ElseIf: $5, Else: $7}
+		$$ = &ast.IfStmt{If: $2, Then: $4, ElseIf: $5, Else: $7, ElsePos: $6.Position(), End: $<tok>8.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| IF expr '{' compstmt stmt_elsifs '}'
 	{
-		$$ = &ast.IfStmt{If: $2, Then: $4, ElseIf: $5, Else: nil}
+		$$ = &ast.IfStmt{If: $2, Then: $4, ElseIf: $5, Else: nil, End: $<tok>6.Position()}
 		$$.SetPosition($1.Position())
 	}
 
@@ -259,12 +386,24 @@ stmt_case :
 	CASE expr ':' opt_terms compstmt
 	{
 		$$ = &ast.CaseStmt{Expr: $2, Stmts: $5}
+		$$.SetPosition($1.Position())
 	}
 
 stmt_default :
 	DEFAULT ':' opt_terms compstmt
 	{
 		$$ = &ast.DefaultStmt{Stmts: $4}
+		$$.SetPosition($1.Position())
+	}
+
+catch_kinds :
+	STRING
+	{
+		$$ = []string{$1.Lit}
+	}
+	| catch_kinds ',' STRING
+	{
+		$$ = append($1, $3.Lit)
 	}
 
 expr_pair :
@@ -370,6 +509,10 @@ expr :
 		$$ = &ast.StringExpr{Lit: $1.Lit}
 		$$.SetPosition($1.Position())
 	}
+	| TEMPLATE
+	{
+		$$ = $1
+	}
 	| TRUE
 	{
 		$$ = &ast.ConstExpr{Value: "истина"}
@@ -390,6 +533,11 @@ expr :
 		$$ = &ast.ConstExpr{Value: "null"}
 		$$.SetPosition($1.Position())
 	}
+	| THIS
+	{
+		$$ = &ast.IdentExpr{Lit: $1.Lit, Id: ast.ThisObject}
+		$$.SetPosition($1.Position())
+	}
 	| TERNARY expr ',' expr ',' expr ')'
 	{
 		$$ = &ast.TernaryOpExpr{Expr: $2, Lhs: $4, Rhs: $6}
@@ -402,22 +550,22 @@ expr :
 	}
 	| FUNC '(' expr_idents ')' opt_terms compstmt '}'
 	{
-		$$ = &ast.FuncExpr{Name:names.UniqueNames.Set("<анонимная функция>"), Args: $3, Stmts: $6}
+		$$ = &ast.FuncExpr{Name:names.UniqueNames.Set("<анонимная функция>"), Args: $3, Stmts: $6, End: $<tok>7.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| FUNC '(' IDENT VARARG ')' opt_terms compstmt '}'
 	{
-		$$ = &ast.FuncExpr{Name:names.UniqueNames.Set("<анонимная функция>"), Args: []int{names.UniqueNames.Set($3.Lit)}, Stmts: $7, VarArg: true}
+		$$ = &ast.FuncExpr{Name:names.UniqueNames.Set("<анонимная функция>"), Args: []int{names.UniqueNames.Set($3.Lit)}, Stmts: $7, VarArg: true, End: $<tok>8.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| FUNC IDENT '(' expr_idents ')' opt_terms compstmt '}'
 	{
-		$$ = &ast.FuncExpr{Name: names.UniqueNames.Set($2.Lit), Args: $4, Stmts: $7}
+		$$ = &ast.FuncExpr{Name: names.UniqueNames.Set($2.Lit), Args: $4, Stmts: $7, End: $<tok>8.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| FUNC IDENT '(' IDENT VARARG ')' opt_terms compstmt '}'
 	{
-		$$ = &ast.FuncExpr{Name: names.UniqueNames.Set($2.Lit), Args: []int{names.UniqueNames.Set($4.Lit)}, Stmts: $8, VarArg: true}
+		$$ = &ast.FuncExpr{Name: names.UniqueNames.Set($2.Lit), Args: []int{names.UniqueNames.Set($4.Lit)}, Stmts: $8, VarArg: true, End: $<tok>9.Position()}
 		$$.SetPosition($1.Position())
 	}
 	| '[' opt_terms exprs opt_terms ']'
@@ -433,19 +581,27 @@ expr :
 	| '{' opt_terms expr_pairs opt_terms '}'
 	{
 		mapExpr := make(map[string]ast.Expr)
+		var keys []string
 		for _, v := range $3 {
+			if _, ok := mapExpr[v.(*ast.PairExpr).Key]; !ok {
+				keys = append(keys, v.(*ast.PairExpr).Key)
+			}
 			mapExpr[v.(*ast.PairExpr).Key] = v.(*ast.PairExpr).Value
 		}
-		$$ = &ast.MapExpr{MapExpr: mapExpr}
+		$$ = &ast.MapExpr{MapExpr: mapExpr, Keys: keys}
 		if l, ok := yylex.(*Lexer); ok { $$.SetPosition(l.pos) }
 	}
 	| '{' opt_terms expr_pairs ',' opt_terms '}'
 	{
 		mapExpr := make(map[string]ast.Expr)
+		var keys []string
 		for _, v := range $3 {
+			if _, ok := mapExpr[v.(*ast.PairExpr).Key]; !ok {
+				keys = append(keys, v.(*ast.PairExpr).Key)
+			}
 			mapExpr[v.(*ast.PairExpr).Key] = v.(*ast.PairExpr).Value
 		}
-		$$ = &ast.MapExpr{MapExpr: mapExpr}
+		$$ = &ast.MapExpr{MapExpr: mapExpr, Keys: keys}
 		if l, ok := yylex.(*Lexer); ok { $$.SetPosition(l.pos) }
 	}
 	| '(' expr ')'
@@ -668,6 +824,11 @@ expr :
 		$$ = &ast.MakeExpr{Type: $2.Name}
 		$$.SetPosition($1.Position())
 	}
+	| MAKE typ '(' exprs ')'
+	{
+		$$ = &ast.MakeExpr{Type: $2.Name, Args: $4}
+		$$.SetPosition($1.Position())
+	}
 	| MAKE CHAN
 	{
 		$$ = &ast.MakeChanExpr{SizeExpr: &ast.NoneExpr{}}
@@ -0,0 +1,166 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// protobuf - запись сообщений в формате Protocol Buffers, которого достаточно для профиля pprof
+type protobuf struct {
+	buf []byte
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.buf = append(b.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	b.buf = append(b.buf, byte(x))
+}
+
+func (b *protobuf) tag(field, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+// int64 записывает поле целого числа, нулевые значения не записываются
+func (b *protobuf) int64(field int, x int64) {
+	if x != 0 {
+		b.tag(field, 0)
+		b.varint(uint64(x))
+	}
+}
+
+func (b *protobuf) bytes(field int, x []byte) {
+	b.tag(field, 2)
+	b.varint(uint64(len(x)))
+	b.buf = append(b.buf, x...)
+}
+
+// packed записывает повторяющееся поле целых чисел
+func (b *protobuf) packed(field int, xs []int64) {
+	var p protobuf
+	for _, x := range xs {
+		p.varint(uint64(x))
+	}
+	b.bytes(field, p.buf)
+}
+
+// message записывает вложенное сообщение, заполненное функцией f
+func (b *protobuf) message(field int, f func(m *protobuf)) {
+	var m protobuf
+	f(&m)
+	b.bytes(field, m.buf)
+}
+
+// Поля сообщения Profile из profile.proto
+const (
+	profSampleType        = 1
+	profSample            = 2
+	profLocation          = 4
+	profFunction          = 5
+	profStringTable       = 6
+	profTimeNanos         = 9
+	profDurationNanos     = 10
+	profPeriodType        = 11
+	profPeriod            = 12
+	profDefaultSampleType = 14
+)
+
+// WriteProfile записывает профиль в формате pprof, сжатый gzip, для просмотра командой go tool pprof.
+// Каждый стек вызовов функций на языке Гонец записывается одним образцом со значениями
+// вызовов, инструкций, времени в наносекундах и созданных значений.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	root, dur := p.snapshot()
+
+	strs := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		if i, ok := strs[s]; ok {
+			return i
+		}
+		strs[s] = int64(len(table))
+		table = append(table, s)
+		return strs[s]
+	}
+
+	var b protobuf
+	valueType := func(field int, typ, unit string) {
+		b.message(field, func(m *protobuf) {
+			m.int64(1, str(typ))
+			m.int64(2, str(unit))
+		})
+	}
+	valueType(profSampleType, "calls", "count")
+	valueType(profSampleType, "instructions", "count")
+	valueType(profSampleType, "time", "nanoseconds")
+	valueType(profSampleType, "values", "count")
+
+	type fkey struct{ name, file string }
+	funcs := make(map[fkey]int64)
+	locs := make(map[location]int64)
+	var locOrder []location
+
+	var walk func(n *node, stack []int64)
+	walk = func(n *node, stack []int64) {
+		id, ok := locs[n.loc]
+		if !ok {
+			id = int64(len(locs) + 1)
+			locs[n.loc] = id
+			locOrder = append(locOrder, n.loc)
+		}
+		// стек в образце начинается с исполняемой строки
+		stack = append([]int64{id}, stack...)
+		if n.vals != [numValues]int64{} {
+			b.message(profSample, func(m *protobuf) {
+				m.packed(1, stack)
+				m.packed(2, n.vals[:])
+			})
+		}
+		for _, c := range n.children {
+			walk(c, stack)
+		}
+	}
+	for _, c := range root.children {
+		walk(c, nil)
+	}
+
+	for i, loc := range locOrder {
+		fk := fkey{loc.Func, loc.File}
+		fid, ok := funcs[fk]
+		if !ok {
+			fid = int64(len(funcs) + 1)
+			funcs[fk] = fid
+			b.message(profFunction, func(m *protobuf) {
+				m.int64(1, fid)
+				m.int64(2, str(loc.Func))
+				m.int64(3, str(loc.Func))
+				m.int64(4, str(loc.File))
+			})
+		}
+		b.message(profLocation, func(m *protobuf) {
+			m.int64(1, int64(i+1))
+			m.message(4, func(l *protobuf) {
+				l.int64(1, fid)
+				l.int64(2, int64(loc.Line))
+			})
+		})
+	}
+
+	p.mu.Lock()
+	b.int64(profTimeNanos, p.started.UnixNano())
+	p.mu.Unlock()
+	b.int64(profDurationNanos, int64(dur))
+	valueType(profPeriodType, "time", "nanoseconds")
+	b.int64(profPeriod, 1)
+	b.int64(profDefaultSampleType, str("time"))
+	// таблица строк записывается последней, когда в нее добавлены все строки
+	for _, s := range table {
+		b.bytes(profStringTable, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.buf); err != nil {
+		return err
+	}
+	return zw.Close()
+}
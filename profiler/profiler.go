@@ -0,0 +1,286 @@
+// Package profiler - профилировщик кода на языке Гонец, исполняемого виртуальной машиной bincode.
+//
+// Профилировщик устанавливается через bincode.SetProfiler и получает управление перед каждой инструкцией.
+// Время, число исполненных инструкций, вызовы функций и созданные значения (массивы, структуры,
+// объекты Новый, каналы и функции-замыкания) учитываются по стеку вызовов функций на языке Гонец
+// с точностью до строки исходного кода. Результат записывается в формате pprof (WriteProfile)
+// или выводится текстовым отчетом (WriteReport).
+package profiler
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/bincode/binstmt"
+	"github.com/covrom/gonec/core"
+)
+
+// Значения, замеряемые для каждой строки в стеке вызовов
+const (
+	valCalls = iota
+	valInstrs
+	valTime
+	valAllocs
+	numValues
+)
+
+// location - строка исходного кода в функции
+type location struct {
+	Func string
+	File string
+	Line int
+}
+
+// node - узел дерева стеков вызовов, путь от корня до узла - стек, в котором исполнялась строка
+type node struct {
+	loc      location
+	parent   *node
+	children map[location]*node
+	vals     [numValues]int64
+}
+
+func newNode(parent *node, loc location) *node {
+	return &node{loc: loc, parent: parent, children: make(map[location]*node)}
+}
+
+func (n *node) child(loc location) *node {
+	c, ok := n.children[loc]
+	if !ok {
+		c = newNode(n, loc)
+		n.children[loc] = c
+	}
+	return c
+}
+
+// merge добавляет значения дерева src к дереву n
+func (n *node) merge(src *node) {
+	for i, v := range src.vals {
+		n.vals[i] += v
+	}
+	for loc, c := range src.children {
+		n.child(loc).merge(c)
+	}
+}
+
+// thread - стек вызовов, начатый кодом модуля, Старт функции или итерацией параллельного цикла.
+// Дерево потока изменяется только под его блокировкой.
+type thread struct {
+	mu     sync.Mutex
+	root   *node
+	frames []*frame
+}
+
+// frame - кадр стека: исполняемый код модуля, функции или итерации параллельного цикла
+type frame struct {
+	p     *Profiler
+	env   *core.Env
+	t     *thread
+	code  *binstmt.BinCode
+	fn    string
+	file  string
+	base  *node // строка вызывающего кадра или корень дерева потока
+	cur   *node // исполняемая строка
+	line  int
+	call  bool // вызов еще не учтен, он учитывается на первой строке функции
+	start int64
+}
+
+// Profiler собирает профиль исполнения кода
+type Profiler struct {
+	// Env ограничивает профилирование кодом, исполняемым в этом окружении и в его дочерних окружениях,
+	// например в сессии сервиса. Если не задано, профилируется весь код.
+	Env *core.Env
+
+	mu      sync.Mutex
+	frames  map[*core.Env]*frame // исполняемые кадры по окружению, в котором они исполняются
+	threads map[*thread]bool
+	done    *node // дерево завершенных потоков
+	names   map[*binstmt.BinCode]map[int]string
+	started time.Time
+	stopped time.Time
+}
+
+// New создает профилировщик
+func New() *Profiler {
+	return &Profiler{
+		frames:  make(map[*core.Env]*frame),
+		threads: make(map[*thread]bool),
+		done:    newNode(nil, location{}),
+		names:   make(map[*binstmt.BinCode]map[int]string),
+	}
+}
+
+// Start начинает профилирование
+func (p *Profiler) Start() {
+	p.mu.Lock()
+	p.started = time.Now()
+	p.stopped = time.Time{}
+	p.mu.Unlock()
+	bincode.SetProfiler(p)
+}
+
+// Stop заканчивает профилирование, код, исполняемый в этот момент, больше не замеряется
+func (p *Profiler) Stop() {
+	bincode.SetProfiler(nil)
+	p.mu.Lock()
+	p.stopped = time.Now()
+	p.mu.Unlock()
+}
+
+// now возвращает время от начала профилирования в наносекундах
+func (p *Profiler) now() int64 {
+	return int64(time.Since(p.started))
+}
+
+// inEnv сообщает, исполняется ли код в окружении профилирования
+func (p *Profiler) inEnv(env *core.Env) bool {
+	if p.Env == nil {
+		return true
+	}
+	for e := env; e != nil; e = e.Parent() {
+		if e == p.Env {
+			return true
+		}
+	}
+	return false
+}
+
+// Enter реализует bincode.DebugHook. Вызванная функция продолжает стек вызвавшего ее кадра,
+// который находится по окружению вызывающего кода.
+func (p *Profiler) Enter(code *binstmt.BinCode, idx int, env *core.Env, regs core.VMSlice) bincode.DebugFrame {
+	if !p.inEnv(env) {
+		return nil
+	}
+	now := p.now()
+	p.mu.Lock()
+	var caller *frame
+	if c := env.Caller(); c != nil {
+		caller = p.frames[c]
+	}
+	names, ok := p.names[code]
+	if !ok {
+		names = code.Entries()
+		p.names[code] = names
+	}
+	file := code.SourceFile()
+	// pprof не показывает имена в угловых скобках, поэтому они опускаются
+	fn, ok := names[idx]
+	if ok {
+		fn = strings.Trim(fn, "<>")
+	} else {
+		mod := env.String()
+		if (mod == "" || mod == "_") && file != "" {
+			mod = filepath.Base(file)
+		}
+		fn = strings.TrimSpace("модуль " + mod)
+	}
+	f := &frame{p: p, env: env, code: code, fn: fn, file: file, call: true, start: now}
+	if caller != nil {
+		f.t = caller.t
+	} else {
+		f.t = &thread{root: newNode(nil, location{})}
+		p.threads[f.t] = true
+	}
+	p.frames[env] = f
+	p.mu.Unlock()
+
+	t := f.t
+	t.mu.Lock()
+	f.base = t.root
+	if caller != nil {
+		// время до вызова относится к строке вызывающего кадра
+		caller.flush(now)
+		f.base = caller.cur
+	}
+	f.cur = f.base.child(location{Func: fn, File: file})
+	t.frames = append(t.frames, f)
+	t.mu.Unlock()
+	return f
+}
+
+// flush относит время, прошедшее с предыдущего замера, к исполняемой строке
+func (f *frame) flush(now int64) {
+	f.cur.vals[valTime] += now - f.start
+	f.start = now
+}
+
+// Step реализует bincode.DebugFrame
+func (f *frame) Step(idx int) {
+	now := f.p.now()
+	s := f.code.Code[idx]
+	line := 0
+	if _, ok := s.(*binstmt.BinLABEL); !ok {
+		line = f.code.PosAt(idx).Line
+	}
+	t := f.t
+	t.mu.Lock()
+	f.flush(now)
+	if line > 0 && line != f.line {
+		f.line = line
+		f.cur = f.base.child(location{Func: f.fn, File: f.file, Line: line})
+		if f.call {
+			f.call = false
+			f.cur.vals[valCalls]++
+		}
+	}
+	f.cur.vals[valInstrs]++
+	switch s.(type) {
+	case *binstmt.BinMAKESLICE, *binstmt.BinMAKEMAP, *binstmt.BinMAKE, *binstmt.BinMAKEARR,
+		*binstmt.BinMAKECHAN, *binstmt.BinFUNC:
+		f.cur.vals[valAllocs]++
+	}
+	t.mu.Unlock()
+}
+
+// Leave реализует bincode.DebugFrame
+func (f *frame) Leave() {
+	p := f.p
+	now := p.now()
+	t := f.t
+	t.mu.Lock()
+	f.flush(now)
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		if t.frames[i] == f {
+			t.frames = t.frames[:i]
+			break
+		}
+	}
+	n := len(t.frames)
+	if n > 0 {
+		// время вызова уже учтено в вызванной функции
+		t.frames[n-1].start = now
+	}
+	t.mu.Unlock()
+
+	p.mu.Lock()
+	if p.frames[f.env] == f {
+		delete(p.frames, f.env)
+	}
+	if n == 0 {
+		// поток завершен, его дерево добавляется к общему
+		delete(p.threads, t)
+		p.done.merge(t.root)
+	}
+	p.mu.Unlock()
+}
+
+// snapshot возвращает дерево всех потоков, в т.ч. исполняемых сейчас, и длительность профилирования
+func (p *Profiler) snapshot() (*node, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	root := newNode(nil, location{})
+	root.merge(p.done)
+	for t := range p.threads {
+		t.mu.Lock()
+		root.merge(t.root)
+		t.mu.Unlock()
+	}
+	end := p.stopped
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return root, end.Sub(p.started)
+}
@@ -0,0 +1,151 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/covrom/gonec/bincode"
+	"github.com/covrom/gonec/core"
+)
+
+func TestProfiler(t *testing.T) {
+	src := `Функция Фиб(н)
+	Если н < 2 Тогда
+		Возврат н
+	КонецЕсли
+	Возврат Фиб(н-1) + Фиб(н-2)
+КонецФункции
+м = []
+Для к = 1 По 10 Цикл
+	м += [к]
+КонецЦикла
+Сообщить(Фиб(10))
+`
+	_, bins, err := bincode.ParseSrc(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bins.AttachDebugInfo("проф.gnc", "")
+
+	p := New()
+	p.Start()
+	env := core.NewEnv()
+	var out bytes.Buffer
+	env.SetStdOut(&out)
+	_, err = bincode.Run(context.Background(), bins, env)
+	p.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// после остановки код больше не замеряется
+	bincode.Run(context.Background(), bins, env)
+
+	var rep bytes.Buffer
+	if err := p.WriteReport(&rep); err != nil {
+		t.Fatal(err)
+	}
+	fields := func(suffix string) []string {
+		// имена выводятся в написании, которое встретилось первым, в т.ч. в других тестах
+		suffix = strings.ToLower(suffix)
+		for _, l := range strings.Split(strings.ToLower(rep.String()), "\n") {
+			if strings.HasSuffix(l, suffix) {
+				return strings.Fields(strings.TrimSuffix(l, suffix))
+			}
+		}
+		t.Fatalf("нет строки %q в отчете:\n%s", suffix, rep.String())
+		return nil
+	}
+	// собств. % всего % вызовы|инструкции значения
+	if f := fields("  Фиб"); f[4] != "177" || f[5] != "0" {
+		t.Errorf("функция Фиб: %v", f)
+	}
+	if f := fields("  модуль проф.gnc"); f[4] != "1" || f[3] != "100.00%" {
+		t.Errorf("модуль: %v", f)
+	}
+	if f := fields("  проф.gnc:9 модуль проф.gnc"); f[5] != "10" {
+		t.Errorf("строка 9: %v", f)
+	}
+	if f := fields("  проф.gnc:3 Фиб"); f[4] != "178" { // 89 возвратов по две инструкции
+		t.Errorf("строка 3: %v", f)
+	}
+
+	var prof bytes.Buffer
+	if err := p.WriteProfile(&prof); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"фиб", "модуль проф.gnc", "проф.gnc", "nanoseconds"} {
+		if !bytes.Contains(bytes.ToLower(b), []byte(s)) {
+			t.Errorf("нет %q в профиле", s)
+		}
+	}
+}
+
+func TestProtobuf(t *testing.T) {
+	var b protobuf
+	b.int64(1, 0) // нулевое значение не записывается
+	b.int64(1, 300)
+	b.bytes(2, []byte("аб"))
+	b.packed(3, []int64{1, 150})
+	b.message(4, func(m *protobuf) { m.int64(1, 1) })
+	exp := []byte{
+		0x08, 0xac, 0x02, // поле 1, varint 300
+		0x12, 0x04, 0xd0, 0xb0, 0xd0, 0xb1, // поле 2, 4 байта строки
+		0x1a, 0x03, 0x01, 0x96, 0x01, // поле 3, упакованные 1 и 150
+		0x22, 0x02, 0x08, 0x01, // поле 4, вложенное сообщение
+	}
+	if !bytes.Equal(b.buf, exp) {
+		t.Errorf("получено % x, ожидалось % x", b.buf, exp)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	// рекурсия ф -> ф -> г, время каждой функции в стеке учитывается один раз
+	f1 := location{Func: "ф", File: "а.gnc", Line: 1}
+	f2 := location{Func: "ф", File: "а.gnc", Line: 2}
+	g := location{Func: "г", File: "а.gnc", Line: 5}
+	root := newNode(nil, location{})
+	n1 := root.child(f1)
+	n1.vals[valTime] = 10
+	n2 := n1.child(f2)
+	n2.vals[valTime] = 20
+	n2.vals[valCalls] = 1
+	n3 := n2.child(g)
+	n3.vals[valTime] = 30
+
+	byFunc := collect(root, func(l location) location { return location{Func: l.Func} })
+	if len(byFunc) != 2 {
+		t.Fatalf("функции: %+v", byFunc)
+	}
+	// г - 30 собственного времени, ф - 30 собственного и 60 всего
+	if s := byFunc[0]; s.loc.Func != "ф" || s.vals[valTime] != 30 || s.cum != 60 || s.vals[valCalls] != 1 {
+		t.Errorf("ф: %+v", s)
+	}
+	if s := byFunc[1]; s.loc.Func != "г" || s.vals[valTime] != 30 || s.cum != 30 {
+		t.Errorf("г: %+v", s)
+	}
+
+	byLine := collect(root, func(l location) location { return l })
+	if len(byLine) != 3 || byLine[0].loc != g || byLine[1].loc != f2 || byLine[1].cum != 50 || byLine[2].cum != 60 {
+		t.Errorf("строки: %+v", byLine)
+	}
+
+	// слияние деревьев потоков суммирует значения одинаковых стеков
+	other := newNode(nil, location{})
+	other.child(f1).vals[valTime] = 5
+	root.merge(other)
+	if n1.vals[valTime] != 15 || len(root.children) != 1 {
+		t.Errorf("слияние: %+v", n1.vals)
+	}
+}
@@ -0,0 +1,126 @@
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// reportLimit - число строк в каждом разделе текстового отчета
+const reportLimit = 30
+
+// stat - итоги по функции или строке: собственные значения и время вместе с вызванными функциями
+type stat struct {
+	loc  location
+	vals [numValues]int64
+	cum  int64
+}
+
+// collect суммирует значения узлов по ключу key. Время всего учитывается один раз для каждого стека,
+// даже если функция или строка встречается в нем несколько раз при рекурсии.
+func collect(root *node, key func(location) location) []*stat {
+	stats := make(map[location]*stat)
+	var walk func(n *node, path map[location]bool)
+	walk = func(n *node, path map[location]bool) {
+		k := key(n.loc)
+		st, ok := stats[k]
+		if !ok {
+			st = &stat{loc: k}
+			stats[k] = st
+		}
+		for i, v := range n.vals {
+			st.vals[i] += v
+		}
+		added := !path[k]
+		path[k] = true
+		for p := range path {
+			stats[p].cum += n.vals[valTime]
+		}
+		for _, c := range n.children {
+			walk(c, path)
+		}
+		if added {
+			delete(path, k)
+		}
+	}
+	for _, c := range root.children {
+		walk(c, make(map[location]bool))
+	}
+	res := make([]*stat, 0, len(stats))
+	for _, st := range stats {
+		res = append(res, st)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].vals[valTime] != res[j].vals[valTime] {
+			return res[i].vals[valTime] > res[j].vals[valTime]
+		}
+		if res[i].cum != res[j].cum {
+			return res[i].cum > res[j].cum
+		}
+		a, b := res[i].loc, res[j].loc
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return res
+}
+
+func percent(v, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(v) * 100 / float64(total)
+}
+
+func duration(ns int64) string {
+	return time.Duration(ns).Round(time.Microsecond).String()
+}
+
+// WriteReport выводит текстовый отчет: функции и строки исходного кода, упорядоченные по собственному времени
+func (p *Profiler) WriteReport(w io.Writer) error {
+	root, dur := p.snapshot()
+	var total int64
+	for _, st := range collect(root, func(l location) location { return location{} }) {
+		total += st.vals[valTime]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Длительность профилирования %s, время исполнения кода %s\n\n", duration(int64(dur)), duration(total))
+
+	fmt.Fprintln(tw, "собств.\t%\tвсего\t%\tвызовы\tзначения\t\tфункция")
+	funcs := collect(root, func(l location) location { return location{Func: l.Func, File: l.File} })
+	for i, st := range funcs {
+		if i == reportLimit {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%.2f%%\t%s\t%.2f%%\t%d\t%d\t\t%s\n",
+			duration(st.vals[valTime]), percent(st.vals[valTime], total),
+			duration(st.cum), percent(st.cum, total),
+			st.vals[valCalls], st.vals[valAllocs], st.loc.Func)
+	}
+
+	fmt.Fprintln(tw, "\nсобств.\t%\tвсего\t%\tинструкции\tзначения\t\tстрока")
+	lines := collect(root, func(l location) location { return l })
+	for i, st := range lines {
+		if i == reportLimit {
+			break
+		}
+		if st.loc.Line == 0 {
+			continue
+		}
+		where := "строка " + strconv.Itoa(st.loc.Line)
+		if st.loc.File != "" {
+			where = filepath.Base(st.loc.File) + ":" + strconv.Itoa(st.loc.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%.2f%%\t%s\t%.2f%%\t%d\t%d\t\t%s %s\n",
+			duration(st.vals[valTime]), percent(st.vals[valTime], total),
+			duration(st.cum), percent(st.cum, total),
+			st.vals[valInstrs], st.vals[valAllocs], where, st.loc.Func)
+	}
+	return tw.Flush()
+}
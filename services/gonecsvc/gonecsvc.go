@@ -2,11 +2,13 @@ package gonecsvc
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,10 +16,19 @@ import (
 	"github.com/covrom/gonec/bincode/binstmt"
 	"github.com/covrom/gonec/core"
 	"github.com/covrom/gonec/parser"
+	"github.com/covrom/gonec/profiler"
 )
 
+// DefaultPolicy - ограничения исполнения кода одного запроса, которые получает новый сервис интерпретатора
+var DefaultPolicy = core.ExecPolicy{
+	MaxInstructions: 500000000,
+	Timeout:         30 * time.Second,
+	MaxAlloc:        256 << 20,
+}
+
 func NewGonecInterpreter(header core.VMServiceHeader, args []string, tmode bool) *VMGonecInterpreterService {
 	v := &VMGonecInterpreterService{
+		Policy:       DefaultPolicy,
 		hdr:          header,
 		fsArgs:       args,
 		testingMode:  tmode,
@@ -32,6 +43,8 @@ func NewGonecInterpreter(header core.VMServiceHeader, args []string, tmode bool)
 
 type VMGonecInterpreterService struct {
 	core.VMValueStruct
+	// Policy ограничивает исполнение кода каждого запроса, нулевое значение снимает ограничения
+	Policy       core.ExecPolicy
 	hdr          core.VMServiceHeader
 	fsArgs       []string
 	testingMode  bool
@@ -40,6 +53,7 @@ type VMGonecInterpreterService struct {
 	lockSessions sync.RWMutex
 	srv          *http.Server
 	lasterr      error
+	profiling    bool
 }
 
 func (x *VMGonecInterpreterService) vmval() {}
@@ -58,6 +72,7 @@ func (x *VMGonecInterpreterService) Start() error {
 	http.HandleFunc("/"+x.hdr.Path, x.handlerAPI)
 	http.HandleFunc("/"+x.hdr.Path+"/src", x.handlerSource)
 	http.HandleFunc("/"+x.hdr.Path+"/healthcheck", x.handlerHealth) // в таком же формате регистрируется в consul и т.п.
+	http.HandleFunc("/"+x.hdr.Path+"/profile", x.handlerProfile)
 
 	//добавляем горутину на принудительное закрытие сессий через 10 мин без активности
 	go func() {
@@ -222,9 +237,12 @@ func (x *VMGonecInterpreterService) handlerAPI(w http.ResponseWriter, r *http.Re
 		w.Header().Set("Sid", sid)
 
 		env.SetSid(sid)
+		env.SetPolicy(x.Policy)
 		//log.Println("Сессия:",sid)
 
-		err := x.parseAndRun(r.Body, w, env)
+		// код исполняется в контексте запроса: при отключении клиента исполнение прерывается,
+		// горутины, запущенные кодом, завершаются вместе с запросом
+		err := x.parseAndRun(r.Context(), r.Body, w, env)
 
 		if err != nil {
 			time.Sleep(time.Second) //анти-ddos
@@ -243,7 +261,7 @@ func (x *VMGonecInterpreterService) handlerIndex(w http.ResponseWriter, r *http.
 	fmt.Fprint(w, indexPage)
 }
 
-func (x *VMGonecInterpreterService) parseAndRun(r io.Reader, w io.Writer, env *core.Env) (err error) {
+func (x *VMGonecInterpreterService) parseAndRun(ctx context.Context, r io.Reader, w io.Writer, env *core.Env) (err error) {
 	b, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
@@ -276,7 +294,7 @@ func (x *VMGonecInterpreterService) parseAndRun(r io.Reader, w io.Writer, env *c
 	// if *stackvm {
 	// 	_, err = vm.Run(stmts, env)
 	// } else {
-	_, err = bincode.Run(bins, env)
+	_, err = bincode.Run(ctx, bins, env)
 	// }
 	tsRun := time.Since(tstart)
 
@@ -305,6 +323,73 @@ func (x *VMGonecInterpreterService) parseAndRun(r io.Reader, w io.Writer, env *c
 	return nil
 }
 
+// handlerProfile профилирует код, исполняемый в сессии из заголовка Sid (или параметра sid) либо во всех сессиях,
+// в течение seconds секунд (по умолчанию 30) и возвращает профиль pprof, а при format=text - текстовый отчет
+func (x *VMGonecInterpreterService) handlerProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		time.Sleep(time.Second) //анти-ddos
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	sec := 30
+	if s := q.Get("seconds"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > 3600 {
+			http.Error(w, "Неверная длительность профилирования", http.StatusBadRequest)
+			return
+		}
+		sec = n
+	}
+
+	p := profiler.New()
+	sid := r.Header.Get("Sid")
+	if sid == "" {
+		sid = q.Get("sid")
+	}
+	x.lockSessions.Lock()
+	if sid != "" {
+		env, ok := x.sessions[sid]
+		if !ok {
+			x.lockSessions.Unlock()
+			http.Error(w, "Сессия не найдена", http.StatusNotFound)
+			return
+		}
+		p.Env = env
+	}
+	if x.profiling {
+		x.lockSessions.Unlock()
+		http.Error(w, "Профилирование уже выполняется", http.StatusConflict)
+		return
+	}
+	x.profiling = true
+	x.lockSessions.Unlock()
+
+	p.Start()
+	select {
+	case <-time.After(time.Duration(sec) * time.Second):
+	case <-r.Context().Done():
+	}
+	p.Stop()
+
+	x.lockSessions.Lock()
+	x.profiling = false
+	x.lockSessions.Unlock()
+
+	var err error
+	if q.Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		err = p.WriteReport(w)
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="gonec.pprof"`)
+		err = p.WriteProfile(w)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
 func (x *VMGonecInterpreterService) handlerHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	// log.Println("Healthcheck")
@@ -22,8 +22,8 @@ const jsAceLang=`ace.define("ace/mode/gonec_highlight_rules",["require","exports
 			"Число|Строка|Булево|ЦелоеЧисло|Массив|Структура|Дата|Длительность|"+
 			"Импорт|Длина|Диапазон|ТекущаяДата|ПрошлоВремениС|Пауза|Хэш|"+
 			"УникальныйИдентификатор|ПолучитьМассивИзПула|ВернутьМассивВПул|СлучайнаяСтрока|НРег|ВРег|"+
-			"Формат|КодСимвола|ТипЗнч|Сообщить|СообщитьФ|ОбработатьГорутины|ЗагрузитьИВыполнить|"+
-			"ОписаниеОшибки|ПеременнаяОкружения|СтрСодержит|СтрСодержитЛюбой|СтрКоличество|СтрНайти|"+
+			"Формат|КодСимвола|ТипЗнч|Сообщить|СообщитьФ|ОбработатьГорутины|ЗагрузитьИВыполнить|Выполнить|Вычислить|"+
+			"ОписаниеОшибки|ИнформацияОбОшибке|ПеременнаяОкружения|СтрСодержит|СтрСодержитЛюбой|СтрКоличество|СтрНайти|"+
 			"СтрНайтиЛюбой|СтрНайтиПоследний|СтрЗаменить|Окр"
 		);
 	